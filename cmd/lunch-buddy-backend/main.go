@@ -1,6 +1,10 @@
 package main
 
-import "github.com/sHyben/lunch-buddy-backend/internal/app/lunch-buddy-backend/api"
+import (
+	"os"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/app/lunch-buddy-backend/cli"
+)
 
 // @Golang Lunch Buddy API REST
 // @version 1.0
@@ -19,5 +23,5 @@ import "github.com/sHyben/lunch-buddy-backend/internal/app/lunch-buddy-backend/a
 // @in header
 // @name Authorization
 func main() {
-	api.Run("")
+	os.Exit(cli.Run(os.Args[1:]))
 }
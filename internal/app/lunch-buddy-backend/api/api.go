@@ -1,33 +1,105 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/sHyben/lunch-buddy-backend/internal/app/lunch-buddy-backend/api/router"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/availability"
 	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
 	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/events"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/jobs"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/mail"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/webhooks"
+	"os"
 )
 
 // setConfiguration sets up the configuration and the database
 // It is called by Run
 // It is not intended to be called by the user
-// It panics if the configuration or the database could not be set up
-func setConfiguration(configPath string) {
-	config.Setup(configPath)
-	db.SetupDB()
+// It returns an error if the configuration is invalid or the database could not be set up
+func setConfiguration(options config.Options) error {
+	if err := config.Setup(options); err != nil {
+		return err
+	}
+	if err := db.SetupDB(); err != nil {
+		return err
+	}
 	gin.SetMode(config.GetConfig().Server.Mode)
+	return nil
+}
+
+// startEvents registers the configured sinks and starts the dispatcher of the domain events
+// The dispatcher runs in the background for the lifetime of the process
+func startEvents(configuration config.EventsConfiguration) {
+	bus := events.GetBus()
+	if configuration.LogSink {
+		bus.AddSink(events.NewLogSink(os.Stdout))
+	}
+	go events.NewDispatcher(bus, configuration).Run(context.Background())
+}
+
+// startWebhooks queues the deliveries of the domain events and starts sending them
+// The sender runs in the background for the lifetime of the process
+func startWebhooks(configuration config.WebhooksConfiguration) {
+	events.GetBus().AddSink(webhooks.Sink{})
+	go webhooks.NewSender(configuration).Run(context.Background())
+}
+
+// startMail subscribes the emails to the domain events and starts sending the queued emails
+// The sender runs in the background for the lifetime of the process
+// It returns an error if the transport is unknown
+func startMail(configuration config.MailConfiguration) error {
+	sender, err := mail.NewSender(configuration)
+	if err != nil {
+		return err
+	}
+	mail.Subscribe(events.GetBus())
+	go sender.Run(context.Background())
+	return nil
+}
+
+// startAvailability starts fetching the calendar urls of the users again periodically
+// The syncer runs in the background for the lifetime of the process
+func startAvailability(configuration config.AvailabilityConfiguration) {
+	go availability.NewSyncer(configuration).Run(context.Background())
+}
+
+// startJobs registers the built-in jobs and starts running them on their schedule
+// The jobs stay available to the admins when the scheduler is disabled in this instance
+// It returns an error if a schedule is invalid
+func startJobs(configuration config.JobsConfiguration) error {
+	scheduler := jobs.GetScheduler()
+	if err := jobs.RegisterDefaults(scheduler, configuration); err != nil {
+		return err
+	}
+	if !configuration.Enabled {
+		return nil
+	}
+	return scheduler.Start(context.Background(), configuration)
 }
 
 // Run sets up the configuration and the database
 // It starts the web server
-func Run(configPath string) {
-	if configPath == "" {
-		configPath = "data/config.yml"
+// It returns an error if the configuration is invalid or the server stops
+func Run(options config.Options) error {
+	if err := setConfiguration(options); err != nil {
+		return err
 	}
-	setConfiguration(configPath)
 	conf := config.GetConfig()
+	// The subscribers and the sinks are registered before the dispatcher starts delivering
+	startWebhooks(conf.Webhooks)
+	if err := startMail(conf.Mail); err != nil {
+		return err
+	}
+	startEvents(conf.Events)
+	startAvailability(conf.Availability)
+	if err := startJobs(conf.Jobs); err != nil {
+		return err
+	}
 	web := router.Setup()
 	fmt.Println("Go API REST Running on port " + conf.Server.Port)
 	fmt.Println("==================>")
-	_ = web.Run(":" + conf.Server.Port)
+	return web.Run(":" + conf.Server.Port)
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/gin-gonic/gin"
 	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
 	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/geo"
 	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
 	"log"
 	"net/http"
@@ -19,7 +20,7 @@ import (
 // @Router /api/areas/{id} [get]
 // @Security Authorization Token
 func GetAreaById(c *gin.Context) {
-	s := persistence.GetAreaRepository()
+	s := persistence.GetAreaRepository().Scoped(c.Request.Context())
 	id := c.Param("id")
 	if area, err := s.Get(id); err != nil {
 		http_err.NewError(c, http.StatusNotFound, errors.New("area not found"))
@@ -37,7 +38,7 @@ func GetAreaById(c *gin.Context) {
 // @Router /api/areas [get]
 // @Security Authorization Token
 func GetAreas(c *gin.Context) {
-	s := persistence.GetAreaRepository()
+	s := persistence.GetAreaRepository().Scoped(c.Request.Context())
 	var q models.Area
 	_ = c.Bind(&q)
 	if areas, err := s.Query(&q); err != nil {
@@ -54,12 +55,21 @@ func GetAreas(c *gin.Context) {
 // @Produce json
 // @Param area body users.Area true "Area"
 // @Success 201 {object} users.Area
+// @Failure 409 {object} users.Area
 // @Router /api/areas [post]
 // @Security Authorization Token
 func CreateArea(c *gin.Context) {
-	s := persistence.GetAreaRepository()
+	s := persistence.GetAreaRepository().Scoped(c.Request.Context())
 	var areaInput models.Area
 	_ = c.BindJSON(&areaInput)
+	if err := geo.Validate(areaInput.Latitude, areaInput.Longitude); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if existing, err := s.GetByName(areaInput.Name); err == nil {
+		c.JSON(http.StatusConflict, existing)
+		return
+	}
 	if err := s.Add(&areaInput); err != nil {
 		http_err.NewError(c, http.StatusBadRequest, err)
 		log.Println(err)
@@ -71,7 +81,7 @@ func CreateArea(c *gin.Context) {
 
 // UpdateArea godoc
 // @Summary Updates an area
-// @Description Updates an area
+// @Description Updates the name and the coordinates of an area, the missing fields are kept
 // @Produce json
 // @Param id path integer true "Area ID"
 // @Param area body users.Area true "Area"
@@ -79,7 +89,7 @@ func CreateArea(c *gin.Context) {
 // @Router /api/areas/{id} [put]
 // @Security Authorization Token
 func UpdateArea(c *gin.Context) {
-	s := persistence.GetAreaRepository()
+	s := persistence.GetAreaRepository().Scoped(c.Request.Context())
 	id := c.Params.ByName("id")
 	var areaInput models.Area
 	_ = c.BindJSON(&areaInput)
@@ -89,14 +99,20 @@ func UpdateArea(c *gin.Context) {
 	} else {
 		if areaInput.Name != "" {
 			area.Name = areaInput.Name
-		} else {
-			http_err.NewError(c, http.StatusBadRequest, errors.New("name is required"))
 		}
-		if err := s.Update(&areaInput); err != nil {
+		if areaInput.Latitude != nil || areaInput.Longitude != nil {
+			area.Latitude = areaInput.Latitude
+			area.Longitude = areaInput.Longitude
+		}
+		if err := geo.Validate(area.Latitude, area.Longitude); err != nil {
+			http_err.NewError(c, http.StatusBadRequest, err)
+			return
+		}
+		if err := s.Update(area); err != nil {
 			http_err.NewError(c, http.StatusNotFound, err)
 			log.Println(err)
 		} else {
-			c.JSON(http.StatusOK, areaInput)
+			c.JSON(http.StatusOK, area)
 		}
 	}
 }
@@ -110,7 +126,7 @@ func UpdateArea(c *gin.Context) {
 // @Router /api/areas/{id} [delete]
 // @Security Authorization Token
 func DeleteArea(c *gin.Context) {
-	s := persistence.GetAreaRepository()
+	s := persistence.GetAreaRepository().Scoped(c.Request.Context())
 	id := c.Params.ByName("id")
 	/*	var taskInput models.Task
 		_ = c.BindJSON(&taskInput)*/
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/app/lunch-buddy-backend/api/middlewares"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
 	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
 	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/crypto"
 	httpErr "github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
@@ -31,6 +33,10 @@ type LoginOutput struct {
 	Lastname  string    `json:"lastname"`
 	Firstname string    `json:"firstname"`
 	IsSetup   bool      `json:"isSetup"`
+	// EmailVerified is false until the user followed the link of the verification email
+	EmailVerified bool `json:"emailVerified"`
+	// OrganizationID is the organization the token is scoped to, none for the default organization
+	OrganizationID *uuid.UUID `json:"organizationId,omitempty"`
 }
 
 // Login godoc
@@ -41,7 +47,8 @@ type LoginOutput struct {
 func Login(c *gin.Context) {
 	var loginInput LoginInput
 	_ = c.BindJSON(&loginInput)
-	s := persistence.GetUserRepository()
+	// The usernames are unique in the whole deployment, the user is loaded before its organization is known
+	s := persistence.GetUserRepository().Unscoped()
 	if user, err := s.GetByUsername(loginInput.Username); err != nil {
 		httpErr.NewError(c, http.StatusNotFound, errors.New("user not found"))
 		log.Println(err)
@@ -50,9 +57,20 @@ func Login(c *gin.Context) {
 			httpErr.NewError(c, http.StatusForbidden, errors.New("user and password not match"))
 			return
 		}
-		token, _ := crypto.CreateToken(user.Username)
-		loginOutput := LoginOutput{Token: token, ID: user.ID, Username: user.Username, Lastname: user.Lastname,
-			Firstname: user.Firstname, IsSetup: user.IsSetup}
-		c.JSON(http.StatusOK, loginOutput)
+		c.JSON(http.StatusOK, newLoginOutput(user))
 	}
 }
+
+// newLoginOutput returns the token of the user with its account
+// The logins with a password and with the single sign-on return the same output
+func newLoginOutput(user *models.User) LoginOutput {
+	token, _ := crypto.CreateToken(user.Username, middlewares.OrganizationClaim(user))
+	return LoginOutput{Token: token, ID: user.ID, Username: user.Username, Lastname: user.Lastname,
+		Firstname: user.Firstname, IsSetup: user.IsSetup, EmailVerified: user.EmailVerifiedAt != nil, OrganizationID: user.OrganizationID}
+}
+
+// currentUser returns the user authenticated by middlewares.AuthRequired
+// It must only be called by the handlers of authenticated routes
+func currentUser(c *gin.Context) *models.User {
+	return c.MustGet(middlewares.UserKey).(*models.User)
+}
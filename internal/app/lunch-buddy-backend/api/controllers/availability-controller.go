@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/availability"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/availability"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SourceInput godoc
+// @type SourceInput
+// @description A calendar url the busy times are imported from, webcal urls are accepted
+type SourceInput struct {
+	URL  string `json:"url" binding:"required"`
+	Name string `json:"name"`
+}
+
+// AvailabilityOutput godoc
+// @type AvailabilityOutput
+// @description The calendars of the user and the busy times imported from them
+type AvailabilityOutput struct {
+	Sources []models.Source    `json:"sources"`
+	Busy    []models.BusyBlock `json:"busy"`
+}
+
+// GetAvailability godoc
+// @Summary Retrieves the calendars and the upcoming busy times of the authenticated user
+// @Description Get Availability
+// @Produce json
+// @Success 200 {object} AvailabilityOutput
+// @Router /api/me/availability [get]
+// @Security Authorization Token
+func GetAvailability(c *gin.Context) {
+	s := persistence.GetAvailabilityRepository().Scoped(c.Request.Context())
+	user := currentUser(c)
+	sources, err := s.Sources(user.ID)
+	if err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	from, to := availability.Window()
+	blocks, err := s.Blocks([]uuid.UUID{user.ID}, from, to)
+	if err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	c.JSON(http.StatusOK, AvailabilityOutput{Sources: *sources, Busy: *blocks})
+}
+
+// UploadAvailability godoc
+// @Summary Imports the busy times of an iCalendar file
+// @Description The file is sent as the file field of a form or as a text/calendar body, it replaces the previous upload
+// @Accept multipart/form-data
+// @Accept text/calendar
+// @Produce json
+// @Param file formData file false "iCalendar file"
+// @Success 200 {object} availability.Source
+// @Router /api/me/availability/upload [post]
+// @Security Authorization Token
+func UploadAvailability(c *gin.Context) {
+	s := persistence.GetAvailabilityRepository().Scoped(c.Request.Context())
+	user := currentUser(c)
+	var content io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			http_err.NewError(c, http.StatusBadRequest, errors.New("file is required"))
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			http_err.NewError(c, http.StatusBadRequest, err)
+			return
+		}
+		defer file.Close()
+		content = file
+	}
+	source, err := s.UploadSource(user.ID)
+	if err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	if err := availability.Import(user, source, content); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("could not import the calendar: "+err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, source)
+}
+
+// AddAvailabilitySource godoc
+// @Summary Registers a calendar url of the authenticated user
+// @Description The calendar is imported right away and fetched again periodically
+// @Accept json
+// @Produce json
+// @Param source body SourceInput true "Calendar url"
+// @Success 201 {object} availability.Source
+// @Router /api/me/availability/sources [post]
+// @Security Authorization Token
+func AddAvailabilitySource(c *gin.Context) {
+	s := persistence.GetAvailabilityRepository().Scoped(c.Request.Context())
+	user := currentUser(c)
+	var sourceInput SourceInput
+	if err := c.ShouldBindJSON(&sourceInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := availability.CheckURL(sourceInput.URL); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	endpoint, _ := url.Parse(sourceInput.URL)
+	name := sourceInput.Name
+	if name == "" {
+		name = endpoint.Host
+	}
+	source := models.Source{UserID: user.ID, URL: sourceInput.URL, Name: name}
+	if err := s.AddSource(&source); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	if err := availability.Sync(&source); err != nil {
+		if err := s.DeleteSource(&source); err != nil {
+			log.Println(err)
+		}
+		http_err.NewError(c, http.StatusBadRequest, errors.New("could not import the calendar: "+err.Error()))
+		return
+	}
+	c.JSON(http.StatusCreated, source)
+}
+
+// SyncAvailabilitySource godoc
+// @Summary Fetches a calendar url of the authenticated user again
+// @Description The error is kept on the source when the calendar could not be imported
+// @Produce json
+// @Param id path string true "Source ID"
+// @Success 200 {object} availability.Source
+// @Router /api/me/availability/sources/{id}/sync [post]
+// @Security Authorization Token
+func SyncAvailabilitySource(c *gin.Context) {
+	source, ok := loadSource(c)
+	if !ok {
+		return
+	}
+	if source.IsUpload() {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("an uploaded calendar cannot be fetched"))
+		return
+	}
+	if err := availability.Sync(source); err != nil {
+		log.Println(err)
+	}
+	c.JSON(http.StatusOK, source)
+}
+
+// DeleteAvailabilitySource godoc
+// @Summary Deletes a calendar of the authenticated user
+// @Description The busy times imported from it are deleted too
+// @Param id path string true "Source ID"
+// @Success 204
+// @Router /api/me/availability/sources/{id} [delete]
+// @Security Authorization Token
+func DeleteAvailabilitySource(c *gin.Context) {
+	source, ok := loadSource(c)
+	if !ok {
+		return
+	}
+	if err := persistence.GetAvailabilityRepository().Scoped(c.Request.Context()).DeleteSource(source); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// loadSource returns the calendar source of the id parameter
+// It writes a not found error when the source does not exist or belongs to another user
+func loadSource(c *gin.Context) (*models.Source, bool) {
+	user := currentUser(c)
+	source, err := persistence.GetAvailabilityRepository().Scoped(c.Request.Context()).GetSource(c.Param("id"))
+	if err != nil || source.UserID != user.ID {
+		http_err.NewError(c, http.StatusNotFound, errors.New("calendar not found"))
+		return nil, false
+	}
+	return source, true
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/icebreakers"
+	notificationModels "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/notifications"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/notifications"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"log"
+	"net/http"
+)
+
+// LikeOutput godoc
+// @type LikeOutput
+// @description matched is true when the like is mutual and both users became buddies
+type LikeOutput struct {
+	Liked   bool `json:"liked"`
+	Matched bool `json:"matched"`
+}
+
+// LikeUser godoc
+// @Summary Likes a user
+// @Description The authenticated user likes the user, when the like is mutual both become buddies
+// @Produce json
+// @Param username path string true "Username"
+// @Success 200 {object} LikeOutput
+// @Router /api/me/likes/{username} [post]
+// @Security Authorization Token
+func LikeUser(c *gin.Context) {
+	u := persistence.GetUserRepository().Scoped(c.Request.Context())
+	user := currentUser(c)
+	other, err := u.GetByUsername(c.Param("username"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("user not found"))
+		log.Println(err)
+		return
+	}
+	if other.ID == user.ID {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("you cannot like yourself"))
+		return
+	}
+	if u.HasBlacklisted(other, user) || u.HasBlacklisted(user, other) {
+		http_err.NewError(c, http.StatusForbidden, errors.New("user is blocked"))
+		return
+	}
+	if err := u.AddUserLikes(user, []models.User{*other}); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+
+	matched := u.HasLiked(other, user) && !u.AreBuddies(user, other)
+	if matched {
+		if err := makeBuddies(c, user, other); err != nil {
+			http_err.NewError(c, http.StatusInternalServerError, err)
+			log.Println(err)
+			return
+		}
+	} else {
+		notifications.Notify(c.Request.Context(), other.ID, notificationModels.TypeLike, notifications.DisplayName(user)+" likes you", user, nil)
+	}
+	c.JSON(http.StatusOK, LikeOutput{Liked: true, Matched: matched})
+}
+
+// UnlikeUser godoc
+// @Summary Removes a like
+// @Description The authenticated user does not like the user anymore, existing buddies are kept
+// @Produce json
+// @Param username path string true "Username"
+// @Success 204
+// @Router /api/me/likes/{username} [delete]
+// @Security Authorization Token
+func UnlikeUser(c *gin.Context) {
+	u := persistence.GetUserRepository().Scoped(c.Request.Context())
+	other, err := u.GetByUsername(c.Param("username"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("user not found"))
+		log.Println(err)
+		return
+	}
+	if err := u.RemoveUserLikes(currentUser(c), []models.User{*other}); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusNoContent, "")
+	}
+}
+
+// makeBuddies links both users as buddies and gives them their first icebreakers
+// A failure to generate the icebreakers does not undo the match
+func makeBuddies(c *gin.Context, user *models.User, other *models.User) error {
+	u := persistence.GetUserRepository().Scoped(c.Request.Context())
+	if err := u.MakeBuddies(user, other); err != nil {
+		return err
+	}
+	if _, err := icebreakers.Generate(user, other, nil); err != nil {
+		log.Println(err)
+	}
+	notifications.Notify(c.Request.Context(), user.ID, notificationModels.TypeMatch, "You and "+notifications.DisplayName(other)+" are now buddies", other, nil)
+	notifications.Notify(c.Request.Context(), other.ID, notificationModels.TypeMatch, "You and "+notifications.DisplayName(user)+" are now buddies", user, nil)
+	return nil
+}
+
+// BlockUser godoc
+// @Summary Blocks a user
+// @Description The user is added to the blacklist of the authenticated user, the conversation of both users is disabled
+// @Produce json
+// @Param username path string true "Username"
+// @Success 204
+// @Router /api/me/blacklist/{username} [post]
+// @Security Authorization Token
+func BlockUser(c *gin.Context) {
+	u := persistence.GetUserRepository().Scoped(c.Request.Context())
+	user := currentUser(c)
+	other, err := u.GetByUsername(c.Param("username"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("user not found"))
+		log.Println(err)
+		return
+	}
+	if other.ID == user.ID {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("you cannot block yourself"))
+		return
+	}
+	if err := u.AddUserBlacklist(user, []models.User{*other}); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusNoContent, "")
+	}
+}
+
+// UnblockUser godoc
+// @Summary Unblocks a user
+// @Description The user is removed from the blacklist of the authenticated user
+// @Produce json
+// @Param username path string true "Username"
+// @Success 204
+// @Router /api/me/blacklist/{username} [delete]
+// @Security Authorization Token
+func UnblockUser(c *gin.Context) {
+	u := persistence.GetUserRepository().Scoped(c.Request.Context())
+	other, err := u.GetByUsername(c.Param("username"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("user not found"))
+		log.Println(err)
+		return
+	}
+	if err := u.RemoveUserBlacklist(currentUser(c), []models.User{*other}); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusNoContent, "")
+	}
+}
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/calendar"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/crypto"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/ics"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// calendarTokenSize is the number of random bytes of a calendar feed token
+const calendarTokenSize = 24
+
+// CalendarOutput godoc
+// @type CalendarOutput
+// @description The calendar feed of the user, the url is secret and can be added to any calendar application
+type CalendarOutput struct {
+	URL      string `json:"url"`
+	TimeZone string `json:"timezone"`
+}
+
+// GetCalendar godoc
+// @Summary Retrieves the calendar feed url of the authenticated user
+// @Description The feed is created the first time
+// @Produce json
+// @Success 200 {object} CalendarOutput
+// @Router /api/me/calendar [get]
+// @Security Authorization Token
+func GetCalendar(c *gin.Context) {
+	user := currentUser(c)
+	if user.CalendarToken == nil {
+		if err := rotateCalendarToken(c, user); err != nil {
+			http_err.NewError(c, http.StatusInternalServerError, err)
+			log.Println(err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, calendarOutput(c, user))
+}
+
+// ResetCalendar godoc
+// @Summary Replaces the calendar feed url of the authenticated user
+// @Description The previous url stops working
+// @Produce json
+// @Success 200 {object} CalendarOutput
+// @Router /api/me/calendar/reset [post]
+// @Security Authorization Token
+func ResetCalendar(c *gin.Context) {
+	user := currentUser(c)
+	if err := rotateCalendarToken(c, user); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	c.JSON(http.StatusOK, calendarOutput(c, user))
+}
+
+// GetCalendarFeed godoc
+// @Summary Retrieves the calendar feed of a user
+// @Description The daily lunch and the upcoming accepted invitations as an iCalendar (RFC 5545) feed
+// @Produce text/calendar
+// @Param token path string true "Calendar token, optionally followed by .ics"
+// @Success 200 {string} string
+// @Router /api/calendar/{token} [get]
+func GetCalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	// The feed is read without authentication, the user is found by its token before its organization is known
+	user, err := persistence.GetUserRepository().Unscoped().GetByCalendarToken(token)
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("calendar not found"))
+		return
+	}
+	feed, err := calendar.Feed(user)
+	if err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	writeCalendar(c, feed, "")
+}
+
+// GetInvitationEvent godoc
+// @Summary Downloads an invitation of the authenticated user as a calendar event
+// @Description The event is confirmed once the invitation is accepted
+// @Produce text/calendar
+// @Param id path string true "Invitation ID"
+// @Success 200 {string} string
+// @Router /api/me/invitations/{id}/ics [get]
+// @Security Authorization Token
+func GetInvitationEvent(c *gin.Context) {
+	user := currentUser(c)
+	invitation, err := persistence.GetInvitationRepository().Scoped(c.Request.Context()).Get(c.Param("id"))
+	if err != nil || !invitation.Involves(user.ID) {
+		http_err.NewError(c, http.StatusNotFound, errors.New("invitation not found"))
+		return
+	}
+	writeCalendar(c, &ics.Calendar{
+		ProdID: calendar.ProdID,
+		Events: []ics.Event{calendar.InvitationEvent(invitation, user)},
+	}, "lunch-"+invitation.ID.String()+".ics")
+}
+
+// GetLunchEvent godoc
+// @Summary Downloads the daily lunch of the authenticated user as a recurring calendar event
+// @Description The lunch recurs every working day
+// @Produce text/calendar
+// @Success 200 {string} string
+// @Router /api/me/lunch/ics [get]
+// @Security Authorization Token
+func GetLunchEvent(c *gin.Context) {
+	user := currentUser(c)
+	event, ok := calendar.LunchEvent(user)
+	if !ok {
+		http_err.NewError(c, http.StatusNotFound, errors.New("lunch not found"))
+		return
+	}
+	writeCalendar(c, &ics.Calendar{ProdID: calendar.ProdID, Events: []ics.Event{event}}, "lunch.ics")
+}
+
+// rotateCalendarToken gives the user a new calendar feed token
+func rotateCalendarToken(c *gin.Context, user *models.User) error {
+	token, err := crypto.RandomToken(calendarTokenSize)
+	if err != nil {
+		return err
+	}
+	return persistence.GetUserRepository().Scoped(c.Request.Context()).SetCalendarToken(user, token)
+}
+
+// calendarOutput returns the feed url of the user on the host of the request
+func calendarOutput(c *gin.Context, user *models.User) CalendarOutput {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := c.GetHeader("X-Forwarded-Proto"); forwarded != "" {
+		scheme = forwarded
+	}
+	return CalendarOutput{
+		URL:      scheme + "://" + c.Request.Host + "/api/calendar/" + *user.CalendarToken + ".ics",
+		TimeZone: calendar.Location(user).String(),
+	}
+}
+
+// writeCalendar writes the calendar, as an attachment when a file name is given
+func writeCalendar(c *gin.Context, cal *ics.Calendar, filename string) {
+	var buffer bytes.Buffer
+	if err := cal.Encode(&buffer); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	if filename != "" {
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	}
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", buffer.Bytes())
+}
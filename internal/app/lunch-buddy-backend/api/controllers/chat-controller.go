@@ -0,0 +1,339 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	chatModels "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/chat"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/realtime"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/helpers"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The types of the realtime chat events
+const (
+	EventMessage     = "message"
+	EventMessageRead = "message_read"
+	EventTyping      = "typing"
+)
+
+// ConversationInput godoc
+// @type ConversationInput
+// @description The buddy to open a conversation with
+type ConversationInput struct {
+	Buddy string `json:"buddy" binding:"required"`
+}
+
+// MessageInput godoc
+// @type MessageInput
+// @description A message sent to a buddy
+type MessageInput struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// ConversationOutput godoc
+// @type ConversationOutput
+// @description A conversation with a buddy, enabled is false when the users are no longer buddies or one blocked the other
+type ConversationOutput struct {
+	ID          uuid.UUID           `json:"id"`
+	Buddy       string              `json:"buddy"`
+	Firstname   string              `json:"firstname"`
+	Lastname    string              `json:"lastname"`
+	Enabled     bool                `json:"enabled"`
+	Unread      int64               `json:"unread"`
+	LastMessage *chatModels.Message `json:"last_message"`
+}
+
+// ReadReceipt godoc
+// @type ReadReceipt
+// @description Sent to the sender when the recipient read the messages of a conversation
+type ReadReceipt struct {
+	ConversationID uuid.UUID `json:"conversation_id"`
+	Reader         string    `json:"reader"`
+	ReadAt         time.Time `json:"read_at"`
+}
+
+// TypingEvent godoc
+// @type TypingEvent
+// @description Sent to the buddy while the user is typing
+type TypingEvent struct {
+	ConversationID uuid.UUID `json:"conversation_id"`
+	Username       string    `json:"username"`
+}
+
+// GetConversations godoc
+// @Summary Retrieves the conversations of the authenticated user
+// @Description Get Conversations, the most recently active first
+// @Produce json
+// @Success 200 {array} ConversationOutput
+// @Router /api/me/conversations [get]
+// @Security Authorization Token
+func GetConversations(c *gin.Context) {
+	s := persistence.GetChatRepository().Scoped(c.Request.Context())
+	u := persistence.GetUserRepository().Scoped(c.Request.Context())
+	user := currentUser(c)
+	conversations, err := s.ConversationsForUser(user.ID)
+	if err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	output := make([]ConversationOutput, 0, len(*conversations))
+	for i := range *conversations {
+		conversation := &(*conversations)[i]
+		buddy, err := u.Get(conversation.Other(user.ID).String())
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		item, err := conversationOutput(c, conversation, user, buddy)
+		if err != nil {
+			http_err.NewError(c, http.StatusInternalServerError, err)
+			log.Println(err)
+			return
+		}
+		output = append(output, item)
+	}
+	c.JSON(http.StatusOK, output)
+}
+
+// OpenConversation godoc
+// @Summary Opens the conversation with a buddy
+// @Description The conversation is created the first time, only mutual buddies can talk
+// @Accept json
+// @Produce json
+// @Param conversation body ConversationInput true "Buddy"
+// @Success 200 {object} ConversationOutput
+// @Router /api/me/conversations [post]
+// @Security Authorization Token
+func OpenConversation(c *gin.Context) {
+	s := persistence.GetChatRepository().Scoped(c.Request.Context())
+	u := persistence.GetUserRepository().Scoped(c.Request.Context())
+	user := currentUser(c)
+	var conversationInput ConversationInput
+	if err := c.ShouldBindJSON(&conversationInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		log.Println(err)
+		return
+	}
+	buddy, err := u.GetByUsername(conversationInput.Buddy)
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("user not found"))
+		log.Println(err)
+		return
+	}
+	if err := canMessage(c, user, buddy); err != nil {
+		http_err.NewError(c, http.StatusForbidden, err)
+		return
+	}
+	conversation, err := s.OpenConversation(user.ID, buddy.ID)
+	if err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	if output, err := conversationOutput(c, conversation, user, buddy); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, output)
+	}
+}
+
+// GetMessages godoc
+// @Summary Retrieves the messages of a conversation
+// @Description Get Messages, the newest first, older pages are loaded with the before cursor
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param before query string false "Only the messages sent before this RFC 3339 time, e.g. the created_at of the oldest loaded message"
+// @Param limit query integer false "Page size (default 25)"
+// @Success 200 {array} chat.Message
+// @Router /api/me/conversations/{id}/messages [get]
+// @Security Authorization Token
+func GetMessages(c *gin.Context) {
+	s := persistence.GetChatRepository().Scoped(c.Request.Context())
+	conversation, _, ok := loadConversation(c)
+	if !ok {
+		return
+	}
+	var before *time.Time
+	if c.Query("before") != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, c.Query("before"))
+		if err != nil {
+			http_err.NewError(c, http.StatusBadRequest, errors.New("before must be an RFC 3339 time"))
+			return
+		}
+		before = &parsed
+	}
+	if messages, err := s.Messages(conversation.ID, before, helpers.Limit(c.Query("limit"))); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, messages)
+	}
+}
+
+// SendMessage godoc
+// @Summary Sends a message to the buddy of a conversation
+// @Description The message is pushed to both users as a message event
+// @Accept json
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Param message body MessageInput true "Message"
+// @Success 201 {object} chat.Message
+// @Router /api/me/conversations/{id}/messages [post]
+// @Security Authorization Token
+func SendMessage(c *gin.Context) {
+	s := persistence.GetChatRepository().Scoped(c.Request.Context())
+	user := currentUser(c)
+	conversation, buddy, ok := loadConversation(c)
+	if !ok {
+		return
+	}
+	var messageInput MessageInput
+	if err := c.ShouldBindJSON(&messageInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		log.Println(err)
+		return
+	}
+	body := strings.TrimSpace(messageInput.Body)
+	if body == "" {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("message must not be empty"))
+		return
+	}
+	if utf8.RuneCountInString(body) > chatModels.MaxMessageLength {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("message is too long"))
+		return
+	}
+	if err := canMessage(c, user, buddy); err != nil {
+		http_err.NewError(c, http.StatusForbidden, err)
+		return
+	}
+	message := chatModels.Message{SenderID: user.ID, Body: body}
+	if err := s.AddMessage(conversation, &message); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	event := realtime.Event{Type: EventMessage, Data: message}
+	realtime.GetHub().Publish(buddy.ID, event)
+	realtime.GetHub().Publish(user.ID, event)
+	c.JSON(http.StatusCreated, message)
+}
+
+// MarkConversationRead godoc
+// @Summary Marks the received messages of a conversation as read
+// @Description The buddy receives a message_read event as read receipt
+// @Produce json
+// @Param id path string true "Conversation ID"
+// @Success 200 {object} map[string]int64
+// @Router /api/me/conversations/{id}/read [post]
+// @Security Authorization Token
+func MarkConversationRead(c *gin.Context) {
+	s := persistence.GetChatRepository().Scoped(c.Request.Context())
+	user := currentUser(c)
+	conversation, buddy, ok := loadConversation(c)
+	if !ok {
+		return
+	}
+	readAt := time.Now()
+	marked, err := s.MarkRead(conversation.ID, user.ID, readAt)
+	if err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	if marked > 0 {
+		realtime.GetHub().Publish(buddy.ID, realtime.Event{
+			Type: EventMessageRead,
+			Data: ReadReceipt{ConversationID: conversation.ID, Reader: user.Username, ReadAt: readAt},
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"marked": marked})
+}
+
+// SendTyping godoc
+// @Summary Tells the buddy that the authenticated user is typing
+// @Description The buddy receives a typing event, nothing is stored
+// @Param id path string true "Conversation ID"
+// @Success 204
+// @Router /api/me/conversations/{id}/typing [post]
+// @Security Authorization Token
+func SendTyping(c *gin.Context) {
+	user := currentUser(c)
+	conversation, buddy, ok := loadConversation(c)
+	if !ok {
+		return
+	}
+	if err := canMessage(c, user, buddy); err != nil {
+		http_err.NewError(c, http.StatusForbidden, err)
+		return
+	}
+	realtime.GetHub().Publish(buddy.ID, realtime.Event{
+		Type: EventTyping,
+		Data: TypingEvent{ConversationID: conversation.ID, Username: user.Username},
+	})
+	c.Status(http.StatusNoContent)
+}
+
+// loadConversation returns the conversation of the id parameter and the buddy of the authenticated user
+// It writes a not found error when the conversation does not exist or the user is not part of it
+func loadConversation(c *gin.Context) (*chatModels.Conversation, *models.User, bool) {
+	user := currentUser(c)
+	conversation, err := persistence.GetChatRepository().Scoped(c.Request.Context()).GetConversation(c.Param("id"))
+	if err != nil || !conversation.Involves(user.ID) {
+		http_err.NewError(c, http.StatusNotFound, errors.New("conversation not found"))
+		if err != nil {
+			log.Println(err)
+		}
+		return nil, nil, false
+	}
+	buddy, err := persistence.GetUserRepository().Scoped(c.Request.Context()).Get(conversation.Other(user.ID).String())
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("conversation not found"))
+		log.Println(err)
+		return nil, nil, false
+	}
+	return conversation, buddy, true
+}
+
+// canMessage returns an error when the users are not mutual buddies or one blocked the other
+func canMessage(c *gin.Context, user *models.User, buddy *models.User) error {
+	u := persistence.GetUserRepository().Scoped(c.Request.Context())
+	if u.HasBlacklisted(user, buddy) || u.HasBlacklisted(buddy, user) {
+		return errors.New("user is blocked")
+	}
+	if !u.AreBuddies(user, buddy) || !u.AreBuddies(buddy, user) {
+		return errors.New("you can only message your buddies")
+	}
+	return nil
+}
+
+// conversationOutput builds the summary of a conversation seen by the user
+func conversationOutput(c *gin.Context, conversation *chatModels.Conversation, user *models.User, buddy *models.User) (ConversationOutput, error) {
+	s := persistence.GetChatRepository().Scoped(c.Request.Context())
+	lastMessage, err := s.LastMessage(conversation.ID)
+	if err != nil {
+		return ConversationOutput{}, err
+	}
+	unread, err := s.CountUnread(conversation.ID, user.ID)
+	if err != nil {
+		return ConversationOutput{}, err
+	}
+	return ConversationOutput{
+		ID:          conversation.ID,
+		Buddy:       buddy.Username,
+		Firstname:   buddy.Firstname,
+		Lastname:    buddy.Lastname,
+		Enabled:     canMessage(c, user, buddy) == nil,
+		Unread:      unread,
+		LastMessage: lastMessage,
+	}, nil
+}
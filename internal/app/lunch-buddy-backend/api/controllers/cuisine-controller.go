@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"log"
+	"net/http"
+)
+
+// GetCuisines godoc
+// @Summary Retrieves all cuisines
+// @Description Get cuisines ordered by name
+// @Produce json
+// @Success 200 {array} users.Cuisine
+// @Router /api/cuisines [get]
+// @Security Authorization Token
+func GetCuisines(c *gin.Context) {
+	s := persistence.GetCuisineRepository().Scoped(c.Request.Context())
+	if cuisines, err := s.All(); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, cuisines)
+	}
+}
+
+// GetCuisineById godoc
+// @Summary Retrieves cuisine based on given ID
+// @Description get Cuisine by ID
+// @Produce json
+// @Param id path string true "Cuisine ID"
+// @Success 200 {object} users.Cuisine
+// @Router /api/cuisines/{id} [get]
+// @Security Authorization Token
+func GetCuisineById(c *gin.Context) {
+	s := persistence.GetCuisineRepository().Scoped(c.Request.Context())
+	if cuisine, err := s.Get(c.Param("id")); err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("cuisine not found"))
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, cuisine)
+	}
+}
+
+// CreateCuisine godoc
+// @Summary Creates a cuisine
+// @Description The cuisines are managed by the admins, users choose among them
+// @Accept json
+// @Produce json
+// @Param cuisine body users.Cuisine true "Cuisine"
+// @Success 201 {object} users.Cuisine
+// @Failure 409 {object} users.Cuisine
+// @Router /api/admin/cuisines [post]
+// @Security Authorization Token
+func CreateCuisine(c *gin.Context) {
+	s := persistence.GetCuisineRepository().Scoped(c.Request.Context())
+	var cuisineInput models.Cuisine
+	if err := c.ShouldBindJSON(&cuisineInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if models.NameKey(cuisineInput.Name) == "" {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("name is required"))
+		return
+	}
+	if existing, err := s.GetByName(cuisineInput.Name); err == nil {
+		c.JSON(http.StatusConflict, existing)
+		return
+	}
+	cuisine := models.Cuisine{Name: cuisineInput.Name}
+	if err := s.Add(&cuisine); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusCreated, cuisine)
+	}
+}
+
+// UpdateCuisine godoc
+// @Summary Renames a cuisine
+// @Description Update Cuisine
+// @Accept json
+// @Produce json
+// @Param id path string true "Cuisine ID"
+// @Param cuisine body users.Cuisine true "Cuisine"
+// @Success 200 {object} users.Cuisine
+// @Router /api/admin/cuisines/{id} [put]
+// @Security Authorization Token
+func UpdateCuisine(c *gin.Context) {
+	s := persistence.GetCuisineRepository().Scoped(c.Request.Context())
+	var cuisineInput models.Cuisine
+	if err := c.ShouldBindJSON(&cuisineInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if models.NameKey(cuisineInput.Name) == "" {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("name is required"))
+		return
+	}
+	cuisine, err := s.Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("cuisine not found"))
+		log.Println(err)
+		return
+	}
+	if existing, err := s.GetByName(cuisineInput.Name); err == nil && existing.ID != cuisine.ID {
+		http_err.NewError(c, http.StatusConflict, errors.New("the name is already in use"))
+		return
+	}
+	cuisine.Name = cuisineInput.Name
+	if err := s.Update(cuisine); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, cuisine)
+	}
+}
+
+// DeleteCuisine godoc
+// @Summary Deletes a cuisine
+// @Description Delete Cuisine
+// @Param id path string true "Cuisine ID"
+// @Success 204
+// @Router /api/admin/cuisines/{id} [delete]
+// @Security Authorization Token
+func DeleteCuisine(c *gin.Context) {
+	s := persistence.GetCuisineRepository().Scoped(c.Request.Context())
+	if cuisine, err := s.Get(c.Param("id")); err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("cuisine not found"))
+		log.Println(err)
+	} else {
+		if err := s.Delete(cuisine); err != nil {
+			http_err.NewError(c, http.StatusInternalServerError, err)
+			log.Println(err)
+		} else {
+			c.Status(http.StatusNoContent)
+		}
+	}
+}
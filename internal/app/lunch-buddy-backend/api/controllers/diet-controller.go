@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"log"
+	"net/http"
+)
+
+// GetDiets godoc
+// @Summary Retrieves all diets
+// @Description Get diets ordered by name
+// @Produce json
+// @Success 200 {array} users.Diet
+// @Router /api/diets [get]
+// @Security Authorization Token
+func GetDiets(c *gin.Context) {
+	s := persistence.GetDietRepository().Scoped(c.Request.Context())
+	if diets, err := s.All(); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, diets)
+	}
+}
+
+// GetDietById godoc
+// @Summary Retrieves diet based on given ID
+// @Description get Diet by ID
+// @Produce json
+// @Param id path string true "Diet ID"
+// @Success 200 {object} users.Diet
+// @Router /api/diets/{id} [get]
+// @Security Authorization Token
+func GetDietById(c *gin.Context) {
+	s := persistence.GetDietRepository().Scoped(c.Request.Context())
+	if diet, err := s.Get(c.Param("id")); err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("diet not found"))
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, diet)
+	}
+}
+
+// CreateDiet godoc
+// @Summary Creates a dietary restriction or an allergy
+// @Description The diets are managed by the admins, users choose among them
+// @Accept json
+// @Produce json
+// @Param diet body users.Diet true "Diet"
+// @Success 201 {object} users.Diet
+// @Failure 409 {object} users.Diet
+// @Router /api/admin/diets [post]
+// @Security Authorization Token
+func CreateDiet(c *gin.Context) {
+	s := persistence.GetDietRepository().Scoped(c.Request.Context())
+	var dietInput models.Diet
+	if err := c.ShouldBindJSON(&dietInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if models.NameKey(dietInput.Name) == "" {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("name is required"))
+		return
+	}
+	if existing, err := s.GetByName(dietInput.Name); err == nil {
+		c.JSON(http.StatusConflict, existing)
+		return
+	}
+	diet := models.Diet{Name: dietInput.Name}
+	if err := s.Add(&diet); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusCreated, diet)
+	}
+}
+
+// UpdateDiet godoc
+// @Summary Renames a dietary restriction or an allergy
+// @Description Update Diet
+// @Accept json
+// @Produce json
+// @Param id path string true "Diet ID"
+// @Param diet body users.Diet true "Diet"
+// @Success 200 {object} users.Diet
+// @Router /api/admin/diets/{id} [put]
+// @Security Authorization Token
+func UpdateDiet(c *gin.Context) {
+	s := persistence.GetDietRepository().Scoped(c.Request.Context())
+	var dietInput models.Diet
+	if err := c.ShouldBindJSON(&dietInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if models.NameKey(dietInput.Name) == "" {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("name is required"))
+		return
+	}
+	diet, err := s.Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("diet not found"))
+		log.Println(err)
+		return
+	}
+	if existing, err := s.GetByName(dietInput.Name); err == nil && existing.ID != diet.ID {
+		http_err.NewError(c, http.StatusConflict, errors.New("the name is already in use"))
+		return
+	}
+	diet.Name = dietInput.Name
+	if err := s.Update(diet); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, diet)
+	}
+}
+
+// DeleteDiet godoc
+// @Summary Deletes a dietary restriction or an allergy
+// @Description Delete Diet
+// @Param id path string true "Diet ID"
+// @Success 204
+// @Router /api/admin/diets/{id} [delete]
+// @Security Authorization Token
+func DeleteDiet(c *gin.Context) {
+	s := persistence.GetDietRepository().Scoped(c.Request.Context())
+	if diet, err := s.Get(c.Param("id")); err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("diet not found"))
+		log.Println(err)
+	} else {
+		if err := s.Delete(diet); err != nil {
+			http_err.NewError(c, http.StatusInternalServerError, err)
+			log.Println(err)
+		} else {
+			c.Status(http.StatusNoContent)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"log"
+	"net/http"
+	"time"
+)
+
+// FeedbackInput godoc
+// @type FeedbackInput
+// @description A thumbs up or down about the companion of a past lunch, with optional tags such as "great conversation"
+type FeedbackInput struct {
+	InvitationID uuid.UUID `json:"invitation_id"`
+	Positive     *bool     `json:"positive" binding:"required"`
+	Tags         []string  `json:"tags"`
+}
+
+// GetFeedback godoc
+// @Summary Retrieves the feedback given by the authenticated user
+// @Description Get the feedback, the newest first, the feedback about the user is never returned
+// @Produce json
+// @Success 200 {array} users.Feedback
+// @Router /api/me/feedback [get]
+// @Security Authorization Token
+func GetFeedback(c *gin.Context) {
+	s := persistence.GetFeedbackRepository().Scoped(c.Request.Context())
+	if feedback, err := s.ForUser(currentUser(c).ID); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, feedback)
+	}
+}
+
+// CreateFeedback godoc
+// @Summary Gives private feedback about the companion of a past lunch
+// @Description The invitation must be accepted and have taken place, a lunch gets one feedback per user. A thumbs down stops suggesting the companion, a thumbs up favors similar users
+// @Accept json
+// @Produce json
+// @Param feedback body FeedbackInput true "Feedback"
+// @Success 201 {object} users.Feedback
+// @Failure 409 {object} users.Feedback
+// @Router /api/me/feedback [post]
+// @Security Authorization Token
+func CreateFeedback(c *gin.Context) {
+	s := persistence.GetFeedbackRepository().Scoped(c.Request.Context())
+	user := currentUser(c)
+	var feedbackInput FeedbackInput
+	if err := c.ShouldBindJSON(&feedbackInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	invitation, err := persistence.GetInvitationRepository().Scoped(c.Request.Context()).Get(feedbackInput.InvitationID.String())
+	if err != nil || !invitation.Involves(user.ID) {
+		http_err.NewError(c, http.StatusNotFound, errors.New("invitation not found"))
+		return
+	}
+	if !invitation.Met(user.ID, time.Now()) {
+		http_err.NewError(c, http.StatusForbidden, errors.New("you can only give feedback about an accepted lunch which took place"))
+		return
+	}
+	if existing, err := s.GetForInvitation(user.ID, invitation.ID); err == nil {
+		c.JSON(http.StatusConflict, existing)
+		return
+	}
+	feedback := models.Feedback{
+		UserID:       user.ID,
+		AboutID:      invitation.Other(user.ID),
+		InvitationID: invitation.ID,
+		Positive:     *feedbackInput.Positive,
+		Tags:         models.NewTags(feedbackInput.Tags...),
+	}
+	if err := feedback.Validate(); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.Add(&feedback); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusCreated, feedback)
+	}
+}
+
+// UpdateFeedback godoc
+// @Summary Updates a feedback of the authenticated user
+// @Description The thumbs and the tags are replaced
+// @Accept json
+// @Produce json
+// @Param id path string true "Feedback ID"
+// @Param feedback body FeedbackInput true "Feedback"
+// @Success 200 {object} users.Feedback
+// @Router /api/me/feedback/{id} [put]
+// @Security Authorization Token
+func UpdateFeedback(c *gin.Context) {
+	s := persistence.GetFeedbackRepository().Scoped(c.Request.Context())
+	feedback, ok := loadFeedback(c)
+	if !ok {
+		return
+	}
+	var feedbackInput FeedbackInput
+	if err := c.ShouldBindJSON(&feedbackInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	feedback.Positive = *feedbackInput.Positive
+	feedback.Tags = models.NewTags(feedbackInput.Tags...)
+	if err := feedback.Validate(); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.Update(feedback); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, feedback)
+	}
+}
+
+// DeleteFeedback godoc
+// @Summary Deletes a feedback of the authenticated user
+// @Description The companion can be suggested again
+// @Param id path string true "Feedback ID"
+// @Success 204
+// @Router /api/me/feedback/{id} [delete]
+// @Security Authorization Token
+func DeleteFeedback(c *gin.Context) {
+	feedback, ok := loadFeedback(c)
+	if !ok {
+		return
+	}
+	if err := persistence.GetFeedbackRepository().Scoped(c.Request.Context()).Delete(feedback); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// loadFeedback returns the feedback of the id parameter
+// It writes a not found error when the feedback does not exist or was given by another user
+func loadFeedback(c *gin.Context) (*models.Feedback, bool) {
+	feedback, err := persistence.GetFeedbackRepository().Scoped(c.Request.Context()).Get(c.Param("id"))
+	if err != nil || feedback.UserID != currentUser(c).ID {
+		http_err.NewError(c, http.StatusNotFound, errors.New("feedback not found"))
+		return nil, false
+	}
+	return feedback, true
+}
@@ -19,7 +19,7 @@ import (
 // @Router /api/hobbies/{id} [get]
 // @Security Authorization Token
 func GetHobbyById(c *gin.Context) {
-	s := persistence.GetHobbyRepository()
+	s := persistence.GetHobbyRepository().Scoped(c.Request.Context())
 	id := c.Param("id")
 	if hobby, err := s.Get(id); err != nil {
 		http_err.NewError(c, http.StatusNotFound, errors.New("hobby not found"))
@@ -37,7 +37,7 @@ func GetHobbyById(c *gin.Context) {
 // @Router /api/hobbies [get]
 // @Security Authorization Token
 func GetHobbies(c *gin.Context) {
-	s := persistence.GetHobbyRepository()
+	s := persistence.GetHobbyRepository().Scoped(c.Request.Context())
 	var q models.Hobby
 	_ = c.Bind(&q)
 	if hobbies, err := s.Query(&q); err != nil {
@@ -54,12 +54,17 @@ func GetHobbies(c *gin.Context) {
 // @Produce json
 // @Param hobby body users.Hobby true "Hobby"
 // @Success 201 {object} users.Hobby
+// @Failure 409 {object} users.Hobby
 // @Router /api/hobbies [post]
 // @Security Authorization Token
 func CreateHobby(c *gin.Context) {
-	s := persistence.GetHobbyRepository()
+	s := persistence.GetHobbyRepository().Scoped(c.Request.Context())
 	var hobbyInput models.Hobby
 	_ = c.BindJSON(&hobbyInput)
+	if existing, err := s.GetByName(hobbyInput.Name); err == nil {
+		c.JSON(http.StatusConflict, existing)
+		return
+	}
 	if err := s.Add(&hobbyInput); err != nil {
 		http_err.NewError(c, http.StatusBadRequest, err)
 		log.Println(err)
@@ -79,7 +84,7 @@ func CreateHobby(c *gin.Context) {
 // @Router /api/hobbies/{id} [put]
 // @Security Authorization Token
 func UpdateHobby(c *gin.Context) {
-	s := persistence.GetHobbyRepository()
+	s := persistence.GetHobbyRepository().Scoped(c.Request.Context())
 	id := c.Params.ByName("id")
 	var hobbyInput models.Hobby
 	_ = c.BindJSON(&hobbyInput)
@@ -111,7 +116,7 @@ func UpdateHobby(c *gin.Context) {
 // @Router /api/hobbies/{id} [delete]
 // @Security Authorization Token
 func DeleteHobby(c *gin.Context) {
-	s := persistence.GetHobbyRepository()
+	s := persistence.GetHobbyRepository().Scoped(c.Request.Context())
 	id := c.Params.ByName("id")
 	/*	var taskInput models.Task
 		_ = c.BindJSON(&taskInput)*/
@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/tasks"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"log"
+	"net/http"
+)
+
+// IcebreakerResponse godoc
+// @type IcebreakerResponse
+// @description An icebreaker task of the authenticated user with the status of the copy of the buddy
+type IcebreakerResponse struct {
+	models.Task
+	BuddyStatus string `json:"buddy_status"`
+}
+
+// GetIcebreakers godoc
+// @Summary Retrieves the icebreakers of the authenticated user
+// @Description Each icebreaker carries the status of the buddy, both complete their own copy through the tasks endpoints
+// @Produce json
+// @Param buddy_id query string false "Buddy ID"
+// @Param status query string false "Status"
+// @Success 200 {array} IcebreakerResponse
+// @Router /api/me/icebreakers [get]
+// @Security Authorization Token
+// @Tags tasks
+func GetIcebreakers(c *gin.Context) {
+	s := persistence.GetTaskRepository().Scoped(c.Request.Context())
+	var filter persistence.TaskFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	filter.Kind = models.KindIcebreaker
+	tasks, err := s.QueryForUser(currentUser(c).ID, filter)
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("icebreakers not found"))
+		log.Println(err)
+		return
+	}
+	icebreakers := make([]IcebreakerResponse, len(*tasks))
+	for i, task := range *tasks {
+		icebreakers[i] = IcebreakerResponse{Task: task}
+		if counterpart, err := s.Counterpart(&task); err == nil {
+			icebreakers[i].BuddyStatus = counterpart.Status
+		}
+	}
+	c.JSON(http.StatusOK, icebreakers)
+}
+
+// GetEngagement godoc
+// @Summary Retrieves the engagement of the authenticated user
+// @Description Counts the completed tasks and icebreakers
+// @Produce json
+// @Success 200 {object} persistence.Engagement
+// @Router /api/me/engagement [get]
+// @Security Authorization Token
+// @Tags tasks
+func GetEngagement(c *gin.Context) {
+	s := persistence.GetTaskRepository().Scoped(c.Request.Context())
+	if engagement, err := s.Engagement(currentUser(c).ID); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, engagement)
+	}
+}
+
+// GetIcebreakerTemplates godoc
+// @Summary Retrieves the icebreaker templates
+// @Description get templates, optionally filtered by kind (hobby, language or generic)
+// @Produce json
+// @Param kind query string false "Kind"
+// @Success 200 {array} tasks.IcebreakerTemplate
+// @Router /api/admin/icebreakers [get]
+// @Security Authorization Token
+// @Tags tasks
+func GetIcebreakerTemplates(c *gin.Context) {
+	s := persistence.GetIcebreakerRepository()
+	var q models.IcebreakerTemplate
+	_ = c.Bind(&q)
+	if templates, err := s.Query(&q); err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("icebreakers not found"))
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, templates)
+	}
+}
+
+// CreateIcebreakerTemplate godoc
+// @Summary Creates an icebreaker template
+// @Description The text can contain the placeholders {buddy}, {hobby} and {language}
+// @Accept json
+// @Produce json
+// @Param template body tasks.IcebreakerTemplate true "Template"
+// @Success 201 {object} tasks.IcebreakerTemplate
+// @Router /api/admin/icebreakers [post]
+// @Security Authorization Token
+// @Tags tasks
+func CreateIcebreakerTemplate(c *gin.Context) {
+	s := persistence.GetIcebreakerRepository()
+	var templateInput models.IcebreakerTemplate
+	_ = c.BindJSON(&templateInput)
+	if err := validateIcebreakerTemplate(&templateInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.Add(&templateInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusCreated, templateInput)
+	}
+}
+
+// UpdateIcebreakerTemplate godoc
+// @Summary Updates an icebreaker template
+// @Description Update Icebreaker Template
+// @Accept json
+// @Produce json
+// @Param id path string true "Template ID"
+// @Param template body tasks.IcebreakerTemplate true "Template"
+// @Success 200 {object} tasks.IcebreakerTemplate
+// @Router /api/admin/icebreakers/{id} [put]
+// @Security Authorization Token
+// @Tags tasks
+func UpdateIcebreakerTemplate(c *gin.Context) {
+	s := persistence.GetIcebreakerRepository()
+	var templateInput models.IcebreakerTemplate
+	_ = c.BindJSON(&templateInput)
+	if err := validateIcebreakerTemplate(&templateInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if template, err := s.Get(c.Param("id")); err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("icebreaker not found"))
+		log.Println(err)
+	} else {
+		template.Kind = templateInput.Kind
+		template.Name = templateInput.Name
+		template.Text = templateInput.Text
+		if err := s.Update(template); err != nil {
+			http_err.NewError(c, http.StatusNotFound, err)
+			log.Println(err)
+		} else {
+			c.JSON(http.StatusOK, template)
+		}
+	}
+}
+
+// DeleteIcebreakerTemplate godoc
+// @Summary Deletes an icebreaker template
+// @Description The icebreakers already generated are kept
+// @Produce json
+// @Param id path string true "Template ID"
+// @Success 204
+// @Router /api/admin/icebreakers/{id} [delete]
+// @Security Authorization Token
+// @Tags tasks
+func DeleteIcebreakerTemplate(c *gin.Context) {
+	s := persistence.GetIcebreakerRepository()
+	if template, err := s.Get(c.Param("id")); err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("icebreaker not found"))
+		log.Println(err)
+	} else {
+		if err := s.Delete(template); err != nil {
+			http_err.NewError(c, http.StatusNotFound, err)
+			log.Println(err)
+		} else {
+			c.JSON(http.StatusNoContent, "")
+		}
+	}
+}
+
+// validateIcebreakerTemplate checks the kind, the name and the text of a template
+func validateIcebreakerTemplate(template *models.IcebreakerTemplate) error {
+	switch template.Kind {
+	case models.TemplateHobby, models.TemplateLanguage:
+		if template.Name == "" {
+			return errors.New("name is required for hobby and language icebreakers")
+		}
+	case models.TemplateGeneric:
+		template.Name = ""
+	default:
+		return errors.New("kind must be one of hobby, language or generic")
+	}
+	if template.Text == "" {
+		return errors.New("text is required")
+	}
+	return nil
+}
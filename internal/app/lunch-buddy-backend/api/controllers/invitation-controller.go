@@ -0,0 +1,258 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/availability"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/calendar"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/icebreakers"
+	notificationModels "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/notifications"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/notifications"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// InvitationInput godoc
+// @type InvitationInput
+// @description Invitation of another user to have lunch together, at a place of the catalogue or at a free text location
+type InvitationInput struct {
+	Invitee  string     `json:"invitee" binding:"required"`
+	Time     time.Time  `json:"time" binding:"required"`
+	Location string     `json:"location"`
+	PlaceID  *uuid.UUID `json:"place_id"`
+	Message  string     `json:"message"`
+}
+
+// GetInvitations godoc
+// @Summary Retrieves the invitations of the authenticated user
+// @Description Get the sent and received invitations, ordered by time
+// @Produce json
+// @Param role query string false "sent or received"
+// @Param status query string false "pending, accepted, declined, cancelled or expired"
+// @Success 200 {array} users.Invitation
+// @Router /api/me/invitations [get]
+// @Security Authorization Token
+func GetInvitations(c *gin.Context) {
+	s := persistence.GetInvitationRepository().Scoped(c.Request.Context())
+	if invitations, err := s.QueryForUser(currentUser(c).ID, c.Query("role"), c.Query("status")); err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("invitations not found"))
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, invitations)
+	}
+}
+
+// CreateInvitation godoc
+// @Summary Invites a user to lunch
+// @Description Create Invitation
+// @Accept json
+// @Produce json
+// @Param invitation body InvitationInput true "Invitation"
+// @Success 201 {object} users.Invitation
+// @Router /api/me/invitations [post]
+// @Security Authorization Token
+func CreateInvitation(c *gin.Context) {
+	s := persistence.GetInvitationRepository().Scoped(c.Request.Context())
+	u := persistence.GetUserRepository().Scoped(c.Request.Context())
+	user := currentUser(c)
+	var invitationInput InvitationInput
+	if err := c.ShouldBindJSON(&invitationInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	invitee, err := u.GetByUsername(invitationInput.Invitee)
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("user not found"))
+		log.Println(err)
+		return
+	}
+	if invitee.ID == user.ID {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("you cannot invite yourself"))
+		return
+	}
+	if u.HasBlacklisted(invitee, user) || u.HasBlacklisted(user, invitee) {
+		http_err.NewError(c, http.StatusForbidden, errors.New("user is blocked"))
+		return
+	}
+	place, location, ok := resolvePlace(c, invitationInput.PlaceID, invitationInput.Location)
+	if !ok {
+		return
+	}
+	free, err := availability.Free(c.Request.Context(), []uuid.UUID{user.ID, invitee.ID}, invitationInput.Time, invitationInput.Time.Add(models.LunchDuration))
+	if err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	if !free {
+		http_err.NewError(c, http.StatusConflict, errors.New("you or "+invitee.Username+" are busy at that time"))
+		return
+	}
+	invitation := models.Invitation{
+		InviterID: user.ID,
+		InviteeID: invitee.ID,
+		Time:      invitationInput.Time,
+		Message:   invitationInput.Message,
+	}
+	invitation.SetPlace(place, location)
+	if err := s.Add(&invitation); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		log.Println(err)
+	} else {
+		notifications.Notify(c.Request.Context(), invitee.ID, notificationModels.TypeInvitationReceived,
+			notifications.DisplayName(user)+" invited you to lunch", user, &invitation.ID)
+		c.JSON(http.StatusCreated, invitation)
+	}
+}
+
+// GetInvitationSlots godoc
+// @Summary Retrieves the next lunches at which the authenticated user and another user are both free
+// @Description The lunches of the authenticated user are used, or those of the other user when the authenticated user has no lunch time
+// @Produce json
+// @Param invitee query string true "Username of the user to invite"
+// @Param days query integer false "How many days ahead (default 7, at most 31)"
+// @Success 200 {array} string
+// @Router /api/me/invitations/slots [get]
+// @Security Authorization Token
+func GetInvitationSlots(c *gin.Context) {
+	user := currentUser(c)
+	invitee, err := persistence.GetUserRepository().Scoped(c.Request.Context()).GetByUsername(c.Query("invitee"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("user not found"))
+		return
+	}
+	days := 7
+	if c.Query("days") != "" {
+		if days, err = strconv.Atoi(c.Query("days")); err != nil || days < 1 || days > 31 {
+			http_err.NewError(c, http.StatusBadRequest, errors.New("days must be a number between 1 and 31"))
+			return
+		}
+	}
+	now := time.Now()
+	slots := calendar.NextLunches(user, now, days)
+	if len(slots) == 0 {
+		slots = calendar.NextLunches(invitee, now, days)
+	}
+	busy, err := availability.LoadBusy(c.Request.Context(), now, now.AddDate(0, 0, days+1), user.ID, invitee.ID)
+	if err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	free := busy.FreeLunches(slots, user.ID, invitee.ID)
+	if free == nil {
+		free = []time.Time{}
+	}
+	c.JSON(http.StatusOK, free)
+}
+
+// AcceptInvitation godoc
+// @Summary Accepts a received invitation
+// @Description The icebreakers of the pair are generated
+// @Produce json
+// @Param id path string true "Invitation ID"
+// @Success 200 {object} users.Invitation
+// @Router /api/me/invitations/{id}/accept [post]
+// @Security Authorization Token
+func AcceptInvitation(c *gin.Context) {
+	changeInvitationStatus(c, models.InvitationAccepted)
+}
+
+// DeclineInvitation godoc
+// @Summary Declines a received invitation
+// @Description Decline Invitation
+// @Produce json
+// @Param id path string true "Invitation ID"
+// @Success 200 {object} users.Invitation
+// @Router /api/me/invitations/{id}/decline [post]
+// @Security Authorization Token
+func DeclineInvitation(c *gin.Context) {
+	changeInvitationStatus(c, models.InvitationDeclined)
+}
+
+// CancelInvitation godoc
+// @Summary Cancels a sent invitation
+// @Description Cancel Invitation
+// @Produce json
+// @Param id path string true "Invitation ID"
+// @Success 200 {object} users.Invitation
+// @Router /api/me/invitations/{id}/cancel [post]
+// @Security Authorization Token
+func CancelInvitation(c *gin.Context) {
+	changeInvitationStatus(c, models.InvitationCancelled)
+}
+
+// changeInvitationStatus moves a pending invitation to the given status
+// Only the invitee can accept or decline and only the inviter can cancel
+func changeInvitationStatus(c *gin.Context, status string) {
+	s := persistence.GetInvitationRepository().Scoped(c.Request.Context())
+	user := currentUser(c)
+	invitation, err := s.Get(c.Param("id"))
+	if err != nil || !invitation.Involves(user.ID) {
+		http_err.NewError(c, http.StatusNotFound, errors.New("invitation not found"))
+		return
+	}
+	if status == models.InvitationCancelled && invitation.InviterID != user.ID ||
+		status != models.InvitationCancelled && invitation.InviteeID != user.ID {
+		http_err.NewError(c, http.StatusForbidden, errors.New("you cannot "+actionOf(status)+" this invitation"))
+		return
+	}
+	if invitation.Status != models.InvitationPending {
+		http_err.NewError(c, http.StatusConflict, errors.New("invitation is already "+invitation.Status))
+		return
+	}
+	if status == models.InvitationAccepted && invitation.Time.Before(time.Now()) {
+		http_err.NewError(c, http.StatusConflict, errors.New("invitation is already "+models.InvitationExpired))
+		return
+	}
+
+	if err := s.ChangeStatus(invitation, status); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	notifyInvitationStatus(c, invitation, user)
+	if status == models.InvitationAccepted {
+		// The inviter and the invitee are loaded without their hobbies and languages
+		u := persistence.GetUserRepository().Scoped(c.Request.Context())
+		inviter, err := u.Get(invitation.InviterID.String())
+		if err == nil {
+			_, err = icebreakers.Generate(user, inviter, &invitation.ID)
+		}
+		if err != nil {
+			log.Println(err)
+		}
+	}
+	c.JSON(http.StatusOK, invitation)
+}
+
+// notifyInvitationStatus tells the other participant that the user changed the invitation
+func notifyInvitationStatus(c *gin.Context, invitation *models.Invitation, user *models.User) {
+	name := notifications.DisplayName(user)
+	switch invitation.Status {
+	case models.InvitationAccepted:
+		notifications.Notify(c.Request.Context(), invitation.InviterID, notificationModels.TypeInvitationAccepted, name+" accepted your lunch invitation", user, &invitation.ID)
+	case models.InvitationDeclined:
+		notifications.Notify(c.Request.Context(), invitation.InviterID, notificationModels.TypeInvitationDeclined, name+" declined your lunch invitation", user, &invitation.ID)
+	case models.InvitationCancelled:
+		notifications.Notify(c.Request.Context(), invitation.InviteeID, notificationModels.TypeInvitationCancelled, name+" cancelled the lunch invitation", user, &invitation.ID)
+	}
+}
+
+// actionOf returns the verb changing an invitation to the status
+func actionOf(status string) string {
+	switch status {
+	case models.InvitationAccepted:
+		return "accept"
+	case models.InvitationDeclined:
+		return "decline"
+	default:
+		return "cancel"
+	}
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/crypto"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"log"
+	"net/http"
+	"time"
+)
+
+// inviteCodeSize is the number of random bytes of an invite code
+const inviteCodeSize = 8
+
+// InviteCodeInput godoc
+// @type InviteCodeInput
+// @description How many users can register with the code, one by default, and when it expires, never by default
+type InviteCodeInput struct {
+	MaxUses   int        `json:"maxUses"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// GetInviteCodes godoc
+// @Summary Retrieves the invite codes of the organization
+// @Description Get invite codes, the newest first
+// @Produce json
+// @Success 200 {array} users.InviteCode
+// @Router /api/admin/invite-codes [get]
+// @Security Authorization Token
+func GetInviteCodes(c *gin.Context) {
+	s := persistence.GetInviteCodeRepository().Scoped(c.Request.Context())
+	if codes, err := s.All(); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, codes)
+	}
+}
+
+// CreateInviteCode godoc
+// @Summary Generates an invite code
+// @Description The users registering with the code join the organization of the admin
+// @Accept json
+// @Produce json
+// @Param code body InviteCodeInput true "Invite code"
+// @Success 201 {object} users.InviteCode
+// @Router /api/admin/invite-codes [post]
+// @Security Authorization Token
+func CreateInviteCode(c *gin.Context) {
+	var inviteCodeInput InviteCodeInput
+	if err := c.ShouldBindJSON(&inviteCodeInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if inviteCodeInput.MaxUses == 0 {
+		inviteCodeInput.MaxUses = 1
+	}
+	if inviteCodeInput.MaxUses < 0 {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("maxUses must be positive"))
+		return
+	}
+	if inviteCodeInput.ExpiresAt != nil && !inviteCodeInput.ExpiresAt.After(time.Now()) {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("expiresAt must be in the future"))
+		return
+	}
+	token, err := crypto.RandomToken(inviteCodeSize)
+	if err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	admin := currentUser(c)
+	code := models.InviteCode{Code: token, MaxUses: inviteCodeInput.MaxUses, ExpiresAt: inviteCodeInput.ExpiresAt, CreatedByID: &admin.ID}
+	if err := persistence.GetInviteCodeRepository().Scoped(c.Request.Context()).Add(&code); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusCreated, code)
+	}
+}
+
+// DeleteInviteCode godoc
+// @Summary Revokes an invite code
+// @Description The users who registered with it are kept
+// @Param id path string true "Invite code ID"
+// @Success 204
+// @Router /api/admin/invite-codes/{id} [delete]
+// @Security Authorization Token
+func DeleteInviteCode(c *gin.Context) {
+	s := persistence.GetInviteCodeRepository().Scoped(c.Request.Context())
+	code, err := s.Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("invite code not found"))
+		log.Println(err)
+		return
+	}
+	if err := s.Delete(code); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.Status(http.StatusNoContent)
+	}
+}
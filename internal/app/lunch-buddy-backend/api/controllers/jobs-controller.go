@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/jobs"
+	jobModels "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/jobs"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"log"
+	"net/http"
+)
+
+// JobOutput godoc
+// @type JobOutput
+// @description A scheduled job with the outcome of its last run, the state is empty until the job first runs
+type JobOutput struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Schedule    string         `json:"schedule"`
+	State       *jobModels.Job `json:"state"`
+}
+
+// GetJobs godoc
+// @Summary Retrieves the scheduled jobs
+// @Description Get Jobs with their schedule, next run and last run
+// @Produce json
+// @Success 200 {array} JobOutput
+// @Router /api/admin/jobs [get]
+// @Security Authorization Token
+func GetJobs(c *gin.Context) {
+	states, err := persistence.GetJobRepository().All()
+	if err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	byName := map[string]*jobModels.Job{}
+	for i := range *states {
+		byName[(*states)[i].Name] = &(*states)[i]
+	}
+	registered := jobs.GetScheduler().Jobs()
+	output := make([]JobOutput, len(registered))
+	for i, job := range registered {
+		output[i] = JobOutput{
+			Name:        job.Name,
+			Description: job.Description,
+			Schedule:    job.Schedule.Expression,
+			State:       byName[job.Name],
+		}
+	}
+	c.JSON(http.StatusOK, output)
+}
+
+// RunJob godoc
+// @Summary Runs a scheduled job right away
+// @Description The job runs in the request, its next scheduled run is kept
+// @Produce json
+// @Param name path string true "Job name"
+// @Success 200 {object} jobs.Job
+// @Router /api/admin/jobs/{name}/run [post]
+// @Security Authorization Token
+func RunJob(c *gin.Context) {
+	name := c.Param("name")
+	if err := jobs.GetScheduler().RunNow(c.Request.Context(), name); err != nil {
+		switch {
+		case errors.Is(err, jobs.ErrUnknownJob):
+			http_err.NewError(c, http.StatusNotFound, errors.New("job not found"))
+		case errors.Is(err, jobs.ErrLocked):
+			http_err.NewError(c, http.StatusConflict, err)
+		default:
+			http_err.NewError(c, http.StatusInternalServerError, err)
+			log.Println(err)
+		}
+		return
+	}
+	if state, err := persistence.GetJobRepository().Get(name); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, state)
+	}
+}
@@ -18,7 +18,7 @@ import (
 // @Router /api/languages/{id} [get]
 // @Security Authorization Token
 func GetLanguageById(c *gin.Context) {
-	s := persistence.GetLanguageRepository()
+	s := persistence.GetLanguageRepository().Scoped(c.Request.Context())
 	id := c.Param("id")
 	if language, err := s.Get(id); err != nil {
 		http_err.NewError(c, http.StatusNotFound, errors.New("language not found"))
@@ -35,7 +35,7 @@ func GetLanguageById(c *gin.Context) {
 // @Router /api/languages [get]
 // @Security Authorization Token
 func GetLanguages(c *gin.Context) {
-	s := persistence.GetLanguageRepository()
+	s := persistence.GetLanguageRepository().Scoped(c.Request.Context())
 	var q models.Language
 	_ = c.Bind(&q)
 	if languages, err := s.Query(&q); err != nil {
@@ -51,12 +51,17 @@ func GetLanguages(c *gin.Context) {
 // @Description Create a language
 // @Param language body users.Language true "Language"
 // @Success 201 {object} users.Language
+// @Failure 409 {object} users.Language
 // @Router /api/languages [post]
 // @Security Authorization Token
 func CreateLanguage(c *gin.Context) {
-	s := persistence.GetLanguageRepository()
+	s := persistence.GetLanguageRepository().Scoped(c.Request.Context())
 	var languageInput models.Language
 	_ = c.BindJSON(&languageInput)
+	if existing, err := s.GetByName(languageInput.Name); err == nil {
+		c.JSON(http.StatusConflict, existing)
+		return
+	}
 	if err := s.Add(&languageInput); err != nil {
 		http_err.NewError(c, http.StatusBadRequest, err)
 		log.Println(err)
@@ -75,7 +80,7 @@ func CreateLanguage(c *gin.Context) {
 // @Router /api/languages/{id} [put]
 // @Security Authorization Token
 func UpdateLanguage(c *gin.Context) {
-	s := persistence.GetLanguageRepository()
+	s := persistence.GetLanguageRepository().Scoped(c.Request.Context())
 	id := c.Params.ByName("id")
 	var languageInput models.Language
 	_ = c.BindJSON(&languageInput)
@@ -106,7 +111,7 @@ func UpdateLanguage(c *gin.Context) {
 // @Router /api/languages/{id} [delete]
 // @Security Authorization Token
 func DeleteLanguage(c *gin.Context) {
-	s := persistence.GetLanguageRepository()
+	s := persistence.GetLanguageRepository().Scoped(c.Request.Context())
 	id := c.Params.ByName("id")
 	if language, err := s.Get(id); err != nil {
 		http_err.NewError(c, http.StatusNotFound, errors.New("language not found"))
@@ -122,7 +127,7 @@ func DeleteLanguage(c *gin.Context) {
 }
 
 func GetLanguageByName(c *gin.Context) {
-	s := persistence.GetLanguageRepository()
+	s := persistence.GetLanguageRepository().Scoped(c.Request.Context())
 	name := c.Param("name")
 	if language, err := s.GetByName(name); err != nil {
 		http_err.NewError(c, http.StatusNotFound, errors.New("language not found"))
@@ -18,7 +18,7 @@ import (
 // @Router /api/lunches/{id} [get]
 // @Security Authorization Token
 func GetLunchById(c *gin.Context) {
-	s := persistence.GetLunchRepository()
+	s := persistence.GetLunchRepository().Scoped(c.Request.Context())
 	id := c.Param("id")
 	if lunch, err := s.Get(id); err != nil {
 		http_err.NewError(c, http.StatusNotFound, errors.New("lunch not found"))
@@ -35,7 +35,7 @@ func GetLunchById(c *gin.Context) {
 // @Router /api/lunches [get]
 // @Security Authorization Token
 func GetLunches(c *gin.Context) {
-	s := persistence.GetLunchRepository()
+	s := persistence.GetLunchRepository().Scoped(c.Request.Context())
 	var q models.Lunch
 	_ = c.Bind(&q)
 	if lunches, err := s.Query(&q); err != nil {
@@ -48,15 +48,20 @@ func GetLunches(c *gin.Context) {
 
 // CreateLunch godoc
 // @Summary Create a lunch
-// @Description Create a lunch
+// @Description The lunch references a place by its place_id or has a free text location, the location defaults to the name of the place
 // @Param lunch body users.Lunch true "Lunch"
 // @Success 201 {object} users.Lunch
 // @Router /api/lunches [post]
 // @Security Authorization Token
 func CreateLunch(c *gin.Context) {
-	s := persistence.GetLunchRepository()
+	s := persistence.GetLunchRepository().Scoped(c.Request.Context())
 	var lunchInput models.Lunch
 	_ = c.BindJSON(&lunchInput)
+	place, location, ok := resolvePlace(c, lunchInput.PlaceID, lunchInput.Location)
+	if !ok {
+		return
+	}
+	lunchInput.SetPlace(place, location)
 	if err := s.Add(&lunchInput); err != nil {
 		http_err.NewError(c, http.StatusBadRequest, err)
 		log.Println(err)
@@ -67,14 +72,14 @@ func CreateLunch(c *gin.Context) {
 
 // UpdateLunch godoc
 // @Summary Update a lunch
-// @Description Update a lunch
+// @Description Update a lunch, a new location without a place_id replaces the place by free text
 // @Param id path string true "Lunch ID"
 // @Param lunch body users.Lunch true "Lunch"
 // @Success 200 {object} users.Lunch
 // @Router /api/lunches/{id} [put]
 // @Security Authorization Token
 func UpdateLunch(c *gin.Context) {
-	s := persistence.GetLunchRepository()
+	s := persistence.GetLunchRepository().Scoped(c.Request.Context())
 	id := c.Params.ByName("id")
 	var lunchInput models.Lunch
 	_ = c.BindJSON(&lunchInput)
@@ -82,8 +87,14 @@ func UpdateLunch(c *gin.Context) {
 		http_err.NewError(c, http.StatusNotFound, errors.New("lunch not found"))
 		log.Println(err)
 	} else {
-		if lunchInput.Location != "" {
-			lunch.Location = lunchInput.Location
+		if lunchInput.PlaceID != nil {
+			place, location, ok := resolvePlace(c, lunchInput.PlaceID, lunchInput.Location)
+			if !ok {
+				return
+			}
+			lunch.SetPlace(place, location)
+		} else if lunchInput.Location != "" {
+			lunch.SetPlace(nil, lunchInput.Location)
 		}
 		if !lunchInput.Time.IsZero() {
 			lunch.Time = lunchInput.Time
@@ -105,7 +116,7 @@ func UpdateLunch(c *gin.Context) {
 // @Router /api/lunches/{id} [delete]
 // @Security Authorization Token
 func DeleteLunch(c *gin.Context) {
-	s := persistence.GetLunchRepository()
+	s := persistence.GetLunchRepository().Scoped(c.Request.Context())
 	id := c.Params.ByName("id")
 	if lunch, err := s.Get(id); err != nil {
 		http_err.NewError(c, http.StatusNotFound, errors.New("lunch not found"))
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/realtime"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/helpers"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// keepAliveInterval is the interval of the comments keeping an idle stream open through proxies
+const keepAliveInterval = 25 * time.Second
+
+// NotificationsOutput godoc
+// @type NotificationsOutput
+// @description A page of notifications with the number of unread ones
+type NotificationsOutput struct {
+	Unread        int64       `json:"unread"`
+	Notifications interface{} `json:"notifications"`
+}
+
+// MarkReadInput godoc
+// @type MarkReadInput
+// @description The ids of the notifications to mark as read, every notification when empty
+type MarkReadInput struct {
+	IDs []uuid.UUID `json:"ids"`
+}
+
+// GetNotifications godoc
+// @Summary Retrieves the notifications of the authenticated user
+// @Description Get Notifications, the newest first
+// @Produce json
+// @Param unread query boolean false "Only the unread notifications"
+// @Param limit query integer false "Page size (default 25)"
+// @Param offset query integer false "Offset"
+// @Success 200 {object} NotificationsOutput
+// @Router /api/me/notifications [get]
+// @Security Authorization Token
+func GetNotifications(c *gin.Context) {
+	s := persistence.GetNotificationRepository().Scoped(c.Request.Context())
+	user := currentUser(c)
+	limit := helpers.Limit(c.Query("limit"))
+	offset := helpers.Offset(c.Query("offset"))
+	notifications, err := s.QueryForUser(user.ID, c.Query("unread") == "true", limit, offset)
+	if err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	unread, err := s.CountUnread(user.ID)
+	if err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	c.JSON(http.StatusOK, NotificationsOutput{Unread: unread, Notifications: notifications})
+}
+
+// MarkNotificationsRead godoc
+// @Summary Marks notifications of the authenticated user as read
+// @Description Without ids every notification is marked as read
+// @Accept json
+// @Produce json
+// @Param ids body MarkReadInput false "Notification IDs"
+// @Success 200 {object} map[string]int64
+// @Router /api/me/notifications/read [post]
+// @Security Authorization Token
+func MarkNotificationsRead(c *gin.Context) {
+	s := persistence.GetNotificationRepository().Scoped(c.Request.Context())
+	var markReadInput MarkReadInput
+	_ = c.ShouldBindJSON(&markReadInput)
+	if marked, err := s.MarkRead(currentUser(c).ID, markReadInput.IDs); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, gin.H{"marked": marked})
+	}
+}
+
+// StreamEvents godoc
+// @Summary Streams the realtime events of the authenticated user
+// @Description Server-Sent Events stream, the event name is the type of the event, e.g. notification
+// @Description Browsers using EventSource can pass the token in the token query parameter
+// @Produce text/event-stream
+// @Success 200
+// @Router /api/me/events [get]
+// @Security Authorization Token
+func StreamEvents(c *gin.Context) {
+	events, unsubscribe := realtime.GetHub().Subscribe(currentUser(c).ID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event.Data)
+			return true
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"log"
+	"net/http"
+)
+
+// MemberInput godoc
+// @type MemberInput
+// @description Whether the member administers its organization
+type MemberInput struct {
+	Admin bool `json:"admin"`
+}
+
+// GetOrganizations godoc
+// @Summary Retrieves all organizations
+// @Description Get organizations ordered by name
+// @Produce json
+// @Success 200 {array} users.Organization
+// @Router /api/admin/organizations [get]
+// @Security Authorization Token
+func GetOrganizations(c *gin.Context) {
+	s := persistence.GetOrganizationRepository()
+	if organizations, err := s.All(); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, organizations)
+	}
+}
+
+// CreateOrganization godoc
+// @Summary Creates an organization
+// @Description An organization with the same slug is returned with a conflict
+// @Accept json
+// @Produce json
+// @Param organization body users.Organization true "Organization"
+// @Success 201 {object} users.Organization
+// @Failure 409 {object} users.Organization
+// @Router /api/admin/organizations [post]
+// @Security Authorization Token
+func CreateOrganization(c *gin.Context) {
+	s := persistence.GetOrganizationRepository()
+	var organizationInput models.Organization
+	if err := c.ShouldBindJSON(&organizationInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	organization := models.Organization{Name: organizationInput.Name, Slug: organizationInput.Slug,
+		RegistrationPolicy: organizationInput.RegistrationPolicy, EmailDomains: organizationInput.EmailDomains}
+	if err := organization.Validate(); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if existing, err := s.GetBySlug(organization.Slug); err == nil {
+		c.JSON(http.StatusConflict, existing)
+		return
+	}
+	if err := s.Add(&organization); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusCreated, organization)
+	}
+}
+
+// UpdateOrganization godoc
+// @Summary Renames an organization or changes its registration policy
+// @Description The name, the slug, the registration policy and the email domains are replaced
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param organization body users.Organization true "Organization"
+// @Success 200 {object} users.Organization
+// @Router /api/admin/organizations/{id} [put]
+// @Security Authorization Token
+func UpdateOrganization(c *gin.Context) {
+	s := persistence.GetOrganizationRepository()
+	organization, err := s.Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("organization not found"))
+		log.Println(err)
+		return
+	}
+	var organizationInput models.Organization
+	if err := c.ShouldBindJSON(&organizationInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	organization.Name, organization.Slug = organizationInput.Name, organizationInput.Slug
+	organization.RegistrationPolicy, organization.EmailDomains = organizationInput.RegistrationPolicy, organizationInput.EmailDomains
+	if err := organization.Validate(); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if existing, err := s.GetBySlug(organization.Slug); err == nil && existing.ID != organization.ID {
+		http_err.NewError(c, http.StatusConflict, errors.New("the slug is already in use"))
+		return
+	}
+	if err := s.Update(organization); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, organization)
+	}
+}
+
+// DeleteOrganization godoc
+// @Summary Deletes an organization without members
+// @Description Its taxonomies, teams, departments and invite codes are deleted too, an organization with members is a conflict
+// @Param id path string true "Organization ID"
+// @Success 204
+// @Router /api/admin/organizations/{id} [delete]
+// @Security Authorization Token
+func DeleteOrganization(c *gin.Context) {
+	s := persistence.GetOrganizationRepository()
+	organization, err := s.Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("organization not found"))
+		log.Println(err)
+		return
+	}
+	if members, err := s.Members(organization.ID); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	} else if members > 0 {
+		http_err.NewError(c, http.StatusConflict, errors.New("the organization still has members"))
+		return
+	}
+	if err := s.Delete(organization); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// SetOrganizationMember godoc
+// @Summary Moves a user to an organization or changes its role
+// @Description A user who changes organization leaves its team, its taxonomies, buddies, blocked and liked users behind and must log in again
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID, default for the default organization"
+// @Param username path string true "Username"
+// @Param member body MemberInput true "Role"
+// @Success 200 {object} UserResponse
+// @Router /api/admin/organizations/{id}/members/{username} [put]
+// @Security Authorization Token
+func SetOrganizationMember(c *gin.Context) {
+	var memberInput MemberInput
+	if err := c.ShouldBindJSON(&memberInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	s := persistence.GetOrganizationRepository()
+	var organization *models.Organization
+	if c.Param("id") != "default" {
+		var err error
+		if organization, err = s.Get(c.Param("id")); err != nil {
+			http_err.NewError(c, http.StatusNotFound, errors.New("organization not found"))
+			log.Println(err)
+			return
+		}
+	}
+	// The usernames are unique in the whole deployment, the platform administrators move users between organizations
+	user, err := persistence.GetUserRepository().Unscoped().GetByUsername(c.Param("username"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("user not found"))
+		log.Println(err)
+		return
+	}
+	if err := s.SetMember(user, organization, memberInput.Admin); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, CreateUserCard(user))
+	}
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/mail"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/crypto"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"log"
+	"net/http"
+	"time"
+)
+
+// passwordResetTokenSize is the number of random bytes of a password reset token
+const passwordResetTokenSize = 32
+
+// PasswordResetInput godoc
+// @type PasswordResetInput
+// @description The username of the account whose password is forgotten
+type PasswordResetInput struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// NewPasswordInput godoc
+// @type NewPasswordInput
+// @description The token of the password reset email and the new password
+type NewPasswordInput struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RequestPasswordReset godoc
+// @Summary Sends a password reset link
+// @Description The answer is the same whether the user exists or not
+// @Accept json
+// @Param reset body PasswordResetInput true "Username"
+// @Success 202
+// @Router /api/password-reset [post]
+func RequestPasswordReset(c *gin.Context) {
+	var passwordResetInput PasswordResetInput
+	if err := c.ShouldBindJSON(&passwordResetInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	c.Status(http.StatusAccepted)
+
+	// The usernames are unique in the whole deployment, the user is loaded before its organization is known
+	user, err := persistence.GetUserRepository().Unscoped().GetByUsername(passwordResetInput.Username)
+	if err != nil {
+		return
+	}
+	token, err := crypto.RandomToken(passwordResetTokenSize)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	reset := models.PasswordReset{UserID: user.ID, TokenHash: crypto.HashToken(token), ExpiresAt: time.Now().Add(models.PasswordResetTTL)}
+	organization := db.WithOrganization(c.Request.Context(), user.OrganizationID)
+	if err := persistence.GetPasswordResetRepository().Scoped(organization).Add(&reset); err != nil {
+		log.Println(err)
+		return
+	}
+	if err := mail.QueuePasswordReset(user, token); err != nil {
+		log.Println(err)
+	}
+}
+
+// ResetPassword godoc
+// @Summary Chooses a new password with a password reset token
+// @Description The token can be used once, every other pending reset of the user is cancelled
+// @Accept json
+// @Param password body NewPasswordInput true "Token and new password"
+// @Success 204
+// @Router /api/password-reset/confirm [post]
+func ResetPassword(c *gin.Context) {
+	// The reset is found by its token before the organization of its user is known
+	s := persistence.GetPasswordResetRepository().Unscoped()
+	var newPasswordInput NewPasswordInput
+	if err := c.ShouldBindJSON(&newPasswordInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	reset, err := s.GetByTokenHash(crypto.HashToken(newPasswordInput.Token))
+	if err != nil || !reset.Valid() {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("the link is invalid or expired"))
+		return
+	}
+	if err := s.Use(reset, crypto.HashAndSalt([]byte(newPasswordInput.Password))); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.Status(http.StatusNoContent)
+	}
+}
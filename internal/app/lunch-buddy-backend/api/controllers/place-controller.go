@@ -0,0 +1,337 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/places"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/helpers"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// GetPlaces godoc
+// @Summary Searches the places to have lunch at
+// @Description Matches the name, the aliases and the address ignoring case and diacritics, the places are ordered by name without a query
+// @Produce json
+// @Param q query string false "Typed text"
+// @Param cuisine query string false "Cuisine tag, e.g. italian"
+// @Param dietary query string false "Dietary option, e.g. vegan"
+// @Param max_price query integer false "Highest price level, from 1 to 4"
+// @Param limit query integer false "Maximum number of places (default 25)"
+// @Param fuzzy query boolean false "Tolerate typos (default true)"
+// @Success 200 {array} users.Place
+// @Router /api/places [get]
+// @Security Authorization Token
+func GetPlaces(c *gin.Context) {
+	s := persistence.GetPlaceRepository()
+	filter, ok := placeFilter(c)
+	if !ok {
+		return
+	}
+	if found, err := s.Search(filter); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else if ratePlaces(c, placesOf(found)...) {
+		c.JSON(http.StatusOK, found)
+	}
+}
+
+// GetCompatiblePlaces godoc
+// @Summary Searches the places catering for every diet of the authenticated user
+// @Description Same as the places search, the places lacking a dietary option of one of the diets of the user are left out
+// @Produce json
+// @Param q query string false "Typed text"
+// @Param cuisine query string false "Cuisine tag, e.g. italian"
+// @Param max_price query integer false "Highest price level, from 1 to 4"
+// @Param limit query integer false "Maximum number of places (default 25)"
+// @Param fuzzy query boolean false "Tolerate typos (default true)"
+// @Success 200 {array} users.Place
+// @Router /api/me/places [get]
+// @Security Authorization Token
+func GetCompatiblePlaces(c *gin.Context) {
+	s := persistence.GetPlaceRepository()
+	filter, ok := placeFilter(c)
+	if !ok {
+		return
+	}
+	filter.Diets = currentUser(c).Diets
+	if found, err := s.Search(filter); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else if ratePlaces(c, placesOf(found)...) {
+		c.JSON(http.StatusOK, found)
+	}
+}
+
+// GetNearbyPlaces godoc
+// @Summary Searches the places near an area of the authenticated user
+// @Description The places within the radius of the area are ordered by distance in meters, the places without coordinates are left out
+// @Produce json
+// @Param area query string false "Area ID, the first area of the user with coordinates by default"
+// @Param radius query number false "Radius in meters (default geo.radius, at most geo.max_radius)"
+// @Param q query string false "Typed text"
+// @Param cuisine query string false "Cuisine tag, e.g. italian"
+// @Param dietary query string false "Dietary option, e.g. vegan"
+// @Param max_price query integer false "Highest price level, from 1 to 4"
+// @Param limit query integer false "Maximum number of places (default 25)"
+// @Param compatible query boolean false "Only the places catering for every diet of the user (default false)"
+// @Success 200 {array} places.Nearby
+// @Router /api/me/places/near [get]
+// @Security Authorization Token
+func GetNearbyPlaces(c *gin.Context) {
+	geoConfiguration := config.GetConfig().Geo
+	filter, ok := placeFilter(c)
+	if !ok {
+		return
+	}
+	radius := geoConfiguration.Radius
+	if value := c.Query("radius"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 || parsed > geoConfiguration.MaxRadius {
+			http_err.NewError(c, http.StatusBadRequest, fmt.Errorf("radius must be a number of meters between 0 and %g", geoConfiguration.MaxRadius))
+			return
+		}
+		radius = parsed
+	}
+	user := currentUser(c)
+	if c.Query("compatible") == "true" {
+		filter.Diets = user.Diets
+	}
+	origin, err := places.Origin(user, c.Query("area"))
+	if err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if nearby, err := places.Near(origin, radius, filter); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		rated := make([]*models.Place, len(nearby))
+		for i := range nearby {
+			rated[i] = &nearby[i].Place
+		}
+		if ratePlaces(c, rated...) {
+			c.JSON(http.StatusOK, nearby)
+		}
+	}
+}
+
+// GetPlaceById godoc
+// @Summary Retrieves place based on given ID
+// @Description get Place by ID
+// @Produce json
+// @Param id path string true "Place ID"
+// @Success 200 {object} users.Place
+// @Router /api/places/{id} [get]
+// @Security Authorization Token
+func GetPlaceById(c *gin.Context) {
+	s := persistence.GetPlaceRepository()
+	if place, err := s.Get(c.Param("id")); err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("place not found"))
+		log.Println(err)
+	} else if ratePlaces(c, place) {
+		c.JSON(http.StatusOK, place)
+	}
+}
+
+// CreatePlace godoc
+// @Summary Creates a place
+// @Description A place with the same name at the same address is returned with a conflict
+// @Accept json
+// @Produce json
+// @Param place body users.Place true "Place"
+// @Success 201 {object} users.Place
+// @Failure 409 {object} users.Place
+// @Router /api/admin/places [post]
+// @Security Authorization Token
+func CreatePlace(c *gin.Context) {
+	s := persistence.GetPlaceRepository()
+	var placeInput models.Place
+	if err := c.ShouldBindJSON(&placeInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	placeInput.ID = uuid.Nil
+	if err := placeInput.Validate(); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if existing, err := s.GetByNameAndAddress(placeInput.Name, placeInput.Address); err == nil {
+		c.JSON(http.StatusConflict, existing)
+		return
+	}
+	if err := s.Add(&placeInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusCreated, placeInput)
+	}
+}
+
+// UpdatePlace godoc
+// @Summary Updates a place
+// @Description Only the given fields are changed
+// @Accept json
+// @Produce json
+// @Param id path string true "Place ID"
+// @Param place body users.Place true "Place"
+// @Success 200 {object} users.Place
+// @Router /api/admin/places/{id} [put]
+// @Security Authorization Token
+func UpdatePlace(c *gin.Context) {
+	s := persistence.GetPlaceRepository()
+	place, err := s.Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("place not found"))
+		log.Println(err)
+		return
+	}
+	id := place.ID
+	if err := c.ShouldBindJSON(place); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	place.ID = id
+	if err := place.Validate(); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.Update(place); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else if ratePlaces(c, place) {
+		c.JSON(http.StatusOK, place)
+	}
+}
+
+// DeletePlace godoc
+// @Summary Deletes a place
+// @Description The lunches and invitations at the place keep their free text location
+// @Param id path string true "Place ID"
+// @Success 204
+// @Router /api/admin/places/{id} [delete]
+// @Security Authorization Token
+func DeletePlace(c *gin.Context) {
+	s := persistence.GetPlaceRepository()
+	if place, err := s.Get(c.Param("id")); err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("place not found"))
+		log.Println(err)
+	} else {
+		if err := s.Delete(place); err != nil {
+			http_err.NewError(c, http.StatusInternalServerError, err)
+			log.Println(err)
+		} else {
+			c.Status(http.StatusNoContent)
+		}
+	}
+}
+
+// MergePlace godoc
+// @Summary Merges a duplicate place into a canonical one
+// @Description Moves every lunch and invitation of the place to the canonical place, deletes the place and keeps its name as an alias
+// @Accept json
+// @Produce json
+// @Param id path string true "Duplicate place ID"
+// @Param merge body MergeInput true "Canonical place"
+// @Success 200 {object} users.Place
+// @Router /api/admin/places/{id}/merge [post]
+// @Security Authorization Token
+func MergePlace(c *gin.Context) {
+	s := persistence.GetPlaceRepository()
+	var mergeInput MergeInput
+	if err := c.ShouldBindJSON(&mergeInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	duplicate, err := s.Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("place not found"))
+		log.Println(err)
+		return
+	}
+	canonical, err := s.Get(mergeInput.Into)
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("canonical place not found"))
+		log.Println(err)
+		return
+	}
+	if duplicate.ID == canonical.ID {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("a place cannot be merged into itself"))
+		return
+	}
+	if err := s.Merge(duplicate, canonical); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	// The rating of the canonical place now includes the reviews of the duplicate
+	if ratePlaces(c, canonical) {
+		c.JSON(http.StatusOK, canonical)
+	}
+}
+
+// ratePlaces sets the rating of the places from the reviews of the organization of the request
+// It writes an internal server error and returns false when the reviews cannot be loaded
+func ratePlaces(c *gin.Context, places ...*models.Place) bool {
+	if err := persistence.GetReviewRepository().Scoped(c.Request.Context()).Rate(places); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return false
+	}
+	return true
+}
+
+// placesOf returns pointers to the places so their rating can be set
+func placesOf(places []models.Place) []*models.Place {
+	pointers := make([]*models.Place, len(places))
+	for i := range places {
+		pointers[i] = &places[i]
+	}
+	return pointers
+}
+
+// placeFilter reads the search filter of the query parameters
+// It writes a bad request error when a parameter is invalid
+func placeFilter(c *gin.Context) (persistence.PlaceFilter, bool) {
+	filter := persistence.PlaceFilter{
+		Query:   c.Query("q"),
+		Cuisine: c.Query("cuisine"),
+		Dietary: c.Query("dietary"),
+		Limit:   helpers.Limit(c.DefaultQuery("limit", "25")),
+		Fuzzy:   c.DefaultQuery("fuzzy", "true") != "false",
+	}
+	if value := c.Query("max_price"); value != "" {
+		maxPrice, err := strconv.Atoi(value)
+		if err != nil || maxPrice < 1 || maxPrice > models.MaxPriceLevel {
+			http_err.NewError(c, http.StatusBadRequest, errors.New("max_price must be between 1 and 4"))
+			return filter, false
+		}
+		filter.MaxPrice = maxPrice
+	}
+	return filter, true
+}
+
+// resolvePlace returns the place referenced by a lunch or an invitation and its location
+// The location defaults to the name of the place, a free text location is kept as is
+// It writes a bad request error when the place does not exist
+func resolvePlace(c *gin.Context, placeID *uuid.UUID, location string) (*models.Place, string, bool) {
+	if placeID == nil || *placeID == uuid.Nil {
+		return nil, location, true
+	}
+	place, err := persistence.GetPlaceRepository().Get(placeID.String())
+	if err != nil {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("place not found"))
+		return nil, "", false
+	}
+	if location == "" {
+		location = place.Name
+	}
+	return place, location, true
+}
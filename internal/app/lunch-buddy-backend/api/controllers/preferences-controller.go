@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/matching"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/helpers"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"log"
+	"net/http"
+)
+
+// Preferences godoc
+// @type Preferences
+// @description The emails the user accepts, the password resets are always sent
+// @description The team matching is any, same or other, it only applies to the members of a team
+type Preferences struct {
+	EmailInvitations *bool   `json:"email_invitations"`
+	EmailReminders   *bool   `json:"email_reminders"`
+	EmailSuggestions *bool   `json:"email_suggestions"`
+	TeamMatching     *string `json:"team_matching"`
+}
+
+// GetPreferences godoc
+// @Summary Retrieves the notification and matching preferences of the authenticated user
+// @Description Get Preferences
+// @Produce json
+// @Success 200 {object} Preferences
+// @Router /api/me/preferences [get]
+// @Security Authorization Token
+func GetPreferences(c *gin.Context) {
+	user := currentUser(c)
+	teamMatching := user.TeamMatching
+	if teamMatching == "" {
+		teamMatching = models.TeamMatchingAny
+	}
+	c.JSON(http.StatusOK, Preferences{
+		EmailInvitations: &user.EmailInvitations,
+		EmailReminders:   &user.EmailReminders,
+		EmailSuggestions: &user.EmailSuggestions,
+		TeamMatching:     &teamMatching,
+	})
+}
+
+// UpdatePreferences godoc
+// @Summary Updates the notification and matching preferences of the authenticated user
+// @Description The missing preferences are left unchanged
+// @Accept json
+// @Produce json
+// @Param preferences body Preferences true "Preferences"
+// @Success 200 {object} Preferences
+// @Router /api/me/preferences [put]
+// @Security Authorization Token
+func UpdatePreferences(c *gin.Context) {
+	u := persistence.GetUserRepository().Scoped(c.Request.Context())
+	user := currentUser(c)
+	var preferences Preferences
+	if err := c.ShouldBindJSON(&preferences); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if preferences.TeamMatching != nil && !models.ValidTeamMatching(*preferences.TeamMatching) {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("team_matching must be any, same or other"))
+		return
+	}
+	if preferences.EmailInvitations != nil {
+		user.EmailInvitations = *preferences.EmailInvitations
+	}
+	if preferences.EmailReminders != nil {
+		user.EmailReminders = *preferences.EmailReminders
+	}
+	if preferences.EmailSuggestions != nil {
+		user.EmailSuggestions = *preferences.EmailSuggestions
+	}
+	if preferences.TeamMatching != nil {
+		user.TeamMatching = *preferences.TeamMatching
+	}
+	if err := u.Update(user); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	GetPreferences(c)
+}
+
+// GetSuggestions godoc
+// @Summary Retrieves the users the authenticated user could have lunch with
+// @Description The best matches first, the same matches are sent by the weekly suggestions email
+// @Produce json
+// @Param limit query integer false "Number of suggestions (default 25)"
+// @Success 200 {array} matching.Suggestion
+// @Router /api/me/suggestions [get]
+// @Security Authorization Token
+func GetSuggestions(c *gin.Context) {
+	if suggestions, err := matching.Suggest(currentUser(c), helpers.Limit(c.Query("limit"))); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, suggestions)
+	}
+}
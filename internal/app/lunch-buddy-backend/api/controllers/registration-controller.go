@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/registration"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/crypto"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"log"
+	"net/http"
+	"time"
+)
+
+// RegistrationInput godoc
+// @type RegistrationInput
+// @description The account to register, the username is an email address and the invite code is required by the invite policy
+type RegistrationInput struct {
+	Username   string `json:"username" binding:"required"`
+	Firstname  string `json:"firstname"`
+	Lastname   string `json:"lastname"`
+	Password   string `json:"password" binding:"required"`
+	InviteCode string `json:"inviteCode"`
+}
+
+// VerificationInput godoc
+// @type VerificationInput
+// @description The token of the verification email
+type VerificationInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// Register godoc
+// @Summary Registers a new user
+// @Description The registration policy of the deployment or of the organization of the email domain decides whether
+// @Description the user can register, a valid invite code is always accepted and the user joins its organization.
+// @Description A verification email is sent, the profile cannot be set up before the email address is verified.
+// @Accept json
+// @Produce json
+// @Param user body RegistrationInput true "User"
+// @Success 201 {object} users.User
+// @Failure 403 {object} http_err.HTTPError
+// @Failure 409 {object} http_err.HTTPError
+// @Router /api/register [post]
+func Register(c *gin.Context) {
+	var registrationInput RegistrationInput
+	if err := c.ShouldBindJSON(&registrationInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	decision, err := registration.Decide(registrationInput.Username, registrationInput.InviteCode)
+	switch {
+	case errors.Is(err, registration.ErrInvalidEmail), errors.Is(err, registration.ErrInvalidInviteCode):
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	case errors.Is(err, registration.ErrInviteRequired), errors.Is(err, registration.ErrDomainNotAllowed):
+		http_err.NewError(c, http.StatusForbidden, err)
+		return
+	case err != nil:
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	user := models.User{
+		Username:  registrationInput.Username,
+		Firstname: registrationInput.Firstname,
+		Lastname:  registrationInput.Lastname,
+		Hash:      crypto.HashAndSalt([]byte(registrationInput.Password)),
+	}
+	verify := config.GetConfig().Registration.VerifyEmail
+	if !verify {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	// The user joins the organization of the decision, not the one of the request
+	s := persistence.GetUserRepository().Scoped(db.WithOrganization(c.Request.Context(), decision.OrganizationID))
+	if err := s.Register(&user, decision.InviteCode); errors.Is(err, persistence.ErrUsernameTaken) {
+		http_err.NewError(c, http.StatusConflict, err)
+		return
+	} else if errors.Is(err, persistence.ErrInviteCodeUsed) {
+		http_err.NewError(c, http.StatusBadRequest, registration.ErrInvalidInviteCode)
+		return
+	} else if err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		log.Println(err)
+		return
+	}
+	if verify {
+		if err := registration.SendVerification(&user); err != nil {
+			log.Println(err)
+		}
+	}
+	c.JSON(http.StatusCreated, user)
+}
+
+// VerifyEmail godoc
+// @Summary Verifies the email address of a user
+// @Description The token is the one of the verification email, the profile is set up if it is already complete
+// @Accept json
+// @Param verification body VerificationInput true "Token"
+// @Success 204
+// @Router /api/verify-email [post]
+func VerifyEmail(c *gin.Context) {
+	var verificationInput VerificationInput
+	if err := c.ShouldBindJSON(&verificationInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	user, err := registration.Verify(verificationInput.Token)
+	if err != nil {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("the link is invalid or expired"))
+		log.Println(err)
+		return
+	}
+	completeProfile(c, user)
+	c.Status(http.StatusNoContent)
+}
+
+// ResendVerification godoc
+// @Summary Sends the verification email again
+// @Description A user whose email address is already verified is a conflict
+// @Success 202
+// @Failure 409 {object} http_err.HTTPError
+// @Router /api/me/verify-email [post]
+// @Security Authorization Token
+func ResendVerification(c *gin.Context) {
+	user := currentUser(c)
+	if user.EmailVerifiedAt != nil {
+		http_err.NewError(c, http.StatusConflict, errors.New("the email address is already verified"))
+		return
+	}
+	if err := registration.SendVerification(user); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.Status(http.StatusAccepted)
+	}
+}
@@ -0,0 +1,278 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/notifications"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/helpers"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ReviewInput godoc
+// @type ReviewInput
+// @description The rating from 1 to 5 of the place of an attended lunch, a short text and the dishes eaten
+type ReviewInput struct {
+	InvitationID uuid.UUID `json:"invitation_id"`
+	Rating       int       `json:"rating" binding:"required"`
+	Text         string    `json:"text"`
+	Dishes       []string  `json:"dishes"`
+}
+
+// ReviewOutput godoc
+// @type ReviewOutput
+// @description A review with the display name of its author
+type ReviewOutput struct {
+	models.Review
+	Author string `json:"author"`
+}
+
+// DishCount godoc
+// @type DishCount
+// @description A dish and the number of reviews tagging it
+type DishCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// PlaceReviewsOutput godoc
+// @type PlaceReviewsOutput
+// @description A place with its aggregate rating, its most tagged dishes and its reviews
+type PlaceReviewsOutput struct {
+	Place   models.Place   `json:"place"`
+	Dishes  []DishCount    `json:"dishes"`
+	Reviews []ReviewOutput `json:"reviews"`
+}
+
+// GetReviews godoc
+// @Summary Retrieves the reviews written by the authenticated user
+// @Description Get the reviews, the newest first
+// @Produce json
+// @Success 200 {array} users.Review
+// @Router /api/me/reviews [get]
+// @Security Authorization Token
+func GetReviews(c *gin.Context) {
+	s := persistence.GetReviewRepository().Scoped(c.Request.Context())
+	if reviews, err := s.ForUser(currentUser(c).ID); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, reviews)
+	}
+}
+
+// CreateReview godoc
+// @Summary Reviews the place of a lunch the authenticated user attended
+// @Description The invitation must be accepted, have taken place and reference a place of the catalogue, a lunch is reviewed once
+// @Accept json
+// @Produce json
+// @Param review body ReviewInput true "Review"
+// @Success 201 {object} users.Review
+// @Failure 409 {object} users.Review
+// @Router /api/me/reviews [post]
+// @Security Authorization Token
+func CreateReview(c *gin.Context) {
+	s := persistence.GetReviewRepository().Scoped(c.Request.Context())
+	user := currentUser(c)
+	var reviewInput ReviewInput
+	if err := c.ShouldBindJSON(&reviewInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	invitation, err := persistence.GetInvitationRepository().Scoped(c.Request.Context()).Get(reviewInput.InvitationID.String())
+	if err != nil || !invitation.Involves(user.ID) {
+		http_err.NewError(c, http.StatusNotFound, errors.New("invitation not found"))
+		return
+	}
+	if !invitation.Attended(user.ID, time.Now()) {
+		http_err.NewError(c, http.StatusForbidden, errors.New("you can only review the place of an accepted lunch which took place"))
+		return
+	}
+	if existing, err := s.GetForInvitation(user.ID, invitation.ID); err == nil {
+		c.JSON(http.StatusConflict, existing)
+		return
+	}
+	review := models.Review{
+		UserID:       user.ID,
+		PlaceID:      *invitation.PlaceID,
+		InvitationID: invitation.ID,
+		Rating:       reviewInput.Rating,
+		Text:         reviewInput.Text,
+		Dishes:       models.NewTags(reviewInput.Dishes...),
+	}
+	if err := review.Validate(); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.Add(&review); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusCreated, review)
+	}
+}
+
+// UpdateReview godoc
+// @Summary Updates a review of the authenticated user
+// @Description The rating, the text and the dishes are replaced
+// @Accept json
+// @Produce json
+// @Param id path string true "Review ID"
+// @Param review body ReviewInput true "Review"
+// @Success 200 {object} users.Review
+// @Router /api/me/reviews/{id} [put]
+// @Security Authorization Token
+func UpdateReview(c *gin.Context) {
+	s := persistence.GetReviewRepository().Scoped(c.Request.Context())
+	review, ok := loadReview(c)
+	if !ok {
+		return
+	}
+	var reviewInput ReviewInput
+	if err := c.ShouldBindJSON(&reviewInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	review.Rating = reviewInput.Rating
+	review.Text = reviewInput.Text
+	review.Dishes = models.NewTags(reviewInput.Dishes...)
+	if err := review.Validate(); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.Update(review); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, review)
+	}
+}
+
+// DeleteReview godoc
+// @Summary Deletes a review of the authenticated user
+// @Description The rating of the place is computed again
+// @Param id path string true "Review ID"
+// @Success 204
+// @Router /api/me/reviews/{id} [delete]
+// @Security Authorization Token
+func DeleteReview(c *gin.Context) {
+	review, ok := loadReview(c)
+	if !ok {
+		return
+	}
+	if err := persistence.GetReviewRepository().Scoped(c.Request.Context()).Delete(review); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// GetPlaceReviews godoc
+// @Summary Retrieves the reviews of a place
+// @Description The place carries its average rating, the dishes are ordered by the number of reviews tagging them
+// @Description Only the reviews of the users of the organization are listed
+// @Produce json
+// @Param id path string true "Place ID"
+// @Success 200 {object} PlaceReviewsOutput
+// @Router /api/places/{id}/reviews [get]
+// @Security Authorization Token
+func GetPlaceReviews(c *gin.Context) {
+	place, err := persistence.GetPlaceRepository().Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("place not found"))
+		log.Println(err)
+		return
+	}
+	reviews, err := persistence.GetReviewRepository().Scoped(c.Request.Context()).ForPlace(place.ID)
+	if err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	// The reviews are the ones of the organization of the request, the deleted authors are left out
+	visible := []models.Review{}
+	for _, review := range *reviews {
+		if review.User != nil {
+			visible = append(visible, review)
+		}
+	}
+	if !ratePlaces(c, place) {
+		return
+	}
+	output := PlaceReviewsOutput{Place: *place, Dishes: dishCounts(visible), Reviews: []ReviewOutput{}}
+	for _, review := range visible {
+		output.Reviews = append(output.Reviews, ReviewOutput{Review: review, Author: notifications.DisplayName(review.User)})
+	}
+	c.JSON(http.StatusOK, output)
+}
+
+// GetTopPlaces godoc
+// @Summary Retrieves the places best rated by the users of an area
+// @Description The places are ordered by the average rating given by the users of the area, then by their number of reviews
+// @Produce json
+// @Param id path string true "Area ID"
+// @Param min_reviews query integer false "Minimal number of reviews from the area (default 1)"
+// @Param limit query integer false "Maximum number of places (default 10)"
+// @Success 200 {array} persistence.PlaceRating
+// @Router /api/areas/{id}/top-places [get]
+// @Security Authorization Token
+func GetTopPlaces(c *gin.Context) {
+	area, err := persistence.GetAreaRepository().Scoped(c.Request.Context()).Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("area not found"))
+		log.Println(err)
+		return
+	}
+	minReviews, err := strconv.Atoi(c.DefaultQuery("min_reviews", "1"))
+	if err != nil || minReviews < 1 {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("min_reviews must be a positive number"))
+		return
+	}
+	limit := helpers.Limit(c.DefaultQuery("limit", "10"))
+	if ratings, err := persistence.GetReviewRepository().Scoped(c.Request.Context()).TopPlacesForArea(area.ID, minReviews, limit); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, ratings)
+	}
+}
+
+// loadReview returns the review of the id parameter
+// It writes a not found error when the review does not exist or belongs to another user
+func loadReview(c *gin.Context) (*models.Review, bool) {
+	review, err := persistence.GetReviewRepository().Scoped(c.Request.Context()).Get(c.Param("id"))
+	if err != nil || review.UserID != currentUser(c).ID {
+		http_err.NewError(c, http.StatusNotFound, errors.New("review not found"))
+		return nil, false
+	}
+	return review, true
+}
+
+// dishCounts returns the dishes tagged in the reviews, the most tagged first
+func dishCounts(reviews []models.Review) []DishCount {
+	counts := map[string]int{}
+	for _, review := range reviews {
+		for _, dish := range review.Dishes {
+			counts[dish]++
+		}
+	}
+	dishes := []DishCount{}
+	for name, count := range counts {
+		dishes = append(dishes, DishCount{Name: name, Count: count})
+	}
+	sort.Slice(dishes, func(i, j int) bool {
+		if dishes[i].Count != dishes[j].Count {
+			return dishes[i].Count > dishes[j].Count
+		}
+		return dishes[i].Name < dishes[j].Name
+	})
+	return dishes
+}
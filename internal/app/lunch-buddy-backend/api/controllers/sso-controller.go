@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/sso"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/oidc"
+	"log"
+	"net/http"
+)
+
+// StartSSO godoc
+// @Summary Starts a single sign-on login
+// @Description Redirects to the identity provider, which redirects back to /api/sso/callback
+// @Success 302
+// @Failure 404 {object} http_err.HTTPError
+// @Router /api/sso/login [get]
+func StartSSO(c *gin.Context) {
+	location, err := sso.Begin(c.Request.Context())
+	if errors.Is(err, sso.ErrDisabled) {
+		http_err.NewError(c, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		http_err.NewError(c, http.StatusBadGateway, errors.New("the identity provider is unavailable"))
+		log.Println(err)
+		return
+	}
+	c.Redirect(http.StatusFound, location)
+}
+
+// CompleteSSO godoc
+// @Summary Completes a single sign-on login
+// @Description The callback of the identity provider, it returns the same token as /api/login.
+// @Description An unknown identity is provisioned or linked to the user with the same username, if the configuration allows it.
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State"
+// @Success 200 {object} LoginOutput
+// @Failure 401 {object} http_err.HTTPError
+// @Failure 409 {object} http_err.HTTPError
+// @Router /api/sso/callback [get]
+func CompleteSSO(c *gin.Context) {
+	if providerError := c.Query("error"); providerError != "" {
+		http_err.NewError(c, http.StatusUnauthorized, &oidc.Error{Code: providerError, Description: c.Query("error_description")})
+		return
+	}
+	user, err := sso.Complete(c.Request.Context(), c.Query("code"), c.Query("state"))
+	var tokenError *oidc.Error
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, newLoginOutput(user))
+	case errors.Is(err, sso.ErrDisabled):
+		http_err.NewError(c, http.StatusNotFound, err)
+	case errors.Is(err, sso.ErrLinkRefused):
+		http_err.NewError(c, http.StatusConflict, err)
+	case errors.Is(err, sso.ErrInvalidState), errors.Is(err, sso.ErrNoUsername), errors.Is(err, sso.ErrNotProvisioned), errors.As(err, &tokenError):
+		http_err.NewError(c, http.StatusUnauthorized, err)
+	default:
+		http_err.NewError(c, http.StatusUnauthorized, errors.New("the login could not be verified"))
+		log.Println(err)
+	}
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/stats"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MaxDashboardDays is the longest window of the dashboard
+const MaxDashboardDays = 366
+
+// GetStats godoc
+// @Summary Retrieves the lunch history of the authenticated user
+// @Description Counts the lunches per week and month, the buddies met, the favorite places, the most shared hobbies, the streaks of weeks with a lunch and the completed tasks
+// @Produce json
+// @Success 200 {object} stats.Stats
+// @Router /api/me/stats [get]
+// @Security Authorization Token
+func GetStats(c *gin.Context) {
+	if userStats, err := stats.ForUser(currentUser(c), time.Now()); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, userStats)
+	}
+}
+
+// GetDashboard godoc
+// @Summary Retrieves the anonymised connections between the areas
+// @Description Counts the lunches of the last days per area and how many of them were with users of other areas and with members of other teams, the small areas and teams are grouped so no user can be singled out
+// @Produce json
+// @Param days query integer false "Number of days before now (default 90, at most 366)"
+// @Success 200 {object} stats.Dashboard
+// @Router /api/admin/dashboard [get]
+// @Security Authorization Token
+func GetDashboard(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "90"))
+	if err != nil || days < 1 || days > MaxDashboardDays {
+		http_err.NewError(c, http.StatusBadRequest, fmt.Errorf("days must be between 1 and %d", MaxDashboardDays))
+		return
+	}
+	now := time.Now()
+	if dashboard, err := stats.LoadDashboard(c.Request.Context(), now.AddDate(0, 0, -days), now); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, dashboard)
+	}
+}
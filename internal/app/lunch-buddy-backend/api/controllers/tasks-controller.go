@@ -3,25 +3,78 @@ package controllers
 import (
 	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/tasks"
 	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
 	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
 	"log"
 	"net/http"
+	"time"
 )
 
+// TaskInput godoc
+// @type TaskInput
+// @description Task of the authenticated user
+// @description status is one of todo, doing or done and priority one of low, normal or high
+type TaskInput struct {
+	Name         string     `json:"name" binding:"required"`
+	Text         string     `json:"text"`
+	Status       string     `json:"status"`
+	Priority     string     `json:"priority"`
+	DueDate      *time.Time `json:"due_date"`
+	LunchID      *uuid.UUID `json:"lunch_id"`
+	InvitationID *uuid.UUID `json:"invitation_id"`
+}
+
+// CompleteTasksInput godoc
+// @type CompleteTasksInput
+// @description The ids of the tasks to mark as done
+type CompleteTasksInput struct {
+	IDs []uuid.UUID `json:"ids" binding:"required"`
+}
+
+// validate checks the status and the priority of the input
+func (t TaskInput) validate() error {
+	if t.Status != "" && !models.ValidStatus(t.Status) {
+		return errors.New("status must be one of todo, doing or done")
+	}
+	if t.Priority != "" && !models.ValidPriority(t.Priority) {
+		return errors.New("priority must be one of low, normal or high")
+	}
+	return nil
+}
+
+// checkLinks checks that the lunch and the invitation the input links the task to belong to the user
+// It returns an error when one of them is not found or belongs to other users
+func (t TaskInput) checkLinks(c *gin.Context, userID uuid.UUID) error {
+	if t.LunchID != nil {
+		lunch, err := persistence.GetLunchRepository().Scoped(c.Request.Context()).Get(t.LunchID.String())
+		if err != nil || lunch.UserID != userID {
+			return errors.New("lunch not found")
+		}
+	}
+	if t.InvitationID != nil {
+		invitation, err := persistence.GetInvitationRepository().Scoped(c.Request.Context()).Get(t.InvitationID.String())
+		if err != nil || !invitation.Involves(userID) {
+			return errors.New("invitation not found")
+		}
+	}
+	return nil
+}
+
 // GetTaskById godoc
-// @Summary Retrieves task based on given ID
+// @Summary Retrieves a task of the authenticated user
 // @Description get Task by ID
 // @Produce json
-// @Param id path integer true "Task ID"
+// @Param id path string true "Task ID"
 // @Success 200 {object} tasks.Task
-// @Router /api/tasks/{id} [get]
+// @Router /api/me/tasks/{id} [get]
 // @Security Authorization Token
+// @Tags tasks
 func GetTaskById(c *gin.Context) {
-	s := persistence.GetTaskRepository()
+	s := persistence.GetTaskRepository().Scoped(c.Request.Context())
 	id := c.Param("id")
-	if task, err := s.Get(id); err != nil {
+	if task, err := s.GetForUser(currentUser(c).ID, id); err != nil {
 		http_err.NewError(c, http.StatusNotFound, errors.New("task not found"))
 		log.Println(err)
 	} else {
@@ -30,23 +83,28 @@ func GetTaskById(c *gin.Context) {
 }
 
 // GetTasks godoc
-// @Summary Retrieves tasks based on query
-// @Description Get Tasks
+// @Summary Retrieves the tasks of the authenticated user
+// @Description Get Tasks, ordered by due date
 // @Produce json
-// @Param username query string false "Username"
-// @Param taskname query string false "Taskname"
-// @Param firstname query string false "Firstname"
-// @Param lastname query string false "Lastname"
+// @Param status query string false "Status"
+// @Param priority query string false "Priority"
+// @Param due_before query string false "Due before (RFC 3339)"
+// @Param due_after query string false "Due after (RFC 3339)"
+// @Param lunch_id query string false "Lunch ID"
+// @Param invitation_id query string false "Invitation ID"
 // @Success 200 {array} []tasks.Task
-// @Router /api/tasks [get]
+// @Router /api/me/tasks [get]
 // @Security Authorization Token
 // @Tags tasks
 // @Accept json
 func GetTasks(c *gin.Context) {
-	s := persistence.GetTaskRepository()
-	var q models.Task
-	_ = c.Bind(&q)
-	if tasks, err := s.Query(&q); err != nil {
+	s := persistence.GetTaskRepository().Scoped(c.Request.Context())
+	var filter persistence.TaskFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if tasks, err := s.QueryForUser(currentUser(c).ID, filter); err != nil {
 		http_err.NewError(c, http.StatusNotFound, errors.New("tasks not found"))
 		log.Println(err)
 	} else {
@@ -55,78 +113,142 @@ func GetTasks(c *gin.Context) {
 }
 
 // CreateTask godoc
-// @Summary Creates a new task
+// @Summary Creates a new task for the authenticated user
 // @Description Create Task
 // @Produce json
-// @Param task body tasks.Task true "Task"
+// @Param task body TaskInput true "Task"
 // @Success 201 {object} tasks.Task
-// @Router /api/tasks [post]
+// @Router /api/me/tasks [post]
 // @Security Authorization Token
 // @Tags tasks
 // @Accept json
 func CreateTask(c *gin.Context) {
-	s := persistence.GetTaskRepository()
-	userId := c.Params.ByName("user_id")
-	if _, err := s.Get(userId); err != nil {
-		http_err.NewError(c, http.StatusNotFound, errors.New("user not found"))
+	s := persistence.GetTaskRepository().Scoped(c.Request.Context())
+	var taskInput TaskInput
+	if err := c.ShouldBindJSON(&taskInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := taskInput.validate(); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := taskInput.checkLinks(c, currentUser(c).ID); err != nil {
+		http_err.NewError(c, http.StatusNotFound, err)
+		return
+	}
+	task := models.Task{
+		Name:         taskInput.Name,
+		Text:         taskInput.Text,
+		Status:       taskInput.Status,
+		Priority:     taskInput.Priority,
+		DueDate:      taskInput.DueDate,
+		UserID:       currentUser(c).ID,
+		LunchID:      taskInput.LunchID,
+		InvitationID: taskInput.InvitationID,
+	}
+	if err := s.Add(&task); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
 		log.Println(err)
 	} else {
-		var taskInput models.Task
-		_ = c.BindJSON(&taskInput)
-		if err := s.Add(&taskInput); err != nil {
-			http_err.NewError(c, http.StatusBadRequest, err)
-			log.Println(err)
-		} else {
-			c.JSON(http.StatusCreated, taskInput)
-		}
+		c.JSON(http.StatusCreated, task)
 	}
 }
 
 // UpdateTask godoc
-// @Summary Updates a task
+// @Summary Updates a task of the authenticated user
 // @Description Update Task
 // @Produce json
-// @Param id path integer true "Task ID"
-// @Param task body tasks.Task true "Task"
+// @Param id path string true "Task ID"
+// @Param task body TaskInput true "Task"
 // @Success 200 {object} tasks.Task
-// @Router /api/tasks/{id} [put]
+// @Router /api/me/tasks/{id} [put]
 // @Security Authorization Token
 // @Tags tasks
 // @Accept json
 func UpdateTask(c *gin.Context) {
-	s := persistence.GetTaskRepository()
+	s := persistence.GetTaskRepository().Scoped(c.Request.Context())
 	id := c.Params.ByName("id")
-	var taskInput models.Task
-	_ = c.BindJSON(&taskInput)
-	if _, err := s.Get(id); err != nil {
+	var taskInput TaskInput
+	if err := c.ShouldBindJSON(&taskInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := taskInput.validate(); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := taskInput.checkLinks(c, currentUser(c).ID); err != nil {
+		http_err.NewError(c, http.StatusNotFound, err)
+		return
+	}
+	if task, err := s.GetForUser(currentUser(c).ID, id); err != nil {
 		http_err.NewError(c, http.StatusNotFound, errors.New("task not found"))
 		log.Println(err)
 	} else {
-		if err := s.Update(&taskInput); err != nil {
+		task.Name = taskInput.Name
+		task.Text = taskInput.Text
+		if taskInput.Status != "" {
+			task.Status = taskInput.Status
+		}
+		if taskInput.Priority != "" {
+			task.Priority = taskInput.Priority
+		}
+		task.DueDate = taskInput.DueDate
+		task.LunchID = taskInput.LunchID
+		task.InvitationID = taskInput.InvitationID
+		if err := s.Update(task); err != nil {
 			http_err.NewError(c, http.StatusNotFound, err)
 			log.Println(err)
 		} else {
-			c.JSON(http.StatusOK, taskInput)
+			c.JSON(http.StatusOK, task)
 		}
 	}
 }
 
+// CompleteTasks godoc
+// @Summary Marks several tasks of the authenticated user as done
+// @Description Bulk completion, the ids of other users are ignored
+// @Accept json
+// @Produce json
+// @Param ids body CompleteTasksInput true "Task IDs"
+// @Success 200 {object} map[string]int64
+// @Router /api/me/tasks/complete [post]
+// @Security Authorization Token
+// @Tags tasks
+func CompleteTasks(c *gin.Context) {
+	s := persistence.GetTaskRepository().Scoped(c.Request.Context())
+	var completeInput CompleteTasksInput
+	if err := c.ShouldBindJSON(&completeInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if len(completeInput.IDs) == 0 {
+		c.JSON(http.StatusOK, gin.H{"completed": 0})
+		return
+	}
+	if completed, err := s.CompleteForUser(currentUser(c).ID, completeInput.IDs); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, gin.H{"completed": completed})
+	}
+}
+
 // DeleteTask godoc
-// @Summary Deletes a task
+// @Summary Deletes a task of the authenticated user
 // @Description Delete Task
 // @Produce json
-// @Param id path integer true "Task ID"
-// @Success 200 {object} tasks.Task
-// @Router /api/tasks/{id} [delete]
+// @Param id path string true "Task ID"
+// @Success 204
+// @Router /api/me/tasks/{id} [delete]
 // @Security Authorization Token
 // @Tags tasks
 // @Accept json
 func DeleteTask(c *gin.Context) {
-	s := persistence.GetTaskRepository()
+	s := persistence.GetTaskRepository().Scoped(c.Request.Context())
 	id := c.Params.ByName("id")
-	/*	var taskInput models.Task
-		_ = c.BindJSON(&taskInput)*/
-	if task, err := s.Get(id); err != nil {
+	if task, err := s.GetForUser(currentUser(c).ID, id); err != nil {
 		http_err.NewError(c, http.StatusNotFound, errors.New("task not found"))
 		log.Println(err)
 	} else {
@@ -0,0 +1,491 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/helpers"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"log"
+	"net/http"
+)
+
+// MergeInput godoc
+// @type MergeInput
+// @property into string
+// @description The id of the canonical entry the duplicate is merged into
+type MergeInput struct {
+	Into string `json:"into" binding:"required"`
+}
+
+// MergeHobby godoc
+// @Summary Merges a duplicate hobby into a canonical one
+// @Description Moves every user of the hobby to the canonical hobby, deletes the hobby and keeps its name as an alias
+// @Accept json
+// @Produce json
+// @Param id path string true "Duplicate hobby ID"
+// @Param merge body MergeInput true "Canonical hobby"
+// @Success 200 {object} users.Hobby
+// @Router /api/admin/hobbies/{id}/merge [post]
+// @Security Authorization Token
+func MergeHobby(c *gin.Context) {
+	s := persistence.GetHobbyRepository().Scoped(c.Request.Context())
+	var mergeInput MergeInput
+	if err := c.ShouldBindJSON(&mergeInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	duplicate, err := s.Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("hobby not found"))
+		log.Println(err)
+		return
+	}
+	canonical, err := s.Get(mergeInput.Into)
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("canonical hobby not found"))
+		log.Println(err)
+		return
+	}
+	if duplicate.ID == canonical.ID {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("a hobby cannot be merged into itself"))
+		return
+	}
+	if err := s.Merge(duplicate, canonical); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, canonical)
+	}
+}
+
+// MergeLanguage godoc
+// @Summary Merges a duplicate language into a canonical one
+// @Description Moves every user of the language to the canonical language, deletes the language and keeps its name as an alias
+// @Accept json
+// @Produce json
+// @Param id path string true "Duplicate language ID"
+// @Param merge body MergeInput true "Canonical language"
+// @Success 200 {object} users.Language
+// @Router /api/admin/languages/{id}/merge [post]
+// @Security Authorization Token
+func MergeLanguage(c *gin.Context) {
+	s := persistence.GetLanguageRepository().Scoped(c.Request.Context())
+	var mergeInput MergeInput
+	if err := c.ShouldBindJSON(&mergeInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	duplicate, err := s.Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("language not found"))
+		log.Println(err)
+		return
+	}
+	canonical, err := s.Get(mergeInput.Into)
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("canonical language not found"))
+		log.Println(err)
+		return
+	}
+	if duplicate.ID == canonical.ID {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("a language cannot be merged into itself"))
+		return
+	}
+	if err := s.Merge(duplicate, canonical); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, canonical)
+	}
+}
+
+// MergeArea godoc
+// @Summary Merges a duplicate area into a canonical one
+// @Description Moves every user of the area to the canonical area, deletes the area and keeps its name as an alias
+// @Accept json
+// @Produce json
+// @Param id path string true "Duplicate area ID"
+// @Param merge body MergeInput true "Canonical area"
+// @Success 200 {object} users.Area
+// @Router /api/admin/areas/{id}/merge [post]
+// @Security Authorization Token
+func MergeArea(c *gin.Context) {
+	s := persistence.GetAreaRepository().Scoped(c.Request.Context())
+	var mergeInput MergeInput
+	if err := c.ShouldBindJSON(&mergeInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	duplicate, err := s.Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("area not found"))
+		log.Println(err)
+		return
+	}
+	canonical, err := s.Get(mergeInput.Into)
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("canonical area not found"))
+		log.Println(err)
+		return
+	}
+	if duplicate.ID == canonical.ID {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("an area cannot be merged into itself"))
+		return
+	}
+	if err := s.Merge(duplicate, canonical); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, canonical)
+	}
+}
+
+// MergeDiet godoc
+// @Summary Merges a duplicate diet into a canonical one
+// @Description Moves every user of the diet to the canonical diet, deletes the diet and keeps its name as an alias
+// @Accept json
+// @Produce json
+// @Param id path string true "Duplicate diet ID"
+// @Param merge body MergeInput true "Canonical diet"
+// @Success 200 {object} users.Diet
+// @Router /api/admin/diets/{id}/merge [post]
+// @Security Authorization Token
+func MergeDiet(c *gin.Context) {
+	s := persistence.GetDietRepository().Scoped(c.Request.Context())
+	var mergeInput MergeInput
+	if err := c.ShouldBindJSON(&mergeInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	duplicate, err := s.Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("diet not found"))
+		log.Println(err)
+		return
+	}
+	canonical, err := s.Get(mergeInput.Into)
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("canonical diet not found"))
+		log.Println(err)
+		return
+	}
+	if duplicate.ID == canonical.ID {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("a diet cannot be merged into itself"))
+		return
+	}
+	if err := s.Merge(duplicate, canonical); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, canonical)
+	}
+}
+
+// MergeCuisine godoc
+// @Summary Merges a duplicate cuisine into a canonical one
+// @Description Moves every user of the cuisine to the canonical cuisine, deletes the cuisine and keeps its name as an alias
+// @Accept json
+// @Produce json
+// @Param id path string true "Duplicate cuisine ID"
+// @Param merge body MergeInput true "Canonical cuisine"
+// @Success 200 {object} users.Cuisine
+// @Router /api/admin/cuisines/{id}/merge [post]
+// @Security Authorization Token
+func MergeCuisine(c *gin.Context) {
+	s := persistence.GetCuisineRepository().Scoped(c.Request.Context())
+	var mergeInput MergeInput
+	if err := c.ShouldBindJSON(&mergeInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	duplicate, err := s.Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("cuisine not found"))
+		log.Println(err)
+		return
+	}
+	canonical, err := s.Get(mergeInput.Into)
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("canonical cuisine not found"))
+		log.Println(err)
+		return
+	}
+	if duplicate.ID == canonical.ID {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("a cuisine cannot be merged into itself"))
+		return
+	}
+	if err := s.Merge(duplicate, canonical); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, canonical)
+	}
+}
+
+// GetAliases godoc
+// @Summary Retrieves the taxonomy aliases
+// @Description Get the aliases of the organization, optionally filtered by kind (hobby, language, area, diet or cuisine) and target
+// @Produce json
+// @Param kind query string false "Kind"
+// @Param target_id query string false "Target ID"
+// @Success 200 {array} users.Alias
+// @Router /api/admin/aliases [get]
+// @Security Authorization Token
+func GetAliases(c *gin.Context) {
+	s := persistence.GetAliasRepository().Scoped(c.Request.Context())
+	var q models.Alias
+	_ = c.Bind(&q)
+	if q.Kind == models.AliasKindPlace {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("the aliases of the places are listed by /api/admin/place-aliases"))
+		return
+	}
+	if aliases, err := s.Query(&q); err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("aliases not found"))
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, aliases)
+	}
+}
+
+// CreateAlias godoc
+// @Summary Creates a taxonomy alias
+// @Description Maps a synonym to a canonical hobby, language, area, diet or cuisine of the organization
+// @Accept json
+// @Produce json
+// @Param alias body users.Alias true "Alias"
+// @Success 201 {object} users.Alias
+// @Router /api/admin/aliases [post]
+// @Security Authorization Token
+func CreateAlias(c *gin.Context) {
+	var aliasInput models.Alias
+	if err := c.ShouldBindJSON(&aliasInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if models.NameKey(aliasInput.Name) == "" {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("name is required"))
+		return
+	}
+
+	var targetErr, nameErr error
+	switch aliasInput.Kind {
+	case models.AliasKindHobby:
+		_, targetErr = persistence.GetHobbyRepository().Scoped(c.Request.Context()).Get(aliasInput.TargetID.String())
+		_, nameErr = persistence.GetHobbyRepository().Scoped(c.Request.Context()).GetByName(aliasInput.Name)
+	case models.AliasKindLanguage:
+		_, targetErr = persistence.GetLanguageRepository().Scoped(c.Request.Context()).Get(aliasInput.TargetID.String())
+		_, nameErr = persistence.GetLanguageRepository().Scoped(c.Request.Context()).GetByName(aliasInput.Name)
+	case models.AliasKindArea:
+		_, targetErr = persistence.GetAreaRepository().Scoped(c.Request.Context()).Get(aliasInput.TargetID.String())
+		_, nameErr = persistence.GetAreaRepository().Scoped(c.Request.Context()).GetByName(aliasInput.Name)
+	case models.AliasKindDiet:
+		_, targetErr = persistence.GetDietRepository().Scoped(c.Request.Context()).Get(aliasInput.TargetID.String())
+		_, nameErr = persistence.GetDietRepository().Scoped(c.Request.Context()).GetByName(aliasInput.Name)
+	case models.AliasKindCuisine:
+		_, targetErr = persistence.GetCuisineRepository().Scoped(c.Request.Context()).Get(aliasInput.TargetID.String())
+		_, nameErr = persistence.GetCuisineRepository().Scoped(c.Request.Context()).GetByName(aliasInput.Name)
+	default:
+		http_err.NewError(c, http.StatusBadRequest, errors.New("kind must be one of hobby, language, area, diet or cuisine"))
+		return
+	}
+	// The alias belongs to the organization of the request
+	aliasInput.OrganizationID = nil
+	addAlias(c, persistence.GetAliasRepository().Scoped(c.Request.Context()), &aliasInput, targetErr, nameErr)
+}
+
+// DeleteAlias godoc
+// @Summary Deletes a taxonomy alias
+// @Description Delete an alias of the organization
+// @Produce json
+// @Param id path string true "Alias ID"
+// @Success 204
+// @Router /api/admin/aliases/{id} [delete]
+// @Security Authorization Token
+func DeleteAlias(c *gin.Context) {
+	deleteAlias(c, persistence.GetAliasRepository().Scoped(c.Request.Context()), false)
+}
+
+// GetPlaceAliases godoc
+// @Summary Retrieves the aliases of the places
+// @Description Get the aliases of the places shared by every organization, optionally filtered by target
+// @Produce json
+// @Param target_id query string false "Target ID"
+// @Success 200 {array} users.Alias
+// @Router /api/admin/place-aliases [get]
+// @Security Authorization Token
+func GetPlaceAliases(c *gin.Context) {
+	// The places and their aliases are shared by every organization
+	s := persistence.GetAliasRepository().Unscoped()
+	var q models.Alias
+	_ = c.Bind(&q)
+	q.Kind = models.AliasKindPlace
+	if aliases, err := s.Query(&q); err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("aliases not found"))
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, aliases)
+	}
+}
+
+// CreatePlaceAlias godoc
+// @Summary Creates an alias of a place
+// @Description Maps a synonym to a canonical place, the alias is shared by every organization
+// @Accept json
+// @Produce json
+// @Param alias body users.Alias true "Alias"
+// @Success 201 {object} users.Alias
+// @Router /api/admin/place-aliases [post]
+// @Security Authorization Token
+func CreatePlaceAlias(c *gin.Context) {
+	var aliasInput models.Alias
+	if err := c.ShouldBindJSON(&aliasInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if models.NameKey(aliasInput.Name) == "" {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("name is required"))
+		return
+	}
+	aliasInput.Kind = models.AliasKindPlace
+	// The aliases of the places have no organization
+	aliasInput.OrganizationID = nil
+	_, targetErr := persistence.GetPlaceRepository().Get(aliasInput.TargetID.String())
+	_, nameErr := persistence.GetPlaceRepository().GetByName(aliasInput.Name)
+	addAlias(c, persistence.GetAliasRepository().Unscoped(), &aliasInput, targetErr, nameErr)
+}
+
+// DeletePlaceAlias godoc
+// @Summary Deletes an alias of a place
+// @Description Delete an alias of a place shared by every organization
+// @Produce json
+// @Param id path string true "Alias ID"
+// @Success 204
+// @Router /api/admin/place-aliases/{id} [delete]
+// @Security Authorization Token
+func DeletePlaceAlias(c *gin.Context) {
+	deleteAlias(c, persistence.GetAliasRepository().Unscoped(), true)
+}
+
+// addAlias adds the alias once its target is found and its name is free
+func addAlias(c *gin.Context, s *persistence.AliasRepository, alias *models.Alias, targetErr error, nameErr error) {
+	if targetErr != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("target not found"))
+		return
+	}
+	if nameErr == nil {
+		http_err.NewError(c, http.StatusConflict, errors.New("the name is already in use"))
+		return
+	}
+
+	if err := s.Add(alias); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusCreated, alias)
+	}
+}
+
+// deleteAlias deletes the alias of the id parameter
+// The aliases of the places are only deleted when place is true, the other aliases when it is false
+func deleteAlias(c *gin.Context, s *persistence.AliasRepository, place bool) {
+	if alias, err := s.Get(c.Param("id")); err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("alias not found"))
+		log.Println(err)
+	} else if (alias.Kind == models.AliasKindPlace) != place {
+		http_err.NewError(c, http.StatusNotFound, errors.New("alias not found"))
+	} else {
+		if err := s.Delete(alias); err != nil {
+			http_err.NewError(c, http.StatusNotFound, err)
+			log.Println(err)
+		} else {
+			c.JSON(http.StatusNoContent, "")
+		}
+	}
+}
+
+// SearchHobbies godoc
+// @Summary Suggests hobbies for the autocomplete
+// @Description Prefix and fuzzy search ignoring case and diacritics, ranked by relevance and number of users
+// @Produce json
+// @Param q query string false "Typed text"
+// @Param limit query integer false "Maximum number of suggestions (default 10)"
+// @Param fuzzy query boolean false "Tolerate typos (default true)"
+// @Success 200 {array} persistence.Suggestion
+// @Router /api/hobbies/search [get]
+// @Security Authorization Token
+func SearchHobbies(c *gin.Context) {
+	searchTaxonomy(c, persistence.GetHobbyRepository().Scoped(c.Request.Context()).Search)
+}
+
+// SearchLanguages godoc
+// @Summary Suggests languages for the autocomplete
+// @Description Prefix and fuzzy search ignoring case and diacritics, ranked by relevance and number of users
+// @Produce json
+// @Param q query string false "Typed text"
+// @Param limit query integer false "Maximum number of suggestions (default 10)"
+// @Param fuzzy query boolean false "Tolerate typos (default true)"
+// @Success 200 {array} persistence.Suggestion
+// @Router /api/languages/search [get]
+// @Security Authorization Token
+func SearchLanguages(c *gin.Context) {
+	searchTaxonomy(c, persistence.GetLanguageRepository().Scoped(c.Request.Context()).Search)
+}
+
+// SearchAreas godoc
+// @Summary Suggests areas for the autocomplete
+// @Description Prefix and fuzzy search ignoring case and diacritics, ranked by relevance and number of users
+// @Produce json
+// @Param q query string false "Typed text"
+// @Param limit query integer false "Maximum number of suggestions (default 10)"
+// @Param fuzzy query boolean false "Tolerate typos (default true)"
+// @Success 200 {array} persistence.Suggestion
+// @Router /api/areas/search [get]
+// @Security Authorization Token
+func SearchAreas(c *gin.Context) {
+	searchTaxonomy(c, persistence.GetAreaRepository().Scoped(c.Request.Context()).Search)
+}
+
+// SearchDiets godoc
+// @Summary Suggests diets for the autocomplete
+// @Description Prefix and fuzzy search ignoring case and diacritics, ranked by relevance and number of users
+// @Produce json
+// @Param q query string false "Typed text"
+// @Param limit query integer false "Maximum number of suggestions (default 10)"
+// @Param fuzzy query boolean false "Tolerate typos (default true)"
+// @Success 200 {array} persistence.Suggestion
+// @Router /api/diets/search [get]
+// @Security Authorization Token
+func SearchDiets(c *gin.Context) {
+	searchTaxonomy(c, persistence.GetDietRepository().Scoped(c.Request.Context()).Search)
+}
+
+// SearchCuisines godoc
+// @Summary Suggests cuisines for the autocomplete
+// @Description Prefix and fuzzy search ignoring case and diacritics, ranked by relevance and number of users
+// @Produce json
+// @Param q query string false "Typed text"
+// @Param limit query integer false "Maximum number of suggestions (default 10)"
+// @Param fuzzy query boolean false "Tolerate typos (default true)"
+// @Success 200 {array} persistence.Suggestion
+// @Router /api/cuisines/search [get]
+// @Security Authorization Token
+func SearchCuisines(c *gin.Context) {
+	searchTaxonomy(c, persistence.GetCuisineRepository().Scoped(c.Request.Context()).Search)
+}
+
+// searchTaxonomy answers an autocomplete request with the given search function
+func searchTaxonomy(c *gin.Context, search func(query string, limit int, fuzzy bool) ([]persistence.Suggestion, error)) {
+	limit := helpers.Limit(c.DefaultQuery("limit", "10"))
+	fuzzy := c.DefaultQuery("fuzzy", "true") != "false"
+	if suggestions, err := search(c.Query("q"), limit, fuzzy); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		if suggestions == nil {
+			suggestions = []persistence.Suggestion{}
+		}
+		c.JSON(http.StatusOK, suggestions)
+	}
+}
@@ -0,0 +1,393 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/fixtures"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// TeamInput godoc
+// @type TeamInput
+// @description The name of a team and the name of its department, the department is created when it does not exist
+type TeamInput struct {
+	Name       string `json:"name" binding:"required"`
+	Department string `json:"department"`
+}
+
+// TeamOutput godoc
+// @type TeamOutput
+// @description A team with the usernames of its members
+type TeamOutput struct {
+	models.Team
+	Members []string `json:"members"`
+}
+
+// MembershipInput godoc
+// @type MembershipInput
+// @description The name of the team of a user, an empty name removes the user from its team
+type MembershipInput struct {
+	Team string `json:"team"`
+}
+
+// GetTeams godoc
+// @Summary Retrieves all teams
+// @Description Get teams ordered by name with their department
+// @Produce json
+// @Success 200 {array} users.Team
+// @Router /api/teams [get]
+// @Security Authorization Token
+func GetTeams(c *gin.Context) {
+	s := persistence.GetTeamRepository().Scoped(c.Request.Context())
+	if teams, err := s.All(); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, teams)
+	}
+}
+
+// GetTeamById godoc
+// @Summary Retrieves team based on given ID
+// @Description get Team by ID with the usernames of its members
+// @Produce json
+// @Param id path string true "Team ID"
+// @Success 200 {object} TeamOutput
+// @Router /api/teams/{id} [get]
+// @Security Authorization Token
+func GetTeamById(c *gin.Context) {
+	s := persistence.GetTeamRepository().Scoped(c.Request.Context())
+	team, err := s.Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("team not found"))
+		log.Println(err)
+		return
+	}
+	if members, err := s.Members(team.ID); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, TeamOutput{Team: *team, Members: members})
+	}
+}
+
+// CreateTeam godoc
+// @Summary Creates a team
+// @Description A team with the same name is returned with a conflict
+// @Accept json
+// @Produce json
+// @Param team body TeamInput true "Team"
+// @Success 201 {object} users.Team
+// @Failure 409 {object} users.Team
+// @Router /api/admin/teams [post]
+// @Security Authorization Token
+func CreateTeam(c *gin.Context) {
+	s := persistence.GetTeamRepository().Scoped(c.Request.Context())
+	var teamInput TeamInput
+	if err := c.ShouldBindJSON(&teamInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if models.NameKey(teamInput.Name) == "" {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("name is required"))
+		return
+	}
+	if existing, err := s.GetByName(teamInput.Name); err == nil {
+		c.JSON(http.StatusConflict, existing)
+		return
+	}
+	team := models.Team{Name: teamInput.Name}
+	if !setDepartment(c, &team, teamInput.Department) {
+		return
+	}
+	if err := s.Add(&team); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusCreated, team)
+	}
+}
+
+// UpdateTeam godoc
+// @Summary Renames a team or moves it to another department
+// @Description An empty department removes the team from its department
+// @Accept json
+// @Produce json
+// @Param id path string true "Team ID"
+// @Param team body TeamInput true "Team"
+// @Success 200 {object} users.Team
+// @Router /api/admin/teams/{id} [put]
+// @Security Authorization Token
+func UpdateTeam(c *gin.Context) {
+	s := persistence.GetTeamRepository().Scoped(c.Request.Context())
+	var teamInput TeamInput
+	if err := c.ShouldBindJSON(&teamInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if models.NameKey(teamInput.Name) == "" {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("name is required"))
+		return
+	}
+	team, err := s.Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("team not found"))
+		log.Println(err)
+		return
+	}
+	if existing, err := s.GetByName(teamInput.Name); err == nil && existing.ID != team.ID {
+		http_err.NewError(c, http.StatusConflict, errors.New("the name is already in use"))
+		return
+	}
+	team.Name = teamInput.Name
+	if !setDepartment(c, team, teamInput.Department) {
+		return
+	}
+	if err := s.Update(team); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, team)
+	}
+}
+
+// DeleteTeam godoc
+// @Summary Deletes a team
+// @Description Its members are left without team
+// @Param id path string true "Team ID"
+// @Success 204
+// @Router /api/admin/teams/{id} [delete]
+// @Security Authorization Token
+func DeleteTeam(c *gin.Context) {
+	s := persistence.GetTeamRepository().Scoped(c.Request.Context())
+	if team, err := s.Get(c.Param("id")); err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("team not found"))
+		log.Println(err)
+	} else {
+		if err := s.Delete(team); err != nil {
+			http_err.NewError(c, http.StatusInternalServerError, err)
+			log.Println(err)
+		} else {
+			c.Status(http.StatusNoContent)
+		}
+	}
+}
+
+// ImportTeams godoc
+// @Summary Assigns users to their teams and departments in bulk
+// @Description The CSV file has the username, team and department columns, it is sent as the file field of a form or as a text/csv body
+// @Description The missing teams and departments are created, nothing is changed when a username is unknown
+// @Accept multipart/form-data
+// @Accept text/csv
+// @Produce json
+// @Param file formData file false "CSV file"
+// @Success 200 {object} fixtures.Report
+// @Router /api/admin/teams/import [post]
+// @Security Authorization Token
+func ImportTeams(c *gin.Context) {
+	var content io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			http_err.NewError(c, http.StatusBadRequest, errors.New("file is required"))
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			http_err.NewError(c, http.StatusBadRequest, err)
+			return
+		}
+		defer file.Close()
+		content = file
+	}
+	memberships, err := fixtures.ReadMembershipsCSV(content)
+	if err != nil {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("could not read the teams: "+err.Error()))
+		return
+	}
+	if report, err := fixtures.ApplyMemberships(c.Request.Context(), memberships); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// SetUserTeam godoc
+// @Summary Assigns a user to a team
+// @Description An empty team removes the user from its team
+// @Accept json
+// @Produce json
+// @Param username path string true "Username"
+// @Param membership body MembershipInput true "Team"
+// @Success 200 {object} UserResponse
+// @Router /api/admin/users/{username}/team [put]
+// @Security Authorization Token
+func SetUserTeam(c *gin.Context) {
+	var membershipInput MembershipInput
+	if err := c.ShouldBindJSON(&membershipInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	user, err := persistence.GetUserRepository().Scoped(c.Request.Context()).GetByUsername(c.Param("username"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("user not found"))
+		log.Println(err)
+		return
+	}
+	s := persistence.GetTeamRepository().Scoped(c.Request.Context())
+	var team *models.Team
+	if models.NameKey(membershipInput.Team) != "" {
+		if team, err = s.GetByName(membershipInput.Team); err != nil {
+			http_err.NewError(c, http.StatusNotFound, errors.New("team not found"))
+			return
+		}
+	}
+	if err := s.SetTeam(user, team); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, CreateUserCard(user))
+	}
+}
+
+// GetDepartments godoc
+// @Summary Retrieves all departments
+// @Description Get departments ordered by name
+// @Produce json
+// @Success 200 {array} users.Department
+// @Router /api/departments [get]
+// @Security Authorization Token
+func GetDepartments(c *gin.Context) {
+	s := persistence.GetTeamRepository().Scoped(c.Request.Context())
+	if departments, err := s.AllDepartments(); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, departments)
+	}
+}
+
+// CreateDepartment godoc
+// @Summary Creates a department
+// @Description A department with the same name is returned with a conflict
+// @Accept json
+// @Produce json
+// @Param department body users.Department true "Department"
+// @Success 201 {object} users.Department
+// @Failure 409 {object} users.Department
+// @Router /api/admin/departments [post]
+// @Security Authorization Token
+func CreateDepartment(c *gin.Context) {
+	s := persistence.GetTeamRepository().Scoped(c.Request.Context())
+	var departmentInput models.Department
+	if err := c.ShouldBindJSON(&departmentInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if models.NameKey(departmentInput.Name) == "" {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("name is required"))
+		return
+	}
+	if existing, err := s.GetDepartmentByName(departmentInput.Name); err == nil {
+		c.JSON(http.StatusConflict, existing)
+		return
+	}
+	department := models.Department{Name: departmentInput.Name}
+	if err := s.AddDepartment(&department); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusCreated, department)
+	}
+}
+
+// UpdateDepartment godoc
+// @Summary Renames a department
+// @Description Update Department
+// @Accept json
+// @Produce json
+// @Param id path string true "Department ID"
+// @Param department body users.Department true "Department"
+// @Success 200 {object} users.Department
+// @Router /api/admin/departments/{id} [put]
+// @Security Authorization Token
+func UpdateDepartment(c *gin.Context) {
+	s := persistence.GetTeamRepository().Scoped(c.Request.Context())
+	var departmentInput models.Department
+	if err := c.ShouldBindJSON(&departmentInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if models.NameKey(departmentInput.Name) == "" {
+		http_err.NewError(c, http.StatusBadRequest, errors.New("name is required"))
+		return
+	}
+	department, err := s.GetDepartment(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("department not found"))
+		log.Println(err)
+		return
+	}
+	if existing, err := s.GetDepartmentByName(departmentInput.Name); err == nil && existing.ID != department.ID {
+		http_err.NewError(c, http.StatusConflict, errors.New("the name is already in use"))
+		return
+	}
+	department.Name = departmentInput.Name
+	if err := s.UpdateDepartment(department); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, department)
+	}
+}
+
+// DeleteDepartment godoc
+// @Summary Deletes a department
+// @Description Its teams are left without department
+// @Param id path string true "Department ID"
+// @Success 204
+// @Router /api/admin/departments/{id} [delete]
+// @Security Authorization Token
+func DeleteDepartment(c *gin.Context) {
+	s := persistence.GetTeamRepository().Scoped(c.Request.Context())
+	if department, err := s.GetDepartment(c.Param("id")); err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("department not found"))
+		log.Println(err)
+	} else {
+		if err := s.DeleteDepartment(department); err != nil {
+			http_err.NewError(c, http.StatusInternalServerError, err)
+			log.Println(err)
+		} else {
+			c.Status(http.StatusNoContent)
+		}
+	}
+}
+
+// setDepartment moves the team to the department of the name, creating it when it does not exist
+// An empty name removes the team from its department
+// It writes an internal error when the department could not be created
+func setDepartment(c *gin.Context, team *models.Team, name string) bool {
+	team.DepartmentID, team.Department = nil, nil
+	if models.NameKey(name) == "" {
+		return true
+	}
+	s := persistence.GetTeamRepository().Scoped(c.Request.Context())
+	department, err := s.GetDepartmentByName(name)
+	if err != nil {
+		department = &models.Department{Name: name}
+		if err := s.AddDepartment(department); err != nil {
+			http_err.NewError(c, http.StatusInternalServerError, err)
+			log.Println(err)
+			return false
+		}
+	}
+	team.DepartmentID, team.Department = &department.ID, department
+	return true
+}
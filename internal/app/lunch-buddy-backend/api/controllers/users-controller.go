@@ -4,8 +4,13 @@ import (
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/calendar"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
 	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
 	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/registration"
 	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/crypto"
 	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
 	"log"
@@ -21,35 +26,50 @@ type UserInput struct {
 }
 
 type UserResponse struct {
-	Username      string   `json:"username"`
-	FirstName     string   `json:"firstName"`
-	LastName      string   `json:"lastName"`
-	Bio           string   `json:"bio"`
-	IsSetup       bool     `json:"isSetup"`
-	Hobbies       []string `json:"hobbies"`
-	Languages     []string `json:"languages"`
-	Areas         []string `json:"areas"`
-	LunchStart    string   `json:"lunchStart"`
-	LunchEnd      string   `json:"lunchEnd"`
-	LunchType     string   `json:"lunchType"`
-	LunchFood     string   `json:"lunchFood"`
-	LunchLocation string   `json:"lunchLocation"`
-	Buddies       []string `json:"buddies"`
-	Blacklist     []string `json:"blacklist"`
-	Likes         []string `json:"likes"`
+	Username      string     `json:"username"`
+	FirstName     string     `json:"firstName"`
+	LastName      string     `json:"lastName"`
+	Bio           string     `json:"bio"`
+	IsSetup       bool       `json:"isSetup"`
+	EmailVerified bool       `json:"emailVerified"`
+	Hobbies       []string   `json:"hobbies"`
+	Languages     []string   `json:"languages"`
+	Areas         []string   `json:"areas"`
+	Diets         []string   `json:"diets"`
+	Cuisines      []string   `json:"cuisines"`
+	Team          string     `json:"team"`
+	Department    string     `json:"department"`
+	TeamMatching  string     `json:"teamMatching"`
+	LunchStart    string     `json:"lunchStart"`
+	LunchEnd      string     `json:"lunchEnd"`
+	LunchType     string     `json:"lunchType"`
+	LunchFood     string     `json:"lunchFood"`
+	LunchLocation string     `json:"lunchLocation"`
+	LunchPlaceID  *uuid.UUID `json:"lunchPlaceId,omitempty"`
+	TimeZone      string     `json:"timezone"`
+	Buddies       []string   `json:"buddies"`
+	Blacklist     []string   `json:"blacklist"`
+	Likes         []string   `json:"likes"`
 }
 
 type UserInformation struct {
-	AreaNames     []string `json:"areaName"`
-	HobbyNames    []string `json:"hobbyNames"`
-	LanguageNames []string `json:"languageNames"`
-	LunchLocation string   `json:"lunchLocation"`
-	LunchTime     string   `json:"lunchTime"`
-	LunchType     string   `json:"lunchType"`
-	LunchFood     string   `json:"lunchFood"`
-	Bio           string   `json:"bio"`
+	AreaNames     []string   `json:"areaName"`
+	HobbyNames    []string   `json:"hobbyNames"`
+	LanguageNames []string   `json:"languageNames"`
+	DietNames     []string   `json:"dietNames"`
+	CuisineNames  []string   `json:"cuisineNames"`
+	LunchLocation string     `json:"lunchLocation"`
+	LunchPlaceID  *uuid.UUID `json:"lunchPlaceId"`
+	LunchTime     string     `json:"lunchTime"`
+	LunchType     string     `json:"lunchType"`
+	LunchFood     string     `json:"lunchFood"`
+	Bio           string     `json:"bio"`
+	TimeZone      string     `json:"timezone"`
 }
 
+// lunchTimeLayouts are the accepted formats of the lunch time
+var lunchTimeLayouts = []string{"15:04:05", "15:04"}
+
 // GetUserById godoc
 // @Summary Retrieves user based on given ID
 // @Description get User by ID
@@ -59,7 +79,7 @@ type UserInformation struct {
 // @Router /api/users/{id} [get]
 // @Security Authorization Token
 func GetUserById(c *gin.Context) {
-	s := persistence.GetUserRepository()
+	s := persistence.GetUserRepository().Scoped(c.Request.Context())
 	id := c.Param("id")
 	if user, err := s.Get(id); err != nil {
 		http_err.NewError(c, http.StatusNotFound, errors.New("user not found"))
@@ -80,7 +100,7 @@ func GetUserById(c *gin.Context) {
 // @Router /api/users [get]
 // @Security Authorization Token
 func GetUsers(c *gin.Context) {
-	s := persistence.GetUserRepository()
+	s := persistence.GetUserRepository().Scoped(c.Request.Context())
 	var q models.User
 	_ = c.Bind(&q)
 	if users, err := s.Query(&q); err != nil {
@@ -93,15 +113,16 @@ func GetUsers(c *gin.Context) {
 
 // CreateUser godoc
 // @Summary Creates a new user
-// @Description Create User
+// @Description Create User, the users created by an admin do not have to verify their email address
 // @Accept json
 // @Produce json
 // @Param user body UserInput true "User"
 // @Success 201 {object} users.User
+// @Failure 409 {object} http_err.HTTPError
 // @Router /api/users [post]
 // @Security Authorization Token
 func CreateUser(c *gin.Context) {
-	s := persistence.GetUserRepository()
+	s := persistence.GetUserRepository().Scoped(c.Request.Context())
 	var userInput UserInput
 	_ = c.BindJSON(&userInput)
 	user := models.User{
@@ -111,7 +132,11 @@ func CreateUser(c *gin.Context) {
 		Hash:      crypto.HashAndSalt([]byte(userInput.Password)),
 		//Role:      models.UserRole{RoleName: userInput.Role},
 	}
-	if err := s.Add(&user); err != nil {
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	if err := s.Add(&user); errors.Is(err, persistence.ErrUsernameTaken) {
+		http_err.NewError(c, http.StatusConflict, err)
+	} else if err != nil {
 		http_err.NewError(c, http.StatusBadRequest, err)
 		log.Println(err)
 	} else {
@@ -121,54 +146,90 @@ func CreateUser(c *gin.Context) {
 
 // UpdateUser godoc
 // @Summary Updates an existing user
-// @Description Update User
+// @Description Update User, only the user itself or an admin can update it.
+// @Description A new username has to be verified again unless an admin changes it.
 // @Accept json
 // @Produce json
 // @Param id path integer true "User ID"
 // @Param user body UserInput true "User"
 // @Success 200 {object} users.User
+// @Failure 403 {object} http_err.HTTPError
+// @Failure 409 {object} http_err.HTTPError
 // @Router /api/users/{id} [put]
 // @Security Authorization Token
 func UpdateUser(c *gin.Context) {
-	s := persistence.GetUserRepository()
+	s := persistence.GetUserRepository().Scoped(c.Request.Context())
 	id := c.Params.ByName("id")
 	var userInput UserInput
-	_ = c.BindJSON(&userInput)
-	if user, err := s.Get(id); err != nil {
+	if err := c.ShouldBindJSON(&userInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	user, err := s.Get(id)
+	if err != nil {
 		http_err.NewError(c, http.StatusNotFound, errors.New("user not found"))
 		log.Println(err)
-	} else {
-		user.Username = userInput.Username
-		user.Lastname = userInput.Lastname
-		user.Firstname = userInput.Firstname
-		user.Hash = crypto.HashAndSalt([]byte(userInput.Password))
-		//user.Role = models.UserRole{RoleName: userInput.Role}
-		if err := s.Update(user); err != nil {
-			http_err.NewError(c, http.StatusNotFound, err)
+		return
+	}
+	if !canManage(c, user) {
+		return
+	}
+	renamed := userInput.Username != user.Username
+	if renamed {
+		if _, ok := registration.Domain(userInput.Username); !ok && !isAdmin(currentUser(c)) {
+			http_err.NewError(c, http.StatusBadRequest, registration.ErrInvalidEmail)
+			return
+		}
+		if taken, err := s.UsernameTaken(userInput.Username, user.ID); err != nil {
+			http_err.NewError(c, http.StatusInternalServerError, err)
 			log.Println(err)
-		} else {
-			c.JSON(http.StatusOK, user)
+			return
+		} else if taken {
+			http_err.NewError(c, http.StatusConflict, persistence.ErrUsernameTaken)
+			return
 		}
 	}
+	user.Username = userInput.Username
+	user.Lastname = userInput.Lastname
+	user.Firstname = userInput.Firstname
+	user.Hash = crypto.HashAndSalt([]byte(userInput.Password))
+	//user.Role = models.UserRole{RoleName: userInput.Role}
+	// The user proves again that the new username is its email address
+	verify := renamed && !isAdmin(currentUser(c)) && config.GetConfig().Registration.VerifyEmail
+	if verify {
+		user.EmailVerifiedAt = nil
+	}
+	if err := s.Update(user); err != nil {
+		http_err.NewError(c, http.StatusNotFound, err)
+		log.Println(err)
+		return
+	}
+	if verify {
+		if err := registration.SendVerification(user); err != nil {
+			log.Println(err)
+		}
+	}
+	c.JSON(http.StatusOK, user)
 }
 
 // DeleteUser godoc
 // @Summary Deletes a user
-// @Description Delete User
+// @Description Delete User, only the user itself or an admin can delete it
 // @Produce json
 // @Param id path integer true "User ID"
 // @Success 204
+// @Failure 403 {object} http_err.HTTPError
 // @Router /api/users/{id} [delete]
 // @Security Authorization Token
 func DeleteUser(c *gin.Context) {
-	s := persistence.GetUserRepository()
+	s := persistence.GetUserRepository().Scoped(c.Request.Context())
 	id := c.Params.ByName("id")
 	/*	var userInput UserInput
 		_ = c.BindJSON(&userInput)*/
 	if user, err := s.Get(id); err != nil {
 		http_err.NewError(c, http.StatusNotFound, errors.New("user not found"))
 		log.Println(err)
-	} else {
+	} else if canManage(c, user) {
 		if err := s.Delete(user); err != nil {
 			http_err.NewError(c, http.StatusNotFound, err)
 			log.Println(err)
@@ -178,6 +239,23 @@ func DeleteUser(c *gin.Context) {
 	}
 }
 
+// canManage returns true if the authenticated user is the user itself or an admin of its organization
+// Only the admins of the deployment manage the other admins of the deployment
+// It responds with 403 otherwise
+func canManage(c *gin.Context, user *models.User) bool {
+	current := currentUser(c)
+	if current.ID == user.ID || current.IsAdmin || (current.OrganizationAdmin && !user.IsAdmin) {
+		return true
+	}
+	http_err.NewError(c, http.StatusForbidden, errors.New("you can only manage your own account"))
+	return false
+}
+
+// isAdmin returns true if the user administers the deployment or its organization
+func isAdmin(user *models.User) bool {
+	return user.IsAdmin || user.OrganizationAdmin
+}
+
 // GetUserByUsername godoc
 // @Summary Retrieves user based on given username
 // @Description get User by username
@@ -187,7 +265,7 @@ func DeleteUser(c *gin.Context) {
 // @Router /api/users/username/{username} [get]
 // @Security Authorization Token
 func GetUserByUsername(c *gin.Context) {
-	s := persistence.GetUserRepository()
+	s := persistence.GetUserRepository().Scoped(c.Request.Context())
 	username := c.Param("username")
 	if user, err := s.GetByUsername(username); err != nil {
 		http_err.NewError(c, http.StatusNotFound, errors.New("user not found"))
@@ -200,13 +278,13 @@ func GetUserByUsername(c *gin.Context) {
 }
 
 func AddUserInformation(c *gin.Context) {
-	u := persistence.GetUserRepository()
+	u := persistence.GetUserRepository().Scoped(c.Request.Context())
 
 	id := c.Param("id")
 	if user, err := u.Get(id); err != nil {
 		http_err.NewError(c, http.StatusNotFound, errors.New("user not found"))
 		log.Println(err)
-	} else {
+	} else if canManage(c, user) {
 		var userInformation UserInformation
 		_ = c.BindJSON(&userInformation)
 
@@ -217,17 +295,31 @@ func AddUserInformation(c *gin.Context) {
 				log.Println(err)
 			}
 		}
+		if userInformation.TimeZone != "" {
+			if _, err := time.LoadLocation(userInformation.TimeZone); err != nil {
+				http_err.NewError(c, http.StatusBadRequest, errors.New("unknown time zone "+userInformation.TimeZone))
+				return
+			}
+			user.TimeZone = userInformation.TimeZone
+			if err := u.Update(user); err != nil {
+				http_err.NewError(c, http.StatusNotFound, err)
+				log.Println(err)
+			}
+		}
 		AddUserAreas(c, userInformation, user)
 		AddUserLunch(c, userInformation, user)
 		AddUserHobbies(c, userInformation, user)
 		AddUserLanguages(c, userInformation, user)
+		AddUserDiets(c, userInformation, user)
+		AddUserCuisines(c, userInformation, user)
+		completeProfile(c, user)
 		c.JSON(http.StatusOK, user)
 	}
 }
 
 func AddUserAreas(c *gin.Context, userInformation UserInformation, user *models.User) {
-	u := persistence.GetUserRepository()
-	a := persistence.GetAreaRepository()
+	u := persistence.GetUserRepository().Scoped(c.Request.Context())
+	a := persistence.GetAreaRepository().Scoped(c.Request.Context())
 
 	if userInformation.AreaNames != nil && len(userInformation.AreaNames) > 0 {
 		for _, areaName := range userInformation.AreaNames {
@@ -253,15 +345,15 @@ func AddUserAreas(c *gin.Context, userInformation UserInformation, user *models.
 }
 
 func AddUserLunch(c *gin.Context, userInformation UserInformation, user *models.User) {
-	u := persistence.GetUserRepository()
-	l := persistence.GetLunchRepository()
-
-	if userInformation.LunchLocation != "" && userInformation.LunchTime != "" && userInformation.LunchType != "" && userInformation.LunchFood != "" {
-		loc, _ := time.LoadLocation("Europe/Bratislava")
-		currentTime := time.Now()
-		fmt.Println(currentTime.Format("2006-01-02"))
-		//if lunchTime, err := time.Parse("2006-01-01 15:04:05 +01:00", "1970-01-01 "+userInformation.LunchTime+" +01:00"); err != nil {
-		if lunchTime, err := time.ParseInLocation("2006-01-02 15:04:05 (MST)", currentTime.Format("2006-01-02")+" "+userInformation.LunchTime+" (CET)", loc); err != nil {
+	u := persistence.GetUserRepository().Scoped(c.Request.Context())
+	l := persistence.GetLunchRepository().Scoped(c.Request.Context())
+
+	if (userInformation.LunchLocation != "" || userInformation.LunchPlaceID != nil) && userInformation.LunchTime != "" && userInformation.LunchType != "" && userInformation.LunchFood != "" {
+		place, location, ok := resolvePlace(c, userInformation.LunchPlaceID, userInformation.LunchLocation)
+		if !ok {
+			return
+		}
+		if lunchTime, err := parseLunchTime(userInformation.LunchTime, calendar.Location(user)); err != nil {
 			http_err.NewError(c, http.StatusBadRequest, err)
 			log.Println(err)
 		} else {
@@ -278,7 +370,8 @@ func AddUserLunch(c *gin.Context, userInformation UserInformation, user *models.
 			//if existingLunch, err := l.Get(user.Lunch.ID.String()); err != nil || existingLunch.Time.IsZero() {
 			if existingLunch, err := u.GetUserLunch(user); err != nil || existingLunch.Time.IsZero() {
 				fmt.Println("Lunch not found, creating new one")
-				lunch := models.Lunch{Location: userInformation.LunchLocation, Time: lunchTime, Type: userInformation.LunchType, Food: userInformation.LunchFood, UserID: user.ID}
+				lunch := models.Lunch{Time: lunchTime, Type: userInformation.LunchType, Food: userInformation.LunchFood, UserID: user.ID}
+				lunch.SetPlace(place, location)
 				if err := l.Add(&lunch); err != nil {
 					http_err.NewError(c, http.StatusNotFound, err)
 					log.Println(err)
@@ -286,7 +379,7 @@ func AddUserLunch(c *gin.Context, userInformation UserInformation, user *models.
 			} else {
 				fmt.Println("Lunch found, updating")
 				fmt.Println("Lunch: ", existingLunch)
-				existingLunch.Location = userInformation.LunchLocation
+				existingLunch.SetPlace(place, location)
 				existingLunch.Time = lunchTime
 				existingLunch.Type = userInformation.LunchType
 				existingLunch.Food = userInformation.LunchFood
@@ -301,8 +394,8 @@ func AddUserLunch(c *gin.Context, userInformation UserInformation, user *models.
 }
 
 func AddUserHobbies(c *gin.Context, userInformation UserInformation, user *models.User) {
-	u := persistence.GetUserRepository()
-	h := persistence.GetHobbyRepository()
+	u := persistence.GetUserRepository().Scoped(c.Request.Context())
+	h := persistence.GetHobbyRepository().Scoped(c.Request.Context())
 
 	if userInformation.HobbyNames != nil && len(userInformation.HobbyNames) > 0 {
 		log.Println(userInformation.HobbyNames)
@@ -316,7 +409,7 @@ func AddUserHobbies(c *gin.Context, userInformation UserInformation, user *model
 				} else {
 					hobbies = append(hobbies, *hobby)
 				}
-			} else {
+			} else if !containsHobby(hobbies, hobby) {
 				hobbies = append(hobbies, *hobby)
 			}
 		}
@@ -329,8 +422,8 @@ func AddUserHobbies(c *gin.Context, userInformation UserInformation, user *model
 }
 
 func AddUserLanguages(c *gin.Context, userInformation UserInformation, user *models.User) {
-	u := persistence.GetUserRepository()
-	l := persistence.GetLanguageRepository()
+	u := persistence.GetUserRepository().Scoped(c.Request.Context())
+	l := persistence.GetLanguageRepository().Scoped(c.Request.Context())
 
 	if userInformation.LanguageNames != nil && len(userInformation.LanguageNames) > 0 {
 		var languages []models.Language
@@ -343,7 +436,7 @@ func AddUserLanguages(c *gin.Context, userInformation UserInformation, user *mod
 				} else {
 					languages = append(languages, *language)
 				}
-			} else {
+			} else if !containsLanguage(languages, language) {
 				languages = append(languages, *language)
 			}
 		}
@@ -355,8 +448,132 @@ func AddUserLanguages(c *gin.Context, userInformation UserInformation, user *mod
 	}
 }
 
+// AddUserDiets replaces the dietary restrictions and allergies of the user
+// The diets are chosen from the managed taxonomy, an empty list clears them
+func AddUserDiets(c *gin.Context, userInformation UserInformation, user *models.User) {
+	if userInformation.DietNames == nil {
+		return
+	}
+	d := persistence.GetDietRepository().Scoped(c.Request.Context())
+	diets := []models.Diet{}
+	for _, dietName := range userInformation.DietNames {
+		diet, err := d.GetByName(dietName)
+		if err != nil {
+			http_err.NewError(c, http.StatusBadRequest, errors.New("unknown diet "+dietName))
+			return
+		}
+		if !containsDiet(diets, diet) {
+			diets = append(diets, *diet)
+		}
+	}
+	if err := persistence.GetUserRepository().Scoped(c.Request.Context()).ChangeUserDiets(user, diets); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	}
+}
+
+// AddUserCuisines replaces the favorite cuisines of the user
+// The cuisines are chosen from the managed taxonomy, an empty list clears them
+func AddUserCuisines(c *gin.Context, userInformation UserInformation, user *models.User) {
+	if userInformation.CuisineNames == nil {
+		return
+	}
+	k := persistence.GetCuisineRepository().Scoped(c.Request.Context())
+	cuisines := []models.Cuisine{}
+	for _, cuisineName := range userInformation.CuisineNames {
+		cuisine, err := k.GetByName(cuisineName)
+		if err != nil {
+			http_err.NewError(c, http.StatusBadRequest, errors.New("unknown cuisine "+cuisineName))
+			return
+		}
+		if !containsCuisine(cuisines, cuisine) {
+			cuisines = append(cuisines, *cuisine)
+		}
+	}
+	if err := persistence.GetUserRepository().Scoped(c.Request.Context()).ChangeUserCuisines(user, cuisines); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	}
+}
+
+// completeProfile marks the profile of the user as set up once every part of it is filled in
+// and its email address is verified
+func completeProfile(c *gin.Context, user *models.User) {
+	if user.IsSetup {
+		return
+	}
+	u := persistence.GetUserRepository().Scoped(db.WithOrganization(c.Request.Context(), user.OrganizationID))
+	updated, err := u.Get(user.ID.String())
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if updated.HasCompleteProfile() && updated.EmailVerifiedAt != nil {
+		if err := u.CompleteProfile(user); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// containsHobby returns true if the hobby is already in hobbies
+// Different names can resolve to the same hobby through its aliases
+func containsHobby(hobbies []models.Hobby, hobby *models.Hobby) bool {
+	for _, h := range hobbies {
+		if h.ID == hobby.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// containsLanguage returns true if the language is already in languages
+// Different names can resolve to the same language through its aliases
+func containsLanguage(languages []models.Language, language *models.Language) bool {
+	for _, l := range languages {
+		if l.ID == language.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// containsDiet returns true if the diet is already in diets
+// Different names can resolve to the same diet through its aliases
+func containsDiet(diets []models.Diet, diet *models.Diet) bool {
+	for _, d := range diets {
+		if d.ID == diet.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// containsCuisine returns true if the cuisine is already in cuisines
+// Different names can resolve to the same cuisine through its aliases
+func containsCuisine(cuisines []models.Cuisine, cuisine *models.Cuisine) bool {
+	for _, k := range cuisines {
+		if k.ID == cuisine.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLunchTime returns today at the time of day in the location
+func parseLunchTime(value string, location *time.Location) (time.Time, error) {
+	var err error
+	for _, layout := range lunchTimeLayouts {
+		var clock time.Time
+		if clock, err = time.Parse(layout, value); err == nil {
+			now := time.Now().In(location)
+			return time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, location), nil
+		}
+	}
+	return time.Time{}, err
+}
+
 func GetUserCard(c *gin.Context) {
-	u := persistence.GetUserRepository()
+	u := persistence.GetUserRepository().Scoped(c.Request.Context())
 
 	name := c.Param("name")
 	if user, err := u.GetByUsername(name); err != nil {
@@ -389,35 +606,60 @@ func CreateUserCard(user *models.User) UserResponse {
 	for i, blackList := range user.Blacklist {
 		blackListNames[i] = blackList.Username
 	}
+	dietNames := make([]string, len(user.Diets))
+	for i, diet := range user.Diets {
+		dietNames[i] = diet.Name
+	}
+	cuisineNames := make([]string, len(user.Cuisines))
+	for i, cuisine := range user.Cuisines {
+		cuisineNames[i] = cuisine.Name
+	}
 	likesNames := make([]string, len(user.Likes))
 	for i, like := range user.Likes {
 		likesNames[i] = like.Username
 	}
 
+	teamName, departmentName := "", ""
+	if user.Team != nil {
+		teamName = user.Team.Name
+		if user.Team.Department != nil {
+			departmentName = user.Team.Department.Name
+		}
+	}
+
+	lunchTime := user.Lunch.Time.In(calendar.Location(user))
 	userResponse := UserResponse{
 		Username:      user.Username,
 		FirstName:     user.Firstname,
 		LastName:      user.Lastname,
 		Bio:           user.Bio,
 		IsSetup:       user.IsSetup,
+		EmailVerified: user.EmailVerifiedAt != nil,
 		Hobbies:       hobbiesNames,
 		Languages:     languageNames,
 		Areas:         areasNames,
+		Diets:         dietNames,
+		Cuisines:      cuisineNames,
+		Team:          teamName,
+		Department:    departmentName,
+		TeamMatching:  user.TeamMatching,
 		Buddies:       buddiesNames,
 		Blacklist:     blackListNames,
 		Likes:         likesNames,
 		LunchLocation: user.Lunch.Location,
-		LunchStart:    user.Lunch.Time.Format("15:04"),
-		LunchEnd:      user.Lunch.Time.Add(time.Hour / 2).Format("15:04"),
+		LunchPlaceID:  user.Lunch.PlaceID,
+		LunchStart:    lunchTime.Format("15:04"),
+		LunchEnd:      lunchTime.Add(models.LunchDuration).Format("15:04"),
 		LunchType:     user.Lunch.Type,
 		LunchFood:     user.Lunch.Food,
+		TimeZone:      calendar.Location(user).String(),
 	}
 
 	return userResponse
 }
 
 func GetUsersForDashboard(c *gin.Context) {
-	u := persistence.GetUserRepository()
+	u := persistence.GetUserRepository().Scoped(c.Request.Context())
 
 	if users, err := u.GetRandomFiveUsersWithAssociation(); err != nil {
 		http_err.NewError(c, http.StatusNotFound, errors.New("users not found"))
@@ -0,0 +1,287 @@
+package controllers
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/events"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/webhooks"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/webhooks"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/crypto"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/helpers"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/http-err"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// webhookSecretSize is the number of random bytes of a generated signing secret
+const webhookSecretSize = 32
+
+// WebhookInput godoc
+// @type WebhookInput
+// @description A webhook endpoint, every event is sent when events is empty
+// @description A signing secret is generated when it is empty on creation
+type WebhookInput struct {
+	URL         string   `json:"url" binding:"required"`
+	Description string   `json:"description"`
+	Secret      string   `json:"secret"`
+	Events      []string `json:"events"`
+	Active      *bool    `json:"active"`
+}
+
+// WebhookOutput godoc
+// @type WebhookOutput
+// @description A webhook, the secret is only returned when the webhook is created
+type WebhookOutput struct {
+	ID          uuid.UUID `json:"id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	Events      []string  `json:"events"`
+	Active      bool      `json:"active"`
+	Secret      string    `json:"secret,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// GetWebhooks godoc
+// @Summary Retrieves the webhooks
+// @Description Get Webhooks
+// @Produce json
+// @Success 200 {array} WebhookOutput
+// @Router /api/admin/webhooks [get]
+// @Security Authorization Token
+func GetWebhooks(c *gin.Context) {
+	s := persistence.GetWebhookRepository()
+	all, err := s.All()
+	if err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+		return
+	}
+	output := make([]WebhookOutput, len(*all))
+	for i := range *all {
+		output[i] = webhookOutput(&(*all)[i])
+	}
+	c.JSON(http.StatusOK, output)
+}
+
+// GetWebhookById godoc
+// @Summary Retrieves a webhook based on given ID
+// @Description Get Webhook by ID
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} WebhookOutput
+// @Router /api/admin/webhooks/{id} [get]
+// @Security Authorization Token
+func GetWebhookById(c *gin.Context) {
+	s := persistence.GetWebhookRepository()
+	if webhook, err := s.Get(c.Param("id")); err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("webhook not found"))
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, webhookOutput(webhook))
+	}
+}
+
+// CreateWebhook godoc
+// @Summary Registers a webhook
+// @Description The requests are signed with the secret, see the X-LunchBuddy-Signature header
+// @Accept json
+// @Produce json
+// @Param webhook body WebhookInput true "Webhook"
+// @Success 201 {object} WebhookOutput
+// @Router /api/admin/webhooks [post]
+// @Security Authorization Token
+func CreateWebhook(c *gin.Context) {
+	s := persistence.GetWebhookRepository()
+	var webhookInput WebhookInput
+	if err := c.ShouldBindJSON(&webhookInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		log.Println(err)
+		return
+	}
+	webhook := models.Webhook{Active: true}
+	if err := applyWebhookInput(&webhook, &webhookInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if webhook.Secret == "" {
+		secret, err := crypto.RandomToken(webhookSecretSize)
+		if err != nil {
+			http_err.NewError(c, http.StatusInternalServerError, err)
+			log.Println(err)
+			return
+		}
+		webhook.Secret = secret
+	}
+	if err := s.Add(&webhook); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		log.Println(err)
+		return
+	}
+	output := webhookOutput(&webhook)
+	output.Secret = webhook.Secret
+	c.JSON(http.StatusCreated, output)
+}
+
+// UpdateWebhook godoc
+// @Summary Updates a webhook
+// @Description The secret is kept when it is empty
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param webhook body WebhookInput true "Webhook"
+// @Success 200 {object} WebhookOutput
+// @Router /api/admin/webhooks/{id} [put]
+// @Security Authorization Token
+func UpdateWebhook(c *gin.Context) {
+	s := persistence.GetWebhookRepository()
+	var webhookInput WebhookInput
+	if err := c.ShouldBindJSON(&webhookInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		log.Println(err)
+		return
+	}
+	webhook, err := s.Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("webhook not found"))
+		log.Println(err)
+		return
+	}
+	if err := applyWebhookInput(webhook, &webhookInput); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.Update(webhook); err != nil {
+		http_err.NewError(c, http.StatusBadRequest, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, webhookOutput(webhook))
+	}
+}
+
+// DeleteWebhook godoc
+// @Summary Deletes a webhook
+// @Description The delivery log of the webhook is deleted too
+// @Param id path string true "Webhook ID"
+// @Success 204
+// @Router /api/admin/webhooks/{id} [delete]
+// @Security Authorization Token
+func DeleteWebhook(c *gin.Context) {
+	s := persistence.GetWebhookRepository()
+	webhook, err := s.Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("webhook not found"))
+		log.Println(err)
+		return
+	}
+	if err := s.Delete(webhook); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusNoContent, "")
+	}
+}
+
+// PingWebhook godoc
+// @Summary Sends a test ping to a webhook
+// @Description The ping is sent right away and its outcome is returned, it is kept in the delivery log
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} webhooks.Delivery
+// @Router /api/admin/webhooks/{id}/ping [post]
+// @Security Authorization Token
+func PingWebhook(c *gin.Context) {
+	s := persistence.GetWebhookRepository()
+	webhook, err := s.Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("webhook not found"))
+		log.Println(err)
+		return
+	}
+	if delivery, err := webhooks.NewSender(config.GetConfig().Webhooks).Ping(webhook); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, delivery)
+	}
+}
+
+// GetWebhookDeliveries godoc
+// @Summary Retrieves the delivery log of a webhook
+// @Description Get Deliveries, the newest first
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param status query string false "pending, succeeded or failed"
+// @Param limit query integer false "Page size (default 25)"
+// @Param offset query integer false "Offset"
+// @Success 200 {array} webhooks.Delivery
+// @Router /api/admin/webhooks/{id}/deliveries [get]
+// @Security Authorization Token
+func GetWebhookDeliveries(c *gin.Context) {
+	s := persistence.GetWebhookRepository()
+	webhook, err := s.Get(c.Param("id"))
+	if err != nil {
+		http_err.NewError(c, http.StatusNotFound, errors.New("webhook not found"))
+		log.Println(err)
+		return
+	}
+	limit := helpers.Limit(c.Query("limit"))
+	offset := helpers.Offset(c.Query("offset"))
+	if deliveries, err := s.Deliveries(webhook.ID, c.Query("status"), limit, offset); err != nil {
+		http_err.NewError(c, http.StatusInternalServerError, err)
+		log.Println(err)
+	} else {
+		c.JSON(http.StatusOK, deliveries)
+	}
+}
+
+// applyWebhookInput validates the input and copies it to the webhook
+func applyWebhookInput(webhook *models.Webhook, webhookInput *WebhookInput) error {
+	endpoint, err := url.Parse(webhookInput.URL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return errors.New("url must be an absolute http or https url")
+	}
+	for _, eventType := range webhookInput.Events {
+		if !validEventType(eventType) {
+			return errors.New("unknown event " + eventType)
+		}
+	}
+	webhook.URL = webhookInput.URL
+	webhook.Description = webhookInput.Description
+	webhook.SetEventTypes(webhookInput.Events)
+	if webhookInput.Secret != "" {
+		webhook.Secret = webhookInput.Secret
+	}
+	if webhookInput.Active != nil {
+		webhook.Active = *webhookInput.Active
+	}
+	return nil
+}
+
+// validEventType returns true if the event type is a domain event
+func validEventType(eventType string) bool {
+	for _, known := range events.Types {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookOutput returns the webhook without its secret
+func webhookOutput(webhook *models.Webhook) WebhookOutput {
+	return WebhookOutput{
+		ID:          webhook.ID,
+		URL:         webhook.URL,
+		Description: webhook.Description,
+		Events:      webhook.EventTypes(),
+		Active:      webhook.Active,
+		CreatedAt:   webhook.CreatedAt,
+		UpdatedAt:   webhook.UpdatedAt,
+	}
+}
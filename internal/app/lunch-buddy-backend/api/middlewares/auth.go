@@ -2,20 +2,120 @@ package middlewares
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
 	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/crypto"
 	"net/http"
 )
 
+// UserKey is the key of the authenticated user in the gin context
+// It is set by AuthRequired and AdminRequired
+const UserKey = "user"
+
 // AuthRequired is a middleware that checks if the request has a valid token
+// It loads the user the token was issued for and stores it under UserKey
 // It is called by router.Setup
 func AuthRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authorizationHeader := c.GetHeader("authorization")
-		if !crypto.ValidateToken(authorizationHeader) {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		if _, ok := authenticate(c); !ok {
+			return
+		}
+		c.Next()
+	}
+}
+
+// AdminRequired is a middleware that checks if the request has a valid token of an admin
+// The admins of the deployment and the admins of the organization of the token are accepted,
+// the data of the request stays scoped to that organization, see Tenant
+// It stores the admin under UserKey
+// It is called by router.Setup
+func AdminRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := authenticate(c)
+		if !ok {
+			return
+		}
+		if !user.IsAdmin && !user.OrganizationAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// PlatformAdminRequired is a middleware that checks if the request has a valid token of an admin of the deployment
+// It guards what is shared by every organization, such as the organizations themselves and the jobs
+// It stores the admin under UserKey
+// It is called by router.Setup
+func PlatformAdminRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := authenticate(c)
+		if !ok {
 			return
-		} else {
-			c.Next()
 		}
+		if !user.IsAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Tenant is a middleware that scopes the queries of the request to the organization of its token
+// The requests without a valid token are scoped to the default organization, the data without organization
+// The organization is read from the context of the request with db.Organization
+// It is called by router.Setup
+func Tenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var organizationID *uuid.UUID
+		if claims, err := crypto.ParseClaims(token(c)); err == nil && claims.Organization != "" {
+			parsed, err := uuid.Parse(claims.Organization)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+				return
+			}
+			organizationID = &parsed
+		}
+		c.Request = c.Request.WithContext(db.WithOrganization(c.Request.Context(), organizationID))
+		c.Next()
+	}
+}
+
+// authenticate loads the user of the token and stores it under UserKey
+// The token of a user who moved to another organization is rejected
+// It aborts the request and returns false if the token or the user is invalid
+func authenticate(c *gin.Context) (*models.User, bool) {
+	claims, err := crypto.ParseClaims(token(c))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, false
+	}
+	// The usernames are unique in the whole deployment, the user is loaded before its organization is known
+	user, err := persistence.GetUserRepository().Unscoped().GetByUsername(claims.Username)
+	if err != nil || OrganizationClaim(user) != claims.Organization {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, false
+	}
+	c.Set(UserKey, user)
+	return user, true
+}
+
+// OrganizationClaim returns the organization claim of the tokens of the user, empty for the default organization
+func OrganizationClaim(user *models.User) string {
+	if user.OrganizationID == nil {
+		return ""
+	}
+	return user.OrganizationID.String()
+}
+
+// token returns the token of the request
+// It is read from the authorization header, or from the token query parameter
+// for the clients which cannot set headers such as the browser EventSource
+func token(c *gin.Context) string {
+	if token := c.GetHeader("authorization"); token != "" {
+		return token
 	}
+	return c.Query("token")
 }
@@ -8,7 +8,9 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	"github.com/swaggo/gin-swagger"
 	"io"
+	"net/url"
 	"os"
+	"strings"
 )
 
 // Setup sets up the router
@@ -28,7 +30,7 @@ func Setup() *gin.Engine {
 			param.ClientIP,
 			param.TimeStamp.Format("02/Jan/2006:15:04:05 -0700"),
 			param.Method,
-			param.Path,
+			redactToken(param.Path),
 			param.Request.Proto,
 			param.StatusCode,
 			param.Latency,
@@ -38,35 +40,47 @@ func Setup() *gin.Engine {
 	}))
 	app.Use(gin.Recovery())
 	app.Use(middlewares.CORS())
+	app.Use(middlewares.Tenant())
 	app.NoRoute(middlewares.NoRouteHandler())
 	//app.Use(middlewares.AuthRequired())
 
 	// Routes
 	// ================== Login Routes
 	app.POST("/api/login", controllers.Login)
-	app.POST("/api/register", controllers.CreateUser)
+	app.POST("/api/register", controllers.Register)
+	app.POST("/api/verify-email", controllers.VerifyEmail)
+	app.GET("/api/sso/login", controllers.StartSSO)
+	app.GET("/api/sso/callback", controllers.CompleteSSO)
+	app.POST("/api/password-reset", controllers.RequestPasswordReset)
+	app.POST("/api/password-reset/confirm", controllers.ResetPassword)
+	// ================== Calendar Routes
+	app.GET("/api/calendar/:token", controllers.GetCalendarFeed)
 	// ================== Docs Routes
 	app.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	// ================== User Routes
 	app.GET("/api/users", controllers.GetUsers)
 	app.GET("/api/users/:id", controllers.GetUserById)
 	app.GET("/api/users/username/:username", controllers.GetUserByUsername)
-	app.POST("/api/users", controllers.CreateUser)
-	app.PUT("/api/users/:id", controllers.UpdateUser)
-	app.DELETE("/api/users/:id", controllers.DeleteUser)
-	app.POST("/api/users/:id/information", controllers.AddUserInformation)
+	// The accounts are registered with /api/register, only the admins create them directly
+	app.POST("/api/users", middlewares.AdminRequired(), controllers.CreateUser)
+	// Only the user itself or an admin manages an account
+	app.PUT("/api/users/:id", middlewares.AuthRequired(), controllers.UpdateUser)
+	app.DELETE("/api/users/:id", middlewares.AuthRequired(), controllers.DeleteUser)
+	app.POST("/api/users/:id/information", middlewares.AuthRequired(), controllers.AddUserInformation)
 
 	app.GET("/api/users/card/:name", controllers.GetUserCard)
 	app.GET("/api/users/card", controllers.GetUsersForDashboard)
 
 	// ================== Hobby Routes
 	app.GET("/api/hobbies", controllers.GetHobbies)
+	app.GET("/api/hobbies/search", controllers.SearchHobbies)
 	app.GET("/api/hobbies/:id", controllers.GetHobbyById)
 	app.POST("/api/hobbies", controllers.CreateHobby)
 	app.PUT("/api/hobbies/:id", controllers.UpdateHobby)
 	app.DELETE("/api/hobbies/:id", controllers.DeleteHobby)
 	// ================== Language Routes
 	app.GET("/api/languages", controllers.GetLanguages)
+	app.GET("/api/languages/search", controllers.SearchLanguages)
 	app.GET("/api/languages/:id", controllers.GetLanguageById)
 	app.GET("/api/languages/name/:name", controllers.GetLanguageByName)
 	app.POST("/api/languages", controllers.CreateLanguage)
@@ -80,17 +94,161 @@ func Setup() *gin.Engine {
 	app.DELETE("/api/lunches/:id", controllers.DeleteLunch)
 	// ================== Area Routes
 	app.GET("/api/areas", controllers.GetAreas)
+	app.GET("/api/areas/search", controllers.SearchAreas)
 	app.GET("/api/areas/:id", controllers.GetAreaById)
+	app.GET("/api/areas/:id/top-places", controllers.GetTopPlaces)
 	app.POST("/api/areas", controllers.CreateArea)
 	app.PUT("/api/areas/:id", controllers.UpdateArea)
 	app.DELETE("/api/areas/:id", controllers.DeleteArea)
+	// ================== Place Routes
+	app.GET("/api/places", controllers.GetPlaces)
+	app.GET("/api/places/:id", controllers.GetPlaceById)
+	app.GET("/api/places/:id/reviews", controllers.GetPlaceReviews)
+	// ================== Diet and Cuisine Routes
+	app.GET("/api/diets", controllers.GetDiets)
+	app.GET("/api/diets/search", controllers.SearchDiets)
+	app.GET("/api/diets/:id", controllers.GetDietById)
+	app.GET("/api/cuisines", controllers.GetCuisines)
+	app.GET("/api/cuisines/search", controllers.SearchCuisines)
+	app.GET("/api/cuisines/:id", controllers.GetCuisineById)
+	// ================== Team Routes
+	app.GET("/api/teams", controllers.GetTeams)
+	app.GET("/api/teams/:id", controllers.GetTeamById)
+	app.GET("/api/departments", controllers.GetDepartments)
 
-	// ================== Tasks Routes
-	app.GET("/api/tasks/:id", controllers.GetTaskById)
-	app.GET("/api/tasks", controllers.GetTasks)
-	app.POST("/api/tasks", controllers.CreateTask)
-	app.PUT("/api/tasks/:id", controllers.UpdateTask)
-	app.DELETE("/api/tasks/:id", controllers.DeleteTask)
+	// ================== Admin Routes
+	admin := app.Group("/api/admin", middlewares.AdminRequired())
+	admin.POST("/hobbies/:id/merge", controllers.MergeHobby)
+	admin.POST("/languages/:id/merge", controllers.MergeLanguage)
+	admin.POST("/areas/:id/merge", controllers.MergeArea)
+	admin.POST("/diets", controllers.CreateDiet)
+	admin.PUT("/diets/:id", controllers.UpdateDiet)
+	admin.DELETE("/diets/:id", controllers.DeleteDiet)
+	admin.POST("/diets/:id/merge", controllers.MergeDiet)
+	admin.POST("/cuisines", controllers.CreateCuisine)
+	admin.PUT("/cuisines/:id", controllers.UpdateCuisine)
+	admin.DELETE("/cuisines/:id", controllers.DeleteCuisine)
+	admin.POST("/cuisines/:id/merge", controllers.MergeCuisine)
+	admin.POST("/teams", controllers.CreateTeam)
+	admin.POST("/teams/import", controllers.ImportTeams)
+	admin.PUT("/teams/:id", controllers.UpdateTeam)
+	admin.DELETE("/teams/:id", controllers.DeleteTeam)
+	admin.POST("/departments", controllers.CreateDepartment)
+	admin.PUT("/departments/:id", controllers.UpdateDepartment)
+	admin.DELETE("/departments/:id", controllers.DeleteDepartment)
+	admin.PUT("/users/:username/team", controllers.SetUserTeam)
+	admin.GET("/aliases", controllers.GetAliases)
+	admin.POST("/aliases", controllers.CreateAlias)
+	admin.DELETE("/aliases/:id", controllers.DeleteAlias)
+	admin.GET("/dashboard", controllers.GetDashboard)
+	admin.GET("/invite-codes", controllers.GetInviteCodes)
+	admin.POST("/invite-codes", controllers.CreateInviteCode)
+	admin.DELETE("/invite-codes/:id", controllers.DeleteInviteCode)
+
+	// ================== Platform Admin Routes
+	// The organizations and what every organization shares, the places, the icebreakers, the webhooks and the jobs
+	platform := app.Group("/api/admin", middlewares.PlatformAdminRequired())
+	platform.GET("/organizations", controllers.GetOrganizations)
+	platform.POST("/organizations", controllers.CreateOrganization)
+	platform.PUT("/organizations/:id", controllers.UpdateOrganization)
+	platform.DELETE("/organizations/:id", controllers.DeleteOrganization)
+	platform.PUT("/organizations/:id/members/:username", controllers.SetOrganizationMember)
+	platform.POST("/places", controllers.CreatePlace)
+	platform.PUT("/places/:id", controllers.UpdatePlace)
+	platform.DELETE("/places/:id", controllers.DeletePlace)
+	platform.POST("/places/:id/merge", controllers.MergePlace)
+	platform.GET("/place-aliases", controllers.GetPlaceAliases)
+	platform.POST("/place-aliases", controllers.CreatePlaceAlias)
+	platform.DELETE("/place-aliases/:id", controllers.DeletePlaceAlias)
+	platform.GET("/icebreakers", controllers.GetIcebreakerTemplates)
+	platform.POST("/icebreakers", controllers.CreateIcebreakerTemplate)
+	platform.PUT("/icebreakers/:id", controllers.UpdateIcebreakerTemplate)
+	platform.DELETE("/icebreakers/:id", controllers.DeleteIcebreakerTemplate)
+	platform.GET("/webhooks", controllers.GetWebhooks)
+	platform.POST("/webhooks", controllers.CreateWebhook)
+	platform.GET("/webhooks/:id", controllers.GetWebhookById)
+	platform.PUT("/webhooks/:id", controllers.UpdateWebhook)
+	platform.DELETE("/webhooks/:id", controllers.DeleteWebhook)
+	platform.POST("/webhooks/:id/ping", controllers.PingWebhook)
+	platform.GET("/webhooks/:id/deliveries", controllers.GetWebhookDeliveries)
+	platform.GET("/jobs", controllers.GetJobs)
+	platform.POST("/jobs/:name/run", controllers.RunJob)
+
+	// ================== Authenticated User Routes
+	me := app.Group("/api/me", middlewares.AuthRequired())
+	me.GET("/tasks", controllers.GetTasks)
+	me.GET("/tasks/:id", controllers.GetTaskById)
+	me.POST("/tasks", controllers.CreateTask)
+	me.POST("/tasks/complete", controllers.CompleteTasks)
+	me.PUT("/tasks/:id", controllers.UpdateTask)
+	me.DELETE("/tasks/:id", controllers.DeleteTask)
+	me.GET("/icebreakers", controllers.GetIcebreakers)
+	me.GET("/engagement", controllers.GetEngagement)
+	me.GET("/stats", controllers.GetStats)
+	me.POST("/likes/:username", controllers.LikeUser)
+	me.DELETE("/likes/:username", controllers.UnlikeUser)
+	me.POST("/blacklist/:username", controllers.BlockUser)
+	me.DELETE("/blacklist/:username", controllers.UnblockUser)
+	me.GET("/invitations", controllers.GetInvitations)
+	me.GET("/invitations/slots", controllers.GetInvitationSlots)
+	me.POST("/invitations", controllers.CreateInvitation)
+	me.POST("/invitations/:id/accept", controllers.AcceptInvitation)
+	me.POST("/invitations/:id/decline", controllers.DeclineInvitation)
+	me.POST("/invitations/:id/cancel", controllers.CancelInvitation)
+	me.GET("/invitations/:id/ics", controllers.GetInvitationEvent)
+	me.GET("/lunch/ics", controllers.GetLunchEvent)
+	me.GET("/places", controllers.GetCompatiblePlaces)
+	me.GET("/places/near", controllers.GetNearbyPlaces)
+	me.GET("/reviews", controllers.GetReviews)
+	me.POST("/reviews", controllers.CreateReview)
+	me.PUT("/reviews/:id", controllers.UpdateReview)
+	me.DELETE("/reviews/:id", controllers.DeleteReview)
+	me.GET("/feedback", controllers.GetFeedback)
+	me.POST("/feedback", controllers.CreateFeedback)
+	me.PUT("/feedback/:id", controllers.UpdateFeedback)
+	me.DELETE("/feedback/:id", controllers.DeleteFeedback)
+	me.GET("/calendar", controllers.GetCalendar)
+	me.POST("/calendar/reset", controllers.ResetCalendar)
+	me.GET("/availability", controllers.GetAvailability)
+	me.POST("/availability/upload", controllers.UploadAvailability)
+	me.POST("/availability/sources", controllers.AddAvailabilitySource)
+	me.POST("/availability/sources/:id/sync", controllers.SyncAvailabilitySource)
+	me.DELETE("/availability/sources/:id", controllers.DeleteAvailabilitySource)
+	me.GET("/notifications", controllers.GetNotifications)
+	me.POST("/notifications/read", controllers.MarkNotificationsRead)
+	me.GET("/events", controllers.StreamEvents)
+	me.GET("/preferences", controllers.GetPreferences)
+	me.PUT("/preferences", controllers.UpdatePreferences)
+	me.POST("/verify-email", controllers.ResendVerification)
+	me.GET("/suggestions", controllers.GetSuggestions)
+	me.GET("/conversations", controllers.GetConversations)
+	me.POST("/conversations", controllers.OpenConversation)
+	me.GET("/conversations/:id/messages", controllers.GetMessages)
+	me.POST("/conversations/:id/messages", controllers.SendMessage)
+	me.POST("/conversations/:id/read", controllers.MarkConversationRead)
+	me.POST("/conversations/:id/typing", controllers.SendTyping)
 
 	return app
 }
+
+// calendarPath is the prefix of the calendar feeds, followed by their secret token
+const calendarPath = "/api/calendar/"
+
+// redactToken hides the token query parameter of a logged path
+// The token is accepted in the query by the event stream
+// The token of a calendar feed is hidden too
+func redactToken(path string) string {
+	if strings.HasPrefix(path, calendarPath) {
+		return calendarPath + "redacted"
+	}
+	index := strings.IndexByte(path, '?')
+	if index < 0 {
+		return path
+	}
+	query, err := url.ParseQuery(path[index+1:])
+	if err != nil || !query.Has("token") {
+		return path
+	}
+	query.Set("token", "redacted")
+	return path[:index+1] + query.Encode()
+}
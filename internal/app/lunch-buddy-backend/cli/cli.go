@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+)
+
+// command is a subcommand of the binary
+type command struct {
+	// usage is the one line synopsis printed in the help
+	usage string
+	// run executes the command with the remaining arguments
+	run func(args []string) error
+}
+
+// commands are the subcommands of the binary keyed by their name
+// Nested commands such as "user create" are keyed by their full name
+var commands = map[string]command{
+	"serve":               {usage: "serve [--config path] [--port port] [--mode mode]", run: serve},
+	"migrate":             {usage: "migrate [--config path]", run: migrate},
+	"seed":                {usage: "seed --file fixtures.yml [--config path]", run: seed},
+	"user create":         {usage: "user create --username name [--password pwd] [--firstname name] [--lastname name] [--admin] [--organization slug] [--organization-admin] [--config path]", run: createUser},
+	"user reset-password": {usage: "user reset-password --username name [--password pwd] [--config path]", run: resetPassword},
+	"config validate":     {usage: "config validate [--config path]", run: validateConfig},
+	"config print":        {usage: "config print [--config path]", run: printConfig},
+	"mail suggestions":    {usage: "mail suggestions [--config path]", run: queueSuggestions},
+	"jobs list":           {usage: "jobs list [--config path]", run: listJobs},
+	"jobs run":            {usage: "jobs run --name job [--config path]", run: runJob},
+	"places import":       {usage: "places import --file places.csv [--config path]", run: importPlaces},
+	"teams import":        {usage: "teams import --file teams.csv [--organization slug] [--config path]", run: importTeams},
+}
+
+// Run executes the subcommand given by args
+// Without a subcommand the server is started
+// It returns the exit code of the process
+func Run(args []string) int {
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(os.Stdout)
+		return 0
+	}
+
+	name, rest := args[0], args[1:]
+	if _, ok := commands[name]; !ok && len(rest) > 0 {
+		name, rest = args[0]+" "+args[1], args[2:]
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", strings.Join(args, " "))
+		usage(os.Stderr)
+		return 2
+	}
+
+	if err := cmd.run(rest); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// usage prints the list of commands to w
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(w, "Usage: lunch-buddy-backend <command> [flags]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, name := range names {
+		fmt.Fprintln(w, "  "+commands[name].usage)
+	}
+}
+
+// configFlags registers the --config flag on fs
+// The returned function builds the configuration options once fs is parsed
+func configFlags(fs *flag.FlagSet) func() config.Options {
+	path := fs.String("config", "", "path to the configuration file (default "+config.DefaultPath+")")
+	return func() config.Options {
+		return config.Options{Path: *path, Overrides: map[string]interface{}{}}
+	}
+}
+
+// setup loads the configuration and connects to the database
+// The models are not migrated
+func setup(options config.Options) error {
+	if err := config.Setup(options); err != nil {
+		return err
+	}
+	return db.Connect()
+}
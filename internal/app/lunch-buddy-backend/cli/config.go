@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+)
+
+// validateConfig loads and validates the configuration without starting anything
+func validateConfig(args []string) error {
+	fs := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	options := configFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	configuration, err := config.Load(options())
+	if err != nil {
+		return err
+	}
+	if err := configuration.Validate(); err != nil {
+		return err
+	}
+	fmt.Println("configuration is valid")
+	return nil
+}
+
+// printConfig prints the effective settings with the secrets redacted
+func printConfig(args []string) error {
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	options := configFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return config.Print(os.Stdout, options())
+}
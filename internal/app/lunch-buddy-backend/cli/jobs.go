@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/jobs"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+)
+
+// listJobs prints the scheduled jobs with their last run
+func listJobs(args []string) error {
+	fs := flag.NewFlagSet("jobs list", flag.ContinueOnError)
+	options := configFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	scheduler, err := setupJobs(options())
+	if err != nil {
+		return err
+	}
+	for _, job := range scheduler.Jobs() {
+		fmt.Printf("%-20s %-15s %s\n", job.Name, job.Schedule.Expression, job.Description)
+		if state, err := persistence.GetJobRepository().Get(job.Name); err == nil && state.LastRunAt != nil {
+			fmt.Printf("%-20s last run %s: %s%s\n", "", state.LastRunAt.Format("2006-01-02 15:04"), state.LastResult, state.LastError)
+		}
+	}
+	return nil
+}
+
+// runJob runs a scheduled job right away, unless another instance is running it
+func runJob(args []string) error {
+	fs := flag.NewFlagSet("jobs run", flag.ContinueOnError)
+	name := fs.String("name", "", "name of the job, see jobs list")
+	options := configFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return errors.New("--name is required")
+	}
+	scheduler, err := setupJobs(options())
+	if err != nil {
+		return err
+	}
+	if err := scheduler.RunNow(context.Background(), *name); err != nil {
+		return err
+	}
+	state, err := persistence.GetJobRepository().Get(*name)
+	if err != nil {
+		return err
+	}
+	if state.LastError != "" {
+		return errors.New(state.LastError)
+	}
+	fmt.Println(state.LastResult)
+	return nil
+}
+
+// setupJobs sets up the configuration and the database and registers the built-in jobs
+func setupJobs(options config.Options) (*jobs.Scheduler, error) {
+	if err := setup(options); err != nil {
+		return nil, err
+	}
+	scheduler := jobs.GetScheduler()
+	if err := jobs.RegisterDefaults(scheduler, config.GetConfig().Jobs); err != nil {
+		return nil, err
+	}
+	return scheduler, nil
+}
@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/mail"
+)
+
+// queueSuggestions queues the weekly match suggestions of every user
+// The emails are sent by the running server
+func queueSuggestions(args []string) error {
+	fs := flag.NewFlagSet("mail suggestions", flag.ContinueOnError)
+	options := configFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := setup(options()); err != nil {
+		return err
+	}
+	count, err := mail.QueueWeeklySuggestions()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("queued %d suggestion emails\n", count)
+	return nil
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+)
+
+// migrate migrates the database schema and exits
+func migrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	options := configFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := setup(options()); err != nil {
+		return err
+	}
+	if err := db.Migrate(); err != nil {
+		return err
+	}
+	fmt.Println("database migrated")
+	return nil
+}
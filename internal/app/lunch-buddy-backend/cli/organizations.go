@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+)
+
+// organizationScope returns the organization of the slug and a context scoping the queries to it
+// An empty slug is the default organization
+// It must be called after setup
+func organizationScope(slug string) (*models.Organization, context.Context, error) {
+	if slug == "" {
+		return nil, db.WithOrganization(context.Background(), nil), nil
+	}
+	organization, err := persistence.GetOrganizationRepository().GetBySlug(slug)
+	if err != nil {
+		return nil, nil, fmt.Errorf("organization %q not found", slug)
+	}
+	return organization, db.WithOrganization(context.Background(), &organization.ID), nil
+}
@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/fixtures"
+)
+
+// importPlaces loads the places of a CSV file or of a YAML or JSON fixtures file into the database
+// The places are upserted by name and address so the command can be run repeatedly
+func importPlaces(args []string) error {
+	fs := flag.NewFlagSet("places import", flag.ContinueOnError)
+	options := configFlags(fs)
+	file := fs.String("file", "", "CSV, YAML or JSON places file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("--file is required")
+	}
+
+	// The file is read before connecting so that a broken file fails fast
+	places, err := fixtures.ReadPlaces(*file)
+	if err != nil {
+		return err
+	}
+	if err := setup(options()); err != nil {
+		return err
+	}
+	report, err := fixtures.ApplyPlaces(places)
+	if err != nil {
+		return err
+	}
+	fmt.Println(report)
+	return nil
+}
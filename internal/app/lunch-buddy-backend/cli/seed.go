@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/fixtures"
+)
+
+// seed loads a YAML or JSON fixtures file into the database
+// The entries are upserted so the command can be run repeatedly
+func seed(args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	options := configFlags(fs)
+	file := fs.String("file", "", "YAML or JSON fixtures file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("--file is required")
+	}
+
+	// The file is read before connecting so that a broken file fails fast
+	data, err := fixtures.Read(*file)
+	if err != nil {
+		return err
+	}
+	if err := setup(options()); err != nil {
+		return err
+	}
+	report, err := fixtures.Apply(data)
+	if err != nil {
+		return err
+	}
+	fmt.Println(report)
+	return nil
+}
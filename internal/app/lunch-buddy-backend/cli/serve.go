@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"flag"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/app/lunch-buddy-backend/api"
+)
+
+// serve starts the web server
+// The --port and --mode flags override the configuration
+func serve(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	options := configFlags(fs)
+	port := fs.String("port", "", "port the server listens on")
+	mode := fs.String("mode", "", "gin mode: debug, release or test")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	opts := options()
+	// Only the flags given on the command line override the other layers
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "port":
+			opts.Overrides["server.port"] = *port
+		case "mode":
+			opts.Overrides["server.mode"] = *mode
+		}
+	})
+	return api.Run(opts)
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/fixtures"
+)
+
+// importTeams assigns the users of a CSV file to their teams and departments
+// The missing teams and departments are created so the command can be run repeatedly
+// The users, the teams and the departments are those of the organization given by --organization
+func importTeams(args []string) error {
+	fs := flag.NewFlagSet("teams import", flag.ContinueOnError)
+	options := configFlags(fs)
+	file := fs.String("file", "", "CSV file with the username, team and department columns")
+	organizationSlug := fs.String("organization", "", "slug of the organization of the users, the default organization when empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("--file is required")
+	}
+
+	// The file is read before connecting so that a broken file fails fast
+	memberships, err := fixtures.ReadMemberships(*file)
+	if err != nil {
+		return err
+	}
+	if err := setup(options()); err != nil {
+		return err
+	}
+	_, ctx, err := organizationScope(*organizationSlug)
+	if err != nil {
+		return err
+	}
+	report, err := fixtures.ApplyMemberships(ctx, memberships)
+	if err != nil {
+		return err
+	}
+	fmt.Println(report)
+	return nil
+}
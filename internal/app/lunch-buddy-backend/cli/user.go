@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/crypto"
+)
+
+// createUser creates a user, optionally with the admin role or as a member of an organization
+func createUser(args []string) error {
+	fs := flag.NewFlagSet("user create", flag.ContinueOnError)
+	options := configFlags(fs)
+	username := fs.String("username", "", "username of the new user")
+	password := fs.String("password", "", "password of the new user, read from stdin when empty")
+	firstname := fs.String("firstname", "", "first name of the new user")
+	lastname := fs.String("lastname", "", "last name of the new user")
+	admin := fs.Bool("admin", false, "grant the admin role of the whole deployment")
+	organizationSlug := fs.String("organization", "", "slug of the organization of the new user, the default organization when empty")
+	organizationAdmin := fs.Bool("organization-admin", false, "grant the admin role of the organization")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return errors.New("--username is required")
+	}
+	pwd, err := passwordOrPrompt(*password)
+	if err != nil {
+		return err
+	}
+	if err := setup(options()); err != nil {
+		return err
+	}
+
+	organization, _, err := organizationScope(*organizationSlug)
+	if err != nil {
+		return err
+	}
+	// The usernames are unique in the whole deployment
+	s := persistence.GetUserRepository().Unscoped()
+	if _, err := s.GetByUsername(*username); err == nil {
+		return fmt.Errorf("user %q already exists", *username)
+	}
+	user := models.User{
+		Username:          *username,
+		Firstname:         *firstname,
+		Lastname:          *lastname,
+		Hash:              crypto.HashAndSalt([]byte(pwd)),
+		IsAdmin:           *admin,
+		OrganizationAdmin: *organizationAdmin,
+	}
+	// The users created by an operator do not have to verify their email address
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	if organization != nil {
+		user.OrganizationID = &organization.ID
+	}
+	if err := s.Add(&user); err != nil {
+		return err
+	}
+	fmt.Printf("user %s created with id %s\n", user.Username, user.ID)
+	return nil
+}
+
+// resetPassword replaces the password of an existing user
+func resetPassword(args []string) error {
+	fs := flag.NewFlagSet("user reset-password", flag.ContinueOnError)
+	options := configFlags(fs)
+	username := fs.String("username", "", "username of the user")
+	password := fs.String("password", "", "new password, read from stdin when empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return errors.New("--username is required")
+	}
+	pwd, err := passwordOrPrompt(*password)
+	if err != nil {
+		return err
+	}
+	if err := setup(options()); err != nil {
+		return err
+	}
+
+	s := persistence.GetUserRepository().Unscoped()
+	user, err := s.GetByUsername(*username)
+	if err != nil {
+		return fmt.Errorf("user %q not found", *username)
+	}
+	user.Hash = crypto.HashAndSalt([]byte(pwd))
+	if err := s.Update(user); err != nil {
+		return err
+	}
+	fmt.Printf("password of %s reset\n", user.Username)
+	return nil
+}
+
+// passwordOrPrompt returns password or reads one line from stdin when it is empty
+// Reading from stdin keeps the password out of the shell history
+func passwordOrPrompt(password string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.New("no password given")
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", errors.New("password must not be empty")
+	}
+	return line, nil
+}
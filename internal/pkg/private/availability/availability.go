@@ -0,0 +1,243 @@
+package availability
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/calendar"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/availability"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/ics"
+)
+
+// ErrTooLarge is returned when a calendar is larger than the configured maximal size
+var ErrTooLarge = errors.New("calendar is too large")
+
+// Window returns the span of time the busy blocks are imported for, from yesterday to the horizon
+func Window() (time.Time, time.Time) {
+	now := time.Now()
+	return now.Add(-24 * time.Hour), now.Add(config.GetConfig().Availability.Horizon)
+}
+
+// Import replaces the busy blocks of the source with the busy times of the calendar
+// The floating times of the calendar are read in the time zone of the user
+// It returns an error if the calendar could not be read, the source is left unchanged then
+func Import(user *users.User, source *models.Source, r io.Reader) error {
+	maxSize := config.GetConfig().Availability.MaxSize
+	content, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(content)) > maxSize {
+		return ErrTooLarge
+	}
+	parsed, err := ics.Parse(bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	from, to := Window()
+	periods, err := ics.BusyPeriods(parsed, from, to, calendar.Location(user))
+	if err != nil {
+		return err
+	}
+	blocks := make([]models.BusyBlock, len(periods))
+	for i, period := range periods {
+		blocks[i] = models.BusyBlock{Start: period.Start, End: period.End}
+	}
+	organization := db.WithOrganization(context.Background(), source.OrganizationID)
+	return persistence.GetAvailabilityRepository().Scoped(organization).ReplaceBlocks(source, blocks)
+}
+
+// ErrForbiddenAddress is returned when a calendar url points to a private, loopback or link-local address
+var ErrForbiddenAddress = errors.New("calendar url must point to a public address")
+
+// ErrUnavailable is returned when a calendar url could not be fetched
+// The reason, such as the status of the server, is only logged
+var ErrUnavailable = errors.New("calendar url could not be fetched")
+
+// MaxRedirects is the number of redirects followed when fetching a calendar
+const MaxRedirects = 3
+
+// CheckURL returns an error if the calendar url is not an absolute http, https or webcal url of a public host
+// Every address the host resolves to must be public, the addresses are checked again when the calendar is fetched
+func CheckURL(rawURL string) error {
+	endpoint, err := url.Parse(rawURL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https" && endpoint.Scheme != "webcal") || endpoint.Hostname() == "" {
+		return errors.New("url must be an absolute http, https or webcal url")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), config.GetConfig().Availability.Timeout)
+	defer cancel()
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, endpoint.Hostname())
+	if err != nil {
+		log.Println(err)
+		return ErrUnavailable
+	}
+	for _, address := range addresses {
+		if !publicAddress(address.IP) {
+			return ErrForbiddenAddress
+		}
+	}
+	return nil
+}
+
+// publicAddress returns true if ip is neither private, loopback, link-local, multicast nor unspecified
+func publicAddress(ip net.IP) bool {
+	return !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// client returns the http client fetching the calendars
+// It only connects to public addresses, whatever the host resolves to when connecting, and follows a few redirects
+// The proxy of the environment is not used since it would connect in place of the client
+func client(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicAddress(ip) {
+				return ErrForbiddenAddress
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: timeout},
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			if len(via) >= MaxRedirects {
+				return errors.New("too many redirects")
+			}
+			if request.URL.Scheme != "http" && request.URL.Scheme != "https" {
+				return errors.New("redirect to an unsupported scheme")
+			}
+			return nil
+		},
+	}
+}
+
+// Fetch downloads the calendar of an url, webcal urls are fetched over https
+// Only public addresses are fetched, see CheckURL
+// The calendar urls often carry a secret token, only their host is logged
+func Fetch(rawURL string) ([]byte, error) {
+	if strings.HasPrefix(rawURL, "webcal://") {
+		rawURL = "https://" + strings.TrimPrefix(rawURL, "webcal://")
+	}
+	endpoint, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.New("url must be an absolute http, https or webcal url")
+	}
+	configuration := config.GetConfig().Availability
+	response, err := client(configuration.Timeout).Get(endpoint.String())
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		log.Printf("calendar of %s could not be fetched: %v", endpoint.Host, err)
+		if errors.Is(err, ErrForbiddenAddress) {
+			return nil, ErrForbiddenAddress
+		}
+		return nil, ErrUnavailable
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		log.Printf("calendar of %s could not be fetched: %s", endpoint.Host, response.Status)
+		return nil, ErrUnavailable
+	}
+	content, err := io.ReadAll(io.LimitReader(response.Body, configuration.MaxSize+1))
+	if err != nil {
+		log.Printf("calendar of %s could not be fetched: %v", endpoint.Host, err)
+		return nil, ErrUnavailable
+	}
+	if int64(len(content)) > configuration.MaxSize {
+		return nil, ErrTooLarge
+	}
+	return content, nil
+}
+
+// Sync fetches the calendar of an url source and imports it
+// The error is recorded on the source, which is synchronized again at the next interval
+// The source is synchronized in its organization, see db.WithOrganization
+func Sync(source *models.Source) error {
+	organization := db.WithOrganization(context.Background(), source.OrganizationID)
+	r := persistence.GetAvailabilityRepository().Scoped(organization)
+	content, err := Fetch(source.URL)
+	if err == nil {
+		var user *users.User
+		if user, err = persistence.GetUserRepository().Scoped(organization).Get(source.UserID.String()); err == nil {
+			err = Import(user, source, bytes.NewReader(content))
+		}
+	}
+	if err != nil {
+		now := time.Now()
+		source.SyncedAt = &now
+		source.Error = err.Error()
+		if updateErr := r.UpdateSource(source); updateErr != nil {
+			return updateErr
+		}
+	}
+	return err
+}
+
+// Free returns true if none of the users is busy during the time
+// Only the busy blocks of the organization of ctx are read, see db.WithOrganization
+func Free(ctx context.Context, userIDs []uuid.UUID, start time.Time, end time.Time) (bool, error) {
+	busy, err := persistence.GetAvailabilityRepository().Scoped(ctx).IsBusy(userIDs, start, end)
+	return !busy, err
+}
+
+// Busy is the busy blocks of several users, loaded at once
+type Busy map[uuid.UUID][]models.BusyBlock
+
+// LoadBusy returns the busy blocks of the users overlapping the window, of every user when none is given
+// Only the busy blocks of the organization of ctx are read, see db.WithOrganization
+func LoadBusy(ctx context.Context, from time.Time, to time.Time, userIDs ...uuid.UUID) (Busy, error) {
+	blocks, err := persistence.GetAvailabilityRepository().Scoped(ctx).Blocks(userIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+	busy := Busy{}
+	for _, block := range *blocks {
+		busy[block.UserID] = append(busy[block.UserID], block)
+	}
+	return busy, nil
+}
+
+// Free returns true if none of the users is busy during the time
+func (b Busy) Free(start time.Time, end time.Time, userIDs ...uuid.UUID) bool {
+	for _, userID := range userIDs {
+		for _, block := range b[userID] {
+			if block.Start.Before(end) && start.Before(block.End) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// FreeLunches returns the lunches of the slots at which none of the users is busy
+func (b Busy) FreeLunches(slots []time.Time, userIDs ...uuid.UUID) []time.Time {
+	var free []time.Time
+	for _, slot := range slots {
+		if b.Free(slot, slot.Add(users.LunchDuration), userIDs...) {
+			free = append(free, slot)
+		}
+	}
+	return free
+}
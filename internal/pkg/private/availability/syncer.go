@@ -0,0 +1,65 @@
+package availability
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+)
+
+// syncBatchSize is the number of calendar urls fetched at each tick
+const syncBatchSize = 20
+
+// syncPollInterval is how often the syncer looks for the calendar urls due to be fetched
+const syncPollInterval = time.Minute
+
+// Syncer fetches the calendar urls of the users again once per sync interval
+type Syncer struct {
+	interval time.Duration
+}
+
+// NewSyncer returns a syncer with the settings of the configuration
+func NewSyncer(configuration config.AvailabilityConfiguration) *Syncer {
+	return &Syncer{interval: configuration.SyncInterval}
+}
+
+// Run synchronizes the due sources until the context is cancelled
+// The errors are logged and the syncer keeps running
+func (s *Syncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(syncPollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			count, err := s.SyncDue()
+			if err != nil {
+				log.Println(err)
+			}
+			if err != nil || count < syncBatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncDue synchronizes one batch of the sources not synchronized for an interval
+// A failing source is logged and skipped until the next interval
+// It returns the number of sources attempted
+func (s *Syncer) SyncDue() (int, error) {
+	sources, err := persistence.GetAvailabilityRepository().Unscoped().DueSources(time.Now().Add(-s.interval), syncBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for i := range *sources {
+		if err := Sync(&(*sources)[i]); err != nil {
+			log.Println("calendar source", (*sources)[i].ID, err)
+		}
+	}
+	return len(*sources), nil
+}
@@ -0,0 +1,148 @@
+package calendar
+
+import (
+	"context"
+	"time"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/notifications"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/ics"
+)
+
+// ProdID identifies the application in the calendars
+const ProdID = "-//Lunch Buddy//Lunch Buddy//EN"
+
+// WorkingDays is the recurrence rule of the daily lunch
+const WorkingDays = "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"
+
+// DefaultLocation returns the time zone of the users who did not choose one
+func DefaultLocation() *time.Location {
+	if location, err := time.LoadLocation(config.GetConfig().Server.TimeZone); err == nil {
+		return location
+	}
+	return time.UTC
+}
+
+// Location returns the time zone of the user
+func Location(user *users.User) *time.Location {
+	if user != nil && user.TimeZone != "" {
+		if location, err := time.LoadLocation(user.TimeZone); err == nil {
+			return location
+		}
+	}
+	return DefaultLocation()
+}
+
+// LunchEvent returns the recurring event of the daily lunch of the user
+// It returns false when the user has no lunch time
+// The first occurrence is the first working day on or after the day the lunch time was set
+func LunchEvent(user *users.User) (ics.Event, bool) {
+	lunch := user.Lunch
+	if lunch.Time.IsZero() {
+		return ics.Event{}, false
+	}
+	start := lunch.Time.In(Location(user))
+	for start.Weekday() == time.Saturday || start.Weekday() == time.Sunday {
+		start = start.AddDate(0, 0, 1)
+	}
+	description := lunch.Type
+	if lunch.Food != "" {
+		if description != "" {
+			description += ", "
+		}
+		description += lunch.Food
+	}
+	return ics.Event{
+		UID:          "lunch-" + lunch.ID.String() + "@lunch-buddy",
+		Summary:      "Lunch",
+		Description:  description,
+		Location:     eventLocation(lunch.Location, lunch.Place),
+		Start:        start,
+		End:          start.Add(users.LunchDuration),
+		RRule:        WorkingDays,
+		Status:       ics.StatusConfirmed,
+		Created:      lunch.CreatedAt,
+		LastModified: lunch.UpdatedAt,
+	}, true
+}
+
+// InvitationEvent returns the event of an invitation seen by the user
+// The inviter and the invitee must be loaded
+func InvitationEvent(invitation *users.Invitation, user *users.User) ics.Event {
+	buddy := invitation.Invitee
+	if invitation.InviteeID == user.ID {
+		buddy = invitation.Inviter
+	}
+	status := ics.StatusTentative
+	switch invitation.Status {
+	case users.InvitationAccepted:
+		status = ics.StatusConfirmed
+	case users.InvitationDeclined, users.InvitationCancelled, users.InvitationExpired:
+		status = ics.StatusCancelled
+	}
+	start := invitation.Time.In(Location(user))
+	return ics.Event{
+		UID:          "invitation-" + invitation.ID.String() + "@lunch-buddy",
+		Summary:      "Lunch with " + notifications.DisplayName(buddy),
+		Description:  invitation.Message,
+		Location:     eventLocation(invitation.Location, invitation.Place),
+		Start:        start,
+		End:          start.Add(users.LunchDuration),
+		Status:       status,
+		Created:      invitation.CreatedAt,
+		LastModified: invitation.UpdatedAt,
+	}
+}
+
+// eventLocation returns the location of an event, followed by the address of the place when it is loaded
+func eventLocation(location string, place *users.Place) string {
+	if place == nil || place.Address == "" {
+		return location
+	}
+	if location == "" {
+		return place.Address
+	}
+	return location + ", " + place.Address
+}
+
+// Feed returns the calendar of the user: the daily lunch and the accepted invitations from today on
+// The user must be loaded with its lunch, only the invitations of its organization are read
+func Feed(user *users.User) (*ics.Calendar, error) {
+	calendar := &ics.Calendar{ProdID: ProdID, Name: "Lunch Buddy"}
+	if event, ok := LunchEvent(user); ok {
+		calendar.Events = append(calendar.Events, event)
+	}
+	now := time.Now().In(Location(user))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	organization := db.WithOrganization(context.Background(), user.OrganizationID)
+	invitations, err := persistence.GetInvitationRepository().Scoped(organization).UpcomingForUser(user.ID, users.InvitationAccepted, today)
+	if err != nil {
+		return nil, err
+	}
+	for i := range *invitations {
+		calendar.Events = append(calendar.Events, InvitationEvent(&(*invitations)[i], user))
+	}
+	return calendar, nil
+}
+
+// NextLunches returns the daily lunches of the user starting after the time, on the working days of the following days
+// It returns nothing when the user has no lunch time
+func NextLunches(user *users.User, from time.Time, days int) []time.Time {
+	if user.Lunch.Time.IsZero() {
+		return nil
+	}
+	lunch := user.Lunch.Time.In(Location(user))
+	day := from.In(lunch.Location())
+	var lunches []time.Time
+	for i := 0; i <= days; i++ {
+		date := day.AddDate(0, 0, i)
+		start := time.Date(date.Year(), date.Month(), date.Day(), lunch.Hour(), lunch.Minute(), lunch.Second(), 0, lunch.Location())
+		if start.After(from) && start.Weekday() != time.Saturday && start.Weekday() != time.Sunday {
+			lunches = append(lunches, start)
+		}
+	}
+	return lunches
+}
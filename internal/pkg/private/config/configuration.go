@@ -1,8 +1,6 @@
 package config
 
 import (
-	"github.com/spf13/viper"
-	"log"
 	"time"
 )
 
@@ -11,61 +9,107 @@ var Config *Configuration
 // Configuration is a struct that contains all the configuration data
 // for the application
 type Configuration struct {
-	Server   ServerConfiguration
-	Database DatabaseConfiguration
+	Server       ServerConfiguration       `mapstructure:"server"`
+	Database     DatabaseConfiguration     `mapstructure:"database"`
+	Events       EventsConfiguration       `mapstructure:"events"`
+	Webhooks     WebhooksConfiguration     `mapstructure:"webhooks"`
+	Mail         MailConfiguration         `mapstructure:"mail"`
+	Availability AvailabilityConfiguration `mapstructure:"availability"`
+	Jobs         JobsConfiguration         `mapstructure:"jobs"`
+	Geo          GeoConfiguration          `mapstructure:"geo"`
+	Food         FoodConfiguration         `mapstructure:"food"`
+	Registration RegistrationConfiguration `mapstructure:"registration"`
+	OIDC         OIDCConfiguration         `mapstructure:"oidc"`
 }
 
 // DatabaseConfiguration is a struct that contains all the configuration data
 // for the database
 type DatabaseConfiguration struct {
-	Driver       string
-	Dbname       string
-	Username     string
-	Password     string
-	Host         string
-	Port         string
-	MaxLifetime  int
-	MaxOpenConns int
-	MaxIdleConns int
-	TimeZone     string
+	Driver       string `mapstructure:"driver"`
+	Dbname       string `mapstructure:"dbname"`
+	Username     string `mapstructure:"username"`
+	Password     string `mapstructure:"password"`
+	Host         string `mapstructure:"host"`
+	Port         string `mapstructure:"port"`
+	MaxLifetime  int    `mapstructure:"max_lifetime"`
+	MaxOpenConns int    `mapstructure:"max_open_conns"`
+	MaxIdleConns int    `mapstructure:"max_idle_conns"`
+	TimeZone     string `mapstructure:"timezone"`
 }
 
 // ServerConfiguration is a struct that contains all the configuration data
 // for the server
 type ServerConfiguration struct {
-	Port                   string
-	Secret                 string
-	Mode                   string
-	AccessTokenPrivateKey  string
-	AccessTokenPublicKey   string
-	RefreshTokenPrivateKey string
-	RefreshTokenPublicKey  string
-	AccessTokenExpiresIn   time.Duration
-	RefreshTokenExpiresIn  time.Duration
-	AccessTokenMaxAge      int
-	RefreshTokenMaxAge     int
+	Port                   string        `mapstructure:"port"`
+	Secret                 string        `mapstructure:"secret"`
+	Mode                   string        `mapstructure:"mode"`
+	AccessTokenPrivateKey  string        `mapstructure:"access_token_private_key"`
+	AccessTokenPublicKey   string        `mapstructure:"access_token_public_key"`
+	RefreshTokenPrivateKey string        `mapstructure:"refresh_token_private_key"`
+	RefreshTokenPublicKey  string        `mapstructure:"refresh_token_public_key"`
+	AccessTokenExpiresIn   time.Duration `mapstructure:"access_token_expires_in"`
+	RefreshTokenExpiresIn  time.Duration `mapstructure:"refresh_token_expires_in"`
+	AccessTokenMaxAge      int           `mapstructure:"access_token_max_age"`
+	RefreshTokenMaxAge     int           `mapstructure:"refresh_token_max_age"`
+	TimeZone               string        `mapstructure:"timezone"`
 }
 
-// Setup helps you to set up the configuration
-// It reads the configuration file and unmarshals it into the configuration struct
-// It sets the configuration struct as a global variable
-// It panics if something went wrong
-func Setup(configPath string) {
-	var configuration *Configuration
+// EventsConfiguration is a struct that contains all the configuration data
+// for the dispatcher of the domain events
+type EventsConfiguration struct {
+	DispatchInterval time.Duration `mapstructure:"dispatch_interval"`
+	BatchSize        int           `mapstructure:"batch_size"`
+	MaxAttempts      int           `mapstructure:"max_attempts"`
+	LogSink          bool          `mapstructure:"log_sink"`
+}
+
+// WebhooksConfiguration is a struct that contains all the configuration data
+// for the delivery of the webhooks
+type WebhooksConfiguration struct {
+	Timeout      time.Duration `mapstructure:"timeout"`
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	BatchSize    int           `mapstructure:"batch_size"`
+	MaxAttempts  int           `mapstructure:"max_attempts"`
+}
 
-	viper.SetConfigFile(configPath)
-	viper.SetConfigType("yaml")
+// MailConfiguration is a struct that contains all the configuration data
+// for the emails
+type MailConfiguration struct {
+	// Transport is smtp, file or log
+	Transport    string            `mapstructure:"transport"`
+	From         string            `mapstructure:"from"`
+	BaseURL      string            `mapstructure:"base_url"`
+	Templates    string            `mapstructure:"templates"`
+	Dir          string            `mapstructure:"dir"`
+	SMTP         SMTPConfiguration `mapstructure:"smtp"`
+	PollInterval time.Duration     `mapstructure:"poll_interval"`
+	BatchSize    int               `mapstructure:"batch_size"`
+	MaxAttempts  int               `mapstructure:"max_attempts"`
+}
 
-	if err := viper.ReadInConfig(); err != nil {
-		log.Fatalf("Error reading config file, %s", err)
-	}
+// SMTPConfiguration is a struct that contains all the configuration data
+// for the SMTP server sending the emails
+type SMTPConfiguration struct {
+	Host     string `mapstructure:"host"`
+	Port     string `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
 
-	err := viper.Unmarshal(&configuration)
+// Setup helps you to set up the configuration
+// It loads the layered configuration described by options and validates it
+// It sets the configuration struct as a global variable
+// It returns an error if the configuration could not be loaded or is invalid
+func Setup(options Options) error {
+	configuration, err := Load(options)
 	if err != nil {
-		log.Fatalf("Unable to decode into struct, %v", err)
+		return err
+	}
+	if err := configuration.Validate(); err != nil {
+		return err
 	}
-
 	Config = configuration
+	return nil
 }
 
 // GetConfig returns the configuration struct
@@ -73,3 +117,105 @@ func Setup(configPath string) {
 func GetConfig() *Configuration {
 	return Config
 }
+
+// AvailabilityConfiguration is a struct that contains all the configuration data
+// for the import of the busy times of the users
+type AvailabilityConfiguration struct {
+	// Horizon is how far ahead the busy times are imported
+	Horizon      time.Duration `mapstructure:"horizon"`
+	SyncInterval time.Duration `mapstructure:"sync_interval"`
+	Timeout      time.Duration `mapstructure:"timeout"`
+	// MaxSize is the maximal size of an imported calendar in bytes
+	MaxSize int64 `mapstructure:"max_size"`
+}
+
+// JobsConfiguration is a struct that contains all the configuration data
+// for the scheduled jobs
+type JobsConfiguration struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	// Lease is how long a job is locked by the instance running it
+	Lease time.Duration `mapstructure:"lease"`
+	// ReminderBefore is how long before an accepted lunch the reminder is sent
+	ReminderBefore time.Duration `mapstructure:"reminder_before"`
+	// Schedules are the cron expressions of the jobs keyed by job name
+	Schedules map[string]string `mapstructure:"schedules"`
+}
+
+// The modes of the geo-aware matching
+const (
+	GeoMatchingOff     = "off"
+	GeoMatchingPrefer  = "prefer"
+	GeoMatchingRequire = "require"
+)
+
+// GeoConfiguration is a struct that contains all the configuration data
+// for the distances between the areas and the places
+type GeoConfiguration struct {
+	// WalkingDistance is the distance in meters under which two areas are nearby
+	WalkingDistance float64 `mapstructure:"walking_distance"`
+	// Matching is off, prefer to rank the users of nearby areas higher, or require to suggest only them
+	Matching string `mapstructure:"matching"`
+	// Radius is the default radius in meters of the places near the user, MaxRadius the largest one accepted
+	Radius    float64 `mapstructure:"radius"`
+	MaxRadius float64 `mapstructure:"max_radius"`
+	// PostGIS computes the distances in the database, it requires postgres with the postgis extension
+	PostGIS bool `mapstructure:"postgis"`
+}
+
+// The modes of the food-aware matching
+const (
+	FoodMatchingOff     = "off"
+	FoodMatchingPrefer  = "prefer"
+	FoodMatchingRequire = "require"
+)
+
+// FoodConfiguration is a struct that contains all the configuration data
+// for the diets and the cuisines of the users
+type FoodConfiguration struct {
+	// Matching is off, prefer to rank the users sharing diets and cuisines higher,
+	// or require to suggest only the users with a place catering for the diets of both
+	Matching string `mapstructure:"matching"`
+}
+
+// The registration policies
+const (
+	RegistrationOpen   = "open"
+	RegistrationDomain = "domain"
+	RegistrationInvite = "invite"
+)
+
+// RegistrationConfiguration is a struct that contains all the configuration data
+// for the registration of the users of the default organization
+type RegistrationConfiguration struct {
+	// Policy is open, domain to accept only the email addresses of the domains, or invite to require an invite code
+	// The organizations can override it
+	Policy  string   `mapstructure:"policy"`
+	Domains []string `mapstructure:"domains"`
+	// VerifyEmail requires the registered users to verify their email address before their profile is set up
+	VerifyEmail bool `mapstructure:"verify_email"`
+	// VerificationTTL is how long a verification link is valid
+	VerificationTTL time.Duration `mapstructure:"verification_ttl"`
+}
+
+// OIDCConfiguration is a struct that contains all the configuration data
+// for the single sign-on with an OpenID Connect provider
+type OIDCConfiguration struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Issuer is the url of the provider, its configuration is read from the well-known document
+	Issuer       string `mapstructure:"issuer"`
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+	// RedirectURL is the url of the callback, /api/sso/callback of the server
+	RedirectURL string   `mapstructure:"redirect_url"`
+	Scopes      []string `mapstructure:"scopes"`
+	// UsernameClaim is the claim of the id token holding the username, usually email
+	UsernameClaim string `mapstructure:"username_claim"`
+	// Provision creates the unknown users at their first login
+	Provision bool `mapstructure:"provision"`
+	// LinkAccounts links an existing user with the same username, the username must be the email address the provider verified
+	LinkAccounts bool          `mapstructure:"link_accounts"`
+	Timeout      time.Duration `mapstructure:"timeout"`
+	// LoginTTL is how long the user has to log in at the provider
+	LoginTTL time.Duration `mapstructure:"login_ttl"`
+}
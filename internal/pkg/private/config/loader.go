@@ -0,0 +1,153 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix is the prefix of the environment variables that override the configuration
+// A key such as server.port is read from LUNCHBUDDY_SERVER_PORT
+const EnvPrefix = "LUNCHBUDDY"
+
+// DefaultPath is the configuration file used when no path is given
+// It is optional, the configuration can be provided only by the environment
+const DefaultPath = "data/config.yml"
+
+// Options describes where the configuration is loaded from
+// The layers are applied in this order: defaults, file, environment, overrides
+type Options struct {
+	// Path is the configuration file, DefaultPath is used when it is empty
+	Path string
+	// Overrides are values coming from the command line, keyed like server.port
+	Overrides map[string]interface{}
+}
+
+// defaults are the lowest configuration layer
+var defaults = map[string]interface{}{
+	"server.port":                       "3000",
+	"server.secret":                     "",
+	"server.mode":                       "release",
+	"server.access_token_private_key":   "",
+	"server.access_token_public_key":    "",
+	"server.refresh_token_private_key":  "",
+	"server.refresh_token_public_key":   "",
+	"server.access_token_expires_in":    "15m",
+	"server.refresh_token_expires_in":   "60m",
+	"server.access_token_max_age":       15,
+	"server.refresh_token_max_age":      60,
+	"server.timezone":                   "Europe/Bratislava",
+	"database.driver":                   "postgres",
+	"database.dbname":                   "",
+	"database.username":                 "",
+	"database.password":                 "",
+	"database.host":                     "localhost",
+	"database.port":                     "5432",
+	"database.max_lifetime":             7200,
+	"database.max_open_conns":           150,
+	"database.max_idle_conns":           50,
+	"database.timezone":                 "UTC",
+	"events.dispatch_interval":          "1s",
+	"events.batch_size":                 100,
+	"events.max_attempts":               10,
+	"events.log_sink":                   false,
+	"webhooks.timeout":                  "10s",
+	"webhooks.poll_interval":            "5s",
+	"webhooks.batch_size":               20,
+	"webhooks.max_attempts":             8,
+	"mail.transport":                    "log",
+	"mail.from":                         "Lunch Buddy <no-reply@localhost>",
+	"mail.base_url":                     "http://localhost:3000",
+	"mail.templates":                    "web/template/email",
+	"mail.dir":                          "log/mail",
+	"mail.smtp.host":                    "",
+	"mail.smtp.port":                    "587",
+	"mail.smtp.username":                "",
+	"mail.smtp.password":                "",
+	"mail.poll_interval":                "5s",
+	"mail.batch_size":                   20,
+	"mail.max_attempts":                 5,
+	"availability.horizon":              "1440h",
+	"availability.sync_interval":        "1h",
+	"availability.timeout":              "10s",
+	"availability.max_size":             1048576,
+	"jobs.enabled":                      true,
+	"jobs.poll_interval":                "30s",
+	"jobs.lease":                        "10m",
+	"jobs.reminder_before":              "30m",
+	"jobs.schedules.lunch_reminders":    "*/5 * * * *",
+	"jobs.schedules.expire_invitations": "*/15 * * * *",
+	"jobs.schedules.match_suggestions":  "0 6 * * *",
+	"jobs.schedules.suggestion_emails":  "0 7 * * 1",
+	"jobs.schedules.cleanup_tokens":     "0 3 * * *",
+	"geo.walking_distance":              800,
+	"geo.matching":                      "prefer",
+	"geo.radius":                        1000,
+	"geo.max_radius":                    10000,
+	"geo.postgis":                       false,
+	"food.matching":                     "prefer",
+	"registration.policy":               "open",
+	"registration.domains":              []string{},
+	"registration.verify_email":         true,
+	"registration.verification_ttl":     "48h",
+	"oidc.enabled":                      false,
+	"oidc.issuer":                       "",
+	"oidc.client_id":                    "",
+	"oidc.client_secret":                "",
+	"oidc.redirect_url":                 "http://localhost:3000/api/sso/callback",
+	"oidc.scopes":                       []string{"openid", "email", "profile"},
+	"oidc.username_claim":               "email",
+	"oidc.provision":                    true,
+	"oidc.link_accounts":                true,
+	"oidc.timeout":                      "10s",
+	"oidc.login_ttl":                    "10m",
+}
+
+// newViper builds a viper instance with every configuration layer applied
+// It returns an error if the configuration file could not be read
+func newViper(options Options) (*viper.Viper, error) {
+	v := viper.New()
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+
+	path := options.Path
+	if path == "" {
+		path = DefaultPath
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			path = ""
+		}
+	}
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("error reading config file %s: %w", path, err)
+		}
+	}
+
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	for key, value := range options.Overrides {
+		v.Set(key, value)
+	}
+	return v, nil
+}
+
+// Load reads the configuration without validating it or setting it globally
+// It returns an error if the configuration could not be read or decoded
+func Load(options Options) (*Configuration, error) {
+	v, err := newViper(options)
+	if err != nil {
+		return nil, err
+	}
+	var configuration Configuration
+	if err := v.Unmarshal(&configuration); err != nil {
+		return nil, fmt.Errorf("unable to decode config: %w", err)
+	}
+	return &configuration, nil
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// redacted replaces the value of secret settings when they are printed
+const redacted = "********"
+
+// sensitiveKeys are the key fragments of settings that must never be printed
+var sensitiveKeys = []string{"secret", "password", "private_key"}
+
+// Print writes the effective settings described by options to w
+// Every setting is printed on its own line as key = value, sorted by key
+// The secrets are redacted
+// It returns an error if the configuration could not be read
+func Print(w io.Writer, options Options) error {
+	v, err := newViper(options)
+	if err != nil {
+		return err
+	}
+	keys := v.AllKeys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := fmt.Sprint(v.Get(key))
+		if isSensitive(key) && value != "" {
+			value = redacted
+		}
+		if _, err := fmt.Fprintf(w, "%s = %s\n", key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isSensitive returns true if the key holds a secret
+func isSensitive(key string) bool {
+	for _, fragment := range sensitiveKeys {
+		if strings.Contains(key, fragment) {
+			return true
+		}
+	}
+	return false
+}
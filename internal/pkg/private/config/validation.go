@@ -0,0 +1,202 @@
+package config
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MinSecretLength is the minimal length of the token signing secret
+const MinSecretLength = 32
+
+// ValidationError holds every problem found in a configuration
+// It is returned by Validate so that all problems are reported at once
+type ValidationError struct {
+	Problems []string
+}
+
+// Error returns the problems as a multi line report
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// add records a problem
+func (e *ValidationError) add(format string, args ...interface{}) {
+	e.Problems = append(e.Problems, fmt.Sprintf(format, args...))
+}
+
+// Validate checks the required fields of the configuration
+// It returns a *ValidationError listing every problem or nil if the configuration is valid
+func (c *Configuration) Validate() error {
+	problems := &ValidationError{}
+
+	if c.Server.Secret == "" {
+		problems.add("server.secret is required")
+	} else if len(c.Server.Secret) < MinSecretLength {
+		problems.add("server.secret must be at least %d characters long, got %d", MinSecretLength, len(c.Server.Secret))
+	}
+	if !validPort(c.Server.Port) {
+		problems.add("server.port must be a number between 1 and 65535, got %q", c.Server.Port)
+	}
+	if _, err := time.LoadLocation(c.Server.TimeZone); err != nil || c.Server.TimeZone == "" {
+		problems.add("server.timezone %q is not a valid time zone", c.Server.TimeZone)
+	}
+	switch c.Server.Mode {
+	case "debug", "release", "test":
+	default:
+		problems.add("server.mode must be one of debug, release or test, got %q", c.Server.Mode)
+	}
+
+	switch c.Database.Driver {
+	case "postgres", "mysql":
+	default:
+		problems.add("database.driver must be one of postgres or mysql, got %q", c.Database.Driver)
+	}
+	if c.Database.Dbname == "" {
+		problems.add("database.dbname is required")
+	}
+	if c.Database.Host == "" {
+		problems.add("database.host is required")
+	}
+	if !validPort(c.Database.Port) {
+		problems.add("database.port must be a number between 1 and 65535, got %q", c.Database.Port)
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 || c.Database.MaxLifetime < 0 {
+		problems.add("database connection pool settings must not be negative")
+	}
+	if _, err := time.LoadLocation(c.Database.TimeZone); err != nil {
+		problems.add("database.timezone %q is not a valid time zone", c.Database.TimeZone)
+	}
+
+	if c.Events.DispatchInterval <= 0 {
+		problems.add("events.dispatch_interval must be positive, got %s", c.Events.DispatchInterval)
+	}
+	if c.Events.BatchSize < 1 {
+		problems.add("events.batch_size must be at least 1, got %d", c.Events.BatchSize)
+	}
+	if c.Events.MaxAttempts < 1 {
+		problems.add("events.max_attempts must be at least 1, got %d", c.Events.MaxAttempts)
+	}
+
+	if c.Webhooks.Timeout <= 0 || c.Webhooks.PollInterval <= 0 {
+		problems.add("webhooks.timeout and webhooks.poll_interval must be positive")
+	}
+	if c.Webhooks.BatchSize < 1 {
+		problems.add("webhooks.batch_size must be at least 1, got %d", c.Webhooks.BatchSize)
+	}
+	if c.Webhooks.MaxAttempts < 1 {
+		problems.add("webhooks.max_attempts must be at least 1, got %d", c.Webhooks.MaxAttempts)
+	}
+
+	switch c.Mail.Transport {
+	case "log", "file":
+	case "smtp":
+		if c.Mail.SMTP.Host == "" {
+			problems.add("mail.smtp.host is required by the smtp transport")
+		}
+		if !validPort(c.Mail.SMTP.Port) {
+			problems.add("mail.smtp.port must be a number between 1 and 65535, got %q", c.Mail.SMTP.Port)
+		}
+	default:
+		problems.add("mail.transport must be one of smtp, file or log, got %q", c.Mail.Transport)
+	}
+	if _, err := mail.ParseAddress(c.Mail.From); err != nil {
+		problems.add("mail.from %q is not a valid address", c.Mail.From)
+	}
+	if base, err := url.Parse(c.Mail.BaseURL); err != nil || base.Scheme == "" || base.Host == "" {
+		problems.add("mail.base_url must be an absolute url, got %q", c.Mail.BaseURL)
+	}
+	if c.Mail.PollInterval <= 0 || c.Mail.BatchSize < 1 || c.Mail.MaxAttempts < 1 {
+		problems.add("mail.poll_interval, mail.batch_size and mail.max_attempts must be positive")
+	}
+
+	if c.Availability.Horizon <= 0 || c.Availability.SyncInterval <= 0 || c.Availability.Timeout <= 0 {
+		problems.add("availability.horizon, availability.sync_interval and availability.timeout must be positive")
+	}
+	if c.Availability.MaxSize < 1 {
+		problems.add("availability.max_size must be at least 1, got %d", c.Availability.MaxSize)
+	}
+
+	if c.Jobs.PollInterval <= 0 || c.Jobs.Lease <= 0 || c.Jobs.ReminderBefore <= 0 {
+		problems.add("jobs.poll_interval, jobs.lease and jobs.reminder_before must be positive")
+	}
+	for name, expression := range c.Jobs.Schedules {
+		if expression == "" {
+			problems.add("jobs.schedules.%s must not be empty", name)
+		}
+	}
+
+	if c.Geo.WalkingDistance <= 0 || c.Geo.Radius <= 0 || c.Geo.MaxRadius < c.Geo.Radius {
+		problems.add("geo.walking_distance and geo.radius must be positive and geo.max_radius at least geo.radius")
+	}
+	switch c.Geo.Matching {
+	case GeoMatchingOff, GeoMatchingPrefer, GeoMatchingRequire:
+	default:
+		problems.add("geo.matching must be one of off, prefer or require, got %q", c.Geo.Matching)
+	}
+	if c.Geo.PostGIS && c.Database.Driver != "postgres" {
+		problems.add("geo.postgis requires the postgres driver, got %q", c.Database.Driver)
+	}
+	switch c.Food.Matching {
+	case FoodMatchingOff, FoodMatchingPrefer, FoodMatchingRequire:
+	default:
+		problems.add("food.matching must be one of off, prefer or require, got %q", c.Food.Matching)
+	}
+	switch c.Registration.Policy {
+	case RegistrationOpen, RegistrationInvite:
+	case RegistrationDomain:
+		if len(c.Registration.Domains) == 0 {
+			problems.add("registration.domains is required by the domain policy")
+		}
+	default:
+		problems.add("registration.policy must be one of open, domain or invite, got %q", c.Registration.Policy)
+	}
+	if c.Registration.VerificationTTL <= 0 {
+		problems.add("registration.verification_ttl must be positive, got %s", c.Registration.VerificationTTL)
+	}
+
+	if c.OIDC.Enabled {
+		if issuer, err := url.Parse(c.OIDC.Issuer); err != nil || issuer.Scheme == "" || issuer.Host == "" {
+			problems.add("oidc.issuer must be an absolute url, got %q", c.OIDC.Issuer)
+		}
+		if c.OIDC.ClientID == "" {
+			problems.add("oidc.client_id is required")
+		}
+		if redirect, err := url.Parse(c.OIDC.RedirectURL); err != nil || redirect.Scheme == "" || redirect.Host == "" {
+			problems.add("oidc.redirect_url must be an absolute url, got %q", c.OIDC.RedirectURL)
+		}
+		if !containsString(c.OIDC.Scopes, "openid") {
+			problems.add("oidc.scopes must contain openid")
+		}
+		if c.OIDC.UsernameClaim == "" {
+			problems.add("oidc.username_claim is required")
+		}
+		if c.OIDC.Timeout <= 0 || c.OIDC.LoginTTL <= 0 {
+			problems.add("oidc.timeout and oidc.login_ttl must be positive")
+		}
+	}
+
+	if len(problems.Problems) > 0 {
+		return problems
+	}
+	return nil
+}
+
+// validPort returns true if the port is a number between 1 and 65535
+func validPort(port string) bool {
+	number, err := strconv.Atoi(port)
+	return err == nil && number > 0 && number <= 65535
+}
+
+// containsString returns true if the values contain the value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
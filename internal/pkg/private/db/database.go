@@ -1,15 +1,26 @@
 package db
 
 import (
+	"context"
 	"fmt"
+	"github.com/google/uuid"
 	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/availability"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/chat"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/events"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/jobs"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/mail"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/notifications"
 	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/tasks"
 	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/webhooks"
 	"gorm.io/driver/mysql"
 	_ "gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	_ "gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
 	"time"
 )
 
@@ -22,8 +33,19 @@ type Database struct {
 	*gorm.DB
 }
 
-// SetupDB opens a database and saves the reference to `Database` struct.
-func SetupDB() {
+// SetupDB opens a database and migrates the project models
+// It returns an error if the database could not be opened or migrated
+func SetupDB() error {
+	if err := Connect(); err != nil {
+		return err
+	}
+	return Migrate()
+}
+
+// Connect opens a database and saves the reference to `Database` struct.
+// It does not migrate the models
+// It returns an error if the database could not be opened
+func Connect() error {
 	var db = DB
 
 	configuration := config.GetConfig()
@@ -35,62 +57,396 @@ func SetupDB() {
 	host := configuration.Database.Host
 	port := configuration.Database.Port
 	timezone := configuration.Database.TimeZone
-	fmt.Println("timezone: ", timezone)
 
 	if driver == "postgres" { // POSTGRES
 		dsn := "host=" + host + " port=" + port + " user=" + username + " dbname=" + database + "  sslmode=disable password=" + password + " TimeZone=" + timezone
 		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
-		if err != nil {
-			fmt.Println("db err: ", err)
-		}
 	} else if driver == "mysql" { // MYSQL
 		dsn := username + ":" + password + "@tcp(" + host + ":" + port + ")/" + database + "?charset=utf8&parseTime=True&loc=Local"
 		db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
-		if err != nil {
-			fmt.Println("db err: ", err)
-		}
+	} else {
+		err = fmt.Errorf("unsupported database driver %q", driver)
+	}
+	if err != nil {
+		return err
+	}
+	if err := RegisterTenantScope(db); err != nil {
+		return err
 	}
 
 	// Change this to true if you want to see SQL queries
 	//db.LogMode(true)
-	dbConfig, _ := db.DB()
+	dbConfig, err := db.DB()
+	if err != nil {
+		return err
+	}
 	dbConfig.SetMaxIdleConns(configuration.Database.MaxIdleConns)
 	dbConfig.SetMaxOpenConns(configuration.Database.MaxOpenConns)
 	dbConfig.SetConnMaxLifetime(time.Duration(configuration.Database.MaxLifetime) * time.Second)
 
-	/*	db.DB().SetMaxIdleConns(configuration.Database.MaxIdleConns)
-		db.DB().SetMaxOpenConns(configuration.Database.MaxOpenConns)
-		db.DB().SetConnMaxLifetime(time.Duration(configuration.Database.MaxLifetime) * time.Second)*/
 	DB = db
-	migration()
+	return nil
 }
 
-// Auto migrate project models
-func migration() {
-	err := DB.AutoMigrate(&users.User{})
-	if err != nil {
-		return
+// Migrate auto migrates the project models
+// It returns the first error encountered
+func Migrate() error {
+	// The migration sees the data of every organization
+	database := DB.WithContext(WithoutOrganization(context.Background()))
+	// The users registered before the email verification are considered verified
+	verified := DB.Migrator().HasTable(&users.User{}) && DB.Migrator().HasColumn(&users.User{}, "email_verified_at")
+	// The rows stored before these models had an organization are assigned to the organization of their user
+	owners := map[interface{}]string{&users.Invitation{}: "inviter_id", &chat.Conversation{}: "user_a_id", &users.Feedback{}: "user_id",
+		&notifications.Notification{}: "user_id", &availability.Source{}: "user_id", &availability.BusyBlock{}: "user_id",
+		&users.PasswordReset{}: "user_id", &users.Review{}: "user_id"}
+	for model := range owners {
+		if !DB.Migrator().HasTable(model) || DB.Migrator().HasColumn(model, organizationColumn) {
+			delete(owners, model)
+		}
 	}
-	err = DB.AutoMigrate(&tasks.Task{})
-	if err != nil {
-		return
+	// The aliases were unique in the whole deployment, they are now unique per organization
+	// and assigned to the organization of their target
+	aliases := DB.Migrator().HasTable(&users.Alias{}) && !DB.Migrator().HasColumn(&users.Alias{}, organizationColumn)
+	if aliases && DB.Migrator().HasIndex(&users.Alias{}, "idx_alias_kind_name_key") {
+		if err := DB.Migrator().DropIndex(&users.Alias{}, "idx_alias_kind_name_key"); err != nil {
+			return err
+		}
 	}
-	err = DB.AutoMigrate(&users.Hobby{})
-	if err != nil {
-		return
+	if err := uniqueNameKeys(database); err != nil {
+		return err
 	}
-	err = DB.AutoMigrate(&users.Language{})
-	if err != nil {
-		return
+	if err := uniqueUsernames(database); err != nil {
+		return err
 	}
-	err = DB.AutoMigrate(&users.Lunch{})
+	err := DB.AutoMigrate(
+		&users.Organization{},
+		&users.User{},
+		&tasks.Task{},
+		&users.Hobby{},
+		&users.Language{},
+		&users.Diet{},
+		&users.Cuisine{},
+		&users.Department{},
+		&users.Team{},
+		&users.Place{},
+		&users.Lunch{},
+		&users.Area{},
+		&users.Alias{},
+		&users.Invitation{},
+		&users.Review{},
+		&users.Feedback{},
+		&tasks.IcebreakerTemplate{},
+		&notifications.Notification{},
+		&chat.Conversation{},
+		&chat.Message{},
+		&events.OutboxEvent{},
+		&webhooks.Webhook{},
+		&webhooks.Delivery{},
+		&mail.Email{},
+		&users.PasswordReset{},
+		&users.InviteCode{},
+		&users.SSOLogin{},
+		&availability.Source{},
+		&availability.BusyBlock{},
+		&jobs.Job{},
+	)
 	if err != nil {
-		return
+		return err
 	}
-	err = DB.AutoMigrate(&users.Area{})
-	if err != nil {
-		return
+	// The ratings of the places averaged the reviews of every organization, they are now computed per organization
+	for _, column := range []string{"rating", "rating_count"} {
+		if DB.Migrator().HasColumn(&users.Place{}, column) {
+			if err := DB.Migrator().DropColumn(&users.Place{}, column); err != nil {
+				return err
+			}
+		}
+	}
+	// The databases created by gorm v1 enforced a task per user with the unique index "user_id" of the former
+	// unique_index tag, users can now have many tasks
+	// The table name is given so the index is looked up by its name, never through the fields of the model
+	if DB.Migrator().HasIndex("tasks", "user_id") {
+		if err := DB.Migrator().DropIndex("tasks", "user_id"); err != nil {
+			return err
+		}
+	}
+	// The names of these taxonomies were unique in the whole deployment, they are now unique per organization
+	for model, index := range map[interface{}]string{&users.Diet{}: "idx_diets_name_key", &users.Cuisine{}: "idx_cuisines_name_key",
+		&users.Department{}: "idx_departments_name_key", &users.Team{}: "idx_teams_name_key"} {
+		if DB.Migrator().HasIndex(model, index) {
+			if err := DB.Migrator().DropIndex(model, index); err != nil {
+				return err
+			}
+		}
+	}
+	if !verified {
+		if err := database.Model(&users.User{}).Where("email_verified_at IS NULL").
+			UpdateColumn("email_verified_at", gorm.Expr("created_at")).Error; err != nil {
+			return err
+		}
+	}
+	for model, owner := range owners {
+		organization := gorm.Expr("(SELECT organization_id FROM users WHERE users.id = ?)", clause.Column{Table: clause.CurrentTable, Name: owner})
+		if err := database.Model(model).Where(organizationColumn+" IS NULL").UpdateColumn(organizationColumn, organization).Error; err != nil {
+			return err
+		}
+	}
+	if aliases {
+		for kind, table := range map[string]string{users.AliasKindHobby: "hobbies", users.AliasKindLanguage: "languages",
+			users.AliasKindArea: "areas", users.AliasKindDiet: "diets", users.AliasKindCuisine: "cuisines"} {
+			organization := gorm.Expr("(SELECT organization_id FROM "+table+" WHERE "+table+".id = ?)", clause.Column{Table: clause.CurrentTable, Name: "target_id"})
+			if err := database.Model(&users.Alias{}).Where("kind = ?", kind).UpdateColumn(organizationColumn, organization).Error; err != nil {
+				return err
+			}
+		}
+	}
+	if err := defaultIndexes(database); err != nil {
+		return err
+	}
+	return backfillSearchKeys(database)
+}
+
+// backfillSearchKeys sets the search keys of the taxonomies and aliases stored before the search keys existed
+func backfillSearchKeys(database *gorm.DB) error {
+	for _, table := range []string{"hobbies", "languages", "areas", "diets", "cuisines", "aliases"} {
+		var rows []struct {
+			ID   uuid.UUID
+			Name string
+		}
+		if err := database.Table(table).Select("id, name").Where("search_key = ?", "").Scan(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := database.Table(table).Where("id = ?", row.ID).Update("search_key", users.SearchKey(row.Name)).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// taxonomies are the taxonomies whose name keys are unique per organization
+// with their join table, the column of the join table, the kind of their aliases,
+// their unique index and the unique index of the default organization, see defaultIndexes
+var taxonomies = []struct {
+	model        interface{}
+	table        string
+	join         string
+	column       string
+	kind         string
+	index        string
+	defaultIndex string
+}{
+	{&users.Hobby{}, "hobbies", "user_hobbies", "hobby_id", users.AliasKindHobby, "idx_hobbies_organization_name_key", "idx_hobbies_default_name_key"},
+	{&users.Language{}, "languages", "user_languages", "language_id", users.AliasKindLanguage, "idx_languages_organization_name_key", "idx_languages_default_name_key"},
+	{&users.Area{}, "areas", "user_areas", "area_id", users.AliasKindArea, "idx_areas_organization_name_key", "idx_areas_default_name_key"},
+	{&users.Diet{}, "diets", "user_diets", "diet_id", users.AliasKindDiet, "idx_diets_organization_name_key", "idx_diets_default_name_key"},
+	{&users.Cuisine{}, "cuisines", "user_cuisines", "cuisine_id", users.AliasKindCuisine, "idx_cuisines_organization_name_key", "idx_cuisines_default_name_key"},
+}
+
+// uniqueNameKeys prepares the taxonomies stored before their name keys were unique
+// The names are normalized, the name keys are backfilled and the entries with the same name key in the same organization,
+// or both without organization, are merged into the oldest one like the merges of the admins, so the unique indexes can be created
+func uniqueNameKeys(database *gorm.DB) error {
+	for _, taxonomy := range taxonomies {
+		if !DB.Migrator().HasTable(taxonomy.model) ||
+			DB.Migrator().HasIndex(taxonomy.model, taxonomy.index) && DB.Migrator().HasIndex(taxonomy.table, taxonomy.defaultIndex) {
+			continue
+		}
+		for _, field := range []string{"NameKey", "OrganizationID"} {
+			if !DB.Migrator().HasColumn(taxonomy.model, field) {
+				if err := DB.Migrator().AddColumn(taxonomy.model, field); err != nil {
+					return err
+				}
+			}
+		}
+		// The name keys were only indexed
+		if DB.Migrator().HasIndex(taxonomy.model, "idx_"+taxonomy.table+"_name_key") {
+			if err := DB.Migrator().DropIndex(taxonomy.model, "idx_"+taxonomy.table+"_name_key"); err != nil {
+				return err
+			}
+		}
+
+		var entries []struct {
+			ID             uuid.UUID
+			Name           string
+			NameKey        string
+			OrganizationID *uuid.UUID
+		}
+		if err := database.Table(taxonomy.table).Select("id, name, name_key, organization_id").Order("created_at asc, id asc").Scan(&entries).Error; err != nil {
+			return err
+		}
+		aliases := DB.Migrator().HasTable(&users.Alias{})
+		canonical := map[string]uuid.UUID{}
+		for _, entry := range entries {
+			name := users.NormalizeName(entry.Name)
+			key := users.NameKey(name)
+			if name != entry.Name || key != entry.NameKey {
+				err := database.Table(taxonomy.table).Where("id = ?", entry.ID).Updates(map[string]interface{}{"name": name, "name_key": key}).Error
+				if err != nil {
+					return err
+				}
+			}
+			organization := ""
+			if entry.OrganizationID != nil {
+				organization = entry.OrganizationID.String()
+			}
+			canonicalID, ok := canonical[organization+"/"+key]
+			if !ok {
+				canonical[organization+"/"+key] = entry.ID
+				continue
+			}
+			err := database.Transaction(func(tx *gorm.DB) error {
+				// The derived table is needed by MySQL which cannot select from the table it deletes from
+				err := tx.Exec("DELETE FROM "+taxonomy.join+" WHERE "+taxonomy.column+" = ? AND user_id IN (SELECT user_id FROM (SELECT user_id FROM "+taxonomy.join+" WHERE "+taxonomy.column+" = ?) AS linked)",
+					entry.ID, canonicalID).Error
+				if err != nil {
+					return err
+				}
+				if err := tx.Exec("UPDATE "+taxonomy.join+" SET "+taxonomy.column+" = ? WHERE "+taxonomy.column+" = ?", canonicalID, entry.ID).Error; err != nil {
+					return err
+				}
+				if aliases {
+					if err := tx.Exec("UPDATE aliases SET target_id = ? WHERE kind = ? AND target_id = ?", canonicalID, taxonomy.kind, entry.ID).Error; err != nil {
+						return err
+					}
+				}
+				return tx.Exec("DELETE FROM "+taxonomy.table+" WHERE id = ?", entry.ID).Error
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// uniqueUsernames renames the users sharing their username with another user, so the unique index can be created
+// The former unique_index tag was ignored and the same username could be registered again
+// The oldest user who verified the username keeps it, or the oldest user when none did,
+// the others are renamed after their id and cannot log in anymore
+func uniqueUsernames(database *gorm.DB) error {
+	if !DB.Migrator().HasTable(&users.User{}) || DB.Migrator().HasIndex(&users.User{}, "idx_users_username") {
+		return nil
+	}
+	var usernames []string
+	if err := database.Table("users").Select("username").Group("username").Having("COUNT(*) > 1").Scan(&usernames).Error; err != nil {
+		return err
+	}
+	for _, username := range usernames {
+		var accounts []struct {
+			ID              uuid.UUID
+			EmailVerifiedAt *time.Time
+		}
+		if err := database.Table("users").Select("id, email_verified_at").Where("username = ?", username).
+			Order("created_at asc, id asc").Scan(&accounts).Error; err != nil {
+			return err
+		}
+		owner := accounts[0].ID
+		for _, account := range accounts {
+			if account.EmailVerifiedAt != nil {
+				owner = account.ID
+				break
+			}
+		}
+		for _, account := range accounts {
+			if account.ID == owner {
+				continue
+			}
+			renamed := "duplicate-" + account.ID.String() + "-" + username
+			if err := database.Table("users").Where("id = ?", account.ID).Update("username", renamed).Error; err != nil {
+				return err
+			}
+			log.Printf("the user %s shared the username %q with the user %s, it is renamed to %q", account.ID, username, owner, renamed)
+		}
+	}
+	return nil
+}
+
+// defaultIndexes creates the unique indexes of the name keys of the taxonomies, the teams, the departments
+// and the aliases without organization
+// The unique indexes with the organization_id column do not apply to them, the rows with a NULL column are distinct,
+// so the rows of the default organization and the aliases of the places are indexed on their own
+// The duplicated aliases without organization are deleted first, the oldest one is kept
+func defaultIndexes(database *gorm.DB) error {
+	for _, taxonomy := range taxonomies {
+		if err := createDefaultIndex(taxonomy.table, taxonomy.defaultIndex, "name_key"); err != nil {
+			return err
+		}
+	}
+	// The teams and the departments are merged into the oldest one, their members and teams move to it
+	for _, grouping := range []struct{ table, index, references, column string }{
+		{"departments", "idx_departments_default_name_key", "teams", "department_id"},
+		{"teams", "idx_teams_default_name_key", "users", "team_id"},
+	} {
+		if !DB.Migrator().HasTable(grouping.table) || DB.Migrator().HasIndex(grouping.table, grouping.index) {
+			continue
+		}
+		var entries []struct {
+			ID      uuid.UUID
+			NameKey string
+		}
+		if err := database.Table(grouping.table).Select("id, name_key").Where(organizationColumn + " IS NULL").
+			Order("created_at asc, id asc").Scan(&entries).Error; err != nil {
+			return err
+		}
+		canonical := map[string]uuid.UUID{}
+		for _, entry := range entries {
+			canonicalID, ok := canonical[entry.NameKey]
+			if !ok {
+				canonical[entry.NameKey] = entry.ID
+				continue
+			}
+			err := database.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec("UPDATE "+grouping.references+" SET "+grouping.column+" = ? WHERE "+grouping.column+" = ?", canonicalID, entry.ID).Error; err != nil {
+					return err
+				}
+				return tx.Exec("DELETE FROM "+grouping.table+" WHERE id = ?", entry.ID).Error
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if err := createDefaultIndex(grouping.table, grouping.index, "name_key"); err != nil {
+			return err
+		}
+	}
+	if DB.Migrator().HasIndex("aliases", "idx_alias_default_kind_name_key") {
+		return nil
+	}
+	var aliases []struct {
+		ID      uuid.UUID
+		Kind    string
+		NameKey string
+	}
+	if err := database.Table("aliases").Select("id, kind, name_key").Where(organizationColumn + " IS NULL").
+		Order("created_at asc, id asc").Scan(&aliases).Error; err != nil {
+		return err
+	}
+	kept := map[string]bool{}
+	for _, alias := range aliases {
+		if !kept[alias.Kind+"/"+alias.NameKey] {
+			kept[alias.Kind+"/"+alias.NameKey] = true
+			continue
+		}
+		if err := database.Exec("DELETE FROM aliases WHERE id = ?", alias.ID).Error; err != nil {
+			return err
+		}
+	}
+	return createDefaultIndex("aliases", "idx_alias_default_kind_name_key", "kind, name_key")
+}
+
+// createDefaultIndex creates the unique index of the columns of the rows of the table without organization
+// It is a partial index, MySQL has none and indexes the organization as an expression which is never NULL instead
+// The index is not declared on the models, gorm would make a single unique column unique for every organization
+func createDefaultIndex(table string, index string, columns string) error {
+	if DB.Migrator().HasIndex(table, index) {
+		return nil
+	}
+	statement := "CREATE UNIQUE INDEX " + index + " ON " + table + " (" + columns + ") WHERE " + organizationColumn + " IS NULL"
+	if DB.Dialector.Name() == "mysql" {
+		statement = "CREATE UNIQUE INDEX " + index + " ON " + table + " ((COALESCE(" + organizationColumn + ", '')), " + columns + ")"
 	}
+	return DB.Exec(statement).Error
 }
 
 func GetDB() *gorm.DB {
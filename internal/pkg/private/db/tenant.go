@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
+)
+
+// organizationColumn is the column holding the organization of the scoped models
+const organizationColumn = "organization_id"
+
+// ErrNoOrganization is returned by the queries of the scoped models whose context is neither scoped to an organization
+// nor explicitly unscoped, see WithOrganization and WithoutOrganization
+var ErrNoOrganization = errors.New("the query is not scoped to an organization")
+
+// ErrOtherOrganization is returned when a row of another organization is created in a scoped context
+var ErrOtherOrganization = errors.New("the row belongs to another organization")
+
+// organizationKey is the context key of the organization of a request
+type organizationKey struct{}
+
+// unscopedKey is the context key marking the queries of the jobs and the commands as unscoped
+type unscopedKey struct{}
+
+// WithOrganization returns a copy of ctx scoping the queries to the organization
+// A nil organization scopes them to the data without organization
+func WithOrganization(ctx context.Context, organizationID *uuid.UUID) context.Context {
+	return context.WithValue(ctx, organizationKey{}, organizationID)
+}
+
+// WithoutOrganization returns a copy of ctx whose queries see the data of every organization
+// It is only meant for the jobs, the commands and the lookups made before the organization of a user is known
+func WithoutOrganization(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unscopedKey{}, true)
+}
+
+// Unscoped returns true if ctx was explicitly unscoped with WithoutOrganization
+func Unscoped(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	unscoped, _ := ctx.Value(unscopedKey{}).(bool)
+	return unscoped
+}
+
+// Organization returns the organization the queries of ctx are scoped to
+// It returns false when ctx is not scoped, e.g. for the jobs and the commands
+func Organization(ctx context.Context) (*uuid.UUID, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	organizationID, ok := ctx.Value(organizationKey{}).(*uuid.UUID)
+	return organizationID, ok
+}
+
+// RegisterTenantScope registers the callbacks scoping the queries of a scoped context to its organization
+// The reads, updates and deletes of the models with an organization_id column only match the rows of the organization
+// and the created rows are assigned to it, see WithOrganization
+// They fail with ErrNoOrganization when the context is neither scoped nor explicitly unscoped
+// The raw SQL queries are not scoped
+func RegisterTenantScope(database *gorm.DB) error {
+	callbacks := database.Callback()
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:query", scopeOrganization); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("tenant:row", scopeOrganization); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:update", scopeOrganization); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("tenant:delete", scopeOrganization); err != nil {
+		return err
+	}
+	return callbacks.Create().Before("gorm:create").Register("tenant:create", assignOrganization)
+}
+
+// scopeOrganization adds the organization of the statement context to its conditions
+func scopeOrganization(tx *gorm.DB) {
+	if tx.Statement.Schema == nil || tx.Statement.Schema.LookUpField(organizationColumn) == nil || tx.Statement.SQL.Len() > 0 {
+		return
+	}
+	organizationID, ok := Organization(tx.Statement.Context)
+	if !ok {
+		if !Unscoped(tx.Statement.Context) {
+			tx.AddError(ErrNoOrganization)
+		}
+		return
+	}
+	column := clause.Column{Table: clause.CurrentTable, Name: organizationColumn}
+	var condition clause.Expression = clause.Eq{Column: column, Value: nil}
+	if organizationID != nil {
+		condition = clause.Eq{Column: column, Value: *organizationID}
+	}
+	tx.Statement.AddClause(clause.Where{Exprs: []clause.Expression{condition}})
+}
+
+// assignOrganization assigns the created rows to the organization of the statement context
+// The rows created with an unscoped context keep the organization they were given,
+// those given another organization in a scoped context are refused
+func assignOrganization(tx *gorm.DB) {
+	if tx.Statement.Schema == nil {
+		return
+	}
+	field := tx.Statement.Schema.LookUpField(organizationColumn)
+	if field == nil {
+		return
+	}
+	organizationID, ok := Organization(tx.Statement.Context)
+	if !ok {
+		if !Unscoped(tx.Statement.Context) {
+			tx.AddError(ErrNoOrganization)
+		}
+		return
+	}
+	assign := func(row reflect.Value) {
+		value, zero := field.ValueOf(tx.Statement.Context, row)
+		switch {
+		case zero:
+			if organizationID != nil {
+				tx.AddError(field.Set(tx.Statement.Context, row, organizationID))
+			}
+		case organizationID == nil || *value.(*uuid.UUID) != *organizationID:
+			tx.AddError(ErrOtherOrganization)
+		}
+	}
+	switch tx.Statement.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < tx.Statement.ReflectValue.Len(); i++ {
+			assign(reflect.Indirect(tx.Statement.ReflectValue.Index(i)))
+		}
+	case reflect.Struct:
+		assign(tx.Statement.ReflectValue)
+	}
+}
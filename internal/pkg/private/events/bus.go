@@ -0,0 +1,85 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// AllTypes subscribes a handler to every event type
+const AllTypes = "*"
+
+// Handler is an in-process subscriber of the domain events
+// An event can be delivered more than once, the handlers must be idempotent
+type Handler func(event Event) error
+
+// Sink receives every domain event, e.g. to forward it to another system
+// An event can be delivered more than once, the sinks must be idempotent
+type Sink interface {
+	// Name identifies the sink in the errors
+	Name() string
+	// Deliver receives an event, an error makes the dispatcher retry it later
+	Deliver(event Event) error
+}
+
+// Bus delivers the domain events to the subscribers and the sinks
+// It is safe for concurrent use
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+	sinks    []Sink
+}
+
+// bus is created eagerly because subscribers register during the start-up
+var bus = NewBus()
+
+// GetBus returns the bus of the process
+// It returns the singleton instance of the bus
+func GetBus() *Bus {
+	return bus
+}
+
+// NewBus returns a bus without subscribers
+func NewBus() *Bus {
+	return &Bus{handlers: map[string][]Handler{}}
+}
+
+// Subscribe registers a handler for an event type or AllTypes
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// AddSink registers a sink receiving every event
+func (b *Bus) AddSink(sink Sink) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sinks = append(b.sinks, sink)
+}
+
+// Deliver passes the event to its subscribers and to every sink
+// Every receiver is called even when another one fails
+// It returns an error listing the failures
+func (b *Bus) Deliver(event Event) error {
+	b.mu.RLock()
+	handlers := append(append([]Handler{}, b.handlers[event.Type]...), b.handlers[AllTypes]...)
+	sinks := append([]Sink{}, b.sinks...)
+	b.mu.RUnlock()
+
+	var failures []string
+	for _, handler := range handlers {
+		if err := handler(event); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	for _, sink := range sinks {
+		if err := sink.Deliver(event); err != nil {
+			failures = append(failures, sink.Name()+": "+err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("delivering %s %s failed: %s", event.Type, event.ID, strings.Join(failures, "; "))
+	}
+	return nil
+}
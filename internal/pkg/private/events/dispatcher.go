@@ -0,0 +1,135 @@
+package events
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/events"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxBackoff is the longest delay between two attempts to dispatch an event
+const MaxBackoff = time.Hour
+
+// Lease is how long the events claimed by a dispatcher are hidden from the other dispatchers
+const Lease = 5 * time.Minute
+
+// Dispatcher moves the events of the outbox to the bus
+// An event is marked as dispatched only after the bus delivered it, so it is delivered at least once
+// Several instances of the application can run a dispatcher, each claims its events for a lease before delivering them
+type Dispatcher struct {
+	bus         *Bus
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+}
+
+// NewDispatcher returns a dispatcher delivering to the bus with the settings of the configuration
+func NewDispatcher(bus *Bus, configuration config.EventsConfiguration) *Dispatcher {
+	return &Dispatcher{
+		bus:         bus,
+		interval:    configuration.DispatchInterval,
+		batchSize:   configuration.BatchSize,
+		maxAttempts: configuration.MaxAttempts,
+	}
+}
+
+// Run dispatches the pending events until the context is cancelled
+// The errors are logged and the dispatcher keeps running
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		for {
+			count, err := d.DispatchPending()
+			if err != nil {
+				log.Println(err)
+			}
+			// A full batch means more events are probably waiting
+			if err != nil || count < d.batchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchPending delivers one batch of the due events, the oldest first
+// The events are claimed in a short transaction and delivered outside of it, so no row stays locked while delivering
+// A failed event is retried later with an exponential backoff until the maximal number of attempts
+// It returns the number of events handled, successfully or not
+func (d *Dispatcher) DispatchPending() (int, error) {
+	pending, err := d.claim()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for i := range pending {
+		row := &pending[i]
+		now := time.Now()
+		updates := map[string]interface{}{"attempts": row.Attempts + 1, "updated_at": now}
+		if err := d.bus.Deliver(fromOutbox(row)); err != nil {
+			log.Println(err)
+			updates["last_error"] = err.Error()
+			if row.Attempts+1 >= d.maxAttempts {
+				updates["failed_at"] = now
+			} else {
+				updates["next_attempt_at"] = now.Add(Backoff(row.Attempts + 1))
+			}
+		} else {
+			updates["dispatched_at"] = now
+		}
+		if err := db.GetDB().Model(row).Updates(updates).Error; err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// claim locks the due events and postpones them by the lease
+// An event whose dispatcher stopped is delivered again once the lease expired
+func (d *Dispatcher) claim() ([]models.OutboxEvent, error) {
+	var pending []models.OutboxEvent
+	lease := time.Now().Add(Lease)
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("dispatched_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?", time.Now()).
+			Order("next_attempt_at").Order("created_at").
+			Limit(d.batchSize).Find(&pending).Error
+		if err != nil || len(pending) == 0 {
+			return err
+		}
+		ids := make([]uuid.UUID, len(pending))
+		for i, row := range pending {
+			ids[i] = row.ID
+		}
+		return tx.Model(&models.OutboxEvent{}).Where("id IN ?", ids).Update("next_attempt_at", lease).Error
+	})
+	return pending, err
+}
+
+// Backoff returns the delay before the next attempt after the given number of failed attempts
+// It doubles from one second up to MaxBackoff
+func Backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		return 0
+	}
+	if attempts > 12 {
+		return MaxBackoff
+	}
+	delay := time.Second << (attempts - 1)
+	if delay > MaxBackoff {
+		return MaxBackoff
+	}
+	return delay
+}
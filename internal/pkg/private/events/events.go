@@ -0,0 +1,93 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/events"
+	"gorm.io/gorm"
+)
+
+// The types of the domain events
+const (
+	UserRegistered          = "user.registered"
+	UserLiked               = "user.liked"
+	BuddyMatched            = "buddy.matched"
+	LunchInvitationSent     = "lunch_invitation.sent"
+	LunchInvitationAccepted = "lunch_invitation.accepted"
+	ProfileCompleted        = "profile.completed"
+)
+
+// Types lists every domain event type
+var Types = []string{UserRegistered, UserLiked, BuddyMatched, LunchInvitationSent, LunchInvitationAccepted, ProfileCompleted}
+
+// UserRegisteredPayload is the payload of UserRegistered
+type UserRegisteredPayload struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+}
+
+// UserLikedPayload is the payload of UserLiked
+type UserLikedPayload struct {
+	UserID  uuid.UUID `json:"user_id"`
+	LikedID uuid.UUID `json:"liked_id"`
+}
+
+// BuddyMatchedPayload is the payload of BuddyMatched
+// The user is the one whose like made the match mutual
+type BuddyMatchedPayload struct {
+	UserID  uuid.UUID `json:"user_id"`
+	BuddyID uuid.UUID `json:"buddy_id"`
+}
+
+// LunchInvitationPayload is the payload of LunchInvitationSent and LunchInvitationAccepted
+type LunchInvitationPayload struct {
+	InvitationID uuid.UUID `json:"invitation_id"`
+	InviterID    uuid.UUID `json:"inviter_id"`
+	InviteeID    uuid.UUID `json:"invitee_id"`
+	Time         time.Time `json:"time"`
+	Location     string    `json:"location"`
+}
+
+// ProfileCompletedPayload is the payload of ProfileCompleted
+type ProfileCompletedPayload struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// Event is a domain event delivered to the subscribers and the sinks
+// The id is stable across redeliveries, it can be used to ignore duplicates
+type Event struct {
+	ID          uuid.UUID       `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID uuid.UUID       `json:"aggregate_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// Decode unmarshals the payload of the event into v
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// Record writes an event to the outbox with the transaction of the state change
+// The aggregate is the entity the event is about, e.g. the user or the invitation
+// It returns an error if the payload could not be encoded or stored, the transaction should then be rolled back
+func Record(tx *gorm.DB, eventType string, aggregateID uuid.UUID, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.OutboxEvent{Type: eventType, AggregateID: aggregateID, Payload: string(data)}).Error
+}
+
+// fromOutbox returns the event stored in an outbox row
+func fromOutbox(row *models.OutboxEvent) Event {
+	return Event{
+		ID:          row.ID,
+		Type:        row.Type,
+		AggregateID: row.AggregateID,
+		OccurredAt:  row.CreatedAt,
+		Payload:     json.RawMessage(row.Payload),
+	}
+}
@@ -0,0 +1,36 @@
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// LogSink writes every event as a JSON line
+// It is useful to follow the events while developing
+type LogSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewLogSink returns a sink writing to w
+func NewLogSink(w io.Writer) *LogSink {
+	return &LogSink{w: w}
+}
+
+// Name identifies the sink in the errors
+func (s *LogSink) Name() string {
+	return "log"
+}
+
+// Deliver writes the event
+func (s *LogSink) Deliver(event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(data, '\n'))
+	return err
+}
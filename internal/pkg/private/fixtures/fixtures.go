@@ -0,0 +1,390 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/tasks"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/crypto"
+	"github.com/spf13/viper"
+)
+
+// defaultOrganization scopes the taxonomies and the demo users of the fixtures to the default organization
+// The names are unique per organization, so the entries of the other organizations are never reused
+var defaultOrganization = db.WithOrganization(context.Background(), nil)
+
+// Fixtures is the content of a fixtures file
+// The taxonomies are curated lists of names, the users are optional demo users
+// The icebreakers are the conversation starters given to new buddies
+// The places are the catalogue of places to have lunch at
+type Fixtures struct {
+	Hobbies     []string     `mapstructure:"hobbies"`
+	Languages   []string     `mapstructure:"languages"`
+	Areas       []string     `mapstructure:"areas"`
+	Diets       []string     `mapstructure:"diets"`
+	Cuisines    []string     `mapstructure:"cuisines"`
+	Icebreakers []Icebreaker `mapstructure:"icebreakers"`
+	Places      []Place      `mapstructure:"places"`
+	Users       []User       `mapstructure:"users"`
+}
+
+// Icebreaker is a conversation starter template
+// The name is the hobby or language the template is about, it is empty for a generic template
+type Icebreaker struct {
+	Kind string `mapstructure:"kind"`
+	Name string `mapstructure:"name"`
+	Text string `mapstructure:"text"`
+}
+
+// User is a demo user with its profile
+// The hobbies, languages, areas, diets and cuisines are referenced by name
+// The admin flag is only applied to an existing user when it is given
+type User struct {
+	Username  string   `mapstructure:"username"`
+	Password  string   `mapstructure:"password"`
+	Firstname string   `mapstructure:"firstname"`
+	Lastname  string   `mapstructure:"lastname"`
+	Bio       string   `mapstructure:"bio"`
+	Admin     *bool    `mapstructure:"admin"`
+	Hobbies   []string `mapstructure:"hobbies"`
+	Languages []string `mapstructure:"languages"`
+	Areas     []string `mapstructure:"areas"`
+	Diets     []string `mapstructure:"diets"`
+	Cuisines  []string `mapstructure:"cuisines"`
+	Lunch     *Lunch   `mapstructure:"lunch"`
+}
+
+// Lunch is the lunch preference of a demo user
+// The time is given as 15:04 in the configured time zone
+// The place is referenced by name, the location defaults to it
+type Lunch struct {
+	Location string `mapstructure:"location"`
+	Place    string `mapstructure:"place"`
+	Time     string `mapstructure:"time"`
+	Type     string `mapstructure:"type"`
+	Food     string `mapstructure:"food"`
+}
+
+// Report counts the entries touched by Apply
+type Report struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
+// String returns a human readable summary of the report
+func (r Report) String() string {
+	return fmt.Sprintf("%d entries created, %d entries updated", r.Created, r.Updated)
+}
+
+// Read reads a YAML or JSON fixtures file
+// The format is chosen by the file extension
+// It returns an error if the file could not be read or decoded
+func Read(path string) (*Fixtures, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("error reading fixtures file %s: %w", path, err)
+	}
+	var fixtures Fixtures
+	if err := v.Unmarshal(&fixtures); err != nil {
+		return nil, fmt.Errorf("unable to decode fixtures %s: %w", path, err)
+	}
+	return &fixtures, nil
+}
+
+// Load reads the fixtures file and applies it to the database
+func Load(path string) (Report, error) {
+	fixtures, err := Read(path)
+	if err != nil {
+		return Report{}, err
+	}
+	return Apply(fixtures)
+}
+
+// Apply upserts the fixtures into the database
+// The taxonomies are upserted by name, the places by name and address and the users by username
+// Applying the same fixtures twice does not create duplicates
+// The password of an existing user is never changed
+// It returns an error on the first entry that could not be stored
+func Apply(fixtures *Fixtures) (Report, error) {
+	var report Report
+
+	for _, name := range fixtures.Hobbies {
+		if _, err := upsertHobby(name, &report); err != nil {
+			return report, err
+		}
+	}
+	for _, name := range fixtures.Languages {
+		if _, err := upsertLanguage(name, &report); err != nil {
+			return report, err
+		}
+	}
+	for _, name := range fixtures.Areas {
+		if _, err := upsertArea(name, &report); err != nil {
+			return report, err
+		}
+	}
+	for _, name := range fixtures.Diets {
+		if _, err := upsertDiet(name, &report); err != nil {
+			return report, err
+		}
+	}
+	for _, name := range fixtures.Cuisines {
+		if _, err := upsertCuisine(name, &report); err != nil {
+			return report, err
+		}
+	}
+	for _, icebreaker := range fixtures.Icebreakers {
+		if err := upsertIcebreaker(icebreaker, &report); err != nil {
+			return report, err
+		}
+	}
+	places, err := ApplyPlaces(fixtures.Places)
+	report.Created += places.Created
+	report.Updated += places.Updated
+	if err != nil {
+		return report, err
+	}
+	for _, user := range fixtures.Users {
+		if err := upsertUser(user, &report); err != nil {
+			return report, fmt.Errorf("user %s: %w", user.Username, err)
+		}
+	}
+	return report, nil
+}
+
+// upsertHobby returns the hobby with the given name, creating it if needed
+func upsertHobby(name string, report *Report) (*models.Hobby, error) {
+	h := persistence.GetHobbyRepository().Scoped(defaultOrganization)
+	if hobby, err := h.GetByName(name); err == nil {
+		return hobby, nil
+	}
+	hobby := &models.Hobby{Name: name}
+	if err := h.Add(hobby); err != nil {
+		return nil, err
+	}
+	report.Created++
+	return hobby, nil
+}
+
+// upsertLanguage returns the language with the given name, creating it if needed
+func upsertLanguage(name string, report *Report) (*models.Language, error) {
+	l := persistence.GetLanguageRepository().Scoped(defaultOrganization)
+	if language, err := l.GetByName(name); err == nil {
+		return language, nil
+	}
+	language := &models.Language{Name: name}
+	if err := l.Add(language); err != nil {
+		return nil, err
+	}
+	report.Created++
+	return language, nil
+}
+
+// upsertArea returns the area with the given name, creating it if needed
+func upsertArea(name string, report *Report) (*models.Area, error) {
+	a := persistence.GetAreaRepository().Scoped(defaultOrganization)
+	if area, err := a.GetByName(name); err == nil {
+		return area, nil
+	}
+	area := &models.Area{Name: name}
+	if err := a.Add(area); err != nil {
+		return nil, err
+	}
+	report.Created++
+	return area, nil
+}
+
+// upsertDiet returns the diet with the given name, creating it if needed
+func upsertDiet(name string, report *Report) (*models.Diet, error) {
+	d := persistence.GetDietRepository().Scoped(defaultOrganization)
+	if diet, err := d.GetByName(name); err == nil {
+		return diet, nil
+	}
+	diet := &models.Diet{Name: name}
+	if err := d.Add(diet); err != nil {
+		return nil, err
+	}
+	report.Created++
+	return diet, nil
+}
+
+// upsertCuisine returns the cuisine with the given name, creating it if needed
+func upsertCuisine(name string, report *Report) (*models.Cuisine, error) {
+	k := persistence.GetCuisineRepository().Scoped(defaultOrganization)
+	if cuisine, err := k.GetByName(name); err == nil {
+		return cuisine, nil
+	}
+	cuisine := &models.Cuisine{Name: name}
+	if err := k.Add(cuisine); err != nil {
+		return nil, err
+	}
+	report.Created++
+	return cuisine, nil
+}
+
+// upsertIcebreaker creates the icebreaker template unless the same one exists
+func upsertIcebreaker(fixture Icebreaker, report *Report) error {
+	s := persistence.GetIcebreakerRepository()
+	existing, err := s.Query(&tasks.IcebreakerTemplate{Kind: fixture.Kind, NameKey: models.NameKey(fixture.Name), Text: fixture.Text})
+	if err != nil {
+		return err
+	}
+	if len(*existing) > 0 {
+		return nil
+	}
+	if err := s.Add(&tasks.IcebreakerTemplate{Kind: fixture.Kind, Name: fixture.Name, Text: fixture.Text}); err != nil {
+		return err
+	}
+	report.Created++
+	return nil
+}
+
+// upsertUser creates or updates a demo user and replaces its profile
+// A new user is set up and is an admin when the fixture says so, the admin flag of an existing user
+// only changes when the fixture gives it, so loading the fixtures again keeps the changes made since
+func upsertUser(fixture User, report *Report) error {
+	s := persistence.GetUserRepository().Scoped(defaultOrganization)
+	user, err := s.GetByUsername(fixture.Username)
+	if err != nil {
+		user = &models.User{
+			Username: fixture.Username,
+			Hash:     crypto.HashAndSalt([]byte(fixture.Password)),
+			IsSetup:  true,
+		}
+	}
+	user.Firstname = fixture.Firstname
+	user.Lastname = fixture.Lastname
+	user.Bio = fixture.Bio
+	if fixture.Admin != nil {
+		user.IsAdmin = *fixture.Admin
+	}
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	if err != nil {
+		if err := s.Add(user); err != nil {
+			return err
+		}
+		report.Created++
+	} else {
+		if err := s.Update(user); err != nil {
+			return err
+		}
+		report.Updated++
+	}
+
+	if len(fixture.Hobbies) > 0 {
+		var hobbies []models.Hobby
+		for _, name := range fixture.Hobbies {
+			hobby, err := upsertHobby(name, report)
+			if err != nil {
+				return err
+			}
+			hobbies = append(hobbies, *hobby)
+		}
+		if err := s.ChangeUserHobbies(user, hobbies); err != nil {
+			return err
+		}
+	}
+	if len(fixture.Languages) > 0 {
+		var languages []models.Language
+		for _, name := range fixture.Languages {
+			language, err := upsertLanguage(name, report)
+			if err != nil {
+				return err
+			}
+			languages = append(languages, *language)
+		}
+		if err := s.ChangeUserLanguages(user, languages); err != nil {
+			return err
+		}
+	}
+	if len(fixture.Diets) > 0 {
+		var diets []models.Diet
+		for _, name := range fixture.Diets {
+			diet, err := upsertDiet(name, report)
+			if err != nil {
+				return err
+			}
+			diets = append(diets, *diet)
+		}
+		if err := s.ChangeUserDiets(user, diets); err != nil {
+			return err
+		}
+	}
+	if len(fixture.Cuisines) > 0 {
+		var cuisines []models.Cuisine
+		for _, name := range fixture.Cuisines {
+			cuisine, err := upsertCuisine(name, report)
+			if err != nil {
+				return err
+			}
+			cuisines = append(cuisines, *cuisine)
+		}
+		if err := s.ChangeUserCuisines(user, cuisines); err != nil {
+			return err
+		}
+	}
+	for _, name := range fixture.Areas {
+		area, err := upsertArea(name, report)
+		if err != nil {
+			return err
+		}
+		if err := s.ChangeUserArea(user, area); err != nil {
+			return err
+		}
+	}
+	if fixture.Lunch != nil {
+		return upsertLunch(user, fixture.Lunch)
+	}
+	return nil
+}
+
+// upsertLunch creates or updates the lunch preference of a user
+func upsertLunch(user *models.User, fixture *Lunch) error {
+	location, err := time.LoadLocation(config.GetConfig().Database.TimeZone)
+	if err != nil {
+		return err
+	}
+	now := time.Now().In(location)
+	lunchTime, err := time.ParseInLocation("2006-01-02 15:04", now.Format("2006-01-02")+" "+fixture.Time, location)
+	if err != nil {
+		return fmt.Errorf("invalid lunch time %q: %w", fixture.Time, err)
+	}
+
+	var place *models.Place
+	lunchLocation := fixture.Location
+	if fixture.Place != "" {
+		if place, err = persistence.GetPlaceRepository().GetByName(fixture.Place); err != nil {
+			return fmt.Errorf("unknown lunch place %q", fixture.Place)
+		}
+		if lunchLocation == "" {
+			lunchLocation = place.Name
+		}
+	}
+
+	l := persistence.GetLunchRepository().Scoped(defaultOrganization)
+	lunches, err := l.Query(&models.Lunch{UserID: user.ID})
+	if err != nil {
+		return err
+	}
+	if len(*lunches) == 0 {
+		lunch := models.Lunch{UserID: user.ID, Time: lunchTime, Type: fixture.Type, Food: fixture.Food}
+		lunch.SetPlace(place, lunchLocation)
+		return l.Add(&lunch)
+	}
+	lunch := (*lunches)[0]
+	lunch.SetPlace(place, lunchLocation)
+	lunch.Time = lunchTime
+	lunch.Type = fixture.Type
+	lunch.Food = fixture.Food
+	return l.Update(&lunch)
+}
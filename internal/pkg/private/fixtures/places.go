@@ -0,0 +1,197 @@
+package fixtures
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+)
+
+// Place is a place to have lunch at
+// The aliases are other names of the place, e.g. "Canteen" for "Kantína"
+type Place struct {
+	Name         string   `mapstructure:"name"`
+	Address      string   `mapstructure:"address"`
+	Latitude     *float64 `mapstructure:"latitude"`
+	Longitude    *float64 `mapstructure:"longitude"`
+	Cuisines     []string `mapstructure:"cuisines"`
+	PriceLevel   int      `mapstructure:"price_level"`
+	OpeningHours string   `mapstructure:"opening_hours"`
+	Dietary      []string `mapstructure:"dietary"`
+	Aliases      []string `mapstructure:"aliases"`
+}
+
+// placeColumns are the columns of a places CSV file, the lists are separated by semicolons
+var placeColumns = []string{"name", "address", "latitude", "longitude", "cuisines", "price_level", "opening_hours", "dietary", "aliases"}
+
+// ReadPlaces reads the places of a CSV file or of the places of a YAML or JSON fixtures file
+// The format is chosen by the file extension
+// The first line of a CSV file names its columns, see placeColumns
+// It returns an error if the file could not be read or decoded
+func ReadPlaces(path string) ([]Place, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".csv") {
+		fixtures, err := Read(path)
+		if err != nil {
+			return nil, err
+		}
+		return fixtures.Places, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading places file %s: %w", path, err)
+	}
+	defer file.Close()
+	places, err := readPlacesCSV(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode places %s: %w", path, err)
+	}
+	return places, nil
+}
+
+// readPlacesCSV decodes the places of a CSV file
+func readPlacesCSV(r io.Reader) ([]Place, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		known := false
+		for _, column := range placeColumns {
+			known = known || column == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown column %q, the columns are %s", name, strings.Join(placeColumns, ", "))
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("the name column is required")
+	}
+
+	var places []Place
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return places, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		value := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		place := Place{
+			Name:         value("name"),
+			Address:      value("address"),
+			Cuisines:     splitList(value("cuisines")),
+			OpeningHours: value("opening_hours"),
+			Dietary:      splitList(value("dietary")),
+			Aliases:      splitList(value("aliases")),
+		}
+		if place.Latitude, err = parseCoordinate(value("latitude")); err != nil {
+			return nil, fmt.Errorf("line %d: invalid latitude: %w", line, err)
+		}
+		if place.Longitude, err = parseCoordinate(value("longitude")); err != nil {
+			return nil, fmt.Errorf("line %d: invalid longitude: %w", line, err)
+		}
+		if price := value("price_level"); price != "" {
+			if place.PriceLevel, err = strconv.Atoi(price); err != nil {
+				return nil, fmt.Errorf("line %d: invalid price level: %w", line, err)
+			}
+		}
+		places = append(places, place)
+	}
+}
+
+// splitList splits a semicolon separated list
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ";")
+}
+
+// parseCoordinate parses a latitude or a longitude, an empty value is no coordinate
+func parseCoordinate(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	coordinate, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &coordinate, nil
+}
+
+// ApplyPlaces upserts the places into the database
+// The places are upserted by name and address, so two branches of a chain are two places
+// It returns an error on the first place that could not be stored
+func ApplyPlaces(places []Place) (Report, error) {
+	var report Report
+	for _, place := range places {
+		if _, err := upsertPlace(place, &report); err != nil {
+			return report, fmt.Errorf("place %s: %w", place.Name, err)
+		}
+	}
+	return report, nil
+}
+
+// upsertPlace creates or updates a place and adds its aliases
+func upsertPlace(fixture Place, report *Report) (*models.Place, error) {
+	p := persistence.GetPlaceRepository()
+	place, findErr := p.GetByNameAndAddress(fixture.Name, fixture.Address)
+	if findErr != nil {
+		place = &models.Place{Name: fixture.Name, Address: fixture.Address}
+	}
+	place.Latitude = fixture.Latitude
+	place.Longitude = fixture.Longitude
+	place.Cuisines = models.NewTags(fixture.Cuisines...)
+	place.PriceLevel = fixture.PriceLevel
+	place.OpeningHours = fixture.OpeningHours
+	place.Dietary = models.NewTags(fixture.Dietary...)
+	if err := place.Validate(); err != nil {
+		return nil, err
+	}
+
+	if findErr != nil {
+		if err := p.Add(place); err != nil {
+			return nil, err
+		}
+		report.Created++
+	} else {
+		if err := p.Update(place); err != nil {
+			return nil, err
+		}
+		report.Updated++
+	}
+
+	// The aliases of the places are shared by every organization
+	a := persistence.GetAliasRepository().Unscoped()
+	for _, name := range fixture.Aliases {
+		if models.NameKey(name) == "" || models.NameKey(name) == place.NameKey {
+			continue
+		}
+		if _, err := p.GetByName(name); err == nil {
+			continue
+		}
+		if err := a.Add(&models.Alias{Kind: models.AliasKindPlace, Name: name, TargetID: place.ID}); err != nil {
+			return nil, err
+		}
+		report.Created++
+	}
+	return place, nil
+}
@@ -0,0 +1,157 @@
+package fixtures
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+)
+
+// Membership assigns a user to a team of a department
+// An empty team removes the user from its team
+type Membership struct {
+	Username   string
+	Team       string
+	Department string
+}
+
+// membershipColumns are the columns of a teams CSV file
+var membershipColumns = []string{"username", "team", "department"}
+
+// ReadMemberships reads the memberships of a CSV file
+// It returns an error if the file could not be read or decoded
+func ReadMemberships(path string) ([]Membership, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading teams file %s: %w", path, err)
+	}
+	defer file.Close()
+	memberships, err := ReadMembershipsCSV(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode teams %s: %w", path, err)
+	}
+	return memberships, nil
+}
+
+// ReadMembershipsCSV decodes the memberships of a CSV file
+// The first line names its columns, see membershipColumns, the username column is required
+func ReadMembershipsCSV(r io.Reader) ([]Membership, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		known := false
+		for _, column := range membershipColumns {
+			known = known || column == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown column %q, the columns are %s", name, strings.Join(membershipColumns, ", "))
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["username"]; !ok {
+		return nil, fmt.Errorf("the username column is required")
+	}
+
+	var memberships []Membership
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return memberships, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		value := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		membership := Membership{Username: value("username"), Team: value("team"), Department: value("department")}
+		if membership.Username == "" {
+			return nil, fmt.Errorf("line %d: the username is required", line)
+		}
+		memberships = append(memberships, membership)
+	}
+}
+
+// ApplyMemberships assigns the users to their teams, creating the missing teams and departments
+// The users, the teams and the departments are those of the organization of ctx, see db.WithOrganization
+// Every user is looked up first, so an unknown username fails the import before anything is changed
+// The department of an existing team is only changed when the membership names one
+func ApplyMemberships(ctx context.Context, memberships []Membership) (Report, error) {
+	var report Report
+	u := persistence.GetUserRepository().Scoped(ctx)
+	members := make([]*models.User, len(memberships))
+	for i, membership := range memberships {
+		user, err := u.GetByUsername(membership.Username)
+		if err != nil {
+			return report, fmt.Errorf("user %s not found", membership.Username)
+		}
+		members[i] = user
+	}
+
+	t := persistence.GetTeamRepository().Scoped(ctx)
+	for i, membership := range memberships {
+		var team *models.Team
+		if models.NameKey(membership.Team) != "" {
+			var err error
+			if team, err = upsertTeam(t, membership, &report); err != nil {
+				return report, fmt.Errorf("team %s: %w", membership.Team, err)
+			}
+		}
+		if err := t.SetTeam(members[i], team); err != nil {
+			return report, fmt.Errorf("user %s: %w", membership.Username, err)
+		}
+		report.Updated++
+	}
+	return report, nil
+}
+
+// upsertTeam returns the team of the membership, creating it and its department when they do not exist
+func upsertTeam(t *persistence.TeamRepository, membership Membership, report *Report) (*models.Team, error) {
+	var department *models.Department
+	if models.NameKey(membership.Department) != "" {
+		var err error
+		if department, err = t.GetDepartmentByName(membership.Department); err != nil {
+			department = &models.Department{Name: membership.Department}
+			if err := t.AddDepartment(department); err != nil {
+				return nil, err
+			}
+			report.Created++
+		}
+	}
+
+	team, err := t.GetByName(membership.Team)
+	if err != nil {
+		team = &models.Team{Name: membership.Team}
+		if department != nil {
+			team.DepartmentID, team.Department = &department.ID, department
+		}
+		if err := t.Add(team); err != nil {
+			return nil, err
+		}
+		report.Created++
+		return team, nil
+	}
+	if department != nil && (team.DepartmentID == nil || *team.DepartmentID != department.ID) {
+		team.DepartmentID, team.Department = &department.ID, department
+		if err := t.Update(team); err != nil {
+			return nil, err
+		}
+		report.Updated++
+	}
+	return team, nil
+}
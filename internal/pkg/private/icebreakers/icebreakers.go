@@ -0,0 +1,171 @@
+package icebreakers
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/tasks"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"gorm.io/gorm"
+)
+
+// PerPair is the number of icebreakers generated at once for a pair of buddies
+const PerPair = 3
+
+// candidate is a template chosen for a pair with the values of its placeholders
+type candidate struct {
+	template tasks.IcebreakerTemplate
+	hobby    string
+	language string
+}
+
+// Generate creates shared icebreaker tasks for two buddies
+// The templates of their shared hobbies come first, then the ones of their shared languages
+// The generic templates are used when there are not enough specific ones
+// A template is never given twice to the same pair
+// Each buddy gets its own copy of every icebreaker, linked to the invitation when one is given
+// The users must have their hobbies and languages loaded, the tasks belong to their organization
+// It returns the tasks created for user
+func Generate(user *users.User, buddy *users.User, invitationID *uuid.UUID) ([]tasks.Task, error) {
+	candidates, err := candidates(user, buddy)
+	if err != nil {
+		return nil, err
+	}
+	organization := db.WithOrganization(context.Background(), user.OrganizationID)
+	used, err := persistence.GetTaskRepository().Scoped(organization).UsedTemplates(user.ID, buddy.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var chosen []candidate
+	for _, c := range candidates {
+		if len(chosen) == PerPair {
+			break
+		}
+		if !used[c.template.ID] {
+			used[c.template.ID] = true
+			chosen = append(chosen, c)
+		}
+	}
+
+	var created []tasks.Task
+	err = db.GetDB().WithContext(organization).Transaction(func(tx *gorm.DB) error {
+		for _, c := range chosen {
+			mine := newTask(c, user, buddy, invitationID)
+			theirs := newTask(c, buddy, user, invitationID)
+			if err := tx.Create(&mine).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&theirs).Error; err != nil {
+				return err
+			}
+			created = append(created, mine)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// candidates returns the templates matching the pair in order of preference
+// The templates of the same group are shuffled so that pairs do not all get the same ones
+func candidates(user *users.User, buddy *users.User) ([]candidate, error) {
+	s := persistence.GetIcebreakerRepository()
+
+	hobbies := sharedHobbies(user, buddy)
+	hobbyKeys := make([]string, 0, len(hobbies))
+	for key := range hobbies {
+		hobbyKeys = append(hobbyKeys, key)
+	}
+	hobbyTemplates, err := s.ForNames(tasks.TemplateHobby, hobbyKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	languages := sharedLanguages(user, buddy)
+	languageKeys := make([]string, 0, len(languages))
+	for key := range languages {
+		languageKeys = append(languageKeys, key)
+	}
+	languageTemplates, err := s.ForNames(tasks.TemplateLanguage, languageKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	genericTemplates, err := s.Query(&tasks.IcebreakerTemplate{Kind: tasks.TemplateGeneric})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []candidate
+	for _, group := range [][]tasks.IcebreakerTemplate{hobbyTemplates, languageTemplates, *genericTemplates} {
+		rand.Shuffle(len(group), func(i, j int) { group[i], group[j] = group[j], group[i] })
+		for _, template := range group {
+			c := candidate{template: template}
+			switch template.Kind {
+			case tasks.TemplateHobby:
+				c.hobby = hobbies[template.NameKey]
+			case tasks.TemplateLanguage:
+				c.language = languages[template.NameKey]
+			}
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+// sharedHobbies returns the names of the hobbies of both users keyed by their name key
+func sharedHobbies(user *users.User, buddy *users.User) map[string]string {
+	theirs := map[uuid.UUID]bool{}
+	for _, hobby := range buddy.Hobbies {
+		theirs[hobby.ID] = true
+	}
+	shared := map[string]string{}
+	for _, hobby := range user.Hobbies {
+		if theirs[hobby.ID] {
+			shared[users.NameKey(hobby.Name)] = hobby.Name
+		}
+	}
+	return shared
+}
+
+// sharedLanguages returns the names of the languages of both users keyed by their name key
+func sharedLanguages(user *users.User, buddy *users.User) map[string]string {
+	theirs := map[uuid.UUID]bool{}
+	for _, language := range buddy.Languages {
+		theirs[language.ID] = true
+	}
+	shared := map[string]string{}
+	for _, language := range user.Languages {
+		if theirs[language.ID] {
+			shared[users.NameKey(language.Name)] = language.Name
+		}
+	}
+	return shared
+}
+
+// newTask returns the copy of an icebreaker owned by owner
+func newTask(c candidate, owner *users.User, buddy *users.User, invitationID *uuid.UUID) tasks.Task {
+	buddyName := buddy.Firstname
+	if buddyName == "" {
+		buddyName = buddy.Username
+	}
+	text := strings.NewReplacer("{buddy}", buddyName, "{hobby}", c.hobby, "{language}", c.language).Replace(c.template.Text)
+	templateID := c.template.ID
+	buddyID := buddy.ID
+	return tasks.Task{
+		Name:         "Icebreaker with " + buddyName,
+		Text:         text,
+		Kind:         tasks.KindIcebreaker,
+		UserID:       owner.ID,
+		BuddyID:      &buddyID,
+		TemplateID:   &templateID,
+		InvitationID: invitationID,
+	}
+}
@@ -0,0 +1,143 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/mail"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/matching"
+	notificationModels "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/notifications"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/notifications"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+)
+
+// The names of the built-in jobs, they are also the keys of their schedules in the configuration
+const (
+	LunchReminders    = "lunch_reminders"
+	ExpireInvitations = "expire_invitations"
+	MatchSuggestions  = "match_suggestions"
+	SuggestionEmails  = "suggestion_emails"
+	CleanupTokens     = "cleanup_tokens"
+)
+
+// RegisterDefaults registers the built-in jobs on the schedules of the configuration
+// The jobs are locked for the lease of the configuration
+// It returns an error if a schedule is missing or invalid
+func RegisterDefaults(s *Scheduler, configuration config.JobsConfiguration) error {
+	s.lease = configuration.Lease
+	builtins := []struct {
+		name        string
+		description string
+		run         Func
+	}{
+		{LunchReminders, "Reminds both users of an accepted lunch shortly before it", remindLunches(configuration.ReminderBefore)},
+		{ExpireInvitations, "Expires the pending invitations whose time has passed", expireInvitations},
+		{MatchSuggestions, "Notifies every user of its best lunch match of the day", suggestMatches},
+		{SuggestionEmails, "Queues the weekly match suggestion emails", queueSuggestionEmails},
+		{CleanupTokens, "Deletes the expired and used password reset tokens, the expired invite codes and single sign-on logins", cleanupTokens},
+	}
+	for _, builtin := range builtins {
+		expression, ok := configuration.Schedules[builtin.name]
+		if !ok {
+			return fmt.Errorf("jobs.schedules.%s is missing", builtin.name)
+		}
+		if err := s.Register(builtin.name, builtin.description, expression, builtin.run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remindLunches returns the job reminding the accepted lunches starting within the duration
+// Each lunch is reminded once, by email and by notification
+func remindLunches(before time.Duration) Func {
+	return func(ctx context.Context) (string, error) {
+		r := persistence.GetInvitationRepository().Unscoped()
+		now := time.Now()
+		invitations, err := r.DueReminders(now, now.Add(before))
+		if err != nil {
+			return "", err
+		}
+		for i := range *invitations {
+			invitation := &(*invitations)[i]
+			if invitation.Inviter == nil || invitation.Invitee == nil {
+				continue
+			}
+			if err := mail.QueueLunchReminder(invitation); err != nil {
+				return "", err
+			}
+			if err := r.MarkReminded(invitation); err != nil {
+				return "", err
+			}
+			organization := db.WithOrganization(ctx, invitation.OrganizationID)
+			for _, pair := range [][2]*users.User{{invitation.Inviter, invitation.Invitee}, {invitation.Invitee, invitation.Inviter}} {
+				notifications.Notify(organization, pair[0].ID, notificationModels.TypeLunchReminder,
+					"Lunch with "+notifications.DisplayName(pair[1])+" "+mail.When(invitation.Time, pair[0]), pair[1], &invitation.ID)
+			}
+		}
+		return fmt.Sprintf("%d lunches reminded", len(*invitations)), nil
+	}
+}
+
+// expireInvitations expires the pending invitations whose time has passed
+func expireInvitations(ctx context.Context) (string, error) {
+	count, err := persistence.GetInvitationRepository().Unscoped().ExpirePending(time.Now())
+	return fmt.Sprintf("%d invitations expired", count), err
+}
+
+// suggestMatches notifies every user with a complete profile of its best match
+func suggestMatches(ctx context.Context) (string, error) {
+	all, err := persistence.GetUserRepository().Unscoped().All()
+	if err != nil {
+		return "", err
+	}
+	data, err := matching.Load(db.WithoutOrganization(ctx))
+	if err != nil {
+		return "", err
+	}
+	count := 0
+	for i := range *all {
+		if ctx.Err() != nil {
+			return fmt.Sprintf("%d suggestions", count), ctx.Err()
+		}
+		user := &(*all)[i]
+		if !user.HasCompleteProfile() {
+			continue
+		}
+		suggestions := matching.Rank(user, *all, data, 1)
+		if len(suggestions) == 0 {
+			continue
+		}
+		best := suggestions[0]
+		notifications.Notify(db.WithOrganization(ctx, user.OrganizationID), user.ID, notificationModels.TypeSuggestion,
+			best.Name+" could be a good lunch buddy", best.User, nil)
+		count++
+	}
+	return fmt.Sprintf("%d suggestions", count), nil
+}
+
+// queueSuggestionEmails queues the weekly suggestion emails
+func queueSuggestionEmails(ctx context.Context) (string, error) {
+	count, err := mail.QueueWeeklySuggestions()
+	return fmt.Sprintf("%d emails queued", count), err
+}
+
+// cleanupTokens deletes the password resets which expired or were used, the invite codes
+// and the single sign-on logins which expired
+func cleanupTokens(ctx context.Context) (string, error) {
+	now := time.Now()
+	resets, err := persistence.GetPasswordResetRepository().Unscoped().DeleteExpired(now)
+	if err != nil {
+		return fmt.Sprintf("%d password resets deleted", resets), err
+	}
+	codes, err := persistence.GetInviteCodeRepository().Unscoped().DeleteExpired(now)
+	if err != nil {
+		return fmt.Sprintf("%d password resets and %d invite codes deleted", resets, codes), err
+	}
+	logins, err := persistence.GetSSOLoginRepository().DeleteExpired(now)
+	return fmt.Sprintf("%d password resets, %d invite codes and %d single sign-on logins deleted", resets, codes, logins), err
+}
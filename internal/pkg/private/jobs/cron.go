@@ -0,0 +1,150 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// macros are the shorthands of the common schedules
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field is the range of a field of a cron expression
+type field struct {
+	name string
+	min  int
+	max  int
+}
+
+// fields are the fields of a cron expression in order
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// Schedule is a parsed cron expression such as */5 * * * * or @daily
+// The fields are minute, hour, day of month, month and day of week, sunday being 0 or 7
+// Like cron, a day matches either the day of month or the day of week when both are restricted
+type Schedule struct {
+	Expression string
+	sets       [5]map[int]bool
+	restricted [5]bool
+}
+
+// ParseSchedule parses a cron expression
+// It supports *, lists, ranges, steps and the @hourly, @daily, @weekly, @monthly and @yearly shorthands
+func ParseSchedule(expression string) (*Schedule, error) {
+	expanded := strings.TrimSpace(expression)
+	if macro, ok := macros[expanded]; ok {
+		expanded = macro
+	}
+	parts := strings.Fields(expanded)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron expression %q must have %d fields", expression, len(fields))
+	}
+	schedule := &Schedule{Expression: expression}
+	for i, part := range parts {
+		set, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expression, err)
+		}
+		schedule.sets[i] = set
+		schedule.restricted[i] = part != "*" && !strings.HasPrefix(part, "*/")
+	}
+	// Sunday is 0 or 7
+	if schedule.sets[4][7] {
+		schedule.sets[4][0] = true
+	}
+	return schedule, nil
+}
+
+// parseField parses a comma separated list of values, ranges and steps
+func parseField(part string, f field) (map[int]bool, error) {
+	set := map[int]bool{}
+	for _, item := range strings.Split(part, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step %q in the %s", stepPart, f.name)
+			}
+		}
+		low, high := f.min, f.max
+		if rangePart != "*" {
+			lowPart, highPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = strconv.Atoi(lowPart); err != nil {
+				return nil, fmt.Errorf("invalid value %q in the %s", lowPart, f.name)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(highPart); err != nil {
+					return nil, fmt.Errorf("invalid value %q in the %s", highPart, f.name)
+				}
+			} else if hasStep {
+				high = f.max
+			}
+		}
+		if low < f.min || high > f.max || low > high {
+			return nil, fmt.Errorf("%s must be between %d and %d", f.name, f.min, f.max)
+		}
+		for value := low; value <= high; value += step {
+			set[value] = true
+		}
+	}
+	if len(set) == 0 {
+		return nil, errors.New("empty " + f.name)
+	}
+	return set, nil
+}
+
+// Next returns the first time matching the schedule strictly after t, to the minute
+// It returns the zero time when nothing matches within five years, e.g. for the 30th of february
+func (s *Schedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := next.AddDate(5, 0, 0)
+	for next.Before(limit) {
+		if !s.sets[3][int(next.Month())] {
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+		if !s.matchesDay(next) {
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+			continue
+		}
+		if !s.sets[1][next.Hour()] {
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+			continue
+		}
+		if !s.sets[0][next.Minute()] {
+			next = next.Add(time.Minute)
+			continue
+		}
+		return next
+	}
+	return time.Time{}
+}
+
+// matchesDay returns true if the day of t matches the day of month and the day of week fields
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dayOfMonth := s.sets[2][t.Day()]
+	dayOfWeek := s.sets[4][int(t.Weekday())]
+	if s.restricted[2] && s.restricted[4] {
+		return dayOfMonth || dayOfWeek
+	}
+	return dayOfMonth && dayOfWeek
+}
@@ -0,0 +1,193 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+)
+
+// ErrUnknownJob is returned when no job is registered under a name
+var ErrUnknownJob = errors.New("unknown job")
+
+// ErrLocked is returned when a job is already running, in this instance or another one
+var ErrLocked = errors.New("job is already running")
+
+// Func is the work of a job
+// It returns a short summary of what it did, e.g. the number of reminders sent
+type Func func(ctx context.Context) (string, error)
+
+// Job is a registered job
+type Job struct {
+	Name        string
+	Description string
+	Schedule    *Schedule
+	Run         Func
+}
+
+// Scheduler runs the registered jobs on their schedule
+// A job row in the database is locked before a job runs, so that only one instance of the server runs it at a time
+type Scheduler struct {
+	mu    sync.RWMutex
+	jobs  map[string]*Job
+	owner string
+	lease time.Duration
+}
+
+var scheduler *Scheduler
+
+// GetScheduler returns the scheduler
+// It creates a new one if it does not exist
+// It returns the singleton instance of the scheduler
+func GetScheduler() *Scheduler {
+	if scheduler == nil {
+		hostname, _ := os.Hostname()
+		scheduler = &Scheduler{
+			jobs:  map[string]*Job{},
+			owner: fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.NewString()[:8]),
+			lease: 10 * time.Minute,
+		}
+	}
+	return scheduler
+}
+
+// Register adds a job run on the cron expression
+// It returns an error if the expression is invalid or never matches
+func (s *Scheduler) Register(name string, description string, expression string, run Func) error {
+	schedule, err := ParseSchedule(expression)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return fmt.Errorf("job %s: cron expression %q never matches", name, expression)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[name] = &Job{Name: name, Description: description, Schedule: schedule, Run: run}
+	return nil
+}
+
+// Jobs returns the registered jobs ordered by name
+func (s *Scheduler) Jobs() []*Job {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Name < jobs[j].Name
+	})
+	return jobs
+}
+
+// Job returns the job registered under the name
+func (s *Scheduler) Job(name string) (*Job, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[name]
+	return job, ok
+}
+
+// Start creates the rows of the jobs and runs the due jobs in the background until the context is cancelled
+// The errors are logged and the scheduler keeps running
+// It returns an error if the rows could not be created
+func (s *Scheduler) Start(ctx context.Context, configuration config.JobsConfiguration) error {
+	if err := s.ensure(); err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(configuration.PollInterval)
+		defer ticker.Stop()
+		for {
+			s.RunDue(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// ensure creates the row of every registered job
+func (s *Scheduler) ensure() error {
+	r := persistence.GetJobRepository()
+	now := time.Now()
+	for _, job := range s.Jobs() {
+		if err := r.Ensure(job.Name, job.Schedule.Expression, job.Schedule.Next(now)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunDue runs the jobs whose next run is due and which are not locked by another instance
+func (s *Scheduler) RunDue(ctx context.Context) {
+	r := persistence.GetJobRepository()
+	for _, job := range s.Jobs() {
+		locked, err := r.Lock(job.Name, s.owner, time.Now(), s.lease, true)
+		if err != nil {
+			log.Println("job", job.Name, err)
+			continue
+		}
+		if locked {
+			s.execute(ctx, job)
+		}
+	}
+}
+
+// RunNow runs a job right away, whatever its schedule
+// It returns ErrUnknownJob or ErrLocked when the job cannot be run, the error of the job is recorded on its row
+func (s *Scheduler) RunNow(ctx context.Context, name string) error {
+	job, ok := s.Job(name)
+	if !ok {
+		return ErrUnknownJob
+	}
+	r := persistence.GetJobRepository()
+	if err := r.Ensure(job.Name, job.Schedule.Expression, job.Schedule.Next(time.Now())); err != nil {
+		return err
+	}
+	locked, err := r.Lock(job.Name, s.owner, time.Now(), s.lease, false)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return ErrLocked
+	}
+	s.execute(ctx, job)
+	return nil
+}
+
+// execute runs a locked job and records its outcome
+// A panic of the job is recorded as its error
+func (s *Scheduler) execute(ctx context.Context, job *Job) {
+	started := time.Now()
+	result, err := s.call(ctx, job)
+	if err != nil {
+		log.Println("job", job.Name, err)
+	}
+	next := job.Schedule.Next(time.Now())
+	if finishErr := persistence.GetJobRepository().Finish(job.Name, s.owner, started, next, result, err); finishErr != nil {
+		log.Println("job", job.Name, finishErr)
+	}
+}
+
+// call runs the job and turns a panic into an error
+func (s *Scheduler) call(ctx context.Context, job *Job) (result string, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return job.Run(ctx)
+}
@@ -0,0 +1,158 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/calendar"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/events"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/matching"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/mail"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/notifications"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+)
+
+// SuggestionsPerEmail is the number of matches of the weekly suggestions
+const SuggestionsPerEmail = 3
+
+// Subscribe registers the emails sent on domain events
+func Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.LunchInvitationSent, invitationSent)
+	bus.Subscribe(events.LunchInvitationAccepted, invitationAccepted)
+}
+
+// invitationSent tells the invitee about a new invitation
+func invitationSent(event events.Event) error {
+	invitation, err := loadInvitation(event)
+	if err != nil || invitation == nil {
+		return err
+	}
+	return Queue(models.KindInvitation, "invitation_received", invitation.Invitee, &event.ID, Data{
+		"Inviter":  notifications.DisplayName(invitation.Inviter),
+		"When":     When(invitation.Time, invitation.Invitee),
+		"Location": invitation.Location,
+		"Message":  invitation.Message,
+	})
+}
+
+// invitationAccepted tells the inviter that the invitation was accepted
+func invitationAccepted(event events.Event) error {
+	invitation, err := loadInvitation(event)
+	if err != nil || invitation == nil {
+		return err
+	}
+	return Queue(models.KindInvitation, "invitation_accepted", invitation.Inviter, &event.ID, Data{
+		"Invitee":  notifications.DisplayName(invitation.Invitee),
+		"When":     When(invitation.Time, invitation.Inviter),
+		"Location": invitation.Location,
+	})
+}
+
+// loadInvitation returns the invitation of the event with its participants
+// It returns nil when the invitation was deleted in the meantime
+func loadInvitation(event events.Event) (*users.Invitation, error) {
+	var payload events.LunchInvitationPayload
+	if err := event.Decode(&payload); err != nil {
+		return nil, err
+	}
+	// The events are delivered outside of any request, in every organization
+	invitation, err := persistence.GetInvitationRepository().Unscoped().Get(payload.InvitationID.String())
+	if err != nil || invitation.Inviter == nil || invitation.Invitee == nil {
+		return nil, nil
+	}
+	return invitation, nil
+}
+
+// QueueLunchReminder reminds both participants of an accepted invitation
+// It returns an error if one of the emails could not be queued
+func QueueLunchReminder(invitation *users.Invitation) error {
+	for _, pair := range [][2]*users.User{{invitation.Inviter, invitation.Invitee}, {invitation.Invitee, invitation.Inviter}} {
+		err := Queue(models.KindReminder, "lunch_reminder", pair[0], nil, Data{
+			"Buddy":    notifications.DisplayName(pair[1]),
+			"When":     When(invitation.Time, pair[0]),
+			"Location": invitation.Location,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueuePasswordReset sends the link to choose a new password
+func QueuePasswordReset(user *users.User, token string) error {
+	return Queue(models.KindPasswordReset, "password_reset", user, nil, Data{
+		"Link":     config.GetConfig().Mail.BaseURL + "/reset-password?token=" + token,
+		"ValidFor": "1 hour",
+	})
+}
+
+// QueueEmailVerification sends the link verifying the username of a registered user
+// The link is valid for the duration
+func QueueEmailVerification(user *users.User, token string, ttl time.Duration) error {
+	return Queue(models.KindVerification, "email_verification", user, nil, Data{
+		"Link":     config.GetConfig().Mail.BaseURL + "/verify-email?token=" + token,
+		"ValidFor": validFor(ttl),
+	})
+}
+
+// validFor returns the duration of a link in words, e.g. 2 days or 12 hours
+func validFor(ttl time.Duration) string {
+	switch {
+	case ttl == 24*time.Hour:
+		return "1 day"
+	case ttl%(24*time.Hour) == 0:
+		return fmt.Sprintf("%d days", ttl/(24*time.Hour))
+	case ttl == time.Hour:
+		return "1 hour"
+	case ttl%time.Hour == 0:
+		return fmt.Sprintf("%d hours", ttl/time.Hour)
+	default:
+		return ttl.String()
+	}
+}
+
+// QueueWeeklySuggestions suggests a few matches to every user accepting the suggestions
+// The users without any match are skipped
+// The users of every organization are suggested the users of their own organization
+// It returns the number of emails queued
+func QueueWeeklySuggestions() (int, error) {
+	all, err := persistence.GetUserRepository().Unscoped().All()
+	if err != nil {
+		return 0, err
+	}
+	data, err := matching.Load(db.WithoutOrganization(context.Background()))
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for i := range *all {
+		user := &(*all)[i]
+		if !user.EmailSuggestions {
+			continue
+		}
+		suggestions := matching.Rank(user, *all, data, SuggestionsPerEmail)
+		if len(suggestions) == 0 {
+			continue
+		}
+		if err := Queue(models.KindSuggestions, "weekly_suggestions", user, nil, Data{"Suggestions": suggestions}); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// When returns the day and the time of a lunch in the time zone of the recipient
+func When(t time.Time, recipient *users.User) string {
+	t = t.In(calendar.Location(recipient))
+	now := time.Now().In(t.Location())
+	if t.YearDay() == now.YearDay() && t.Year() == now.Year() {
+		return "today at " + t.Format("15:04")
+	}
+	return "on " + t.Format("Mon 2 Jan at 15:04")
+}
@@ -0,0 +1,57 @@
+package mail
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileTransport writes every message as an .eml file to a directory
+// It is useful to look at the emails while developing
+type FileTransport struct {
+	dir string
+}
+
+// NewFileTransport returns a transport writing to the directory
+// The directory is created when the first message is written
+func NewFileTransport(dir string) *FileTransport {
+	return &FileTransport{dir: dir}
+}
+
+// Send writes the message
+func (t *FileTransport) Send(message Message) error {
+	data, err := Encode(message)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return err
+	}
+	recipient := strings.NewReplacer("@", "_at_", "<", "", ">", "", " ", "_", "/", "_").Replace(message.To)
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), recipient)
+	return os.WriteFile(filepath.Join(t.dir, name), data, 0o644)
+}
+
+// LogTransport prints the text part of every message instead of sending it
+// It is the default transport so that nothing is sent by accident
+type LogTransport struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewLogTransport returns a transport writing to w
+func NewLogTransport(w io.Writer) *LogTransport {
+	return &LogTransport{w: w}
+}
+
+// Send prints the message
+func (t *LogTransport) Send(message Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err := fmt.Fprintf(t.w, "--- mail to %s: %s\n%s\n---\n", message.To, message.Subject, message.Text)
+	return err
+}
@@ -0,0 +1,67 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"time"
+)
+
+// Encode returns the message in the MIME format, with a text and an optional HTML alternative
+func Encode(message Message) ([]byte, error) {
+	var buffer bytes.Buffer
+	header := func(key string, value string) {
+		fmt.Fprintf(&buffer, "%s: %s\r\n", key, value)
+	}
+	header("From", message.From)
+	header("To", message.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", message.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+
+	if message.HTML == "" {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buffer.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buffer, message.Text); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), nil
+	}
+
+	writer := multipart.NewWriter(&buffer)
+	header("Content-Type", "multipart/alternative; boundary="+writer.Boundary())
+	buffer.WriteString("\r\n")
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	} {
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// writeQuotedPrintable writes the content with the quoted-printable encoding
+func writeQuotedPrintable(w io.Writer, content string) error {
+	encoder := quotedprintable.NewWriter(w)
+	if _, err := encoder.Write([]byte(content)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,86 @@
+package mail
+
+import (
+	"net/mail"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/mail"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/notifications"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+)
+
+// Data is the data of a template
+// The name of the recipient and the base url of the links are added by Queue
+type Data map[string]interface{}
+
+var (
+	renderer     *Renderer
+	rendererOnce sync.Once
+)
+
+// getRenderer returns the renderer of the configured templates
+// It returns the singleton instance of the renderer
+func getRenderer() *Renderer {
+	rendererOnce.Do(func() {
+		renderer = NewRenderer(config.GetConfig().Mail.Templates)
+	})
+	return renderer
+}
+
+// Queue renders an email for the user and adds it to the queue, it is sent in the background
+// Nothing is queued when the user opted out of the kind or the username is not an email address
+// The event is optional, the email of an event is queued only once per user
+// It returns an error if the email could not be rendered or stored
+func Queue(kind string, template string, user *users.User, eventID *uuid.UUID, data Data) error {
+	to, ok := Address(user)
+	if !ok || !Wants(user, kind) {
+		return nil
+	}
+	if data == nil {
+		data = Data{}
+	}
+	data["Name"] = notifications.DisplayName(user)
+	data["BaseURL"] = config.GetConfig().Mail.BaseURL
+	subject, text, html, err := getRenderer().Render(template, data)
+	if err != nil {
+		return err
+	}
+	userID := user.ID
+	return persistence.GetEmailRepository().Enqueue(&models.Email{
+		UserID:  &userID,
+		EventID: eventID,
+		Kind:    kind,
+		To:      to,
+		Subject: subject,
+		Text:    text,
+		HTML:    html,
+	})
+}
+
+// Address returns the address of the user with its name
+// The usernames are email addresses, it returns false when it is not one
+func Address(user *users.User) (string, bool) {
+	address, err := mail.ParseAddress(user.Username)
+	if err != nil {
+		return "", false
+	}
+	return (&mail.Address{Name: notifications.DisplayName(user), Address: address.Address}).String(), true
+}
+
+// Wants returns true if the user accepts the emails of the kind
+// The password resets and the email verifications are always sent
+func Wants(user *users.User, kind string) bool {
+	switch kind {
+	case models.KindInvitation:
+		return user.EmailInvitations
+	case models.KindReminder:
+		return user.EmailReminders
+	case models.KindSuggestions:
+		return user.EmailSuggestions
+	default:
+		return true
+	}
+}
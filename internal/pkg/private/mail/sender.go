@@ -0,0 +1,122 @@
+package mail
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/events"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/mail"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// lease is how long a claimed email is hidden from the other senders
+const lease = 5 * time.Minute
+
+// Sender sends the queued emails through a transport
+// A failed email is retried with an exponential backoff until the maximal number of attempts
+type Sender struct {
+	transport    Transport
+	from         string
+	pollInterval time.Duration
+	batchSize    int
+	maxAttempts  int
+}
+
+// NewSender returns a sender with the settings of the configuration
+// It returns an error if the transport is unknown
+func NewSender(configuration config.MailConfiguration) (*Sender, error) {
+	transport, err := NewTransport(configuration)
+	if err != nil {
+		return nil, err
+	}
+	return &Sender{
+		transport:    transport,
+		from:         configuration.From,
+		pollInterval: configuration.PollInterval,
+		batchSize:    configuration.BatchSize,
+		maxAttempts:  configuration.MaxAttempts,
+	}, nil
+}
+
+// Run sends the due emails until the context is cancelled
+// The errors are logged and the sender keeps running
+func (s *Sender) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			count, err := s.SendPending()
+			if err != nil {
+				log.Println(err)
+			}
+			if err != nil || count < s.batchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendPending sends one batch of the due emails, the oldest first
+// The emails are leased before they are sent so that another instance does not send them at the same time
+// It returns the number of emails attempted
+func (s *Sender) SendPending() (int, error) {
+	emails, err := s.claim()
+	if err != nil {
+		return 0, err
+	}
+	r := persistence.GetEmailRepository()
+	for i := range emails {
+		email := &emails[i]
+		email.Attempts++
+		err := s.transport.Send(Message{From: s.from, To: email.To, Subject: email.Subject, Text: email.Text, HTML: email.HTML})
+		if err == nil {
+			now := time.Now()
+			email.Status = models.StatusSent
+			email.SentAt = &now
+			email.Error = ""
+		} else {
+			log.Println(err)
+			email.Error = err.Error()
+			if email.Attempts >= s.maxAttempts {
+				email.Status = models.StatusFailed
+			} else {
+				email.NextAttemptAt = time.Now().Add(events.Backoff(email.Attempts))
+			}
+		}
+		if err := r.Update(email); err != nil {
+			return i, err
+		}
+	}
+	return len(emails), nil
+}
+
+// claim locks the due emails and postpones them by the lease
+// An email whose sender stopped is sent again once the lease expired
+func (s *Sender) claim() ([]models.Email, error) {
+	var emails []models.Email
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.StatusPending, time.Now()).
+			Order("next_attempt_at").Limit(s.batchSize).Find(&emails).Error
+		if err != nil || len(emails) == 0 {
+			return err
+		}
+		ids := make([]uuid.UUID, len(emails))
+		for i, email := range emails {
+			ids[i] = email.ID
+		}
+		return tx.Model(&models.Email{}).Where("id IN ?", ids).Update("next_attempt_at", time.Now().Add(lease)).Error
+	})
+	return emails, err
+}
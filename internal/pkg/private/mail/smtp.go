@@ -0,0 +1,42 @@
+package mail
+
+import (
+	"net"
+	"net/mail"
+	"net/smtp"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+)
+
+// SMTPTransport sends the messages through an SMTP server
+// STARTTLS is used when the server supports it, the credentials are optional
+type SMTPTransport struct {
+	address string
+	auth    smtp.Auth
+}
+
+// NewSMTPTransport returns a transport for the server of the configuration
+func NewSMTPTransport(configuration config.SMTPConfiguration) *SMTPTransport {
+	transport := &SMTPTransport{address: net.JoinHostPort(configuration.Host, configuration.Port)}
+	if configuration.Username != "" {
+		transport.auth = smtp.PlainAuth("", configuration.Username, configuration.Password, configuration.Host)
+	}
+	return transport
+}
+
+// Send sends the message
+func (t *SMTPTransport) Send(message Message) error {
+	from, err := mail.ParseAddress(message.From)
+	if err != nil {
+		return err
+	}
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return err
+	}
+	data, err := Encode(message)
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(t.address, t.auth, from.Address, []string{to.Address}, data)
+}
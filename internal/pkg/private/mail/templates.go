@@ -0,0 +1,84 @@
+package mail
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+)
+
+// funcs are the functions available in the templates
+var funcs = map[string]interface{}{
+	"join": strings.Join,
+}
+
+// Renderer renders the emails from the templates of a directory
+// An email named invitation_received is made of invitation_received.txt and the optional invitation_received.html
+// Both define a "subject" template, the HTML one defines a "content" template wrapped by layout.html
+// The templates are parsed once and cached, it is safe for concurrent use
+type Renderer struct {
+	dir  string
+	mu   sync.Mutex
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+// NewRenderer returns a renderer of the templates of the directory
+func NewRenderer(dir string) *Renderer {
+	return &Renderer{dir: dir, text: map[string]*texttemplate.Template{}, html: map[string]*htmltemplate.Template{}}
+}
+
+// Render returns the subject, the text and the HTML of the email
+// The HTML is empty when the email has no HTML template
+// It returns an error if the templates could not be parsed or executed
+func (r *Renderer) Render(name string, data interface{}) (subject string, text string, html string, err error) {
+	textTemplate, htmlTemplate, err := r.templates(name)
+	if err != nil {
+		return "", "", "", err
+	}
+	var buffer bytes.Buffer
+	if err := textTemplate.ExecuteTemplate(&buffer, "subject", data); err != nil {
+		return "", "", "", err
+	}
+	subject = strings.TrimSpace(buffer.String())
+	buffer.Reset()
+	if err := textTemplate.Execute(&buffer, data); err != nil {
+		return "", "", "", err
+	}
+	text = buffer.String()
+	if htmlTemplate != nil {
+		buffer.Reset()
+		if err := htmlTemplate.ExecuteTemplate(&buffer, "layout", data); err != nil {
+			return "", "", "", err
+		}
+		html = buffer.String()
+	}
+	return subject, text, html, nil
+}
+
+// templates returns the parsed templates of the email
+func (r *Renderer) templates(name string) (*texttemplate.Template, *htmltemplate.Template, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if textTemplate, ok := r.text[name]; ok {
+		return textTemplate, r.html[name], nil
+	}
+	textTemplate, err := texttemplate.New(name + ".txt").Funcs(funcs).ParseFiles(filepath.Join(r.dir, name+".txt"))
+	if err != nil {
+		return nil, nil, err
+	}
+	var htmlTemplate *htmltemplate.Template
+	htmlPath := filepath.Join(r.dir, name+".html")
+	if _, err := os.Stat(htmlPath); err == nil {
+		htmlTemplate, err = htmltemplate.New(name+".html").Funcs(funcs).ParseFiles(filepath.Join(r.dir, "layout.html"), htmlPath)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	r.text[name] = textTemplate
+	r.html[name] = htmlTemplate
+	return textTemplate, htmlTemplate, nil
+}
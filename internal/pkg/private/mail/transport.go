@@ -0,0 +1,38 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+)
+
+// Message is an email ready to be sent
+// The text part is required, the HTML part is optional
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Transport sends the messages, e.g. through an SMTP server
+type Transport interface {
+	Send(message Message) error
+}
+
+// NewTransport returns the transport chosen by the configuration
+// It returns an error if the transport is unknown
+func NewTransport(configuration config.MailConfiguration) (Transport, error) {
+	switch configuration.Transport {
+	case "smtp":
+		return NewSMTPTransport(configuration.SMTP), nil
+	case "file":
+		return NewFileTransport(configuration.Dir), nil
+	case "log":
+		return NewLogTransport(os.Stdout), nil
+	default:
+		return nil, fmt.Errorf("unknown mail transport %q", configuration.Transport)
+	}
+}
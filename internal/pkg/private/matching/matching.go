@@ -0,0 +1,367 @@
+package matching
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/availability"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/calendar"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/notifications"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/geo"
+)
+
+// The weights of what two users have in common
+const (
+	HobbyWeight     = 3
+	LanguageWeight  = 2
+	AreaWeight      = 2
+	LunchTimeWeight = 2
+	PlaceWeight     = 2
+	NearbyWeight    = 1
+	FeedbackWeight  = 2
+	DietWeight      = 1
+	CuisineWeight   = 1
+)
+
+// SimilarHobbies is how many hobbies a candidate shares with an enjoyed companion to be similar to it
+const SimilarHobbies = 2
+
+// SlotDays is how many days ahead a common free lunch is looked for
+const SlotDays = 7
+
+// Suggestion is a user suggested to have lunch with
+// The reasons explain the score, e.g. the shared hobbies
+// The slot is the next lunch at which both users are free, when one of them has a lunch time
+type Suggestion struct {
+	User     *users.User `json:"-"`
+	Username string      `json:"username"`
+	Name     string      `json:"name"`
+	Score    int         `json:"score"`
+	Reasons  []string    `json:"reasons"`
+	Slot     *time.Time  `json:"slot,omitempty"`
+}
+
+// Suggest returns up to limit users the user could have lunch with, the best matches first
+// The buddies, the liked users and the users blocked in either direction are left out
+// Two users who both speak languages but no common one are never suggested
+// Two users who are busy at every lunch of the next SlotDays days are never suggested either
+// Two users of whom one gave a thumbs down after their last lunch are never suggested again
+// The team preference of both users is honoured, a user without team is never restricted by its own preference
+// The candidates similar to a companion the user gave a thumbs up rank higher
+// With food.matching set to require, two users without a place catering for both of their diets are never suggested
+// With geo.matching set to require, two users whose areas are farther apart than the walking distance are never suggested
+// Only the users of the same organization are ever suggested
+// The user must be loaded with its associations
+func Suggest(user *users.User, limit int) ([]Suggestion, error) {
+	organization := db.WithOrganization(context.Background(), user.OrganizationID)
+	candidates, err := persistence.GetUserRepository().Scoped(organization).All()
+	if err != nil {
+		return nil, err
+	}
+	data, err := Load(organization)
+	if err != nil {
+		return nil, err
+	}
+	return Rank(user, *candidates, data, limit), nil
+}
+
+// Data is what the ranking needs besides the users, loaded once to rank several users
+type Data struct {
+	Busy     availability.Busy
+	Feedback Feedback
+	// Places is the catalogue, searched for a place catering for the diets of two users
+	Places []users.Place
+}
+
+// Load returns the busy blocks, the feedback and the places needed to rank the users
+// Only the busy blocks and the feedback of the organization of ctx are read, see db.WithOrganization
+func Load(ctx context.Context) (Data, error) {
+	busy, err := LoadBusy(ctx)
+	if err != nil {
+		return Data{}, err
+	}
+	feedback, err := LoadFeedback(ctx)
+	if err != nil {
+		return Data{}, err
+	}
+	places, err := persistence.GetPlaceRepository().All()
+	if err != nil {
+		return Data{}, err
+	}
+	return Data{Busy: busy, Feedback: feedback, Places: *places}, nil
+}
+
+// LoadBusy returns the busy blocks of the users during the next SlotDays days
+func LoadBusy(ctx context.Context) (availability.Busy, error) {
+	now := time.Now()
+	return availability.LoadBusy(ctx, now, now.AddDate(0, 0, SlotDays+1))
+}
+
+// Feedback is the opinions of several users about their lunch companions, loaded at once
+// The opinion of a user about a companion is true after a thumbs up, false after a thumbs down
+// Only the last feedback about a companion counts
+type Feedback map[uuid.UUID]map[uuid.UUID]bool
+
+// LoadFeedback returns the opinions of every user about their lunch companions
+func LoadFeedback(ctx context.Context) (Feedback, error) {
+	all, err := persistence.GetFeedbackRepository().Scoped(ctx).All()
+	if err != nil {
+		return nil, err
+	}
+	feedback := Feedback{}
+	for _, given := range *all {
+		if feedback[given.UserID] == nil {
+			feedback[given.UserID] = map[uuid.UUID]bool{}
+		}
+		feedback[given.UserID][given.AboutID] = given.Positive
+	}
+	return feedback, nil
+}
+
+// Disliked returns true if the user gave a thumbs down after the last lunch with the other user
+func (f Feedback) Disliked(userID uuid.UUID, otherID uuid.UUID) bool {
+	positive, ok := f[userID][otherID]
+	return ok && !positive
+}
+
+// Rank returns up to limit of the candidates the user could have lunch with, the best matches first
+// It is Suggest with the candidates and the data already loaded, e.g. to suggest matches to every user at once
+func Rank(user *users.User, candidates []users.User, data Data, limit int) []Suggestion {
+	excluded := map[uuid.UUID]bool{user.ID: true}
+	for _, others := range [][]*users.User{user.Buddies, user.Likes, user.Blacklist} {
+		for _, other := range others {
+			excluded[other.ID] = true
+		}
+	}
+	var enjoyed []*users.User
+	for i := range candidates {
+		if positive, ok := data.Feedback[user.ID][candidates[i].ID]; ok && positive {
+			enjoyed = append(enjoyed, &candidates[i])
+		}
+	}
+
+	now := time.Now()
+	suggestions := []Suggestion{}
+	for i := range candidates {
+		candidate := &candidates[i]
+		if excluded[candidate.ID] || !sameOrganization(user, candidate) || blocks(candidate, user) || !allows(user, candidate) || !allows(candidate, user) ||
+			data.Feedback.Disliked(user.ID, candidate.ID) || data.Feedback.Disliked(candidate.ID, user.ID) {
+			continue
+		}
+		suggestion, ok := score(user, candidate, data.Places)
+		if !ok {
+			continue
+		}
+		if reason, ok := similar(candidate, enjoyed); ok {
+			suggestion.Score += FeedbackWeight
+			suggestion.Reasons = append(suggestion.Reasons, reason)
+		}
+		if slot, ok := commonLunch(user, candidate, data.Busy, now); ok {
+			suggestion.Slot = slot
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Score > suggestions[j].Score
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// score returns what the users have in common
+// It returns false when they have nothing in common, cannot talk, work too far apart or have nowhere to eat together
+func score(user *users.User, candidate *users.User, places []users.Place) (Suggestion, bool) {
+	suggestion := Suggestion{User: candidate, Username: candidate.Username, Name: notifications.DisplayName(candidate), Reasons: []string{}}
+
+	for _, hobby := range user.Hobbies {
+		for _, other := range candidate.Hobbies {
+			if hobby.ID == other.ID {
+				suggestion.Score += HobbyWeight
+				suggestion.Reasons = append(suggestion.Reasons, "likes "+hobby.Name)
+			}
+		}
+	}
+	sharesLanguage := false
+	for _, language := range user.Languages {
+		for _, other := range candidate.Languages {
+			if language.ID == other.ID {
+				sharesLanguage = true
+				suggestion.Score += LanguageWeight
+				suggestion.Reasons = append(suggestion.Reasons, "speaks "+language.Name)
+			}
+		}
+	}
+	if !sharesLanguage && len(user.Languages) > 0 && len(candidate.Languages) > 0 {
+		return suggestion, false
+	}
+	sharesArea := false
+	for _, area := range user.Areas {
+		for _, other := range candidate.Areas {
+			if area.ID == other.ID {
+				sharesArea = true
+				suggestion.Score += AreaWeight
+				suggestion.Reasons = append(suggestion.Reasons, "works in "+area.Name)
+			}
+		}
+	}
+	if distance, ok := areaDistance(user, candidate); ok {
+		geoConfiguration := config.GetConfig().Geo
+		nearby := distance <= geoConfiguration.WalkingDistance
+		if geoConfiguration.Matching == config.GeoMatchingRequire && !nearby {
+			return suggestion, false
+		}
+		if geoConfiguration.Matching == config.GeoMatchingPrefer && nearby && !sharesArea {
+			suggestion.Score += NearbyWeight
+			suggestion.Reasons = append(suggestion.Reasons, fmt.Sprintf("works nearby (%d m)", int(math.Round(distance))))
+		}
+	}
+	if !user.Lunch.Time.IsZero() && !candidate.Lunch.Time.IsZero() &&
+		user.Lunch.Time.Format("15:04") == candidate.Lunch.Time.Format("15:04") {
+		suggestion.Score += LunchTimeWeight
+		suggestion.Reasons = append(suggestion.Reasons, "has lunch at "+candidate.Lunch.Time.Format("15:04"))
+	}
+	if user.Lunch.PlaceID != nil && candidate.Lunch.PlaceID != nil && *user.Lunch.PlaceID == *candidate.Lunch.PlaceID {
+		suggestion.Score += PlaceWeight
+		suggestion.Reasons = append(suggestion.Reasons, "eats at "+candidate.Lunch.Location)
+	}
+	if foodMatching := config.GetConfig().Food.Matching; foodMatching != config.FoodMatchingOff {
+		if foodMatching == config.FoodMatchingRequire && !FoodCompatible(user, candidate, places) {
+			return suggestion, false
+		}
+		for _, diet := range user.Diets {
+			for _, other := range candidate.Diets {
+				if diet.ID == other.ID {
+					suggestion.Score += DietWeight
+					suggestion.Reasons = append(suggestion.Reasons, "shares the "+diet.Name+" diet")
+				}
+			}
+		}
+		for _, cuisine := range user.Cuisines {
+			for _, other := range candidate.Cuisines {
+				if cuisine.ID == other.ID {
+					suggestion.Score += CuisineWeight
+					suggestion.Reasons = append(suggestion.Reasons, "likes "+cuisine.Name+" cuisine")
+				}
+			}
+		}
+	}
+	return suggestion, suggestion.Score > 0
+}
+
+// FoodCompatible returns true if one of the places caters for every diet of both users
+// Two users without any diet are always compatible
+func FoodCompatible(user *users.User, candidate *users.User, places []users.Place) bool {
+	diets := append(append([]users.Diet{}, user.Diets...), candidate.Diets...)
+	if len(diets) == 0 {
+		return true
+	}
+	for i := range places {
+		if places[i].Offers(diets) {
+			return true
+		}
+	}
+	return false
+}
+
+// similar returns why the candidate looks like one of the companions the user enjoyed a lunch with
+// A candidate is similar to a companion when they share SimilarHobbies hobbies, or every hobby of the companion if it has fewer
+// It returns false when the candidate is similar to none of them
+func similar(candidate *users.User, enjoyed []*users.User) (string, bool) {
+	for _, companion := range enjoyed {
+		if companion.ID == candidate.ID {
+			return "you enjoyed your last lunch together", true
+		}
+	}
+	for _, companion := range enjoyed {
+		shared := 0
+		for _, hobby := range companion.Hobbies {
+			for _, other := range candidate.Hobbies {
+				if hobby.ID == other.ID {
+					shared++
+				}
+			}
+		}
+		if shared > 0 && (shared >= SimilarHobbies || shared == len(companion.Hobbies)) {
+			return "similar to " + notifications.DisplayName(companion), true
+		}
+	}
+	return "", false
+}
+
+// areaDistance returns the shortest distance in meters between an area of the user and an area of the candidate
+// It returns false when the areas of one of them have no coordinates
+func areaDistance(user *users.User, candidate *users.User) (float64, bool) {
+	return geo.Nearest(areaPoints(user), areaPoints(candidate))
+}
+
+// areaPoints returns the coordinates of the areas of the user
+func areaPoints(user *users.User) []geo.Point {
+	var points []geo.Point
+	for i := range user.Areas {
+		if point, ok := user.Areas[i].Point(); ok {
+			points = append(points, point)
+		}
+	}
+	return points
+}
+
+// commonLunch returns the next lunch of the user, or of the candidate, at which both are free
+// It returns a nil slot when neither has a lunch time, and false when they are busy at every lunch
+func commonLunch(user *users.User, candidate *users.User, busy availability.Busy, now time.Time) (*time.Time, bool) {
+	slots := calendar.NextLunches(user, now, SlotDays)
+	if len(slots) == 0 {
+		slots = calendar.NextLunches(candidate, now, SlotDays)
+	}
+	if len(slots) == 0 {
+		return nil, true
+	}
+	free := busy.FreeLunches(slots, user.ID, candidate.ID)
+	if len(free) == 0 {
+		return nil, false
+	}
+	return &free[0], true
+}
+
+// sameOrganization returns true if both users belong to the same organization, or both to the default one
+func sameOrganization(user *users.User, candidate *users.User) bool {
+	if user.OrganizationID == nil || candidate.OrganizationID == nil {
+		return user.OrganizationID == nil && candidate.OrganizationID == nil
+	}
+	return *user.OrganizationID == *candidate.OrganizationID
+}
+
+// allows returns true if the team preference of the user accepts the candidate
+// The preference is ignored when the user is not a member of a team
+func allows(user *users.User, candidate *users.User) bool {
+	if user.TeamID == nil {
+		return true
+	}
+	sameTeam := candidate.TeamID != nil && *candidate.TeamID == *user.TeamID
+	switch user.TeamMatching {
+	case users.TeamMatchingSame:
+		return sameTeam
+	case users.TeamMatchingOther:
+		return !sameTeam
+	}
+	return true
+}
+
+// blocks returns true if the candidate has the user in its blacklist
+func blocks(candidate *users.User, user *users.User) bool {
+	for _, blocked := range candidate.Blacklist {
+		if blocked.ID == user.ID {
+			return true
+		}
+	}
+	return false
+}
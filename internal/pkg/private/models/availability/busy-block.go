@@ -0,0 +1,41 @@
+package availability
+
+import (
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// BusyBlock is a time a user is busy, imported from one of its sources
+// The end is excluded
+type BusyBlock struct {
+	models.Model
+	UserID uuid.UUID `gorm:"column:user_id;not null;index:idx_busy_block_user_start" json:"user_id"`
+	// OrganizationID is the organization of the user
+	OrganizationID *uuid.UUID `gorm:"column:organization_id;index" json:"organization_id,omitempty"`
+	SourceID       uuid.UUID  `gorm:"column:source_id;not null;index" json:"source_id"`
+	Start          time.Time  `gorm:"column:starts_at;not null;index:idx_busy_block_user_start" json:"start"`
+	End            time.Time  `gorm:"column:ends_at;not null;" json:"end"`
+}
+
+// BeforeCreate is called before creating a busy block
+// It sets the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *BusyBlock) BeforeCreate(db *gorm.DB) error {
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating a busy block
+// It sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *BusyBlock) BeforeUpdate(db *gorm.DB) error {
+	m.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,48 @@
+package availability
+
+import (
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Source is a calendar the busy times of a user are imported from
+// It is either an uploaded file or an url fetched periodically, the url is empty for an upload
+type Source struct {
+	models.Model
+	UserID uuid.UUID `gorm:"column:user_id;not null;index" json:"user_id"`
+	// OrganizationID is the organization of the user
+	OrganizationID *uuid.UUID `gorm:"column:organization_id;index" json:"organization_id,omitempty"`
+	Name           string     `gorm:"column:name;" json:"name"`
+	URL            string     `gorm:"column:url;" json:"url,omitempty"`
+	SyncedAt       *time.Time `gorm:"column:synced_at;" json:"synced_at"`
+	Error          string     `gorm:"column:error;type:text;" json:"error,omitempty"`
+	Blocks         int        `gorm:"column:blocks;not null;default:0" json:"blocks"`
+}
+
+// IsUpload returns true if the calendar was uploaded rather than registered by url
+func (m *Source) IsUpload() bool {
+	return m.URL == ""
+}
+
+// BeforeCreate is called before creating a source
+// It sets the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Source) BeforeCreate(db *gorm.DB) error {
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating a source
+// It sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Source) BeforeUpdate(db *gorm.DB) error {
+	m.UpdatedAt = time.Now()
+	return nil
+}
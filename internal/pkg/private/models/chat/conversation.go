@@ -0,0 +1,62 @@
+package chat
+
+import (
+	"bytes"
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Conversation represents the one-to-one chat of two buddies
+// The participants are stored ordered so that a pair has a single conversation
+type Conversation struct {
+	models.Model
+	UserAID uuid.UUID `gorm:"column:user_a_id;not null;uniqueIndex:idx_conversation_pair" json:"user_a_id"`
+	UserBID uuid.UUID `gorm:"column:user_b_id;not null;uniqueIndex:idx_conversation_pair;index" json:"user_b_id"`
+	// OrganizationID is the organization of the participants
+	OrganizationID *uuid.UUID `gorm:"column:organization_id;index" json:"organization_id,omitempty"`
+	LastMessageAt  *time.Time `gorm:"column:last_message_at;" json:"last_message_at"`
+}
+
+// NewConversation returns the conversation of two users with the participants ordered
+func NewConversation(userID uuid.UUID, buddyID uuid.UUID) Conversation {
+	if bytes.Compare(userID[:], buddyID[:]) > 0 {
+		userID, buddyID = buddyID, userID
+	}
+	return Conversation{UserAID: userID, UserBID: buddyID}
+}
+
+// Involves returns true if the user is a participant of the conversation
+func (m *Conversation) Involves(userID uuid.UUID) bool {
+	return m.UserAID == userID || m.UserBID == userID
+}
+
+// Other returns the id of the other participant of the conversation
+func (m *Conversation) Other(userID uuid.UUID) uuid.UUID {
+	if m.UserAID == userID {
+		return m.UserBID
+	}
+	return m.UserAID
+}
+
+// BeforeCreate is called before creating a conversation
+// It sets the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Conversation) BeforeCreate(db *gorm.DB) error {
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating a conversation
+// It sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Conversation) BeforeUpdate(db *gorm.DB) error {
+	m.UpdatedAt = time.Now()
+	return nil
+}
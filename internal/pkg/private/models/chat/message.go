@@ -0,0 +1,42 @@
+package chat
+
+import (
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// MaxMessageLength is the maximal length of a message body in characters
+const MaxMessageLength = 4000
+
+// Message represents a message of a conversation
+// The read at timestamp is the read receipt of the recipient
+type Message struct {
+	models.Model
+	ConversationID uuid.UUID  `gorm:"column:conversation_id;not null;index:idx_message_conversation_created" json:"conversation_id"`
+	SenderID       uuid.UUID  `gorm:"column:sender_id;not null;" json:"sender_id"`
+	Body           string     `gorm:"column:body;type:text;not null;" json:"body"`
+	ReadAt         *time.Time `gorm:"column:read_at;" json:"read_at"`
+}
+
+// BeforeCreate is called before creating a message
+// It sets the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Message) BeforeCreate(db *gorm.DB) error {
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating a message
+// It sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Message) BeforeUpdate(db *gorm.DB) error {
+	m.UpdatedAt = time.Now()
+	return nil
+}
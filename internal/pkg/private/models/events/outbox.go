@@ -0,0 +1,49 @@
+package events
+
+import (
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// OutboxEvent represents a domain event waiting to be dispatched
+// It is written in the transaction of the state change that caused it
+// The dispatched at timestamp is set once every subscriber and sink received it
+// The failed at timestamp is set when the dispatcher gave up after too many attempts
+type OutboxEvent struct {
+	models.Model
+	Type          string     `gorm:"column:type;not null;index" json:"type"`
+	AggregateID   uuid.UUID  `gorm:"column:aggregate_id;not null;index" json:"aggregate_id"`
+	Payload       string     `gorm:"column:payload;type:text;not null" json:"payload"`
+	Attempts      int        `gorm:"column:attempts;not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;not null;index:idx_outbox_pending" json:"next_attempt_at"`
+	LastError     string     `gorm:"column:last_error;type:text" json:"last_error"`
+	DispatchedAt  *time.Time `gorm:"column:dispatched_at;index:idx_outbox_pending" json:"dispatched_at"`
+	FailedAt      *time.Time `gorm:"column:failed_at;index:idx_outbox_pending" json:"failed_at"`
+}
+
+// BeforeCreate is called before creating an outbox event
+// It sets the created and updated at timestamps
+// The event is due immediately unless a next attempt is already set
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *OutboxEvent) BeforeCreate(db *gorm.DB) error {
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	if m.NextAttemptAt.IsZero() {
+		m.NextAttemptAt = m.CreatedAt
+	}
+	return nil
+}
+
+// BeforeUpdate is called before updating an outbox event
+// It sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *OutboxEvent) BeforeUpdate(db *gorm.DB) error {
+	m.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Job is the state of a scheduled job shared by the instances of the server
+// The instance which locks the row until the lease expires runs the job, the others skip it
+type Job struct {
+	models.Model
+	Name        string     `gorm:"column:name;not null;uniqueIndex" json:"name"`
+	Schedule    string     `gorm:"column:schedule;not null;" json:"schedule"`
+	NextRunAt   time.Time  `gorm:"column:next_run_at;not null;" json:"next_run_at"`
+	LockedBy    string     `gorm:"column:locked_by;" json:"locked_by,omitempty"`
+	LockedUntil *time.Time `gorm:"column:locked_until;" json:"locked_until,omitempty"`
+	// The outcome of the last run, the duration is in milliseconds
+	LastRunAt    *time.Time `gorm:"column:last_run_at;" json:"last_run_at"`
+	LastDuration int64      `gorm:"column:last_duration;not null;default:0" json:"last_duration"`
+	LastResult   string     `gorm:"column:last_result;type:text;" json:"last_result"`
+	LastError    string     `gorm:"column:last_error;type:text;" json:"last_error,omitempty"`
+	Runs         int        `gorm:"column:runs;not null;default:0" json:"runs"`
+	Failures     int        `gorm:"column:failures;not null;default:0" json:"failures"`
+}
+
+// BeforeCreate is called before creating a job
+// It sets the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Job) BeforeCreate(db *gorm.DB) error {
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating a job
+// It sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Job) BeforeUpdate(db *gorm.DB) error {
+	m.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,70 @@
+package mail
+
+import (
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// The kinds of emails, the users can opt out of every kind but the password resets and the email verifications
+const (
+	KindInvitation    = "invitation"
+	KindReminder      = "reminder"
+	KindPasswordReset = "password_reset"
+	KindSuggestions   = "suggestions"
+	KindVerification  = "verification"
+)
+
+// The statuses of an email
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+)
+
+// Email represents a rendered email waiting in the queue
+// The event is the domain event that caused it, it prevents queuing the same email twice
+type Email struct {
+	models.Model
+	UserID        *uuid.UUID `gorm:"column:user_id;uniqueIndex:idx_email_event_user" json:"user_id"`
+	EventID       *uuid.UUID `gorm:"column:event_id;uniqueIndex:idx_email_event_user" json:"event_id"`
+	Kind          string     `gorm:"column:kind;not null" json:"kind"`
+	To            string     `gorm:"column:recipient;not null" json:"to"`
+	Subject       string     `gorm:"column:subject;not null" json:"subject"`
+	Text          string     `gorm:"column:text;type:text;not null" json:"text"`
+	HTML          string     `gorm:"column:html;type:text" json:"html"`
+	Status        string     `gorm:"column:status;not null;default:pending;index:idx_email_pending" json:"status"`
+	Attempts      int        `gorm:"column:attempts;not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;not null;index:idx_email_pending" json:"next_attempt_at"`
+	Error         string     `gorm:"column:error;type:text" json:"error"`
+	SentAt        *time.Time `gorm:"column:sent_at" json:"sent_at"`
+}
+
+// BeforeCreate is called before creating an email
+// It sets the created and updated at timestamps
+// The email is due immediately unless a next attempt is already set
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Email) BeforeCreate(db *gorm.DB) error {
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	if m.Status == "" {
+		m.Status = StatusPending
+	}
+	if m.NextAttemptAt.IsZero() {
+		m.NextAttemptAt = m.CreatedAt
+	}
+	return nil
+}
+
+// BeforeUpdate is called before updating an email
+// It sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Email) BeforeUpdate(db *gorm.DB) error {
+	m.UpdatedAt = time.Now()
+	return nil
+}
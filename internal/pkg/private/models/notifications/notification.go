@@ -0,0 +1,55 @@
+package notifications
+
+import (
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Types of a notification
+const (
+	TypeLike                = "like"
+	TypeMatch               = "match"
+	TypeInvitationReceived  = "invitation_received"
+	TypeInvitationAccepted  = "invitation_accepted"
+	TypeInvitationDeclined  = "invitation_declined"
+	TypeInvitationCancelled = "invitation_cancelled"
+	TypeLunchReminder       = "lunch_reminder"
+	TypeSuggestion          = "suggestion"
+)
+
+// Notification represents something that happened to a user
+// The actor is the user who caused it, e.g. the user who liked
+type Notification struct {
+	models.Model
+	UserID uuid.UUID `gorm:"column:user_id;not null;index" json:"user_id"`
+	// OrganizationID is the organization of the user
+	OrganizationID *uuid.UUID `gorm:"column:organization_id;index" json:"organization_id,omitempty"`
+	Type           string     `gorm:"column:type;not null;" json:"type"`
+	Message        string     `gorm:"column:message;not null;" json:"message"`
+	ActorID        *uuid.UUID `gorm:"column:actor_id;" json:"actor_id"`
+	InvitationID   *uuid.UUID `gorm:"column:invitation_id;" json:"invitation_id"`
+	ReadAt         *time.Time `gorm:"column:read_at;index" json:"read_at"`
+}
+
+// BeforeCreate is called before creating a notification
+// It sets the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Notification) BeforeCreate(db *gorm.DB) error {
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating a notification
+// It sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Notification) BeforeUpdate(db *gorm.DB) error {
+	m.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,52 @@
+package tasks
+
+import (
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Kinds of an icebreaker template
+const (
+	TemplateHobby    = "hobby"
+	TemplateLanguage = "language"
+	TemplateGeneric  = "generic"
+)
+
+// IcebreakerTemplate represents a conversation starter
+// A hobby or language template is used when both buddies share the hobby or language with this name
+// A generic template is used when they share nothing
+// The text can contain the placeholders {buddy}, {hobby} and {language}
+//
+// Example: "Ask {buddy} about their favourite hiking trail" for the hobby "Hiking"
+type IcebreakerTemplate struct {
+	models.Model
+	Kind    string `gorm:"column:kind;not null;index:idx_icebreaker_kind_name_key" json:"kind" form:"kind"`
+	Name    string `gorm:"column:name;" json:"name" form:"name"`
+	NameKey string `gorm:"column:name_key;index:idx_icebreaker_kind_name_key" json:"-"`
+	Text    string `gorm:"column:text;not null;" json:"text"`
+}
+
+// BeforeCreate is called before creating a template
+// It sets the name key and the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *IcebreakerTemplate) BeforeCreate(db *gorm.DB) error {
+	m.NameKey = users.NameKey(m.Name)
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating a template
+// It sets the name key and the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *IcebreakerTemplate) BeforeUpdate(db *gorm.DB) error {
+	m.NameKey = users.NameKey(m.Name)
+	m.UpdatedAt = time.Now()
+	return nil
+}
@@ -8,34 +8,97 @@ import (
 	"time"
 )
 
-// Task represents a task
-// It is used to map users to tasks
-// It is used to implement a many-to-many relationship between users and tasks
+// Statuses of a task
+const (
+	StatusTodo  = "todo"
+	StatusDoing = "doing"
+	StatusDone  = "done"
+)
+
+// Priorities of a task
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+)
+
+// Kinds of a task
+const (
+	KindPersonal   = "personal"
+	KindIcebreaker = "icebreaker"
+)
+
+// Task represents a task of a user
+// A user can have any number of tasks
+// A task can be linked to a lunch or to an invitation
+// An icebreaker task is shared with a buddy, each of them has its own copy to complete
 type Task struct {
 	models.Model
-	Name   string     `gorm:"column:name;not null;" json:"name" form:"name"`
-	Text   string     `gorm:"column:text;not null;" json:"text" form:"text"`
-	UserID uuid.UUID  `gorm:"column:user_id;unique_index:user_id;not null;" json:"user_id" form:"user_id"`
-	User   users.User `json:"user"`
+	Name        string      `gorm:"column:name;not null;" json:"name" form:"name"`
+	Text        string      `gorm:"column:text;not null;" json:"text" form:"text"`
+	Status      string      `gorm:"column:status;not null;default:todo;index" json:"status" form:"status"`
+	Priority    string      `gorm:"column:priority;not null;default:normal" json:"priority" form:"priority"`
+	DueDate     *time.Time  `gorm:"column:due_date;" json:"due_date"`
+	CompletedAt *time.Time  `gorm:"column:completed_at;" json:"completed_at"`
+	UserID      uuid.UUID   `gorm:"column:user_id;not null;index" json:"user_id" form:"user_id"`
+	User        *users.User `json:"user,omitempty"`
+	// OrganizationID is the organization of the user
+	OrganizationID *uuid.UUID `gorm:"column:organization_id;index" json:"organization_id,omitempty"`
+	LunchID        *uuid.UUID `gorm:"column:lunch_id;" json:"lunch_id" form:"lunch_id"`
+	InvitationID   *uuid.UUID `gorm:"column:invitation_id;" json:"invitation_id" form:"invitation_id"`
+	Kind           string     `gorm:"column:kind;not null;default:personal;index" json:"kind" form:"kind"`
+	BuddyID        *uuid.UUID `gorm:"column:buddy_id;index" json:"buddy_id" form:"buddy_id"`
+	TemplateID     *uuid.UUID `gorm:"column:template_id;" json:"template_id"`
+}
+
+// ValidStatus returns true if status is a known task status
+func ValidStatus(status string) bool {
+	return status == StatusTodo || status == StatusDoing || status == StatusDone
+}
+
+// ValidPriority returns true if priority is a known task priority
+func ValidPriority(priority string) bool {
+	return priority == PriorityLow || priority == PriorityNormal || priority == PriorityHigh
 }
 
 // BeforeCreate is called before creating a task
-// It sets the created and updated at timestamps
+// It sets the defaults, the completion time and the created and updated at timestamps
 // It returns an error if something went wrong
 // It is called by gorm
 // It is not intended to be called by the user
 func (m *Task) BeforeCreate(db *gorm.DB) error {
+	if m.Status == "" {
+		m.Status = StatusTodo
+	}
+	if m.Priority == "" {
+		m.Priority = PriorityNormal
+	}
+	if m.Kind == "" {
+		m.Kind = KindPersonal
+	}
+	m.setCompletedAt()
 	m.CreatedAt = time.Now()
 	m.UpdatedAt = time.Now()
 	return nil
 }
 
 // BeforeUpdate is called before updating a task
-// It sets the updated at timestamp
+// It sets the completion time and the updated at timestamp
 // It returns an error if something went wrong
 // It is called by gorm
 // It is not intended to be called by the user
 func (m *Task) BeforeUpdate(db *gorm.DB) error {
+	m.setCompletedAt()
 	m.UpdatedAt = time.Now()
 	return nil
 }
+
+// setCompletedAt keeps the completion time in sync with the status
+func (m *Task) setCompletedAt() {
+	if m.Status != StatusDone {
+		m.CompletedAt = nil
+	} else if m.CompletedAt == nil {
+		now := time.Now()
+		m.CompletedAt = &now
+	}
+}
@@ -0,0 +1,61 @@
+package users
+
+import (
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Kinds of the entries an alias can point to
+const (
+	AliasKindHobby    = "hobby"
+	AliasKindLanguage = "language"
+	AliasKindArea     = "area"
+	AliasKindPlace    = "place"
+	AliasKindDiet     = "diet"
+	AliasKindCuisine  = "cuisine"
+)
+
+// Alias represents a synonym of a hobby, language, area, place, diet or cuisine
+// A name matching an alias is resolved to the canonical entry instead of creating a new one
+//
+// Example: the alias "Soccer" of kind "hobby" points to the hobby "Football"
+type Alias struct {
+	models.Model
+	Kind      string    `gorm:"column:kind;not null;uniqueIndex:idx_alias_kind_name_key" json:"kind" form:"kind"`
+	Name      string    `gorm:"column:name;not null;" json:"name" form:"name"`
+	NameKey   string    `gorm:"column:name_key;not null;uniqueIndex:idx_alias_kind_name_key" json:"-"`
+	SearchKey string    `gorm:"column:search_key;index;not null;default:''" json:"-"`
+	TargetID  uuid.UUID `gorm:"column:target_id;not null;index" json:"target_id" form:"target_id"`
+	// OrganizationID is the organization of the target, the aliases of the places are shared and have none
+	// The aliases without organization are unique by the index created by db.Migrate
+	OrganizationID *uuid.UUID `gorm:"column:organization_id;uniqueIndex:idx_alias_kind_name_key" json:"organization_id,omitempty"`
+}
+
+// BeforeCreate is called before creating an alias
+// It normalizes the name and sets the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Alias) BeforeCreate(db *gorm.DB) error {
+	m.Name = NormalizeName(m.Name)
+	m.NameKey = NameKey(m.Name)
+	m.SearchKey = SearchKey(m.Name)
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating an alias
+// It normalizes the name and sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Alias) BeforeUpdate(db *gorm.DB) error {
+	m.Name = NormalizeName(m.Name)
+	m.NameKey = NameKey(m.Name)
+	m.SearchKey = SearchKey(m.Name)
+	m.UpdatedAt = time.Now()
+	return nil
+}
@@ -1,35 +1,55 @@
 package users
 
 import (
+	"github.com/google/uuid"
 	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/geo"
 	"gorm.io/gorm"
 	"time"
 )
 
 // Area represents a hobby
+// The coordinates locate the building of the area, they are optional
 type Area struct {
 	models.Model
 	//Location column is an enum representation of the location of the area
-	Name string `gorm:"column:name;unique_index:name;not null;" json:"name"`
+	Name           string     `gorm:"column:name;unique_index:name;not null;" json:"name"`
+	NameKey        string     `gorm:"column:name_key;uniqueIndex:idx_areas_organization_name_key;not null;default:''" json:"-"`
+	SearchKey      string     `gorm:"column:search_key;index;not null;default:''" json:"-"`
+	OrganizationID *uuid.UUID `gorm:"column:organization_id;uniqueIndex:idx_areas_organization_name_key" json:"organization_id,omitempty"`
+	Latitude       *float64   `gorm:"column:latitude;" json:"latitude,omitempty"`
+	Longitude      *float64   `gorm:"column:longitude;" json:"longitude,omitempty"`
+}
+
+// Point returns the coordinates of the area
+// It returns false when they are unknown
+func (m *Area) Point() (geo.Point, bool) {
+	return geo.NewPoint(m.Latitude, m.Longitude)
 }
 
 // BeforeCreate is called before creating a user
-// It sets the created and updated at timestamps
+// It normalizes the name and sets the created and updated at timestamps
 // It returns an error if something went wrong
 // It is called by gorm
 // It is not intended to be called by the user
 func (m *Area) BeforeCreate(db *gorm.DB) error {
+	m.Name = NormalizeName(m.Name)
+	m.NameKey = NameKey(m.Name)
+	m.SearchKey = SearchKey(m.Name)
 	m.CreatedAt = time.Now()
 	m.UpdatedAt = time.Now()
 	return nil
 }
 
 // BeforeUpdate is called before updating a user
-// It sets the updated at timestamp
+// It normalizes the name and sets the updated at timestamp
 // It returns an error if something went wrong
 // It is called by gorm
 // It is not intended to be called by the user
 func (m *Area) BeforeUpdate(db *gorm.DB) error {
+	m.Name = NormalizeName(m.Name)
+	m.NameKey = NameKey(m.Name)
+	m.SearchKey = SearchKey(m.Name)
 	m.UpdatedAt = time.Now()
 	return nil
 }
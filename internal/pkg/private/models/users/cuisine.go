@@ -0,0 +1,45 @@
+package users
+
+import (
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Cuisine represents a cuisine a user likes, e.g. italian or vietnamese
+// The cuisines are a curated taxonomy managed by the admins
+type Cuisine struct {
+	models.Model
+	Name           string     `gorm:"column:name;not null;" json:"name"`
+	NameKey        string     `gorm:"column:name_key;uniqueIndex:idx_cuisines_organization_name_key;not null;default:''" json:"-"`
+	SearchKey      string     `gorm:"column:search_key;index;not null;default:''" json:"-"`
+	OrganizationID *uuid.UUID `gorm:"column:organization_id;uniqueIndex:idx_cuisines_organization_name_key" json:"organization_id,omitempty"`
+}
+
+// BeforeCreate is called before creating a cuisine
+// It normalizes the name and sets the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Cuisine) BeforeCreate(db *gorm.DB) error {
+	m.Name = NormalizeName(m.Name)
+	m.NameKey = NameKey(m.Name)
+	m.SearchKey = SearchKey(m.Name)
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating a cuisine
+// It normalizes the name and sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Cuisine) BeforeUpdate(db *gorm.DB) error {
+	m.Name = NormalizeName(m.Name)
+	m.NameKey = NameKey(m.Name)
+	m.SearchKey = SearchKey(m.Name)
+	m.UpdatedAt = time.Now()
+	return nil
+}
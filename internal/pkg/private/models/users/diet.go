@@ -0,0 +1,45 @@
+package users
+
+import (
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Diet represents a dietary restriction or an allergy, e.g. vegetarian, halal or gluten-free
+// The diets are a curated taxonomy managed by the admins
+type Diet struct {
+	models.Model
+	Name           string     `gorm:"column:name;not null;" json:"name"`
+	NameKey        string     `gorm:"column:name_key;uniqueIndex:idx_diets_organization_name_key;not null;default:''" json:"-"`
+	SearchKey      string     `gorm:"column:search_key;index;not null;default:''" json:"-"`
+	OrganizationID *uuid.UUID `gorm:"column:organization_id;uniqueIndex:idx_diets_organization_name_key" json:"organization_id,omitempty"`
+}
+
+// BeforeCreate is called before creating a diet
+// It normalizes the name and sets the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Diet) BeforeCreate(db *gorm.DB) error {
+	m.Name = NormalizeName(m.Name)
+	m.NameKey = NameKey(m.Name)
+	m.SearchKey = SearchKey(m.Name)
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating a diet
+// It normalizes the name and sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Diet) BeforeUpdate(db *gorm.DB) error {
+	m.Name = NormalizeName(m.Name)
+	m.NameKey = NameKey(m.Name)
+	m.SearchKey = SearchKey(m.Name)
+	m.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,57 @@
+package users
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// MaxFeedbackTags is the number of tags a feedback can have
+const MaxFeedbackTags = 5
+
+// Feedback represents what a user thought of the companion of a past lunch
+// A feedback is a thumbs up or down with optional tags such as "great conversation", once per lunch
+// It is private, only its author ever sees it
+type Feedback struct {
+	models.Model
+	UserID uuid.UUID `gorm:"column:user_id;not null;uniqueIndex:idx_feedback_user_invitation" json:"user_id"`
+	// OrganizationID is the organization of the user
+	OrganizationID *uuid.UUID `gorm:"column:organization_id;index" json:"organization_id,omitempty"`
+	AboutID        uuid.UUID  `gorm:"column:about_id;not null;index" json:"about_id"`
+	InvitationID   uuid.UUID  `gorm:"column:invitation_id;not null;uniqueIndex:idx_feedback_user_invitation" json:"invitation_id"`
+	Positive       bool       `gorm:"column:positive;not null" json:"positive"`
+	Tags           Tags       `gorm:"column:tags;" json:"tags"`
+}
+
+// Validate returns an error if the feedback has too many tags
+func (m *Feedback) Validate() error {
+	if len(m.Tags) > MaxFeedbackTags {
+		return fmt.Errorf("at most %d tags can be given", MaxFeedbackTags)
+	}
+	return nil
+}
+
+// BeforeCreate is called before creating a feedback
+// It normalizes the tags and sets the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Feedback) BeforeCreate(db *gorm.DB) error {
+	m.Tags = NewTags(m.Tags...)
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating a feedback
+// It normalizes the tags and sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Feedback) BeforeUpdate(db *gorm.DB) error {
+	m.Tags = NewTags(m.Tags...)
+	m.UpdatedAt = time.Now()
+	return nil
+}
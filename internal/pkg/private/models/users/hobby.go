@@ -1,6 +1,7 @@
 package users
 
 import (
+	"github.com/google/uuid"
 	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
 	"gorm.io/gorm"
 	"time"
@@ -10,26 +11,35 @@ import (
 type Hobby struct {
 	models.Model
 	//Location column is an enum representation of the location of the area
-	Name string `gorm:"column:name;unique_index:name;not null;" json:"name"`
+	Name           string     `gorm:"column:name;unique_index:name;not null;" json:"name"`
+	NameKey        string     `gorm:"column:name_key;uniqueIndex:idx_hobbies_organization_name_key;not null;default:''" json:"-"`
+	SearchKey      string     `gorm:"column:search_key;index;not null;default:''" json:"-"`
+	OrganizationID *uuid.UUID `gorm:"column:organization_id;uniqueIndex:idx_hobbies_organization_name_key" json:"organization_id,omitempty"`
 }
 
 // BeforeCreate is called before creating a user
-// It sets the created and updated at timestamps
+// It normalizes the name and sets the created and updated at timestamps
 // It returns an error if something went wrong
 // It is called by gorm
 // It is not intended to be called by the user
 func (m *Hobby) BeforeCreate(db *gorm.DB) error {
+	m.Name = NormalizeName(m.Name)
+	m.NameKey = NameKey(m.Name)
+	m.SearchKey = SearchKey(m.Name)
 	m.CreatedAt = time.Now()
 	m.UpdatedAt = time.Now()
 	return nil
 }
 
 // BeforeUpdate is called before updating a user
-// It sets the updated at timestamp
+// It normalizes the name and sets the updated at timestamp
 // It returns an error if something went wrong
 // It is called by gorm
 // It is not intended to be called by the user
 func (m *Hobby) BeforeUpdate(db *gorm.DB) error {
+	m.Name = NormalizeName(m.Name)
+	m.NameKey = NameKey(m.Name)
+	m.SearchKey = SearchKey(m.Name)
 	m.UpdatedAt = time.Now()
 	return nil
 }
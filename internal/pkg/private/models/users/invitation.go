@@ -0,0 +1,95 @@
+package users
+
+import (
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Statuses of an invitation
+const (
+	InvitationPending   = "pending"
+	InvitationAccepted  = "accepted"
+	InvitationDeclined  = "declined"
+	InvitationCancelled = "cancelled"
+	InvitationExpired   = "expired"
+)
+
+// Invitation represents an invitation of a user to have lunch together
+// The inviter creates it, the invitee accepts or declines it
+// The location is free text, it defaults to the name of the place when the invitation references one
+type Invitation struct {
+	models.Model
+	InviterID uuid.UUID `gorm:"column:inviter_id;not null;index" json:"inviter_id"`
+	Inviter   *User     `json:"inviter,omitempty"`
+	InviteeID uuid.UUID `gorm:"column:invitee_id;not null;index" json:"invitee_id"`
+	Invitee   *User     `json:"invitee,omitempty"`
+	// OrganizationID is the organization of the inviter and the invitee
+	OrganizationID *uuid.UUID `gorm:"column:organization_id;index" json:"organization_id,omitempty"`
+	Time           time.Time  `gorm:"column:time;not null;" json:"time"`
+	Location       string     `gorm:"column:location;" json:"location"`
+	PlaceID        *uuid.UUID `gorm:"column:place_id;index" json:"place_id,omitempty"`
+	Place          *Place     `json:"place,omitempty"`
+	Message        string     `gorm:"column:message;" json:"message"`
+	Status         string     `gorm:"column:status;not null;default:pending;index" json:"status"`
+	// RemindedAt is set when the reminder of an accepted invitation is sent
+	RemindedAt *time.Time `gorm:"column:reminded_at;" json:"reminded_at,omitempty"`
+}
+
+// Involves returns true if the user is the inviter or the invitee
+func (m *Invitation) Involves(userID uuid.UUID) bool {
+	return m.InviterID == userID || m.InviteeID == userID
+}
+
+// Other returns the id of the other participant of the invitation
+func (m *Invitation) Other(userID uuid.UUID) uuid.UUID {
+	if m.InviterID == userID {
+		return m.InviteeID
+	}
+	return m.InviterID
+}
+
+// Met returns true if the user had the lunch of the invitation before now
+func (m *Invitation) Met(userID uuid.UUID, now time.Time) bool {
+	return m.Involves(userID) && m.Status == InvitationAccepted && m.Time.Before(now)
+}
+
+// Attended returns true if the user had the lunch of the invitation at a place of the catalogue before now
+func (m *Invitation) Attended(userID uuid.UUID, now time.Time) bool {
+	return m.Met(userID, now) && m.PlaceID != nil
+}
+
+// SetPlace references the place, or no place when it is nil, and sets the location
+func (m *Invitation) SetPlace(place *Place, location string) {
+	m.Place = place
+	m.PlaceID = nil
+	if place != nil {
+		m.PlaceID = &place.ID
+	}
+	m.Location = location
+}
+
+// BeforeCreate is called before creating an invitation
+// It sets the default status and the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Invitation) BeforeCreate(db *gorm.DB) error {
+	if m.Status == "" {
+		m.Status = InvitationPending
+	}
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating an invitation
+// It sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Invitation) BeforeUpdate(db *gorm.DB) error {
+	m.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,46 @@
+package users
+
+import (
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// InviteCode represents a code letting users register into the organization of the code
+// It can be used MaxUses times until it expires, a code without expiry never expires
+type InviteCode struct {
+	models.Model
+	OrganizationID *uuid.UUID `gorm:"column:organization_id;index" json:"organization_id,omitempty"`
+	Code           string     `gorm:"column:code;uniqueIndex;not null" json:"code"`
+	MaxUses        int        `gorm:"column:max_uses;not null;default:1" json:"max_uses"`
+	Uses           int        `gorm:"column:uses;not null;default:0" json:"uses"`
+	ExpiresAt      *time.Time `gorm:"column:expires_at" json:"expires_at"`
+	CreatedByID    *uuid.UUID `gorm:"column:created_by_id" json:"created_by_id,omitempty"`
+}
+
+// Valid returns true if the code has uses left and has not expired at the time
+func (m *InviteCode) Valid(now time.Time) bool {
+	return m.Uses < m.MaxUses && (m.ExpiresAt == nil || now.Before(*m.ExpiresAt))
+}
+
+// BeforeCreate is called before creating an invite code
+// It sets the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *InviteCode) BeforeCreate(db *gorm.DB) error {
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating an invite code
+// It sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *InviteCode) BeforeUpdate(db *gorm.DB) error {
+	m.UpdatedAt = time.Now()
+	return nil
+}
@@ -7,14 +7,34 @@ import (
 	"time"
 )
 
+// LunchDuration is how long a lunch lasts
+const LunchDuration = 30 * time.Minute
+
 // Lunch represents a lunch
+// It recurs every working day at the time of day of Time
+// The location is free text, it defaults to the name of the place when the lunch references one
+// The type and the food are free text too, the structured food preferences are the diets and cuisines of the user
 type Lunch struct {
 	models.Model
-	UserID   uuid.UUID `gorm:"column:user_id;not null;" json:"user_id"`
-	Location string    `gorm:"column:location;not null;" json:"location"`
-	Time     time.Time `gorm:"column:time;" json:"time"` //not null;
-	Type     string    `gorm:"column:type;not null;" json:"type"`
-	Food     string    `gorm:"column:food;not null;" json:"food"`
+	UserID uuid.UUID `gorm:"column:user_id;not null;" json:"user_id"`
+	// OrganizationID is the organization of the user
+	OrganizationID *uuid.UUID `gorm:"column:organization_id;index" json:"organization_id,omitempty"`
+	Location       string     `gorm:"column:location;not null;" json:"location"`
+	PlaceID        *uuid.UUID `gorm:"column:place_id;index" json:"place_id,omitempty"`
+	Place          *Place     `json:"place,omitempty"`
+	Time           time.Time  `gorm:"column:time;" json:"time"` //not null;
+	Type           string     `gorm:"column:type;not null;" json:"type"`
+	Food           string     `gorm:"column:food;not null;" json:"food"`
+}
+
+// SetPlace references the place, or no place when it is nil, and sets the location
+func (m *Lunch) SetPlace(place *Place, location string) {
+	m.Place = place
+	m.PlaceID = nil
+	if place != nil {
+		m.PlaceID = &place.ID
+	}
+	m.Location = location
 }
 
 // BeforeCreate is called before creating a user
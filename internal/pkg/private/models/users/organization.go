@@ -0,0 +1,84 @@
+package users
+
+import (
+	"errors"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// slugPattern is the format of the slug of an organization, e.g. acme-slovakia
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Organization represents a company sharing the deployment with others
+// The users, the taxonomies, the teams, the lunches and the tasks belong to at most one organization
+// The data without organization belongs to the default organization of a single company deployment
+type Organization struct {
+	models.Model
+	Name string `gorm:"column:name;not null;" json:"name"`
+	Slug string `gorm:"column:slug;uniqueIndex;not null;" json:"slug"`
+	// RegistrationPolicy overrides the registration policy of the deployment when it is not empty
+	// EmailDomains are the comma separated domains of the email addresses of the organization,
+	// the users registering with one of them join the organization
+	RegistrationPolicy string `gorm:"column:registration_policy;not null;default:''" json:"registration_policy"`
+	EmailDomains       string `gorm:"column:email_domains;not null;default:''" json:"email_domains"`
+}
+
+// Domains returns the email domains of the organization in lowercase
+func (m *Organization) Domains() []string {
+	var domains []string
+	for _, domain := range strings.Split(m.EmailDomains, ",") {
+		if domain = strings.ToLower(strings.TrimSpace(domain)); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// Validate checks the name, the slug and the registration policy of the organization
+func (m *Organization) Validate() error {
+	if NameKey(m.Name) == "" {
+		return errors.New("name is required")
+	}
+	if !slugPattern.MatchString(m.Slug) {
+		return errors.New("slug must be lowercase letters and digits separated by dashes")
+	}
+	switch m.RegistrationPolicy {
+	case "", config.RegistrationOpen, config.RegistrationInvite:
+	case config.RegistrationDomain:
+		if len(m.Domains()) == 0 {
+			return errors.New("the domain registration policy requires email domains")
+		}
+	default:
+		return errors.New("registration policy must be open, domain or invite")
+	}
+	return nil
+}
+
+// BeforeCreate is called before creating an organization
+// It normalizes the name and the email domains and sets the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Organization) BeforeCreate(db *gorm.DB) error {
+	m.Name = NormalizeName(m.Name)
+	m.EmailDomains = strings.Join(m.Domains(), ",")
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating an organization
+// It normalizes the name and the email domains and sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Organization) BeforeUpdate(db *gorm.DB) error {
+	m.Name = NormalizeName(m.Name)
+	m.EmailDomains = strings.Join(m.Domains(), ",")
+	m.UpdatedAt = time.Now()
+	return nil
+}
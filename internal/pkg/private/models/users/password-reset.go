@@ -0,0 +1,49 @@
+package users
+
+import (
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// PasswordResetTTL is how long a password reset link is valid
+const PasswordResetTTL = time.Hour
+
+// PasswordReset represents a pending password reset of a user
+// Only the hash of the token is stored, the token itself is only sent by email
+type PasswordReset struct {
+	models.Model
+	UserID uuid.UUID `gorm:"column:user_id;not null;index" json:"user_id"`
+	// OrganizationID is the organization of the user
+	OrganizationID *uuid.UUID `gorm:"column:organization_id;index" json:"organization_id,omitempty"`
+	TokenHash      string     `gorm:"column:token_hash;not null;uniqueIndex" json:"-"`
+	ExpiresAt      time.Time  `gorm:"column:expires_at;not null" json:"expires_at"`
+	UsedAt         *time.Time `gorm:"column:used_at" json:"used_at"`
+}
+
+// Valid returns true if the reset was not used and has not expired
+func (m *PasswordReset) Valid() bool {
+	return m.UsedAt == nil && time.Now().Before(m.ExpiresAt)
+}
+
+// BeforeCreate is called before creating a password reset
+// It sets the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *PasswordReset) BeforeCreate(db *gorm.DB) error {
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating a password reset
+// It sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *PasswordReset) BeforeUpdate(db *gorm.DB) error {
+	m.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,93 @@
+package users
+
+import (
+	"errors"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/geo"
+	"gorm.io/gorm"
+	"time"
+)
+
+// MaxPriceLevel is the price level of the most expensive places, 0 means the price is unknown
+const MaxPriceLevel = 4
+
+// Place represents a restaurant, canteen or any other place to have lunch at
+// The cuisines and the dietary options are tags such as "italian" or "vegetarian"
+// The opening hours are free text, e.g. "Mo-Fr 11:00-14:30"
+// The rating aggregates the reviews, it is maintained by the review repository
+type Place struct {
+	models.Model
+	Name         string   `gorm:"column:name;not null;" json:"name"`
+	NameKey      string   `gorm:"column:name_key;index;not null;default:''" json:"-"`
+	Address      string   `gorm:"column:address;" json:"address"`
+	Latitude     *float64 `gorm:"column:latitude;" json:"latitude,omitempty"`
+	Longitude    *float64 `gorm:"column:longitude;" json:"longitude,omitempty"`
+	Cuisines     Tags     `gorm:"column:cuisines;" json:"cuisines"`
+	PriceLevel   int      `gorm:"column:price_level;not null;default:0" json:"price_level"`
+	OpeningHours string   `gorm:"column:opening_hours;" json:"opening_hours"`
+	Dietary      Tags     `gorm:"column:dietary;" json:"dietary"`
+	// Rating is the average rating of the reviews of the place, 0 without reviews
+	// The places are shared by every organization but their reviews are not, so the rating is not stored,
+	// it is the one of the organization of the request set by the places endpoints, see persistence.ReviewRepository.Rate
+	Rating      float64 `gorm:"-" json:"rating"`
+	RatingCount int     `gorm:"-" json:"rating_count"`
+}
+
+// Validate returns an error if the place has no name, an unknown price level or half of its coordinates
+func (m *Place) Validate() error {
+	if NameKey(m.Name) == "" {
+		return errors.New("name is required")
+	}
+	if m.PriceLevel < 0 || m.PriceLevel > MaxPriceLevel {
+		return errors.New("price_level must be between 0 and 4")
+	}
+	return geo.Validate(m.Latitude, m.Longitude)
+}
+
+// Point returns the coordinates of the place
+// It returns false when they are unknown
+func (m *Place) Point() (geo.Point, bool) {
+	return geo.NewPoint(m.Latitude, m.Longitude)
+}
+
+// Offers returns true if the dietary options of the place cover every diet
+// The diets are compared by name, so the diet "Gluten-free" needs the dietary option "gluten-free"
+func (m *Place) Offers(diets []Diet) bool {
+	for _, diet := range diets {
+		if !m.Dietary.Has(diet.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// BeforeCreate is called before creating a place
+// It normalizes the name and the tags and sets the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Place) BeforeCreate(db *gorm.DB) error {
+	m.normalize()
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating a place
+// It normalizes the name and the tags and sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Place) BeforeUpdate(db *gorm.DB) error {
+	m.normalize()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// normalize normalizes the name and the tags
+func (m *Place) normalize() {
+	m.Name = NormalizeName(m.Name)
+	m.NameKey = NameKey(m.Name)
+	m.Cuisines = NewTags(m.Cuisines...)
+	m.Dietary = NewTags(m.Dietary...)
+}
@@ -0,0 +1,70 @@
+package users
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// The bounds of a review
+const (
+	MinRating       = 1
+	MaxRating       = 5
+	MaxReviewLength = 1000
+	MaxReviewDishes = 10
+)
+
+// Review represents the rating of a place by a user who had lunch there
+// A review is written for an accepted invitation at the place, once per user
+// The dishes are tags such as "goulash" or "pizza margherita"
+type Review struct {
+	models.Model
+	UserID       uuid.UUID `gorm:"column:user_id;not null;uniqueIndex:idx_review_user_invitation" json:"user_id"`
+	User         *User     `json:"-"`
+	PlaceID      uuid.UUID `gorm:"column:place_id;not null;index" json:"place_id"`
+	InvitationID uuid.UUID `gorm:"column:invitation_id;not null;uniqueIndex:idx_review_user_invitation" json:"invitation_id"`
+	Rating       int       `gorm:"column:rating;not null" json:"rating"`
+	Text         string    `gorm:"column:text;type:text" json:"text"`
+	Dishes       Tags      `gorm:"column:dishes;" json:"dishes"`
+	// OrganizationID is the organization of the user, the places are shared but their reviews are not
+	OrganizationID *uuid.UUID `gorm:"column:organization_id;index" json:"organization_id,omitempty"`
+}
+
+// Validate returns an error if the rating is out of bounds or the text is too long
+func (m *Review) Validate() error {
+	if m.Rating < MinRating || m.Rating > MaxRating {
+		return fmt.Errorf("rating must be between %d and %d", MinRating, MaxRating)
+	}
+	if len([]rune(m.Text)) > MaxReviewLength {
+		return fmt.Errorf("text must be at most %d characters", MaxReviewLength)
+	}
+	if len(m.Dishes) > MaxReviewDishes {
+		return fmt.Errorf("at most %d dishes can be tagged", MaxReviewDishes)
+	}
+	return nil
+}
+
+// BeforeCreate is called before creating a review
+// It normalizes the dishes and sets the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Review) BeforeCreate(db *gorm.DB) error {
+	m.Dishes = NewTags(m.Dishes...)
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating a review
+// It normalizes the dishes and sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Review) BeforeUpdate(db *gorm.DB) error {
+	m.Dishes = NewTags(m.Dishes...)
+	m.UpdatedAt = time.Now()
+	return nil
+}
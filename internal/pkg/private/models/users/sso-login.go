@@ -0,0 +1,43 @@
+package users
+
+import (
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// SSOLogin represents a single sign-on login waiting for the callback of the provider
+// Only the hash of the state is stored, the PKCE verifier and the nonce never leave the server
+type SSOLogin struct {
+	models.Model
+	StateHash string    `gorm:"column:state_hash;not null;uniqueIndex" json:"-"`
+	Verifier  string    `gorm:"column:verifier;not null" json:"-"`
+	Nonce     string    `gorm:"column:nonce;not null" json:"-"`
+	ExpiresAt time.Time `gorm:"column:expires_at;not null" json:"expires_at"`
+}
+
+// Valid returns true if the login has not expired
+func (m *SSOLogin) Valid() bool {
+	return time.Now().Before(m.ExpiresAt)
+}
+
+// BeforeCreate is called before creating a single sign-on login
+// It sets the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *SSOLogin) BeforeCreate(db *gorm.DB) error {
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating a single sign-on login
+// It sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *SSOLogin) BeforeUpdate(db *gorm.DB) error {
+	m.UpdatedAt = time.Now()
+	return nil
+}
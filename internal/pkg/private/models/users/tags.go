@@ -0,0 +1,62 @@
+package users
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// Tags is a list of lowercase labels, e.g. the cuisines of a place
+// It is stored as a comma separated list and serialized as a JSON array
+type Tags []string
+
+// NewTags returns the normalized tags
+// The tags are lowercased and trimmed, the empty ones and the duplicates are dropped
+func NewTags(values ...string) Tags {
+	tags := Tags{}
+	for _, value := range values {
+		tag := NameKey(strings.ReplaceAll(value, ",", " "))
+		if tag != "" && !tags.Has(tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Has returns true if the list contains the tag, regardless of its case and whitespace
+func (t Tags) Has(tag string) bool {
+	key := NameKey(tag)
+	for _, existing := range t {
+		if existing == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Value returns the comma separated list stored in the database
+// It is called by the database driver
+func (t Tags) Value() (driver.Value, error) {
+	return strings.Join(NewTags(t...), ","), nil
+}
+
+// Scan reads the comma separated list stored in the database
+// It is called by the database driver
+func (t *Tags) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*t = Tags{}
+	case string:
+		*t = NewTags(strings.Split(v, ",")...)
+	case []byte:
+		*t = NewTags(strings.Split(string(v), ",")...)
+	default:
+		return fmt.Errorf("cannot scan %T into tags", value)
+	}
+	return nil
+}
+
+// GormDataType returns the column type of the tags
+func (Tags) GormDataType() string {
+	return "text"
+}
@@ -0,0 +1,37 @@
+package users
+
+import (
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/textsearch"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NormalizeName cleans a hobby, language or area name before it is stored
+// It trims the name, collapses the inner whitespace and capitalizes a lower case name
+//
+// Example: " football  golf" becomes "Football golf", "iOS" is kept as is
+func NormalizeName(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	if name != "" && name == strings.ToLower(name) {
+		first, size := utf8.DecodeRuneInString(name)
+		name = string(unicode.ToUpper(first)) + name[size:]
+	}
+	return name
+}
+
+// NameKey returns the key used to compare hobby, language and area names
+// Two names with the same key are considered the same entry
+//
+// Example: "Football", "football " and " FOOTBALL" share the key "football"
+func NameKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// SearchKey returns the key the autocomplete searches hobby, language, area, diet, cuisine and alias names by
+// It is the name without case and diacritics, so a prefix of the key can be matched in SQL
+//
+// Example: "Čítanie kníh" has the key "citanie knih"
+func SearchKey(name string) string {
+	return textsearch.Fold(name)
+}
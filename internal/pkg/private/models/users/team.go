@@ -0,0 +1,92 @@
+package users
+
+import (
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// The team preferences of the matching
+const (
+	// TeamMatchingAny suggests the users of every team
+	TeamMatchingAny = "any"
+	// TeamMatchingSame only suggests the users of the same team
+	TeamMatchingSame = "same"
+	// TeamMatchingOther only suggests the users of the other teams
+	TeamMatchingOther = "other"
+)
+
+// Department represents a department of the organisation, it groups teams
+type Department struct {
+	models.Model
+	Name           string     `gorm:"column:name;not null;" json:"name"`
+	NameKey        string     `gorm:"column:name_key;uniqueIndex:idx_departments_organization_name_key;not null;default:''" json:"-"`
+	OrganizationID *uuid.UUID `gorm:"column:organization_id;uniqueIndex:idx_departments_organization_name_key" json:"organization_id,omitempty"`
+}
+
+// Team represents a team of the organisation
+// A user is a member of at most one team, see User.TeamID
+type Team struct {
+	models.Model
+	Name           string      `gorm:"column:name;not null;" json:"name"`
+	NameKey        string      `gorm:"column:name_key;uniqueIndex:idx_teams_organization_name_key;not null;default:''" json:"-"`
+	OrganizationID *uuid.UUID  `gorm:"column:organization_id;uniqueIndex:idx_teams_organization_name_key" json:"organization_id,omitempty"`
+	DepartmentID   *uuid.UUID  `gorm:"column:department_id;index" json:"department_id,omitempty"`
+	Department     *Department `json:"department,omitempty"`
+}
+
+// ValidTeamMatching returns true if the preference is one of the team preferences
+func ValidTeamMatching(preference string) bool {
+	return preference == TeamMatchingAny || preference == TeamMatchingSame || preference == TeamMatchingOther
+}
+
+// BeforeCreate is called before creating a department
+// It normalizes the name and sets the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Department) BeforeCreate(db *gorm.DB) error {
+	m.Name = NormalizeName(m.Name)
+	m.NameKey = NameKey(m.Name)
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating a department
+// It normalizes the name and sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Department) BeforeUpdate(db *gorm.DB) error {
+	m.Name = NormalizeName(m.Name)
+	m.NameKey = NameKey(m.Name)
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeCreate is called before creating a team
+// It normalizes the name and sets the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Team) BeforeCreate(db *gorm.DB) error {
+	m.Name = NormalizeName(m.Name)
+	m.NameKey = NameKey(m.Name)
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating a team
+// It normalizes the name and sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Team) BeforeUpdate(db *gorm.DB) error {
+	m.Name = NormalizeName(m.Name)
+	m.NameKey = NameKey(m.Name)
+	m.UpdatedAt = time.Now()
+	return nil
+}
@@ -1,6 +1,7 @@
 package users
 
 import (
+	"github.com/google/uuid"
 	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
 	"gorm.io/gorm"
 	"time"
@@ -9,19 +10,47 @@ import (
 // User represents a user
 type User struct {
 	models.Model
-	Username  string     `gorm:"column:username;not null;unique_index:username" json:"username" form:"username"`
+	Username  string     `gorm:"column:username;not null;uniqueIndex" json:"username" form:"username"`
 	Firstname string     `gorm:"column:firstname;not null;" json:"firstname" form:"firstname"`
 	Lastname  string     `gorm:"column:lastname;not null;" json:"lastname" form:"lastname"`
 	Bio       string     `gorm:"column:bio;" json:"bio"`
 	Hash      string     `gorm:"column:hash;not null;" json:"hash"`
 	IsSetup   bool       `gorm:"column:first_login;not null;default:false" json:"first_login"`
+	IsAdmin   bool       `gorm:"column:is_admin;not null;default:false" json:"is_admin"`
 	Hobbies   []Hobby    `gorm:"many2many:user_hobbies;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 	Languages []Language `gorm:"many2many:user_languages;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 	Areas     []Area     `gorm:"many2many:user_areas;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Diets     []Diet     `gorm:"many2many:user_diets;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Cuisines  []Cuisine  `gorm:"many2many:user_cuisines;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 	Lunch     Lunch      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;default:null;"`
 	Buddies   []*User    `gorm:"many2many:user_buddies;association_joinTable_foreignKey:buddy_id;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 	Blacklist []*User    `gorm:"many2many:user_blacklists;association_joinTable_foreignKey:blacklist_id;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 	Likes     []*User    `gorm:"many2many:user_likes;association_joinTable_foreignKey:like_id;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+
+	// The email notification preferences, the users opt out
+	EmailInvitations bool `gorm:"column:email_invitations;not null;default:true" json:"email_invitations"`
+	EmailReminders   bool `gorm:"column:email_reminders;not null;default:true" json:"email_reminders"`
+	EmailSuggestions bool `gorm:"column:email_suggestions;not null;default:true" json:"email_suggestions"`
+	// EmailVerifiedAt is when the user verified its username, the profile cannot be set up before
+	EmailVerifiedAt *time.Time `gorm:"column:email_verified_at" json:"email_verified_at"`
+
+	// OrganizationID is the organization of the user, OrganizationAdmin whether the user administers it
+	// IsAdmin administers the whole deployment
+	OrganizationID    *uuid.UUID    `gorm:"column:organization_id;index" json:"organization_id,omitempty"`
+	Organization      *Organization `json:"organization,omitempty"`
+	OrganizationAdmin bool          `gorm:"column:organization_admin;not null;default:false" json:"organization_admin"`
+
+	// TeamID is the team the user is a member of, TeamMatching whether the user meets the same team, the other teams or any
+	TeamID       *uuid.UUID `gorm:"column:team_id;index" json:"team_id,omitempty"`
+	Team         *Team      `json:"team,omitempty"`
+	TeamMatching string     `gorm:"column:team_matching;not null;default:any" json:"team_matching"`
+
+	// TimeZone is the IANA name of the time zone of the user, the default one of the server when empty
+	TimeZone string `gorm:"column:timezone" json:"timezone"`
+	// CalendarToken is the secret of the calendar feed url of the user
+	CalendarToken *string `gorm:"column:calendar_token;uniqueIndex" json:"-"`
+	// OIDCSubject is the subject of the user at the single sign-on provider, once the accounts are linked
+	OIDCSubject *string `gorm:"column:oidc_subject;uniqueIndex" json:"-"`
 }
 
 // BeforeCreate is called before creating a user
@@ -44,3 +73,9 @@ func (m *User) BeforeUpdate(db *gorm.DB) error {
 	m.UpdatedAt = time.Now()
 	return nil
 }
+
+// HasCompleteProfile returns true if the user chose an area, a hobby, a language and a lunch
+// The associations must be loaded
+func (m *User) HasCompleteProfile() bool {
+	return len(m.Areas) > 0 && len(m.Hobbies) > 0 && len(m.Languages) > 0 && !m.Lunch.Time.IsZero()
+}
@@ -0,0 +1,62 @@
+package webhooks
+
+import (
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// The statuses of a delivery
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Delivery represents an event sent to a webhook, it is the delivery log
+// A delivery is created once per webhook and event and keeps the outcome of its last attempt
+// The event is nil for the test pings
+type Delivery struct {
+	models.Model
+	WebhookID     uuid.UUID  `gorm:"column:webhook_id;not null;uniqueIndex:idx_delivery_webhook_event" json:"webhook_id"`
+	EventID       *uuid.UUID `gorm:"column:event_id;uniqueIndex:idx_delivery_webhook_event" json:"event_id"`
+	EventType     string     `gorm:"column:event_type;not null" json:"event_type"`
+	Payload       string     `gorm:"column:payload;type:text;not null" json:"payload"`
+	Status        string     `gorm:"column:status;not null;default:pending;index:idx_delivery_pending" json:"status"`
+	Attempts      int        `gorm:"column:attempts;not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;not null;index:idx_delivery_pending" json:"next_attempt_at"`
+	StatusCode    int        `gorm:"column:status_code" json:"status_code"`
+	Response      string     `gorm:"column:response;type:text" json:"response"`
+	Error         string     `gorm:"column:error;type:text" json:"error"`
+	Duration      int64      `gorm:"column:duration_ms" json:"duration_ms"`
+	DeliveredAt   *time.Time `gorm:"column:delivered_at" json:"delivered_at"`
+}
+
+// BeforeCreate is called before creating a delivery
+// It sets the created and updated at timestamps
+// The delivery is due immediately unless a next attempt is already set
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Delivery) BeforeCreate(db *gorm.DB) error {
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	if m.Status == "" {
+		m.Status = DeliveryPending
+	}
+	if m.NextAttemptAt.IsZero() {
+		m.NextAttemptAt = m.CreatedAt
+	}
+	return nil
+}
+
+// BeforeUpdate is called before updating a delivery
+// It sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Delivery) BeforeUpdate(db *gorm.DB) error {
+	m.UpdatedAt = time.Now()
+	return nil
+}
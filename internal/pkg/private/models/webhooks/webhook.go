@@ -0,0 +1,67 @@
+package webhooks
+
+import (
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// Webhook represents an endpoint receiving the domain events
+// The events are a comma separated list of event types, every event is sent when it is empty
+// The secret signs the requests, it is never serialized
+type Webhook struct {
+	models.Model
+	URL         string `gorm:"column:url;not null" json:"url"`
+	Description string `gorm:"column:description" json:"description"`
+	Secret      string `gorm:"column:secret;not null" json:"-"`
+	Events      string `gorm:"column:events" json:"-"`
+	Active      bool   `gorm:"column:active;not null" json:"active"`
+}
+
+// EventTypes returns the event types the webhook subscribes to
+func (m *Webhook) EventTypes() []string {
+	if m.Events == "" {
+		return []string{}
+	}
+	return strings.Split(m.Events, ",")
+}
+
+// SetEventTypes sets the event types the webhook subscribes to
+func (m *Webhook) SetEventTypes(eventTypes []string) {
+	m.Events = strings.Join(eventTypes, ",")
+}
+
+// Subscribes returns true if the webhook receives the events of the type
+func (m *Webhook) Subscribes(eventType string) bool {
+	if m.Events == "" {
+		return true
+	}
+	for _, subscribed := range m.EventTypes() {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// BeforeCreate is called before creating a webhook
+// It sets the created and updated at timestamps
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Webhook) BeforeCreate(db *gorm.DB) error {
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate is called before updating a webhook
+// It sets the updated at timestamp
+// It returns an error if something went wrong
+// It is called by gorm
+// It is not intended to be called by the user
+func (m *Webhook) BeforeUpdate(db *gorm.DB) error {
+	m.UpdatedAt = time.Now()
+	return nil
+}
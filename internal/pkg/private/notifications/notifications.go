@@ -0,0 +1,47 @@
+package notifications
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/notifications"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/realtime"
+)
+
+// EventType is the type of the realtime event carrying a notification
+const EventType = "notification"
+
+// Notify stores a notification for the user and pushes it to its open connections
+// The actor and the invitation are optional
+// The notification belongs to the organization of ctx, see db.WithOrganization
+// A failure is logged and never returned, notifying must not break the action that caused it
+func Notify(ctx context.Context, userID uuid.UUID, kind string, message string, actor *users.User, invitationID *uuid.UUID) {
+	notification := models.Notification{
+		UserID:       userID,
+		Type:         kind,
+		Message:      message,
+		InvitationID: invitationID,
+	}
+	if actor != nil {
+		notification.ActorID = &actor.ID
+	}
+	if err := persistence.GetNotificationRepository().Scoped(ctx).Add(&notification); err != nil {
+		log.Println(err)
+		return
+	}
+	realtime.GetHub().Publish(userID, realtime.Event{Type: EventType, Data: notification})
+}
+
+// DisplayName returns the name of the user shown in the notifications
+func DisplayName(user *users.User) string {
+	if user.Firstname == "" && user.Lastname == "" {
+		return user.Username
+	}
+	if user.Lastname == "" {
+		return user.Firstname
+	}
+	return user.Firstname + " " + user.Lastname
+}
@@ -0,0 +1,88 @@
+package persistence
+
+import (
+	"context"
+	"github.com/google/uuid"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+)
+
+// AliasRepository is a repository for taxonomy aliases
+// It is used to access the database
+// It is a singleton
+type AliasRepository struct {
+	scope
+}
+
+var aliasRepository *AliasRepository
+
+// GetAliasRepository returns the alias repository
+// It creates a new one if it does not exist
+// It returns the singleton instance of the alias repository
+func GetAliasRepository() *AliasRepository {
+	if aliasRepository == nil {
+		aliasRepository = &AliasRepository{}
+	}
+	return aliasRepository
+}
+
+// Scoped returns a copy of the alias repository scoped to the organization of ctx
+// It only sees the aliases of the organization, see db.WithOrganization
+func (r *AliasRepository) Scoped(ctx context.Context) *AliasRepository {
+	return &AliasRepository{scope: scope{ctx: ctx}}
+}
+
+// Unscoped returns a copy of the alias repository seeing the aliases of every organization
+// It is only meant for the aliases of the places which are shared, the jobs and the commands
+func (r *AliasRepository) Unscoped() *AliasRepository {
+	return &AliasRepository{scope: unscoped}
+}
+
+// Get returns an alias by id
+func (r *AliasRepository) Get(id string) (*models.Alias, error) {
+	var alias models.Alias
+	where := models.Alias{}
+	stringToUuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	where.ID = stringToUuid
+	_, err = r.first(&where, &alias, []string{})
+	if err != nil {
+		return nil, err
+	}
+	return &alias, err
+}
+
+// Resolve returns the id of the entry of the given kind the name is an alias of
+// The name is compared by its key so the case and the whitespace do not matter
+func (r *AliasRepository) Resolve(kind string, name string) (uuid.UUID, error) {
+	var alias models.Alias
+	_, err := r.first(&models.Alias{Kind: kind, NameKey: models.NameKey(name)}, &alias, []string{})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return alias.TargetID, nil
+}
+
+// Query returns all aliases that match the given query
+// The fields to match are the fields that are not the zero value for their type
+// The aliases of the places are shared by every organization, they are only returned when the kind is place
+func (r *AliasRepository) Query(q *models.Alias) (*[]models.Alias, error) {
+	var aliases []models.Alias
+	db := r.db()
+	if q.Kind != models.AliasKindPlace {
+		db = db.Where("kind <> ?", models.AliasKindPlace)
+	}
+	err := db.Where(q).Order("kind asc").Order("name asc").Find(&aliases).Error
+	return &aliases, err
+}
+
+// Add adds an alias to the database
+func (r *AliasRepository) Add(alias *models.Alias) error {
+	return r.create(alias)
+}
+
+// Delete deletes an alias from the database
+func (r *AliasRepository) Delete(alias *models.Alias) error {
+	return r.db().Unscoped().Delete(alias).Error
+}
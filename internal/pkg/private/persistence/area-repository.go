@@ -1,15 +1,17 @@
 package persistence
 
 import (
+	"context"
 	"github.com/google/uuid"
-	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
 	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
 )
 
 // AreaRepository is a repository for hobbies
 // It is used to access the database
 // It is a singleton
-type AreaRepository struct{}
+type AreaRepository struct {
+	scope
+}
 
 var areaRepository *AreaRepository
 
@@ -23,6 +25,18 @@ func GetAreaRepository() *AreaRepository {
 	return areaRepository
 }
 
+// Scoped returns a copy of the area repository scoped to the organization of ctx
+// It only sees the data of the organization, see db.WithOrganization
+func (r *AreaRepository) Scoped(ctx context.Context) *AreaRepository {
+	return &AreaRepository{scope: scope{ctx: ctx}}
+}
+
+// Unscoped returns a copy of the area repository seeing the data of every organization
+// It is only meant for the jobs, the commands and the lookups made before the organization of a user is known
+func (r *AreaRepository) Unscoped() *AreaRepository {
+	return &AreaRepository{scope: unscoped}
+}
+
 // Get returns an area by id
 func (r *AreaRepository) Get(id string) (*models.Area, error) {
 	var area models.Area
@@ -32,7 +46,7 @@ func (r *AreaRepository) Get(id string) (*models.Area, error) {
 		return nil, err
 	}
 	where.ID = stringToUuid
-	_, err = First(&where, &area, []string{})
+	_, err = r.first(&where, &area, []string{})
 	if err != nil {
 		return nil, err
 	}
@@ -40,22 +54,21 @@ func (r *AreaRepository) Get(id string) (*models.Area, error) {
 }
 
 // GetByName returns an area by name
+// The name is compared regardless of its case and whitespace
+// The aliases are used when no entry has the name
 func (r *AreaRepository) GetByName(name string) (*models.Area, error) {
 	var area models.Area
-	where := models.Area{}
-	where.Name = name
-	_, err := First(&where, &area, []string{})
-	if err != nil {
+	if err := firstByNameKey(r.db(), areaLink.kind, name, &area); err != nil {
 		return nil, err
 	}
-	return &area, err
+	return &area, nil
 }
 
 // All returns all areas
 // The hobbies are ordered by id ascending
 func (r *AreaRepository) All() (*[]models.Area, error) {
 	var areas []models.Area
-	err := Find(&models.Area{}, &areas, []string{}, "id asc")
+	err := r.find(&models.Area{}, &areas, []string{}, "id asc")
 	return &areas, err
 }
 
@@ -67,24 +80,36 @@ func (r *AreaRepository) All() (*[]models.Area, error) {
 // The fields to match are the fields that are not the zero value for their type
 func (r *AreaRepository) Query(q *models.Area) (*[]models.Area, error) {
 	var hobbies []models.Area
-	err := Find(&q, &hobbies, []string{}, "id asc")
+	err := r.find(&q, &hobbies, []string{}, "id asc")
 	return &hobbies, err
 }
 
 // Add adds an area to the database
 func (r *AreaRepository) Add(area *models.Area) error {
-	err := Create(&area)
-	err = Save(&area)
+	err := r.create(&area)
+	err = r.save(&area)
 	return err
 }
 
 // Update updates an area in the database
 func (r *AreaRepository) Update(area *models.Area) error {
-	return db.GetDB().Save(&area).Error
+	return r.db().Save(&area).Error
 }
 
 // Delete deletes an area from the database
 func (r *AreaRepository) Delete(area *models.Area) error {
-	return db.GetDB().Unscoped().Delete(&area).Error
+	return r.db().Unscoped().Delete(&area).Error
+
+}
+
+// Merge moves every user of the duplicate area to the canonical one
+// The duplicate is deleted and its name becomes an alias of the canonical area
+func (r *AreaRepository) Merge(duplicate *models.Area, canonical *models.Area) error {
+	return areaLink.merge(r.db(), duplicate, duplicate.ID, duplicate.Name, canonical.ID)
+}
 
+// Search returns the areas matching the query for the autocomplete
+// The results are ranked by relevance, then by the number of users
+func (r *AreaRepository) Search(query string, limit int, fuzzy bool) ([]Suggestion, error) {
+	return areaLink.search(r.db(), query, limit, fuzzy)
 }
@@ -0,0 +1,139 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/availability"
+	"gorm.io/gorm"
+)
+
+// AvailabilityRepository is a repository for the calendar sources and the busy blocks of the users
+// It is used to access the database
+// It is a singleton
+type AvailabilityRepository struct {
+	scope
+}
+
+var availabilityRepository *AvailabilityRepository
+
+// GetAvailabilityRepository returns the availability repository
+// It creates a new one if it does not exist
+// It returns the singleton instance of the availability repository
+func GetAvailabilityRepository() *AvailabilityRepository {
+	if availabilityRepository == nil {
+		availabilityRepository = &AvailabilityRepository{}
+	}
+	return availabilityRepository
+}
+
+// Scoped returns a copy of the availability repository scoped to the organization of ctx
+// It only sees the data of the organization, see db.WithOrganization
+func (r *AvailabilityRepository) Scoped(ctx context.Context) *AvailabilityRepository {
+	return &AvailabilityRepository{scope: scope{ctx: ctx}}
+}
+
+// Unscoped returns a copy of the availability repository seeing the data of every organization
+// It is only meant for the jobs, the commands and the lookups made before the organization of a user is known
+func (r *AvailabilityRepository) Unscoped() *AvailabilityRepository {
+	return &AvailabilityRepository{scope: unscoped}
+}
+
+// GetSource returns a calendar source by id
+func (r *AvailabilityRepository) GetSource(id string) (*models.Source, error) {
+	var source models.Source
+	stringToUuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	err = r.db().Where("id = ?", stringToUuid).First(&source).Error
+	return &source, err
+}
+
+// Sources returns the calendar sources of the user ordered by creation
+func (r *AvailabilityRepository) Sources(userID uuid.UUID) (*[]models.Source, error) {
+	var sources []models.Source
+	err := r.db().Where("user_id = ?", userID).Order("created_at").Find(&sources).Error
+	return &sources, err
+}
+
+// UploadSource returns the source of the calendar uploaded by the user
+// It is created the first time, an upload replaces the previous one
+func (r *AvailabilityRepository) UploadSource(userID uuid.UUID) (*models.Source, error) {
+	source := models.Source{UserID: userID, Name: "Upload"}
+	err := r.db().Where("user_id = ? AND url = ?", userID, "").FirstOrCreate(&source).Error
+	return &source, err
+}
+
+// DueSources returns up to limit url sources not synchronized since the time, the least recently synchronized first
+func (r *AvailabilityRepository) DueSources(before time.Time, limit int) (*[]models.Source, error) {
+	var sources []models.Source
+	err := r.db().Where("url <> ? AND (synced_at IS NULL OR synced_at < ?)", "", before).
+		Order("synced_at asc").Limit(limit).Find(&sources).Error
+	return &sources, err
+}
+
+// AddSource adds a calendar source to the database
+func (r *AvailabilityRepository) AddSource(source *models.Source) error {
+	return r.create(source)
+}
+
+// UpdateSource updates a calendar source in the database
+func (r *AvailabilityRepository) UpdateSource(source *models.Source) error {
+	return r.save(source)
+}
+
+// DeleteSource deletes a calendar source and its busy blocks from the database
+func (r *AvailabilityRepository) DeleteSource(source *models.Source) error {
+	return r.db().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("source_id = ?", source.ID).Delete(&models.BusyBlock{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(source).Error
+	})
+}
+
+// ReplaceBlocks replaces the busy blocks of the source and records the synchronization
+func (r *AvailabilityRepository) ReplaceBlocks(source *models.Source, blocks []models.BusyBlock) error {
+	return r.db().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("source_id = ?", source.ID).Delete(&models.BusyBlock{}).Error; err != nil {
+			return err
+		}
+		for i := range blocks {
+			blocks[i].UserID = source.UserID
+			blocks[i].OrganizationID = source.OrganizationID
+			blocks[i].SourceID = source.ID
+		}
+		if len(blocks) > 0 {
+			if err := tx.CreateInBatches(&blocks, 500).Error; err != nil {
+				return err
+			}
+		}
+		now := time.Now()
+		source.SyncedAt = &now
+		source.Error = ""
+		source.Blocks = len(blocks)
+		return tx.Save(source).Error
+	})
+}
+
+// Blocks returns the busy blocks of the users overlapping the window, ordered by start
+// The blocks of every user are returned when the users are nil
+func (r *AvailabilityRepository) Blocks(userIDs []uuid.UUID, from time.Time, to time.Time) (*[]models.BusyBlock, error) {
+	var blocks []models.BusyBlock
+	query := r.db().Where("starts_at < ? AND ends_at > ?", to, from)
+	if userIDs != nil {
+		query = query.Where("user_id IN ?", userIDs)
+	}
+	err := query.Order("starts_at").Find(&blocks).Error
+	return &blocks, err
+}
+
+// IsBusy returns true if one of the users is busy at some point of the window
+func (r *AvailabilityRepository) IsBusy(userIDs []uuid.UUID, from time.Time, to time.Time) (bool, error) {
+	var count int64
+	err := r.db().Model(&models.BusyBlock{}).
+		Where("user_id IN ? AND starts_at < ? AND ends_at > ?", userIDs, to, from).Count(&count).Error
+	return count > 0, err
+}
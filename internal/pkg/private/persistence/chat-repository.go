@@ -0,0 +1,133 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/chat"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ChatRepository is a repository for the conversations of buddies and their messages
+// It is used to access the database
+// It is a singleton
+type ChatRepository struct {
+	scope
+}
+
+var chatRepository *ChatRepository
+
+// GetChatRepository returns the chat repository
+// It creates a new one if it does not exist
+// It returns the singleton instance of the chat repository
+func GetChatRepository() *ChatRepository {
+	if chatRepository == nil {
+		chatRepository = &ChatRepository{}
+	}
+	return chatRepository
+}
+
+// Scoped returns a copy of the chat repository scoped to the organization of ctx
+// It only sees the data of the organization, see db.WithOrganization
+func (r *ChatRepository) Scoped(ctx context.Context) *ChatRepository {
+	return &ChatRepository{scope: scope{ctx: ctx}}
+}
+
+// Unscoped returns a copy of the chat repository seeing the data of every organization
+// It is only meant for the jobs, the commands and the lookups made before the organization of a user is known
+func (r *ChatRepository) Unscoped() *ChatRepository {
+	return &ChatRepository{scope: unscoped}
+}
+
+// GetConversation returns a conversation by id
+func (r *ChatRepository) GetConversation(id string) (*models.Conversation, error) {
+	var conversation models.Conversation
+	stringToUuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	err = r.db().Where("id = ?", stringToUuid).First(&conversation).Error
+	return &conversation, err
+}
+
+// OpenConversation returns the conversation of both users
+// The conversation is created the first time
+func (r *ChatRepository) OpenConversation(userID uuid.UUID, buddyID uuid.UUID) (*models.Conversation, error) {
+	conversation := models.NewConversation(userID, buddyID)
+	var existing models.Conversation
+	err := r.db().Where("user_a_id = ? AND user_b_id = ?", conversation.UserAID, conversation.UserBID).First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err := r.create(&conversation); err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// ConversationsForUser returns the conversations of the user, the most recently active first
+func (r *ChatRepository) ConversationsForUser(userID uuid.UUID) (*[]models.Conversation, error) {
+	var conversations []models.Conversation
+	err := r.db().Where("user_a_id = ? OR user_b_id = ?", userID, userID).
+		Order("COALESCE(last_message_at, created_at) desc").Find(&conversations).Error
+	return &conversations, err
+}
+
+// LastMessage returns the newest message of the conversation or nil when it is empty
+func (r *ChatRepository) LastMessage(conversationID uuid.UUID) (*models.Message, error) {
+	var messages []models.Message
+	err := r.db().Where("conversation_id = ?", conversationID).Order("created_at desc").Limit(1).Find(&messages).Error
+	if err != nil || len(messages) == 0 {
+		return nil, err
+	}
+	return &messages[0], nil
+}
+
+// CountUnread returns the number of messages of the conversation the user has not read
+func (r *ChatRepository) CountUnread(conversationID uuid.UUID, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db().Model(&models.Message{}).
+		Where("conversation_id = ? AND sender_id <> ? AND read_at IS NULL", conversationID, userID).
+		Count(&count).Error
+	return count, err
+}
+
+// Messages returns a page of the messages of the conversation, the newest first
+// Only the messages sent before the given time are returned when it is not nil
+func (r *ChatRepository) Messages(conversationID uuid.UUID, before *time.Time, limit int) (*[]models.Message, error) {
+	var messages []models.Message
+	query := r.db().Where("conversation_id = ?", conversationID)
+	if before != nil {
+		query = query.Where("created_at < ?", *before)
+	}
+	err := query.Order("created_at desc").Limit(limit).Find(&messages).Error
+	return &messages, err
+}
+
+// AddMessage adds a message to the conversation and updates its last activity
+func (r *ChatRepository) AddMessage(conversation *models.Conversation, message *models.Message) error {
+	return r.db().Transaction(func(tx *gorm.DB) error {
+		message.ConversationID = conversation.ID
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		conversation.LastMessageAt = &message.CreatedAt
+		return tx.Model(conversation).Updates(map[string]interface{}{
+			"last_message_at": message.CreatedAt,
+			"updated_at":      message.CreatedAt,
+		}).Error
+	})
+}
+
+// MarkRead marks the messages of the conversation received by the user as read
+// It returns the number of messages marked
+func (r *ChatRepository) MarkRead(conversationID uuid.UUID, userID uuid.UUID, readAt time.Time) (int64, error) {
+	result := r.db().Model(&models.Message{}).
+		Where("conversation_id = ? AND sender_id <> ? AND read_at IS NULL", conversationID, userID).
+		Updates(map[string]interface{}{"read_at": readAt, "updated_at": readAt})
+	return result.RowsAffected, result.Error
+}
@@ -1,30 +1,78 @@
 package persistence
 
 import (
+	"context"
 	"errors"
 	"github.com/google/uuid"
 	database "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
 	"gorm.io/gorm"
 )
 
+// scope carries the context of the queries of a repository
+// The repositories of the organization data embed it, their Scoped copy only sees the data
+// of the organization of the context, see db.WithOrganization
+// The queries of the organization data fail with db.ErrNoOrganization in a scope without organization,
+// such as the singletons and the package functions, unless it is the explicit unscoped scope
+type scope struct {
+	ctx context.Context
+}
+
+// unscoped is the scope of the Unscoped copies of the repositories, it sees the data of every organization
+// It is only meant for the jobs, the commands and the lookups made before the organization of a user is known
+var unscoped = scope{ctx: database.WithoutOrganization(context.Background())}
+
+// db returns the database with the context of the scope
+func (s scope) db() *gorm.DB {
+	if s.ctx == nil {
+		return database.GetDB()
+	}
+	return database.GetDB().WithContext(s.ctx)
+}
+
+// scopeTable adds the organization of the context of the database to the conditions of a raw table
+// The models are scoped by the database callbacks, the raw tables such as "hobbies e" are not
+func scopeTable(tx *gorm.DB, alias string) *gorm.DB {
+	organizationID, ok := database.Organization(tx.Statement.Context)
+	if !ok {
+		if !database.Unscoped(tx.Statement.Context) {
+			tx.AddError(database.ErrNoOrganization)
+		}
+		return tx
+	}
+	if organizationID == nil {
+		return tx.Where(alias + ".organization_id IS NULL")
+	}
+	return tx.Where(alias+".organization_id = ?", *organizationID)
+}
+
 // Create a new record
 func Create(value interface{}) error {
-	return database.GetDB().Create(value).Error
+	return scope{}.create(value)
+}
+
+// create a new record in the scope
+func (s scope) create(value interface{}) error {
+	return s.db().Create(value).Error
 }
 
 // Save a record
 func Save(value interface{}) error {
-	return database.GetDB().Save(value).Error
+	return scope{}.save(value)
+}
+
+// save a record in the scope
+func (s scope) save(value interface{}) error {
+	return s.db().Save(value).Error
 }
 
 // Updates a record
 func Updates(where interface{}, value interface{}) error {
-	return database.GetDB().Model(where).Updates(value).Error
+	return scope{}.db().Model(where).Updates(value).Error
 }
 
 // DeleteByModel delete a record
 func DeleteByModel(model interface{}) (count int64, err error) {
-	db := database.GetDB().Delete(model)
+	db := scope{}.db().Delete(model)
 	err = db.Error
 	if err != nil {
 		return
@@ -35,7 +83,7 @@ func DeleteByModel(model interface{}) (count int64, err error) {
 
 // DeleteByWhere delete a record
 func DeleteByWhere(model, where interface{}) (count int64, err error) {
-	db := database.GetDB().Where(where).Delete(model)
+	db := scope{}.db().Where(where).Delete(model)
 	err = db.Error
 	if err != nil {
 		return
@@ -46,7 +94,7 @@ func DeleteByWhere(model, where interface{}) (count int64, err error) {
 
 // DeleteByID delete a record
 func DeleteByID(model interface{}, id uuid.UUID) (count int64, err error) {
-	db := database.GetDB().Where("id=?", id).Delete(model)
+	db := scope{}.db().Where("id=?", id).Delete(model)
 	err = db.Error
 	if err != nil {
 		return
@@ -57,7 +105,7 @@ func DeleteByID(model interface{}, id uuid.UUID) (count int64, err error) {
 
 // DeleteByIDS delete a record
 func DeleteByIDS(model interface{}, ids []uuid.UUID) (count int64, err error) {
-	db := database.GetDB().Where("id in (?)", ids).Delete(model)
+	db := scope{}.db().Where("id in (?)", ids).Delete(model)
 	err = db.Error
 	if err != nil {
 		return
@@ -68,7 +116,7 @@ func DeleteByIDS(model interface{}, ids []uuid.UUID) (count int64, err error) {
 
 // FirstByID returns the first record found by id
 func FirstByID(out interface{}, id uuid.UUID) (notFound bool, err error) {
-	err = database.GetDB().First(out, id).Error
+	err = scope{}.db().First(out, id).Error
 	if err != nil {
 		//notFound = gorm.IsRecordNotFoundError(err)
 		notFound = errors.Is(err, gorm.ErrRecordNotFound)
@@ -78,7 +126,12 @@ func FirstByID(out interface{}, id uuid.UUID) (notFound bool, err error) {
 
 // First returns the first record found by where
 func First(where interface{}, out interface{}, associations []string) (notFound bool, err error) {
-	db := database.GetDB()
+	return scope{}.first(where, out, associations)
+}
+
+// first returns the first record of the scope found by where
+func (s scope) first(where interface{}, out interface{}, associations []string) (notFound bool, err error) {
+	db := s.db()
 	for _, a := range associations {
 		db = db.Preload(a)
 	}
@@ -92,7 +145,12 @@ func First(where interface{}, out interface{}, associations []string) (notFound
 
 // Find returns all records found by where
 func Find(where interface{}, out interface{}, associations []string, orders ...string) error {
-	db := database.GetDB()
+	return scope{}.find(where, out, associations, orders...)
+}
+
+// find returns all records of the scope found by where
+func (s scope) find(where interface{}, out interface{}, associations []string, orders ...string) error {
+	db := s.db()
 	for _, a := range associations {
 		db = db.Preload(a)
 	}
@@ -107,7 +165,7 @@ func Find(where interface{}, out interface{}, associations []string, orders ...s
 
 // Scan returns the first record found by where
 func Scan(model, where interface{}, out interface{}) (notFound bool, err error) {
-	err = database.GetDB().Model(model).Where(where).Scan(out).Error
+	err = scope{}.db().Model(model).Where(where).Scan(out).Error
 	if err != nil {
 		//notFound = gorm.IsRecordNotFoundError(err)
 		notFound = errors.Is(err, gorm.ErrRecordNotFound)
@@ -118,7 +176,7 @@ func Scan(model, where interface{}, out interface{}) (notFound bool, err error)
 
 // ScanList returns all records found by where
 func ScanList(model, where interface{}, out interface{}, orders ...string) error {
-	db := database.GetDB().Model(model).Where(where)
+	db := scope{}.db().Model(model).Where(where)
 	if len(orders) > 0 {
 		for _, order := range orders {
 			db = db.Order(order)
@@ -0,0 +1,100 @@
+package persistence
+
+import (
+	"context"
+	"github.com/google/uuid"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+)
+
+// CuisineRepository is a repository for cuisines
+// It is used to access the database
+// It is a singleton
+type CuisineRepository struct {
+	scope
+}
+
+var cuisineRepository *CuisineRepository
+
+// GetCuisineRepository returns the cuisine repository
+// It creates a new one if it does not exist
+// It returns the singleton instance of the cuisine repository
+func GetCuisineRepository() *CuisineRepository {
+	if cuisineRepository == nil {
+		cuisineRepository = &CuisineRepository{}
+	}
+	return cuisineRepository
+}
+
+// Scoped returns a copy of the cuisine repository scoped to the organization of ctx
+// It only sees the data of the organization, see db.WithOrganization
+func (r *CuisineRepository) Scoped(ctx context.Context) *CuisineRepository {
+	return &CuisineRepository{scope: scope{ctx: ctx}}
+}
+
+// Unscoped returns a copy of the cuisine repository seeing the data of every organization
+// It is only meant for the jobs, the commands and the lookups made before the organization of a user is known
+func (r *CuisineRepository) Unscoped() *CuisineRepository {
+	return &CuisineRepository{scope: unscoped}
+}
+
+// Get returns a cuisine by id
+func (r *CuisineRepository) Get(id string) (*models.Cuisine, error) {
+	var cuisine models.Cuisine
+	where := models.Cuisine{}
+	stringToUuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	where.ID = stringToUuid
+	_, err = r.first(&where, &cuisine, []string{})
+	if err != nil {
+		return nil, err
+	}
+	return &cuisine, err
+}
+
+// GetByName returns a cuisine by name
+// The name is compared regardless of its case and whitespace
+// The aliases are used when no entry has the name
+func (r *CuisineRepository) GetByName(name string) (*models.Cuisine, error) {
+	var cuisine models.Cuisine
+	if err := firstByNameKey(r.db(), cuisineLink.kind, name, &cuisine); err != nil {
+		return nil, err
+	}
+	return &cuisine, nil
+}
+
+// All returns all cuisines
+// The cuisines are ordered by name ascending
+func (r *CuisineRepository) All() (*[]models.Cuisine, error) {
+	var cuisines []models.Cuisine
+	err := r.find(&models.Cuisine{}, &cuisines, []string{}, "name asc")
+	return &cuisines, err
+}
+
+// Add adds a cuisine to the database
+func (r *CuisineRepository) Add(cuisine *models.Cuisine) error {
+	return r.create(cuisine)
+}
+
+// Update updates a cuisine in the database
+func (r *CuisineRepository) Update(cuisine *models.Cuisine) error {
+	return r.db().Save(cuisine).Error
+}
+
+// Delete deletes a cuisine from the database
+func (r *CuisineRepository) Delete(cuisine *models.Cuisine) error {
+	return r.db().Unscoped().Delete(cuisine).Error
+}
+
+// Merge moves every user of the duplicate cuisine to the canonical one
+// The duplicate is deleted and its name becomes an alias of the canonical cuisine
+func (r *CuisineRepository) Merge(duplicate *models.Cuisine, canonical *models.Cuisine) error {
+	return cuisineLink.merge(r.db(), duplicate, duplicate.ID, duplicate.Name, canonical.ID)
+}
+
+// Search returns the cuisines matching the query for the autocomplete
+// The results are ranked by relevance, then by the number of users
+func (r *CuisineRepository) Search(query string, limit int, fuzzy bool) ([]Suggestion, error) {
+	return cuisineLink.search(r.db(), query, limit, fuzzy)
+}
@@ -0,0 +1,100 @@
+package persistence
+
+import (
+	"context"
+	"github.com/google/uuid"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+)
+
+// DietRepository is a repository for diets
+// It is used to access the database
+// It is a singleton
+type DietRepository struct {
+	scope
+}
+
+var dietRepository *DietRepository
+
+// GetDietRepository returns the diet repository
+// It creates a new one if it does not exist
+// It returns the singleton instance of the diet repository
+func GetDietRepository() *DietRepository {
+	if dietRepository == nil {
+		dietRepository = &DietRepository{}
+	}
+	return dietRepository
+}
+
+// Scoped returns a copy of the diet repository scoped to the organization of ctx
+// It only sees the data of the organization, see db.WithOrganization
+func (r *DietRepository) Scoped(ctx context.Context) *DietRepository {
+	return &DietRepository{scope: scope{ctx: ctx}}
+}
+
+// Unscoped returns a copy of the diet repository seeing the data of every organization
+// It is only meant for the jobs, the commands and the lookups made before the organization of a user is known
+func (r *DietRepository) Unscoped() *DietRepository {
+	return &DietRepository{scope: unscoped}
+}
+
+// Get returns a diet by id
+func (r *DietRepository) Get(id string) (*models.Diet, error) {
+	var diet models.Diet
+	where := models.Diet{}
+	stringToUuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	where.ID = stringToUuid
+	_, err = r.first(&where, &diet, []string{})
+	if err != nil {
+		return nil, err
+	}
+	return &diet, err
+}
+
+// GetByName returns a diet by name
+// The name is compared regardless of its case and whitespace
+// The aliases are used when no entry has the name
+func (r *DietRepository) GetByName(name string) (*models.Diet, error) {
+	var diet models.Diet
+	if err := firstByNameKey(r.db(), dietLink.kind, name, &diet); err != nil {
+		return nil, err
+	}
+	return &diet, nil
+}
+
+// All returns all diets
+// The diets are ordered by name ascending
+func (r *DietRepository) All() (*[]models.Diet, error) {
+	var diets []models.Diet
+	err := r.find(&models.Diet{}, &diets, []string{}, "name asc")
+	return &diets, err
+}
+
+// Add adds a diet to the database
+func (r *DietRepository) Add(diet *models.Diet) error {
+	return r.create(diet)
+}
+
+// Update updates a diet in the database
+func (r *DietRepository) Update(diet *models.Diet) error {
+	return r.db().Save(diet).Error
+}
+
+// Delete deletes a diet from the database
+func (r *DietRepository) Delete(diet *models.Diet) error {
+	return r.db().Unscoped().Delete(diet).Error
+}
+
+// Merge moves every user of the duplicate diet to the canonical one
+// The duplicate is deleted and its name becomes an alias of the canonical diet
+func (r *DietRepository) Merge(duplicate *models.Diet, canonical *models.Diet) error {
+	return dietLink.merge(r.db(), duplicate, duplicate.ID, duplicate.Name, canonical.ID)
+}
+
+// Search returns the diets matching the query for the autocomplete
+// The results are ranked by relevance, then by the number of users
+func (r *DietRepository) Search(query string, limit int, fuzzy bool) ([]Suggestion, error) {
+	return dietLink.search(r.db(), query, limit, fuzzy)
+}
@@ -0,0 +1,35 @@
+package persistence
+
+import (
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/mail"
+	"gorm.io/gorm/clause"
+)
+
+// EmailRepository is a repository for the queue of emails
+// It is used to access the database
+// It is a singleton
+type EmailRepository struct{}
+
+var emailRepository *EmailRepository
+
+// GetEmailRepository returns the email repository
+// It creates a new one if it does not exist
+// It returns the singleton instance of the email repository
+func GetEmailRepository() *EmailRepository {
+	if emailRepository == nil {
+		emailRepository = &EmailRepository{}
+	}
+	return emailRepository
+}
+
+// Enqueue adds an email to the queue
+// An email of the same event to the same user is only added once, so redelivered events are ignored
+func (r *EmailRepository) Enqueue(email *models.Email) error {
+	return db.GetDB().Clauses(clause.OnConflict{DoNothing: true}).Create(email).Error
+}
+
+// Update updates an email of the queue
+func (r *EmailRepository) Update(email *models.Email) error {
+	return Save(email)
+}
@@ -0,0 +1,93 @@
+package persistence
+
+import (
+	"context"
+	"github.com/google/uuid"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+)
+
+// FeedbackRepository is a repository for the feedback about lunch companions
+// It is used to access the database
+// It is a singleton
+type FeedbackRepository struct {
+	scope
+}
+
+var feedbackRepository *FeedbackRepository
+
+// GetFeedbackRepository returns the feedback repository
+// It creates a new one if it does not exist
+// It returns the singleton instance of the feedback repository
+func GetFeedbackRepository() *FeedbackRepository {
+	if feedbackRepository == nil {
+		feedbackRepository = &FeedbackRepository{}
+	}
+	return feedbackRepository
+}
+
+// Scoped returns a copy of the feedback repository scoped to the organization of ctx
+// It only sees the data of the organization, see db.WithOrganization
+func (r *FeedbackRepository) Scoped(ctx context.Context) *FeedbackRepository {
+	return &FeedbackRepository{scope: scope{ctx: ctx}}
+}
+
+// Unscoped returns a copy of the feedback repository seeing the data of every organization
+// It is only meant for the jobs, the commands and the lookups made before the organization of a user is known
+func (r *FeedbackRepository) Unscoped() *FeedbackRepository {
+	return &FeedbackRepository{scope: unscoped}
+}
+
+// Get returns a feedback by id
+func (r *FeedbackRepository) Get(id string) (*models.Feedback, error) {
+	var feedback models.Feedback
+	where := models.Feedback{}
+	stringToUuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	where.ID = stringToUuid
+	_, err = r.first(&where, &feedback, []string{})
+	if err != nil {
+		return nil, err
+	}
+	return &feedback, err
+}
+
+// GetForInvitation returns the feedback about the lunch of the invitation given by the user
+func (r *FeedbackRepository) GetForInvitation(userID uuid.UUID, invitationID uuid.UUID) (*models.Feedback, error) {
+	var feedback models.Feedback
+	_, err := r.first(&models.Feedback{UserID: userID, InvitationID: invitationID}, &feedback, []string{})
+	if err != nil {
+		return nil, err
+	}
+	return &feedback, nil
+}
+
+// ForUser returns the feedback given by the user, the newest first
+func (r *FeedbackRepository) ForUser(userID uuid.UUID) (*[]models.Feedback, error) {
+	var feedback []models.Feedback
+	err := r.find(&models.Feedback{UserID: userID}, &feedback, []string{}, "created_at desc")
+	return &feedback, err
+}
+
+// All returns the feedback given by every user
+func (r *FeedbackRepository) All() (*[]models.Feedback, error) {
+	var feedback []models.Feedback
+	err := r.find(&models.Feedback{}, &feedback, []string{}, "created_at asc")
+	return &feedback, err
+}
+
+// Add adds a feedback to the database
+func (r *FeedbackRepository) Add(feedback *models.Feedback) error {
+	return r.create(feedback)
+}
+
+// Update updates a feedback in the database
+func (r *FeedbackRepository) Update(feedback *models.Feedback) error {
+	return r.db().Save(feedback).Error
+}
+
+// Delete deletes a feedback from the database
+func (r *FeedbackRepository) Delete(feedback *models.Feedback) error {
+	return r.db().Unscoped().Delete(feedback).Error
+}
@@ -1,15 +1,17 @@
 package persistence
 
 import (
+	"context"
 	"github.com/google/uuid"
-	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
 	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
 )
 
 // HobbyRepository is a repository for hobbies
 // It is used to access the database
 // It is a singleton
-type HobbyRepository struct{}
+type HobbyRepository struct {
+	scope
+}
 
 var hobbyRepository *HobbyRepository
 
@@ -23,6 +25,18 @@ func GetHobbyRepository() *HobbyRepository {
 	return hobbyRepository
 }
 
+// Scoped returns a copy of the hobby repository scoped to the organization of ctx
+// It only sees the data of the organization, see db.WithOrganization
+func (r *HobbyRepository) Scoped(ctx context.Context) *HobbyRepository {
+	return &HobbyRepository{scope: scope{ctx: ctx}}
+}
+
+// Unscoped returns a copy of the hobby repository seeing the data of every organization
+// It is only meant for the jobs, the commands and the lookups made before the organization of a user is known
+func (r *HobbyRepository) Unscoped() *HobbyRepository {
+	return &HobbyRepository{scope: unscoped}
+}
+
 // Get returns a hobby by id
 func (r *HobbyRepository) Get(id string) (*models.Hobby, error) {
 	var hobby models.Hobby
@@ -32,7 +46,7 @@ func (r *HobbyRepository) Get(id string) (*models.Hobby, error) {
 		return nil, err
 	}
 	where.ID = stringToUuid
-	_, err = First(&where, &hobby, []string{})
+	_, err = r.first(&where, &hobby, []string{})
 	if err != nil {
 		return nil, err
 	}
@@ -40,22 +54,21 @@ func (r *HobbyRepository) Get(id string) (*models.Hobby, error) {
 }
 
 // GetByName returns a hobby by name
+// The name is compared regardless of its case and whitespace
+// The aliases are used when no entry has the name
 func (r *HobbyRepository) GetByName(name string) (*models.Hobby, error) {
 	var hobby models.Hobby
-	where := models.Hobby{}
-	where.Name = name
-	_, err := First(&where, &hobby, []string{})
-	if err != nil {
+	if err := firstByNameKey(r.db(), hobbyLink.kind, name, &hobby); err != nil {
 		return nil, err
 	}
-	return &hobby, err
+	return &hobby, nil
 }
 
 // All returns all hobbies
 // The hobbies are ordered by id ascending
 func (r *HobbyRepository) All() (*[]models.Hobby, error) {
 	var hobbies []models.Hobby
-	err := Find(&models.Hobby{}, &hobbies, []string{}, "id asc")
+	err := r.find(&models.Hobby{}, &hobbies, []string{}, "id asc")
 	return &hobbies, err
 }
 
@@ -67,24 +80,36 @@ func (r *HobbyRepository) All() (*[]models.Hobby, error) {
 // The fields to match are the fields that are not the zero value for their type
 func (r *HobbyRepository) Query(q *models.Hobby) (*[]models.Hobby, error) {
 	var hobbies []models.Hobby
-	err := Find(&q, &hobbies, []string{}, "id asc")
+	err := r.find(&q, &hobbies, []string{}, "id asc")
 	return &hobbies, err
 }
 
 // Add adds a hobby to the database
 func (r *HobbyRepository) Add(hobby *models.Hobby) error {
-	err := Create(&hobby)
-	err = Save(&hobby)
+	err := r.create(&hobby)
+	err = r.save(&hobby)
 	return err
 }
 
 // Update updates a hobby in the database
 func (r *HobbyRepository) Update(hobby *models.Hobby) error {
-	return db.GetDB().Save(&hobby).Error
+	return r.db().Save(&hobby).Error
 }
 
 // Delete deletes a hobby from the database
 func (r *HobbyRepository) Delete(hobby *models.Hobby) error {
-	return db.GetDB().Unscoped().Delete(&hobby).Error
+	return r.db().Unscoped().Delete(&hobby).Error
+
+}
+
+// Merge moves every user of the duplicate hobby to the canonical one
+// The duplicate is deleted and its name becomes an alias of the canonical hobby
+func (r *HobbyRepository) Merge(duplicate *models.Hobby, canonical *models.Hobby) error {
+	return hobbyLink.merge(r.db(), duplicate, duplicate.ID, duplicate.Name, canonical.ID)
+}
 
+// Search returns the hobbies matching the query for the autocomplete
+// The results are ranked by relevance, then by the number of users
+func (r *HobbyRepository) Search(query string, limit int, fuzzy bool) ([]Suggestion, error) {
+	return hobbyLink.search(r.db(), query, limit, fuzzy)
 }
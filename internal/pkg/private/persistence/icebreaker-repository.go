@@ -0,0 +1,73 @@
+package persistence
+
+import (
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/tasks"
+)
+
+// IcebreakerRepository is a repository for icebreaker templates
+// It is used to access the database
+// It is a singleton
+type IcebreakerRepository struct{}
+
+var icebreakerRepository *IcebreakerRepository
+
+// GetIcebreakerRepository returns the icebreaker template repository
+// It creates a new one if it does not exist
+// It returns the singleton instance of the icebreaker template repository
+func GetIcebreakerRepository() *IcebreakerRepository {
+	if icebreakerRepository == nil {
+		icebreakerRepository = &IcebreakerRepository{}
+	}
+	return icebreakerRepository
+}
+
+// Get returns a template by id
+func (r *IcebreakerRepository) Get(id string) (*models.IcebreakerTemplate, error) {
+	var template models.IcebreakerTemplate
+	where := models.IcebreakerTemplate{}
+	stringToUuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	where.ID = stringToUuid
+	_, err = First(&where, &template, []string{})
+	if err != nil {
+		return nil, err
+	}
+	return &template, err
+}
+
+// Query returns all templates that match the given query
+// The fields to match are the fields that are not the zero value for their type
+func (r *IcebreakerRepository) Query(q *models.IcebreakerTemplate) (*[]models.IcebreakerTemplate, error) {
+	var templates []models.IcebreakerTemplate
+	err := Find(q, &templates, []string{}, "kind asc", "name asc")
+	return &templates, err
+}
+
+// ForNames returns the templates of the given kind for the given name keys
+func (r *IcebreakerRepository) ForNames(kind string, nameKeys []string) ([]models.IcebreakerTemplate, error) {
+	var templates []models.IcebreakerTemplate
+	if len(nameKeys) == 0 {
+		return templates, nil
+	}
+	err := db.GetDB().Where("kind = ? AND name_key IN ?", kind, nameKeys).Find(&templates).Error
+	return templates, err
+}
+
+// Add adds a template to the database
+func (r *IcebreakerRepository) Add(template *models.IcebreakerTemplate) error {
+	return Create(template)
+}
+
+// Update updates a template in the database
+func (r *IcebreakerRepository) Update(template *models.IcebreakerTemplate) error {
+	return db.GetDB().Save(template).Error
+}
+
+// Delete deletes a template from the database
+func (r *IcebreakerRepository) Delete(template *models.IcebreakerTemplate) error {
+	return db.GetDB().Unscoped().Delete(template).Error
+}
@@ -0,0 +1,168 @@
+package persistence
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/events"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"gorm.io/gorm"
+	"time"
+)
+
+// InvitationRepository is a repository for lunch invitations
+// It is used to access the database
+// It is a singleton
+type InvitationRepository struct {
+	scope
+}
+
+var invitationRepository *InvitationRepository
+
+// GetInvitationRepository returns the invitation repository
+// It creates a new one if it does not exist
+// It returns the singleton instance of the invitation repository
+func GetInvitationRepository() *InvitationRepository {
+	if invitationRepository == nil {
+		invitationRepository = &InvitationRepository{}
+	}
+	return invitationRepository
+}
+
+// Scoped returns a copy of the invitation repository scoped to the organization of ctx
+// It only sees the data of the organization, see db.WithOrganization
+func (r *InvitationRepository) Scoped(ctx context.Context) *InvitationRepository {
+	return &InvitationRepository{scope: scope{ctx: ctx}}
+}
+
+// Unscoped returns a copy of the invitation repository seeing the data of every organization
+// It is only meant for the jobs, the commands and the lookups made before the organization of a user is known
+func (r *InvitationRepository) Unscoped() *InvitationRepository {
+	return &InvitationRepository{scope: unscoped}
+}
+
+// Get returns an invitation by id
+// The inviter, the invitee and the place are eager loaded
+func (r *InvitationRepository) Get(id string) (*models.Invitation, error) {
+	var invitation models.Invitation
+	where := models.Invitation{}
+	stringToUuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	where.ID = stringToUuid
+	_, err = r.first(&where, &invitation, []string{"Inviter", "Invitee", "Place"})
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, err
+}
+
+// QueryForUser returns the invitations sent or received by the user
+// The role is "sent", "received" or empty for both
+// The status is ignored when it is empty
+// The invitations are ordered by time
+func (r *InvitationRepository) QueryForUser(userID uuid.UUID, role string, status string) (*[]models.Invitation, error) {
+	var invitations []models.Invitation
+	query := r.db().Preload("Inviter").Preload("Invitee").Preload("Place")
+	switch role {
+	case "sent":
+		query = query.Where("inviter_id = ?", userID)
+	case "received":
+		query = query.Where("invitee_id = ?", userID)
+	default:
+		query = query.Where("inviter_id = ? OR invitee_id = ?", userID, userID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("time asc").Find(&invitations).Error
+	return &invitations, err
+}
+
+// AcceptedBetween returns the accepted invitations taking place within the window, the soonest first
+func (r *InvitationRepository) AcceptedBetween(from time.Time, to time.Time) (*[]models.Invitation, error) {
+	var invitations []models.Invitation
+	err := r.db().Where("status = ? AND time >= ? AND time < ?", models.InvitationAccepted, from, to).
+		Order("time asc").Find(&invitations).Error
+	return &invitations, err
+}
+
+// Add adds an invitation to the database
+// A LunchInvitationSent event is recorded in the same transaction
+func (r *InvitationRepository) Add(invitation *models.Invitation) error {
+	return r.db().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Inviter", "Invitee").Create(invitation).Error; err != nil {
+			return err
+		}
+		return events.Record(tx, events.LunchInvitationSent, invitation.ID, invitationPayload(invitation))
+	})
+}
+
+// Update updates an invitation in the database
+// The inviter and the invitee are not updated
+func (r *InvitationRepository) Update(invitation *models.Invitation) error {
+	return r.db().Omit("Inviter", "Invitee").Save(invitation).Error
+}
+
+// ChangeStatus moves the invitation to the status
+// A LunchInvitationAccepted event is recorded in the same transaction when it is accepted
+func (r *InvitationRepository) ChangeStatus(invitation *models.Invitation, status string) error {
+	return r.db().Transaction(func(tx *gorm.DB) error {
+		invitation.Status = status
+		if err := tx.Omit("Inviter", "Invitee").Save(invitation).Error; err != nil {
+			return err
+		}
+		if status != models.InvitationAccepted {
+			return nil
+		}
+		return events.Record(tx, events.LunchInvitationAccepted, invitation.ID, invitationPayload(invitation))
+	})
+}
+
+// invitationPayload returns the payload of the events of the invitation
+func invitationPayload(invitation *models.Invitation) events.LunchInvitationPayload {
+	return events.LunchInvitationPayload{
+		InvitationID: invitation.ID,
+		InviterID:    invitation.InviterID,
+		InviteeID:    invitation.InviteeID,
+		Time:         invitation.Time,
+		Location:     invitation.Location,
+	}
+}
+
+// UpcomingForUser returns the invitations of the user with the status taking place after the time, the soonest first
+// The inviter, the invitee and the place are eager loaded
+func (r *InvitationRepository) UpcomingForUser(userID uuid.UUID, status string, since time.Time) (*[]models.Invitation, error) {
+	var invitations []models.Invitation
+	err := r.db().Preload("Inviter").Preload("Invitee").Preload("Place").
+		Where("(inviter_id = ? OR invitee_id = ?) AND status = ? AND time >= ?", userID, userID, status, since).
+		Order("time asc").Find(&invitations).Error
+	return &invitations, err
+}
+
+// DueReminders returns the accepted invitations not reminded yet taking place within the window
+// The inviter, the invitee and the place are eager loaded
+func (r *InvitationRepository) DueReminders(from time.Time, to time.Time) (*[]models.Invitation, error) {
+	var invitations []models.Invitation
+	err := r.db().Preload("Inviter").Preload("Invitee").Preload("Place").
+		Where("status = ? AND reminded_at IS NULL AND time > ? AND time <= ?", models.InvitationAccepted, from, to).
+		Order("time asc").Find(&invitations).Error
+	return &invitations, err
+}
+
+// MarkReminded records that the reminder of the invitation was sent
+func (r *InvitationRepository) MarkReminded(invitation *models.Invitation) error {
+	now := time.Now()
+	invitation.RemindedAt = &now
+	return r.db().Model(&models.Invitation{}).Where("id = ?", invitation.ID).
+		Updates(map[string]interface{}{"reminded_at": now, "updated_at": now}).Error
+}
+
+// ExpirePending moves the pending invitations which took place before the time to expired
+// It returns the number of expired invitations
+func (r *InvitationRepository) ExpirePending(before time.Time) (int64, error) {
+	result := r.db().Model(&models.Invitation{}).
+		Where("status = ? AND time < ?", models.InvitationPending, before).
+		Updates(map[string]interface{}{"status": models.InvitationExpired, "updated_at": time.Now()})
+	return result.RowsAffected, result.Error
+}
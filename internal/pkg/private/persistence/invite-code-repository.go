@@ -0,0 +1,91 @@
+package persistence
+
+import (
+	"context"
+	"github.com/google/uuid"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"time"
+)
+
+// InviteCodeRepository is a repository for the invite codes
+// It is used to access the database
+// It is a singleton
+type InviteCodeRepository struct {
+	scope
+}
+
+var inviteCodeRepository *InviteCodeRepository
+
+// GetInviteCodeRepository returns the invite code repository
+// It creates a new one if it does not exist
+// It returns the singleton instance of the invite code repository
+func GetInviteCodeRepository() *InviteCodeRepository {
+	if inviteCodeRepository == nil {
+		inviteCodeRepository = &InviteCodeRepository{}
+	}
+	return inviteCodeRepository
+}
+
+// Scoped returns a copy of the invite code repository scoped to the organization of ctx
+// It only sees the data of the organization, see db.WithOrganization
+func (r *InviteCodeRepository) Scoped(ctx context.Context) *InviteCodeRepository {
+	return &InviteCodeRepository{scope: scope{ctx: ctx}}
+}
+
+// Unscoped returns a copy of the invite code repository seeing the data of every organization
+// It is only meant for the jobs, the commands and the lookups made before the organization of a user is known
+func (r *InviteCodeRepository) Unscoped() *InviteCodeRepository {
+	return &InviteCodeRepository{scope: unscoped}
+}
+
+// Get returns an invite code by id
+func (r *InviteCodeRepository) Get(id string) (*models.InviteCode, error) {
+	var code models.InviteCode
+	where := models.InviteCode{}
+	stringToUuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	where.ID = stringToUuid
+	_, err = r.first(&where, &code, []string{})
+	if err != nil {
+		return nil, err
+	}
+	return &code, err
+}
+
+// GetByCode returns an invite code by its code
+func (r *InviteCodeRepository) GetByCode(code string) (*models.InviteCode, error) {
+	var inviteCode models.InviteCode
+	_, err := r.first(&models.InviteCode{Code: code}, &inviteCode, []string{})
+	if err != nil {
+		return nil, err
+	}
+	return &inviteCode, nil
+}
+
+// All returns all invite codes
+// The codes are ordered by creation, the newest first
+func (r *InviteCodeRepository) All() (*[]models.InviteCode, error) {
+	var codes []models.InviteCode
+	err := r.find(&models.InviteCode{}, &codes, []string{}, "created_at desc")
+	return &codes, err
+}
+
+// Add adds an invite code to the database
+func (r *InviteCodeRepository) Add(code *models.InviteCode) error {
+	return r.create(code)
+}
+
+// Delete deletes an invite code from the database
+// The users registered with it are kept
+func (r *InviteCodeRepository) Delete(code *models.InviteCode) error {
+	return r.db().Delete(code).Error
+}
+
+// DeleteExpired deletes the invite codes which expired before the time
+// It returns the number of deleted codes
+func (r *InviteCodeRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db().Where("expires_at < ?", before).Delete(&models.InviteCode{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,85 @@
+package persistence
+
+import (
+	"time"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/jobs"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobRepository is a repository for the state of the scheduled jobs
+// It is used to access the database
+// It is a singleton
+type JobRepository struct{}
+
+var jobRepository *JobRepository
+
+// GetJobRepository returns the job repository
+// It creates a new one if it does not exist
+// It returns the singleton instance of the job repository
+func GetJobRepository() *JobRepository {
+	if jobRepository == nil {
+		jobRepository = &JobRepository{}
+	}
+	return jobRepository
+}
+
+// Get returns a job by name
+func (r *JobRepository) Get(name string) (*models.Job, error) {
+	var job models.Job
+	err := db.GetDB().Where("name = ?", name).First(&job).Error
+	return &job, err
+}
+
+// All returns all jobs ordered by name
+func (r *JobRepository) All() (*[]models.Job, error) {
+	var jobs []models.Job
+	err := db.GetDB().Order("name").Find(&jobs).Error
+	return &jobs, err
+}
+
+// Ensure creates the row of a job, or reschedules it when its schedule changed
+func (r *JobRepository) Ensure(name string, schedule string, next time.Time) error {
+	job := models.Job{Name: name, Schedule: schedule, NextRunAt: next}
+	if err := db.GetDB().Clauses(clause.OnConflict{DoNothing: true}).Create(&job).Error; err != nil {
+		return err
+	}
+	return db.GetDB().Model(&models.Job{}).Where("name = ? AND schedule <> ?", name, schedule).
+		Updates(map[string]interface{}{"schedule": schedule, "next_run_at": next, "updated_at": time.Now()}).Error
+}
+
+// Lock locks the job for the owner until the lease expires
+// When due is true the job is only locked if its next run is due
+// It returns false when the job is locked by another owner or not due
+func (r *JobRepository) Lock(name string, owner string, now time.Time, lease time.Duration, due bool) (bool, error) {
+	query := db.GetDB().Model(&models.Job{}).
+		Where("name = ? AND (locked_until IS NULL OR locked_until < ?)", name, now)
+	if due {
+		query = query.Where("next_run_at <= ?", now)
+	}
+	result := query.Updates(map[string]interface{}{"locked_by": owner, "locked_until": now.Add(lease), "updated_at": now})
+	return result.RowsAffected == 1, result.Error
+}
+
+// Finish records the outcome of a run, schedules the next one and unlocks the job
+func (r *JobRepository) Finish(name string, owner string, started time.Time, next time.Time, result string, runErr error) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"next_run_at":   next,
+		"locked_by":     "",
+		"locked_until":  nil,
+		"last_run_at":   started,
+		"last_duration": now.Sub(started).Milliseconds(),
+		"last_result":   result,
+		"last_error":    "",
+		"runs":          gorm.Expr("runs + 1"),
+		"updated_at":    now,
+	}
+	if runErr != nil {
+		updates["last_error"] = runErr.Error()
+		updates["failures"] = gorm.Expr("failures + 1")
+	}
+	return db.GetDB().Model(&models.Job{}).Where("name = ? AND locked_by = ?", name, owner).Updates(updates).Error
+}
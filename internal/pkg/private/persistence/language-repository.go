@@ -1,15 +1,17 @@
 package persistence
 
 import (
+	"context"
 	"github.com/google/uuid"
-	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
 	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
 )
 
 // LanguageRepository is a repository for languages
 // It is used to access the database
 // It is a singleton
-type LanguageRepository struct{}
+type LanguageRepository struct {
+	scope
+}
 
 var languageRepository *LanguageRepository
 
@@ -23,6 +25,18 @@ func GetLanguageRepository() *LanguageRepository {
 	return languageRepository
 }
 
+// Scoped returns a copy of the language repository scoped to the organization of ctx
+// It only sees the data of the organization, see db.WithOrganization
+func (r *LanguageRepository) Scoped(ctx context.Context) *LanguageRepository {
+	return &LanguageRepository{scope: scope{ctx: ctx}}
+}
+
+// Unscoped returns a copy of the language repository seeing the data of every organization
+// It is only meant for the jobs, the commands and the lookups made before the organization of a user is known
+func (r *LanguageRepository) Unscoped() *LanguageRepository {
+	return &LanguageRepository{scope: unscoped}
+}
+
 // Get returns a language by id
 func (r *LanguageRepository) Get(id string) (*models.Language, error) {
 	var language models.Language
@@ -32,7 +46,7 @@ func (r *LanguageRepository) Get(id string) (*models.Language, error) {
 		return nil, err
 	}
 	where.ID = stringToUuid
-	_, err = First(&where, &language, []string{})
+	_, err = r.first(&where, &language, []string{})
 	if err != nil {
 		return nil, err
 	}
@@ -40,44 +54,55 @@ func (r *LanguageRepository) Get(id string) (*models.Language, error) {
 }
 
 // GetByName returns a language by name
+// The name is compared regardless of its case and whitespace
+// The aliases are used when no entry has the name
 func (r *LanguageRepository) GetByName(name string) (*models.Language, error) {
 	var language models.Language
-	where := models.Language{}
-	where.Name = name
-	_, err := First(&where, &language, []string{})
-	if err != nil {
+	if err := firstByNameKey(r.db(), languageLink.kind, name, &language); err != nil {
 		return nil, err
 	}
-	return &language, err
+	return &language, nil
 }
 
 // All returns all languages
 func (r *LanguageRepository) All() (*[]models.Language, error) {
 	var languages []models.Language
-	err := Find(&models.Language{}, &languages, []string{}, "id asc")
+	err := r.find(&models.Language{}, &languages, []string{}, "id asc")
 	return &languages, err
 }
 
 // Query returns all languages that match the query
 func (r *LanguageRepository) Query(q *models.Language) (*[]models.Language, error) {
 	var languages []models.Language
-	err := Find(&q, &languages, []string{}, "id asc")
+	err := r.find(&q, &languages, []string{}, "id asc")
 	return &languages, err
 }
 
 // Add adds a language to the database
 func (r *LanguageRepository) Add(language *models.Language) error {
-	err := Create(&language)
-	err = Save(&language)
+	err := r.create(&language)
+	err = r.save(&language)
 	return err
 }
 
 // Update updates a language in the database
 func (r *LanguageRepository) Update(language *models.Language) error {
-	return db.GetDB().Save(&language).Error
+	return r.db().Save(&language).Error
 }
 
 // Delete deletes a language from the database
 func (r *LanguageRepository) Delete(language *models.Language) error {
-	return db.GetDB().Unscoped().Delete(&language).Error
+	return r.db().Unscoped().Delete(&language).Error
+}
+
+// Merge moves every user of the duplicate language to the canonical one
+// The duplicate is deleted and its name becomes an alias of the canonical language
+func (r *LanguageRepository) Merge(duplicate *models.Language, canonical *models.Language) error {
+	return languageLink.merge(r.db(), duplicate, duplicate.ID, duplicate.Name, canonical.ID)
+}
+
+// Search returns the languages matching the query for the autocomplete
+// The results are ranked by relevance, then by the number of users
+func (r *LanguageRepository) Search(query string, limit int, fuzzy bool) ([]Suggestion, error) {
+	return languageLink.search(r.db(), query, limit, fuzzy)
 }
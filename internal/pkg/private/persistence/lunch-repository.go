@@ -1,15 +1,17 @@
 package persistence
 
 import (
+	"context"
 	"github.com/google/uuid"
-	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
 	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
 )
 
 // LunchRepository is a repository for lunches
 // It is used to access the database
 // It is a singleton
-type LunchRepository struct{}
+type LunchRepository struct {
+	scope
+}
 
 var lunchRepository *LunchRepository
 
@@ -23,6 +25,18 @@ func GetLunchRepository() *LunchRepository {
 	return lunchRepository
 }
 
+// Scoped returns a copy of the lunch repository scoped to the organization of ctx
+// It only sees the data of the organization, see db.WithOrganization
+func (r *LunchRepository) Scoped(ctx context.Context) *LunchRepository {
+	return &LunchRepository{scope: scope{ctx: ctx}}
+}
+
+// Unscoped returns a copy of the lunch repository seeing the data of every organization
+// It is only meant for the jobs, the commands and the lookups made before the organization of a user is known
+func (r *LunchRepository) Unscoped() *LunchRepository {
+	return &LunchRepository{scope: unscoped}
+}
+
 // Get returns a lunch by id
 func (r *LunchRepository) Get(id string) (*models.Lunch, error) {
 	var lunch models.Lunch
@@ -32,7 +46,7 @@ func (r *LunchRepository) Get(id string) (*models.Lunch, error) {
 		return nil, err
 	}
 	where.ID = stringToUuid
-	_, err = First(&where, &lunch, []string{})
+	_, err = r.first(&where, &lunch, []string{"Place"})
 	if err != nil {
 		return nil, err
 	}
@@ -42,30 +56,30 @@ func (r *LunchRepository) Get(id string) (*models.Lunch, error) {
 // All returns all lunches
 func (r *LunchRepository) All() (*[]models.Lunch, error) {
 	var lunches []models.Lunch
-	err := Find(&models.Lunch{}, &lunches, []string{}, "id asc")
+	err := r.find(&models.Lunch{}, &lunches, []string{"Place"}, "id asc")
 	return &lunches, err
 }
 
 // Query returns all lunches that match the query
 func (r *LunchRepository) Query(q *models.Lunch) (*[]models.Lunch, error) {
 	var lunches []models.Lunch
-	err := Find(&q, &lunches, []string{}, "id asc")
+	err := r.find(&q, &lunches, []string{"Place"}, "id asc")
 	return &lunches, err
 }
 
 // Add adds a new lunch to the database
 func (r *LunchRepository) Add(lunch *models.Lunch) error {
-	err := Create(&lunch)
-	err = Save(&lunch)
+	err := r.create(&lunch)
+	err = r.save(&lunch)
 	return err
 }
 
 // Update updates a lunch in the database
 func (r *LunchRepository) Update(lunch *models.Lunch) error {
-	return db.GetDB().Save(&lunch).Error
+	return r.db().Save(&lunch).Error
 }
 
 // Delete deletes a lunch from the database
 func (r *LunchRepository) Delete(lunch *models.Lunch) error {
-	return db.GetDB().Unscoped().Delete(&lunch).Error
+	return r.db().Unscoped().Delete(&lunch).Error
 }
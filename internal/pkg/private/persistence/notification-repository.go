@@ -0,0 +1,76 @@
+package persistence
+
+import (
+	"context"
+	"github.com/google/uuid"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/notifications"
+	"time"
+)
+
+// NotificationRepository is a repository for notifications
+// It is used to access the database
+// It is a singleton
+type NotificationRepository struct {
+	scope
+}
+
+var notificationRepository *NotificationRepository
+
+// GetNotificationRepository returns the notification repository
+// It creates a new one if it does not exist
+// It returns the singleton instance of the notification repository
+func GetNotificationRepository() *NotificationRepository {
+	if notificationRepository == nil {
+		notificationRepository = &NotificationRepository{}
+	}
+	return notificationRepository
+}
+
+// Scoped returns a copy of the notification repository scoped to the organization of ctx
+// It only sees the data of the organization, see db.WithOrganization
+func (r *NotificationRepository) Scoped(ctx context.Context) *NotificationRepository {
+	return &NotificationRepository{scope: scope{ctx: ctx}}
+}
+
+// Unscoped returns a copy of the notification repository seeing the data of every organization
+// It is only meant for the jobs, the commands and the lookups made before the organization of a user is known
+func (r *NotificationRepository) Unscoped() *NotificationRepository {
+	return &NotificationRepository{scope: unscoped}
+}
+
+// QueryForUser returns a page of the notifications of the user, the newest first
+// When unreadOnly is true the read notifications are left out
+func (r *NotificationRepository) QueryForUser(userID uuid.UUID, unreadOnly bool, limit int, offset int) (*[]models.Notification, error) {
+	var notifications []models.Notification
+	query := r.db().Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	err := query.Order("created_at desc").Limit(limit).Offset(offset).Find(&notifications).Error
+	return &notifications, err
+}
+
+// CountUnread returns the number of unread notifications of the user
+func (r *NotificationRepository) CountUnread(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db().Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// Add adds a notification to the database
+func (r *NotificationRepository) Add(notification *models.Notification) error {
+	return r.create(notification)
+}
+
+// MarkRead marks the given notifications of the user as read
+// When ids is empty every notification of the user is marked
+// It returns the number of notifications marked
+func (r *NotificationRepository) MarkRead(userID uuid.UUID, ids []uuid.UUID) (int64, error) {
+	now := time.Now()
+	query := r.db().Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	result := query.Updates(map[string]interface{}{"read_at": now, "updated_at": now})
+	return result.RowsAffected, result.Error
+}
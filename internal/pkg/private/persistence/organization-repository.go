@@ -0,0 +1,151 @@
+package persistence
+
+import (
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/availability"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/notifications"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/tasks"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"gorm.io/gorm"
+)
+
+// OrganizationRepository is a repository for the organizations sharing the deployment
+// It is used to access the database
+// It is a singleton
+type OrganizationRepository struct{}
+
+var organizationRepository *OrganizationRepository
+
+// GetOrganizationRepository returns the organization repository
+// It creates a new one if it does not exist
+// It returns the singleton instance of the organization repository
+func GetOrganizationRepository() *OrganizationRepository {
+	if organizationRepository == nil {
+		organizationRepository = &OrganizationRepository{}
+	}
+	return organizationRepository
+}
+
+// userLinks are the join tables linking a user to the data of its organization, with the column referencing the user
+// The taxonomies are per organization and the buddies, blocked and liked users are in the same organization
+var userLinks = [][2]string{
+	{"user_hobbies", "user_id"}, {"user_languages", "user_id"}, {"user_areas", "user_id"},
+	{"user_diets", "user_id"}, {"user_cuisines", "user_id"},
+	{"user_buddies", "user_id"}, {"user_buddies", "buddy_id"},
+	{"user_blacklists", "user_id"}, {"user_blacklists", "blacklist_id"},
+	{"user_likes", "user_id"}, {"user_likes", "like_id"},
+}
+
+// Get returns an organization by id
+func (r *OrganizationRepository) Get(id string) (*models.Organization, error) {
+	var organization models.Organization
+	where := models.Organization{}
+	stringToUuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	where.ID = stringToUuid
+	_, err = First(&where, &organization, []string{})
+	if err != nil {
+		return nil, err
+	}
+	return &organization, err
+}
+
+// GetBySlug returns an organization by slug
+func (r *OrganizationRepository) GetBySlug(slug string) (*models.Organization, error) {
+	var organization models.Organization
+	_, err := First(&models.Organization{Slug: slug}, &organization, []string{})
+	if err != nil {
+		return nil, err
+	}
+	return &organization, nil
+}
+
+// All returns all organizations
+// The organizations are ordered by name ascending
+func (r *OrganizationRepository) All() (*[]models.Organization, error) {
+	var organizations []models.Organization
+	err := Find(&models.Organization{}, &organizations, []string{}, "name asc")
+	return &organizations, err
+}
+
+// Members counts the users of the organization
+func (r *OrganizationRepository) Members(organizationID uuid.UUID) (int64, error) {
+	var count int64
+	err := unscoped.db().Model(&models.User{}).Where("organization_id = ?", organizationID).Count(&count).Error
+	return count, err
+}
+
+// Add adds an organization to the database
+func (r *OrganizationRepository) Add(organization *models.Organization) error {
+	return Create(organization)
+}
+
+// Update updates an organization in the database
+func (r *OrganizationRepository) Update(organization *models.Organization) error {
+	return db.GetDB().Save(organization).Error
+}
+
+// Delete deletes an organization from the database
+// The organization must have no member anymore, its taxonomies, teams, departments and invite codes are deleted
+func (r *OrganizationRepository) Delete(organization *models.Organization) error {
+	return unscoped.db().Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.Hobby{}, &models.Language{}, &models.Area{}, &models.Diet{},
+			&models.Cuisine{}, &models.Team{}, &models.Department{}, &models.InviteCode{}} {
+			if err := tx.Where("organization_id = ?", organization.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Delete(organization).Error
+	})
+}
+
+// SetMember moves the user to the organization, or to the default organization when it is nil
+// The user administers the organization when admin is true
+// When the organization changes, the lunch, the tasks, the notifications, the calendars and the password resets
+// of the user move with it and the links to the data of the previous organization are removed: the taxonomies,
+// the team, the buddies, the blocked and the liked users
+func (r *OrganizationRepository) SetMember(user *models.User, organization *models.Organization, admin bool) error {
+	var organizationID *uuid.UUID
+	if organization != nil {
+		organizationID = &organization.ID
+	}
+	moved := (user.OrganizationID == nil) != (organizationID == nil) ||
+		(user.OrganizationID != nil && *user.OrganizationID != *organizationID)
+	err := unscoped.db().Transaction(func(tx *gorm.DB) error {
+		changes := map[string]interface{}{"organization_id": organizationID, "organization_admin": admin}
+		if moved {
+			changes["team_id"] = nil
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(changes).Error; err != nil {
+			return err
+		}
+		if !moved {
+			return nil
+		}
+		for _, model := range []interface{}{&models.Lunch{}, &tasks.Task{}, &notifications.Notification{},
+			&availability.Source{}, &availability.BusyBlock{}, &models.PasswordReset{}} {
+			if err := tx.Model(model).Where("user_id = ?", user.ID).Update("organization_id", organizationID).Error; err != nil {
+				return err
+			}
+		}
+		for _, link := range userLinks {
+			if err := tx.Exec("DELETE FROM "+link[0]+" WHERE "+link[1]+" = ?", user.ID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	user.OrganizationID, user.Organization, user.OrganizationAdmin = organizationID, organization, admin
+	if moved {
+		user.TeamID, user.Team = nil, nil
+		user.Hobbies, user.Languages, user.Areas, user.Diets, user.Cuisines = nil, nil, nil, nil, nil
+		user.Buddies, user.Blacklist, user.Likes = nil, nil, nil
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package persistence
+
+import (
+	"context"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"gorm.io/gorm"
+	"time"
+)
+
+// PasswordResetRepository is a repository for the password resets
+// It is used to access the database
+// It is a singleton
+type PasswordResetRepository struct {
+	scope
+}
+
+var passwordResetRepository *PasswordResetRepository
+
+// GetPasswordResetRepository returns the password reset repository
+// It creates a new one if it does not exist
+// It returns the singleton instance of the password reset repository
+func GetPasswordResetRepository() *PasswordResetRepository {
+	if passwordResetRepository == nil {
+		passwordResetRepository = &PasswordResetRepository{}
+	}
+	return passwordResetRepository
+}
+
+// Scoped returns a copy of the password reset repository scoped to the organization of ctx
+// It only sees the data of the organization, see db.WithOrganization
+func (r *PasswordResetRepository) Scoped(ctx context.Context) *PasswordResetRepository {
+	return &PasswordResetRepository{scope: scope{ctx: ctx}}
+}
+
+// Unscoped returns a copy of the password reset repository seeing the data of every organization
+// It is only meant for the jobs, the commands and the lookups made before the organization of a user is known
+func (r *PasswordResetRepository) Unscoped() *PasswordResetRepository {
+	return &PasswordResetRepository{scope: unscoped}
+}
+
+// GetByTokenHash returns the password reset of a token hash
+func (r *PasswordResetRepository) GetByTokenHash(tokenHash string) (*models.PasswordReset, error) {
+	var reset models.PasswordReset
+	err := r.db().Where("token_hash = ?", tokenHash).First(&reset).Error
+	return &reset, err
+}
+
+// Add adds a password reset to the database
+func (r *PasswordResetRepository) Add(reset *models.PasswordReset) error {
+	return r.create(reset)
+}
+
+// Use changes the password of the user of the reset and marks every pending reset of the user as used
+func (r *PasswordResetRepository) Use(reset *models.PasswordReset, hash string) error {
+	return r.db().Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Model(&models.User{}).Where("id = ?", reset.UserID).
+			Updates(map[string]interface{}{"hash": hash, "updated_at": now}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.PasswordReset{}).Where("user_id = ? AND used_at IS NULL", reset.UserID).
+			Updates(map[string]interface{}{"used_at": now, "updated_at": now}).Error
+	})
+}
+
+// DeleteExpired deletes the password resets which expired or were used before the time
+// It returns the number of deleted resets
+func (r *PasswordResetRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db().Where("expires_at < ? OR used_at < ?", before, before).Delete(&models.PasswordReset{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,245 @@
+package persistence
+
+import (
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/geo"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/textsearch"
+	"gorm.io/gorm"
+	"sort"
+)
+
+// PlaceRepository is a repository for places
+// It is used to access the database
+// It is a singleton
+type PlaceRepository struct{}
+
+var placeRepository *PlaceRepository
+
+// GetPlaceRepository returns the place repository
+// It creates a new one if it does not exist
+// It returns the singleton instance of the place repository
+func GetPlaceRepository() *PlaceRepository {
+	if placeRepository == nil {
+		placeRepository = &PlaceRepository{}
+	}
+	return placeRepository
+}
+
+// placeAddressScore is the score of a place whose address matches the query, it ranks below every match on a name
+const placeAddressScore = 1
+
+// PlaceFilter narrows the places returned by Search
+// The zero value of a field does not filter
+type PlaceFilter struct {
+	// Query is matched against the name, the aliases and the address
+	Query string
+	// Cuisine is a tag the place must have, e.g. italian
+	Cuisine string
+	// Dietary is a dietary option the place must offer, e.g. vegan
+	Dietary string
+	// Diets are the diets the place must all cater for, e.g. those of a user
+	Diets []models.Diet
+	// MaxPrice is the highest price level, the places with an unknown price are kept
+	MaxPrice int
+	Limit    int
+	Fuzzy    bool
+}
+
+// Get returns a place by id
+func (r *PlaceRepository) Get(id string) (*models.Place, error) {
+	var place models.Place
+	where := models.Place{}
+	stringToUuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	where.ID = stringToUuid
+	_, err = First(&where, &place, []string{})
+	if err != nil {
+		return nil, err
+	}
+	return &place, err
+}
+
+// GetByName returns a place by name
+// The name is compared regardless of its case and whitespace
+// The aliases are used when no place has the name, they are shared by every organization like the places
+func (r *PlaceRepository) GetByName(name string) (*models.Place, error) {
+	var place models.Place
+	if err := firstByNameKey(unscoped.db(), models.AliasKindPlace, name, &place); err != nil {
+		return nil, err
+	}
+	return &place, nil
+}
+
+// GetByNameAndAddress returns the place with the name at the address
+// Both are compared regardless of their case and whitespace, so two branches of a chain are told apart
+func (r *PlaceRepository) GetByNameAndAddress(name string, address string) (*models.Place, error) {
+	var places []models.Place
+	if err := db.GetDB().Where("name_key = ?", models.NameKey(name)).Order("id asc").Find(&places).Error; err != nil {
+		return nil, err
+	}
+	for i := range places {
+		if models.NameKey(places[i].Address) == models.NameKey(address) {
+			return &places[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// All returns all places
+// The places are ordered by name ascending
+func (r *PlaceRepository) All() (*[]models.Place, error) {
+	var places []models.Place
+	err := Find(&models.Place{}, &places, []string{}, "name asc")
+	return &places, err
+}
+
+// Add adds a place to the database
+func (r *PlaceRepository) Add(place *models.Place) error {
+	return Create(place)
+}
+
+// Update updates a place in the database
+func (r *PlaceRepository) Update(place *models.Place) error {
+	return db.GetDB().Save(place).Error
+}
+
+// Delete deletes a place from the database
+// The lunches and invitations at the place keep their free text location
+// The aliases and the reviews of the place are deleted too
+// The places are shared by every organization, so are the lunches and invitations updated
+func (r *PlaceRepository) Delete(place *models.Place) error {
+	return unscoped.db().Transaction(func(tx *gorm.DB) error {
+		if err := unlinkPlace(tx, place.ID, nil); err != nil {
+			return err
+		}
+		if err := tx.Where("place_id = ?", place.ID).Delete(&models.Review{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("kind = ? AND target_id = ?", models.AliasKindPlace, place.ID).Delete(&models.Alias{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(place).Error
+	})
+}
+
+// Merge moves every lunch, invitation and review of the duplicate place to the canonical one
+// The aliases of the duplicate are moved to the canonical place and the duplicate name becomes an alias
+// The duplicate place is deleted
+// Everything is done in one transaction, in every organization since the places are shared
+func (r *PlaceRepository) Merge(duplicate *models.Place, canonical *models.Place) error {
+	return unscoped.db().Transaction(func(tx *gorm.DB) error {
+		if err := unlinkPlace(tx, duplicate.ID, &canonical.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Review{}).Where("place_id = ?", duplicate.ID).Update("place_id", canonical.ID).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&models.Alias{}).Where("kind = ? AND target_id = ?", models.AliasKindPlace, duplicate.ID).Update("target_id", canonical.ID).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(duplicate).Error; err != nil {
+			return err
+		}
+		if models.NameKey(duplicate.Name) == canonical.NameKey {
+			return nil
+		}
+		var existing int64
+		err = tx.Model(&models.Alias{}).Where("kind = ? AND name_key = ?", models.AliasKindPlace, models.NameKey(duplicate.Name)).Count(&existing).Error
+		if err != nil || existing > 0 {
+			return err
+		}
+		return tx.Create(&models.Alias{Kind: models.AliasKindPlace, Name: duplicate.Name, TargetID: canonical.ID}).Error
+	})
+}
+
+// unlinkPlace points the lunches and invitations of the place to another place, or to none when it is nil
+func unlinkPlace(tx *gorm.DB, placeID uuid.UUID, replacement *uuid.UUID) error {
+	if err := tx.Model(&models.Lunch{}).Where("place_id = ?", placeID).Update("place_id", replacement).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Invitation{}).Where("place_id = ?", placeID).Update("place_id", replacement).Error
+}
+
+// Search returns the places matching the filter
+// The places are ranked by relevance of their name, aliases and address when there is a query, by name otherwise
+// The matching ignores the case and the diacritics, so "kantina" matches "Kantína"
+// The ranking is done in Go so it works on every database driver
+func (r *PlaceRepository) Search(filter PlaceFilter) ([]models.Place, error) {
+	places, err := r.All()
+	if err != nil {
+		return nil, err
+	}
+	folded := textsearch.Fold(filter.Query)
+	scores := map[uuid.UUID]int{}
+	if folded != "" {
+		for _, place := range *places {
+			if score, ok := textsearch.Score(folded, textsearch.Fold(place.Name), filter.Fuzzy); ok {
+				scores[place.ID] = score
+			} else if _, ok := textsearch.Score(folded, textsearch.Fold(place.Address), false); ok {
+				scores[place.ID] = placeAddressScore
+			}
+		}
+		var aliases []models.Alias
+		if err := unscoped.db().Where("kind = ?", models.AliasKindPlace).Find(&aliases).Error; err != nil {
+			return nil, err
+		}
+		for _, alias := range aliases {
+			// An alias ranks just below the same match on the place name
+			if score, ok := textsearch.Score(folded, textsearch.Fold(alias.Name), filter.Fuzzy); ok && score-1 > scores[alias.TargetID] {
+				scores[alias.TargetID] = score - 1
+			}
+		}
+	}
+
+	results := []models.Place{}
+	for _, place := range *places {
+		if _, ok := scores[place.ID]; folded != "" && !ok {
+			continue
+		}
+		if filter.Cuisine != "" && !place.Cuisines.Has(filter.Cuisine) {
+			continue
+		}
+		if filter.Dietary != "" && !place.Dietary.Has(filter.Dietary) {
+			continue
+		}
+		if !place.Offers(filter.Diets) {
+			continue
+		}
+		if filter.MaxPrice > 0 && place.PriceLevel > filter.MaxPrice {
+			continue
+		}
+		results = append(results, place)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return scores[results[i].ID] > scores[results[j].ID]
+	})
+	if filter.Limit > 0 && len(results) > filter.Limit {
+		results = results[:filter.Limit]
+	}
+	return results, nil
+}
+
+// Within returns the distance in meters of the places within radius meters of the point
+// The distances are computed by PostGIS, it returns an error when the database does not support it
+func (r *PlaceRepository) Within(center geo.Point, radius float64) (map[uuid.UUID]float64, error) {
+	var rows []struct {
+		ID       uuid.UUID
+		Distance float64
+	}
+	err := db.GetDB().Raw("SELECT id, ST_Distance(ST_MakePoint(longitude, latitude)::geography, ST_MakePoint(?, ?)::geography) AS distance FROM places "+
+		"WHERE latitude IS NOT NULL AND longitude IS NOT NULL AND ST_DWithin(ST_MakePoint(longitude, latitude)::geography, ST_MakePoint(?, ?)::geography, ?)",
+		center.Longitude, center.Latitude, center.Longitude, center.Latitude, radius).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	distances := make(map[uuid.UUID]float64, len(rows))
+	for _, row := range rows {
+		distances[row.ID] = row.Distance
+	}
+	return distances, nil
+}
@@ -0,0 +1,174 @@
+package persistence
+
+import (
+	"context"
+	"github.com/google/uuid"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+)
+
+// ReviewRepository is a repository for the reviews of the places
+// It is used to access the database
+// It is a singleton
+type ReviewRepository struct {
+	scope
+}
+
+var reviewRepository *ReviewRepository
+
+// GetReviewRepository returns the review repository
+// It creates a new one if it does not exist
+// It returns the singleton instance of the review repository
+func GetReviewRepository() *ReviewRepository {
+	if reviewRepository == nil {
+		reviewRepository = &ReviewRepository{}
+	}
+	return reviewRepository
+}
+
+// Scoped returns a copy of the review repository scoped to the organization of ctx
+// It only sees the data of the organization, see db.WithOrganization
+func (r *ReviewRepository) Scoped(ctx context.Context) *ReviewRepository {
+	return &ReviewRepository{scope: scope{ctx: ctx}}
+}
+
+// Unscoped returns a copy of the review repository seeing the data of every organization
+// It is only meant for the jobs, the commands and the lookups made before the organization of a user is known
+func (r *ReviewRepository) Unscoped() *ReviewRepository {
+	return &ReviewRepository{scope: unscoped}
+}
+
+// PlaceRating is a place with the average rating given by a group of users
+type PlaceRating struct {
+	Place   models.Place `json:"place"`
+	Rating  float64      `json:"rating"`
+	Reviews int          `json:"reviews"`
+}
+
+// Get returns a review by id
+func (r *ReviewRepository) Get(id string) (*models.Review, error) {
+	var review models.Review
+	where := models.Review{}
+	stringToUuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	where.ID = stringToUuid
+	_, err = r.first(&where, &review, []string{})
+	if err != nil {
+		return nil, err
+	}
+	return &review, err
+}
+
+// GetForInvitation returns the review of the lunch of the invitation written by the user
+func (r *ReviewRepository) GetForInvitation(userID uuid.UUID, invitationID uuid.UUID) (*models.Review, error) {
+	var review models.Review
+	_, err := r.first(&models.Review{UserID: userID, InvitationID: invitationID}, &review, []string{})
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// ForPlace returns the reviews of the place, the newest first
+// The authors are eager loaded
+func (r *ReviewRepository) ForPlace(placeID uuid.UUID) (*[]models.Review, error) {
+	var reviews []models.Review
+	err := r.find(&models.Review{PlaceID: placeID}, &reviews, []string{"User"}, "created_at desc")
+	return &reviews, err
+}
+
+// ForUser returns the reviews written by the user, the newest first
+func (r *ReviewRepository) ForUser(userID uuid.UUID) (*[]models.Review, error) {
+	var reviews []models.Review
+	err := r.find(&models.Review{UserID: userID}, &reviews, []string{}, "created_at desc")
+	return &reviews, err
+}
+
+// Add adds a review to the database
+func (r *ReviewRepository) Add(review *models.Review) error {
+	return r.db().Omit("User").Create(review).Error
+}
+
+// Update updates a review in the database
+func (r *ReviewRepository) Update(review *models.Review) error {
+	return r.db().Omit("User").Save(review).Error
+}
+
+// Delete deletes a review from the database
+func (r *ReviewRepository) Delete(review *models.Review) error {
+	return r.db().Unscoped().Delete(review).Error
+}
+
+// Rate sets the average rating and the number of reviews of the places from the reviews of the organization of the scope
+// The places are shared by every organization, the reviews of the other organizations are not counted
+func (r *ReviewRepository) Rate(places []*models.Place) error {
+	if len(places) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(places))
+	for i, place := range places {
+		ids[i] = place.ID
+	}
+	var rows []struct {
+		PlaceID uuid.UUID
+		Rating  float64
+		Count   int
+	}
+	err := r.db().Model(&models.Review{}).Select("place_id, AVG(rating) AS rating, COUNT(*) AS count").
+		Where("place_id IN ?", ids).Group("place_id").Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	ratings := make(map[uuid.UUID]int, len(rows))
+	for i, row := range rows {
+		ratings[row.PlaceID] = i
+	}
+	for _, place := range places {
+		place.Rating, place.RatingCount = 0, 0
+		if i, ok := ratings[place.ID]; ok {
+			place.Rating, place.RatingCount = rows[i].Rating, rows[i].Count
+		}
+	}
+	return nil
+}
+
+// TopPlacesForArea returns the places best rated by the users of the area
+// Only the places with at least minReviews reviews from the area are ranked
+// The places are ordered by average rating, then by number of reviews
+func (r *ReviewRepository) TopPlacesForArea(areaID uuid.UUID, minReviews int, limit int) ([]PlaceRating, error) {
+	var rows []struct {
+		PlaceID uuid.UUID
+		Rating  float64
+		Reviews int
+	}
+	// The users of the area are users of its organization, so are their reviews
+	err := r.db().Raw("SELECT r.place_id, AVG(r.rating) AS rating, COUNT(*) AS reviews FROM reviews r "+
+		"JOIN user_areas ua ON ua.user_id = r.user_id WHERE ua.area_id = ? "+
+		"GROUP BY r.place_id HAVING COUNT(*) >= ? ORDER BY rating DESC, reviews DESC LIMIT ?",
+		areaID, minReviews, limit).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.PlaceID
+	}
+	var places []models.Place
+	if len(ids) > 0 {
+		if err := r.db().Where("id IN ?", ids).Find(&places).Error; err != nil {
+			return nil, err
+		}
+	}
+	byID := make(map[uuid.UUID]models.Place, len(places))
+	for _, place := range places {
+		byID[place.ID] = place
+	}
+	ratings := []PlaceRating{}
+	for _, row := range rows {
+		if place, ok := byID[row.PlaceID]; ok {
+			ratings = append(ratings, PlaceRating{Place: place, Rating: row.Rating, Reviews: row.Reviews})
+		}
+	}
+	return ratings, nil
+}
@@ -0,0 +1,57 @@
+package persistence
+
+import (
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"gorm.io/gorm"
+	"time"
+)
+
+// SSOLoginRepository is a repository for the pending single sign-on logins
+// It is used to access the database
+// It is a singleton
+type SSOLoginRepository struct{}
+
+var ssoLoginRepository *SSOLoginRepository
+
+// GetSSOLoginRepository returns the single sign-on login repository
+// It creates a new one if it does not exist
+// It returns the singleton instance of the single sign-on login repository
+func GetSSOLoginRepository() *SSOLoginRepository {
+	if ssoLoginRepository == nil {
+		ssoLoginRepository = &SSOLoginRepository{}
+	}
+	return ssoLoginRepository
+}
+
+// Add adds a single sign-on login to the database
+func (r *SSOLoginRepository) Add(login *models.SSOLogin) error {
+	return Create(login)
+}
+
+// Take returns the single sign-on login of a state hash and deletes it, so that a state is used once
+func (r *SSOLoginRepository) Take(stateHash string) (*models.SSOLogin, error) {
+	var login models.SSOLogin
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("state_hash = ?", stateHash).First(&login).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&login)
+		if result.Error == nil && result.RowsAffected == 0 {
+			// A concurrent callback took it first
+			return gorm.ErrRecordNotFound
+		}
+		return result.Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &login, nil
+}
+
+// DeleteExpired deletes the single sign-on logins which expired before the time
+// It returns the number of deleted logins
+func (r *SSOLoginRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := db.GetDB().Where("expires_at < ?", before).Delete(&models.SSOLogin{})
+	return result.RowsAffected, result.Error
+}
@@ -1,12 +1,40 @@
 package persistence
 
 import (
+	"context"
 	"github.com/google/uuid"
-	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
 	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/tasks"
+	"gorm.io/gorm"
+	"time"
 )
 
-type TaskRepository struct{}
+// TaskRepository is a repository for tasks
+// It is used to access the database
+// It is a singleton
+type TaskRepository struct {
+	scope
+}
+
+// TaskFilter narrows the tasks of a user
+// The fields that are the zero value for their type are ignored
+type TaskFilter struct {
+	Status       string     `form:"status"`
+	Priority     string     `form:"priority"`
+	DueBefore    *time.Time `form:"due_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	DueAfter     *time.Time `form:"due_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	LunchID      *uuid.UUID `form:"lunch_id"`
+	InvitationID *uuid.UUID `form:"invitation_id"`
+	Kind         string     `form:"kind"`
+	BuddyID      *uuid.UUID `form:"buddy_id"`
+}
+
+// Engagement counts the completed tasks of a user
+// The shared icebreakers are the icebreakers completed by both buddies
+type Engagement struct {
+	TasksCompleted       int64 `json:"tasks_completed"`
+	IcebreakersCompleted int64 `json:"icebreakers_completed"`
+	IcebreakersShared    int64 `json:"icebreakers_shared"`
+}
 
 // Singleton
 // The singleton instance of the TaskRepository
@@ -25,6 +53,18 @@ func GetTaskRepository() *TaskRepository {
 	return taskRepository
 }
 
+// Scoped returns a copy of the task repository scoped to the organization of ctx
+// It only sees the data of the organization, see db.WithOrganization
+func (r *TaskRepository) Scoped(ctx context.Context) *TaskRepository {
+	return &TaskRepository{scope: scope{ctx: ctx}}
+}
+
+// Unscoped returns a copy of the task repository seeing the data of every organization
+// It is only meant for the jobs, the commands and the lookups made before the organization of a user is known
+func (r *TaskRepository) Unscoped() *TaskRepository {
+	return &TaskRepository{scope: unscoped}
+}
+
 // Get returns a task by id
 // The user is eager loaded
 func (r *TaskRepository) Get(id string) (*models.Task, error) {
@@ -36,7 +76,7 @@ func (r *TaskRepository) Get(id string) (*models.Task, error) {
 		return nil, err
 	}
 	where.ID = stringToUuid //uuid.Must(uuid.Parse(id))
-	_, err = First(&where, &task, []string{"User"})
+	_, err = r.first(&where, &task, []string{"User"})
 	if err != nil {
 		return nil, err
 	}
@@ -52,7 +92,7 @@ func (r *TaskRepository) Get(id string) (*models.Task, error) {
 // and pass it to this function
 func (r *TaskRepository) All() (*[]models.Task, error) {
 	var tasks []models.Task
-	err := Find(&models.Task{}, &tasks, []string{"User"}, "id asc")
+	err := r.find(&models.Task{}, &tasks, []string{"User"}, "id asc")
 	return &tasks, err
 }
 
@@ -69,26 +109,121 @@ func (r *TaskRepository) All() (*[]models.Task, error) {
 // and pass it to this function
 func (r *TaskRepository) Query(q *models.Task) (*[]models.Task, error) {
 	var tasks []models.Task
-	err := Find(&q, &tasks, []string{"User"}, "id asc")
+	err := r.find(&q, &tasks, []string{"User"}, "id asc")
 	return &tasks, err
 }
 
 // Add adds a new task to the database
 // The user is not eager loaded
 func (r *TaskRepository) Add(task *models.Task) error {
-	err := Create(&task)
-	err = Save(&task)
+	err := r.create(&task)
+	err = r.save(&task)
 	return err
 }
 
 // Update updates a task in the database
 // The user is not eager loaded
 func (r *TaskRepository) Update(task *models.Task) error {
-	return db.GetDB().Omit("User").Save(&task).Error
+	return r.db().Omit("User").Save(&task).Error
 }
 
 // Delete deletes a task from the database
 // The user is not eager loaded
 func (r *TaskRepository) Delete(task *models.Task) error {
-	return db.GetDB().Unscoped().Delete(&task).Error
+	return r.db().Unscoped().Delete(&task).Error
+}
+
+// GetForUser returns a task by id if it belongs to the user
+func (r *TaskRepository) GetForUser(userID uuid.UUID, id string) (*models.Task, error) {
+	var task models.Task
+	stringToUuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	where := models.Task{UserID: userID}
+	where.ID = stringToUuid
+	_, err = r.first(&where, &task, []string{})
+	if err != nil {
+		return nil, err
+	}
+	return &task, err
+}
+
+// QueryForUser returns the tasks of the user that match the filter
+// The tasks are ordered by due date, the tasks without a due date come last
+func (r *TaskRepository) QueryForUser(userID uuid.UUID, filter TaskFilter) (*[]models.Task, error) {
+	var tasks []models.Task
+	query := r.db().Where(&models.Task{
+		UserID:       userID,
+		Status:       filter.Status,
+		Priority:     filter.Priority,
+		LunchID:      filter.LunchID,
+		InvitationID: filter.InvitationID,
+		Kind:         filter.Kind,
+		BuddyID:      filter.BuddyID,
+	})
+	if filter.DueBefore != nil {
+		query = query.Where("due_date < ?", filter.DueBefore)
+	}
+	if filter.DueAfter != nil {
+		query = query.Where("due_date >= ?", filter.DueAfter)
+	}
+	err := query.Order("CASE WHEN due_date IS NULL THEN 1 ELSE 0 END, due_date asc, created_at asc").Find(&tasks).Error
+	return &tasks, err
+}
+
+// CompleteForUser marks the given tasks of the user as done
+// The ids of other users are ignored
+// It returns the number of completed tasks
+func (r *TaskRepository) CompleteForUser(userID uuid.UUID, ids []uuid.UUID) (int64, error) {
+	now := time.Now()
+	result := r.db().Model(&models.Task{}).
+		Where("user_id = ? AND id IN ? AND status <> ?", userID, ids, models.StatusDone).
+		Updates(map[string]interface{}{"status": models.StatusDone, "completed_at": now, "updated_at": now})
+	return result.RowsAffected, result.Error
+}
+
+// UsedTemplates returns the ids of the icebreaker templates already given to the user for the buddy
+func (r *TaskRepository) UsedTemplates(userID uuid.UUID, buddyID uuid.UUID) (map[uuid.UUID]bool, error) {
+	var ids []uuid.UUID
+	err := r.db().Model(&models.Task{}).
+		Where("user_id = ? AND buddy_id = ? AND kind = ? AND template_id IS NOT NULL", userID, buddyID, models.KindIcebreaker).
+		Pluck("template_id", &ids).Error
+	used := map[uuid.UUID]bool{}
+	for _, id := range ids {
+		used[id] = true
+	}
+	return used, err
+}
+
+// Counterpart returns the copy of a shared icebreaker task owned by the buddy
+func (r *TaskRepository) Counterpart(task *models.Task) (*models.Task, error) {
+	var counterpart models.Task
+	err := r.db().
+		Where("user_id = ? AND buddy_id = ? AND template_id = ? AND kind = ?", task.BuddyID, task.UserID, task.TemplateID, models.KindIcebreaker).
+		First(&counterpart).Error
+	if err != nil {
+		return nil, err
+	}
+	return &counterpart, nil
+}
+
+// Engagement returns the completion counts of the user
+func (r *TaskRepository) Engagement(userID uuid.UUID) (*Engagement, error) {
+	var engagement Engagement
+	done := r.db().Model(&models.Task{}).Where("user_id = ? AND status = ?", userID, models.StatusDone)
+	if err := done.Session(&gorm.Session{}).Count(&engagement.TasksCompleted).Error; err != nil {
+		return nil, err
+	}
+	if err := done.Session(&gorm.Session{}).Where("kind = ?", models.KindIcebreaker).Count(&engagement.IcebreakersCompleted).Error; err != nil {
+		return nil, err
+	}
+	err := r.db().Table("tasks AS mine").
+		Joins("JOIN tasks AS theirs ON theirs.user_id = mine.buddy_id AND theirs.buddy_id = mine.user_id AND theirs.template_id = mine.template_id").
+		Where("mine.user_id = ? AND mine.kind = ? AND mine.status = ? AND theirs.status = ?", userID, models.KindIcebreaker, models.StatusDone, models.StatusDone).
+		Count(&engagement.IcebreakersShared).Error
+	if err != nil {
+		return nil, err
+	}
+	return &engagement, nil
 }
@@ -0,0 +1,172 @@
+package persistence
+
+import (
+	"github.com/google/uuid"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/textsearch"
+	"gorm.io/gorm"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// taxonomyLink describes the join table linking users to a taxonomy
+type taxonomyLink struct {
+	// entries is the table of the taxonomy, e.g. hobbies
+	entries string
+	// table is the join table, e.g. user_hobbies
+	table string
+	// column is the column of the join table referencing the taxonomy, e.g. hobby_id
+	column string
+	// kind is the alias kind of the taxonomy
+	kind string
+}
+
+var (
+	hobbyLink    = taxonomyLink{entries: "hobbies", table: "user_hobbies", column: "hobby_id", kind: models.AliasKindHobby}
+	languageLink = taxonomyLink{entries: "languages", table: "user_languages", column: "language_id", kind: models.AliasKindLanguage}
+	areaLink     = taxonomyLink{entries: "areas", table: "user_areas", column: "area_id", kind: models.AliasKindArea}
+	dietLink     = taxonomyLink{entries: "diets", table: "user_diets", column: "diet_id", kind: models.AliasKindDiet}
+	cuisineLink  = taxonomyLink{entries: "cuisines", table: "user_cuisines", column: "cuisine_id", kind: models.AliasKindCuisine}
+)
+
+// Suggestion is a taxonomy entry proposed by the autocomplete
+// The popularity is the number of users linked to the entry
+type Suggestion struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	Popularity int64     `json:"popularity"`
+}
+
+// firstByNameKey finds the taxonomy entry with the same name key as name
+// When there is none, the name is resolved through the aliases of the given kind
+// The aliases are those of the organization of the database context
+// It returns gorm.ErrRecordNotFound if the name is unknown
+func firstByNameKey(database *gorm.DB, kind string, name string, out interface{}) error {
+	err := database.Where("name_key = ?", models.NameKey(name)).First(out).Error
+	if err == nil {
+		return nil
+	}
+	targetID, aliasErr := GetAliasRepository().Scoped(database.Statement.Context).Resolve(kind, name)
+	if aliasErr != nil {
+		return err
+	}
+	return database.Where("id = ?", targetID).First(out).Error
+}
+
+// merge moves every user link from the duplicate entry to the canonical one
+// The users linked to both entries keep a single link
+// The aliases of the duplicate are moved to the canonical entry and the duplicate name becomes an alias
+// The duplicate entry is deleted
+// Everything is done in one transaction, the aliases are those of the organization of the database context
+func (l taxonomyLink) merge(database *gorm.DB, duplicate interface{}, duplicateID uuid.UUID, duplicateName string, canonicalID uuid.UUID) error {
+	return database.Transaction(func(tx *gorm.DB) error {
+		// The derived table is needed by MySQL which cannot select from the table it deletes from
+		err := tx.Exec("DELETE FROM "+l.table+" WHERE "+l.column+" = ? AND user_id IN (SELECT user_id FROM (SELECT user_id FROM "+l.table+" WHERE "+l.column+" = ?) AS linked)",
+			duplicateID, canonicalID).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Exec("UPDATE "+l.table+" SET "+l.column+" = ? WHERE "+l.column+" = ?", canonicalID, duplicateID).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&models.Alias{}).Where("kind = ? AND target_id = ?", l.kind, duplicateID).Update("target_id", canonicalID).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(duplicate).Error; err != nil {
+			return err
+		}
+		var existing int64
+		err = tx.Model(&models.Alias{}).Where("kind = ? AND name_key = ?", l.kind, models.NameKey(duplicateName)).Count(&existing).Error
+		if err != nil || existing > 0 {
+			return err
+		}
+		return tx.Create(&models.Alias{Kind: l.kind, Name: duplicateName, TargetID: canonicalID}).Error
+	})
+}
+
+// searchCandidates is the number of entries and aliases the search ranks at most
+// The most popular of the entries matching the prefix of the query are kept
+const searchCandidates = 200
+
+// searchPattern returns the LIKE pattern of the search keys starting with prefix
+func searchPattern(prefix string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(prefix) + "%"
+}
+
+// search returns the entries matching query ranked by relevance and popularity
+// The matching ignores the case and the diacritics, so "citanie" matches "Čítanie"
+// The aliases match too and suggest their canonical entry
+// The candidates are the entries and aliases whose search key or one of its words starts with the query,
+// or with its first letter when fuzzy, they are ranked in Go so it works on every database driver
+// Only the entries of the organization of the database context are suggested
+func (l taxonomyLink) search(database *gorm.DB, query string, limit int, fuzzy bool) ([]Suggestion, error) {
+	folded := textsearch.Fold(query)
+	prefix := folded
+	if fuzzy && folded != "" {
+		// A typo after the first letter still matches
+		_, size := utf8.DecodeRuneInString(folded)
+		prefix = folded[:size]
+	}
+	pattern := searchPattern(prefix)
+
+	var aliases []models.Alias
+	err := database.Where("kind = ?", l.kind).Where("search_key LIKE ? OR search_key LIKE ?", pattern, "% "+pattern).
+		Limit(searchCandidates).Find(&aliases).Error
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]uuid.UUID, 0, len(aliases))
+	for _, alias := range aliases {
+		targets = append(targets, alias.TargetID)
+	}
+	candidates := "e.search_key LIKE ? OR e.search_key LIKE ?"
+	arguments := []interface{}{pattern, "% " + pattern}
+	if len(targets) > 0 {
+		candidates += " OR e.id IN ?"
+		arguments = append(arguments, targets)
+	}
+	var entries []Suggestion
+	err = scopeTable(database.Table(l.entries+" e"), "e").Select("e.id, e.name, COUNT(j.user_id) AS popularity").
+		Joins("LEFT JOIN "+l.table+" j ON j."+l.column+" = e.id").Where(candidates, arguments...).Group("e.id, e.name").
+		Order("popularity desc, e.name asc").Limit(searchCandidates).Scan(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	scores := map[uuid.UUID]int{}
+	for _, entry := range entries {
+		if score, ok := textsearch.Score(folded, textsearch.Fold(entry.Name), fuzzy); ok {
+			scores[entry.ID] = score
+		}
+	}
+	for _, alias := range aliases {
+		// An alias ranks just below the same match on the entry name
+		if score, ok := textsearch.Score(folded, textsearch.Fold(alias.Name), fuzzy); ok && score-1 > scores[alias.TargetID] {
+			scores[alias.TargetID] = score - 1
+		}
+	}
+
+	var suggestions []Suggestion
+	for _, entry := range entries {
+		if _, ok := scores[entry.ID]; ok {
+			suggestions = append(suggestions, entry)
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if scores[a.ID] != scores[b.ID] {
+			return scores[a.ID] > scores[b.ID]
+		}
+		if a.Popularity != b.Popularity {
+			return a.Popularity > b.Popularity
+		}
+		return a.Name < b.Name
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,167 @@
+package persistence
+
+import (
+	"context"
+	"github.com/google/uuid"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"gorm.io/gorm"
+)
+
+// TeamRepository is a repository for the teams and the departments
+// It is used to access the database
+// It is a singleton
+type TeamRepository struct {
+	scope
+}
+
+var teamRepository *TeamRepository
+
+// GetTeamRepository returns the team repository
+// It creates a new one if it does not exist
+// It returns the singleton instance of the team repository
+func GetTeamRepository() *TeamRepository {
+	if teamRepository == nil {
+		teamRepository = &TeamRepository{}
+	}
+	return teamRepository
+}
+
+// Scoped returns a copy of the team repository scoped to the organization of ctx
+// It only sees the data of the organization, see db.WithOrganization
+func (r *TeamRepository) Scoped(ctx context.Context) *TeamRepository {
+	return &TeamRepository{scope: scope{ctx: ctx}}
+}
+
+// Unscoped returns a copy of the team repository seeing the data of every organization
+// It is only meant for the jobs, the commands and the lookups made before the organization of a user is known
+func (r *TeamRepository) Unscoped() *TeamRepository {
+	return &TeamRepository{scope: unscoped}
+}
+
+// Get returns a team by id
+// The department is eager loaded
+func (r *TeamRepository) Get(id string) (*models.Team, error) {
+	var team models.Team
+	where := models.Team{}
+	stringToUuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	where.ID = stringToUuid
+	_, err = r.first(&where, &team, []string{"Department"})
+	if err != nil {
+		return nil, err
+	}
+	return &team, err
+}
+
+// GetByName returns a team by name
+// The name is compared regardless of its case and whitespace
+func (r *TeamRepository) GetByName(name string) (*models.Team, error) {
+	var team models.Team
+	if err := r.db().Preload("Department").Where("name_key = ?", models.NameKey(name)).First(&team).Error; err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// All returns all teams
+// The teams are ordered by name ascending and their departments are eager loaded
+func (r *TeamRepository) All() (*[]models.Team, error) {
+	var teams []models.Team
+	err := r.find(&models.Team{}, &teams, []string{"Department"}, "name asc")
+	return &teams, err
+}
+
+// Members returns the usernames of the members of the team, ordered by username
+func (r *TeamRepository) Members(teamID uuid.UUID) ([]string, error) {
+	usernames := []string{}
+	err := r.db().Model(&models.User{}).Where("team_id = ?", teamID).Order("username asc").Pluck("username", &usernames).Error
+	return usernames, err
+}
+
+// Add adds a team to the database
+func (r *TeamRepository) Add(team *models.Team) error {
+	return r.db().Omit("Department").Create(team).Error
+}
+
+// Update updates a team in the database
+func (r *TeamRepository) Update(team *models.Team) error {
+	return r.db().Omit("Department").Save(team).Error
+}
+
+// Delete deletes a team from the database
+// Its members are left without team
+func (r *TeamRepository) Delete(team *models.Team) error {
+	return r.db().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("team_id = ?", team.ID).Update("team_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(team).Error
+	})
+}
+
+// SetTeam makes the user a member of the team, or of no team when it is nil
+func (r *TeamRepository) SetTeam(user *models.User, team *models.Team) error {
+	user.Team = team
+	user.TeamID = nil
+	if team != nil {
+		user.TeamID = &team.ID
+	}
+	return r.db().Model(&models.User{}).Where("id = ?", user.ID).Update("team_id", user.TeamID).Error
+}
+
+// GetDepartment returns a department by id
+func (r *TeamRepository) GetDepartment(id string) (*models.Department, error) {
+	var department models.Department
+	where := models.Department{}
+	stringToUuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	where.ID = stringToUuid
+	_, err = r.first(&where, &department, []string{})
+	if err != nil {
+		return nil, err
+	}
+	return &department, err
+}
+
+// GetDepartmentByName returns a department by name
+// The name is compared regardless of its case and whitespace
+func (r *TeamRepository) GetDepartmentByName(name string) (*models.Department, error) {
+	var department models.Department
+	if err := r.db().Where("name_key = ?", models.NameKey(name)).First(&department).Error; err != nil {
+		return nil, err
+	}
+	return &department, nil
+}
+
+// AllDepartments returns all departments
+// The departments are ordered by name ascending
+func (r *TeamRepository) AllDepartments() (*[]models.Department, error) {
+	var departments []models.Department
+	err := r.find(&models.Department{}, &departments, []string{}, "name asc")
+	return &departments, err
+}
+
+// AddDepartment adds a department to the database
+func (r *TeamRepository) AddDepartment(department *models.Department) error {
+	return r.create(department)
+}
+
+// UpdateDepartment updates a department in the database
+func (r *TeamRepository) UpdateDepartment(department *models.Department) error {
+	return r.db().Save(department).Error
+}
+
+// DeleteDepartment deletes a department from the database
+// Its teams are left without department
+func (r *TeamRepository) DeleteDepartment(department *models.Department) error {
+	return r.db().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Team{}).Where("department_id = ?", department.ID).Update("department_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(department).Error
+	})
+}
@@ -1,16 +1,22 @@
 package persistence
 
 import (
+	"context"
+	"errors"
 	"github.com/google/uuid"
-	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	database "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/events"
 	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
 	"gorm.io/gorm"
+	"time"
 )
 
 // UserRepository is a repository for users
 // It is used to access the database
 // It is a singleton
-type UserRepository struct{}
+type UserRepository struct {
+	scope
+}
 
 var userRepository *UserRepository
 
@@ -24,6 +30,18 @@ func GetUserRepository() *UserRepository {
 	return userRepository
 }
 
+// Scoped returns a copy of the user repository scoped to the organization of ctx
+// It only sees the data of the organization, see db.WithOrganization
+func (r *UserRepository) Scoped(ctx context.Context) *UserRepository {
+	return &UserRepository{scope: scope{ctx: ctx}}
+}
+
+// Unscoped returns a copy of the user repository seeing the data of every organization
+// It is only meant for the jobs, the commands and the lookups made before the organization of a user is known
+func (r *UserRepository) Unscoped() *UserRepository {
+	return &UserRepository{scope: unscoped}
+}
+
 // Get returns a user by id
 // The role is eager loaded
 func (r *UserRepository) Get(id string) (*models.User, error) {
@@ -35,7 +53,7 @@ func (r *UserRepository) Get(id string) (*models.User, error) {
 		return nil, err
 	}
 	where.ID = stringToUuid
-	_, err = First(&where, &user, []string{"Hobbies", "Languages", "Lunch", "Buddies", "Blacklist", "Likes", "Areas"})
+	_, err = r.first(&where, &user, []string{"Hobbies", "Languages", "Lunch", "Buddies", "Blacklist", "Likes", "Areas", "Diets", "Cuisines", "Team.Department"})
 	if err != nil {
 		return nil, err
 	}
@@ -48,19 +66,63 @@ func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
 	var user models.User
 	where := models.User{}
 	where.Username = username
-	_, err := First(&where, &user, []string{"Hobbies", "Languages", "Lunch", "Buddies", "Blacklist", "Likes", "Areas"})
+	_, err := r.first(&where, &user, []string{"Hobbies", "Languages", "Lunch", "Buddies", "Blacklist", "Likes", "Areas", "Diets", "Cuisines", "Team.Department"})
 	if err != nil {
 		return nil, err
 	}
 	return &user, err
 }
 
+// GetByCalendarToken returns the user of a calendar feed token with its lunch
+func (r *UserRepository) GetByCalendarToken(token string) (*models.User, error) {
+	var user models.User
+	err := r.db().Preload("Lunch").Where("calendar_token = ?", token).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByOIDCSubject returns the user linked to a subject of the single sign-on provider
+func (r *UserRepository) GetByOIDCSubject(subject string) (*models.User, error) {
+	var user models.User
+	if err := r.db().Where("oidc_subject = ?", subject).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// LinkOIDCSubject links the user to a subject of the single sign-on provider
+func (r *UserRepository) LinkOIDCSubject(user *models.User, subject string) error {
+	user.OIDCSubject = &subject
+	return r.db().Model(&models.User{}).Where("id = ?", user.ID).
+		UpdateColumns(map[string]interface{}{"oidc_subject": subject, "updated_at": time.Now()}).Error
+}
+
+// SetCalendarToken replaces the calendar feed token of the user
+// The previous feed url stops working
+func (r *UserRepository) SetCalendarToken(user *models.User, token string) error {
+	user.CalendarToken = &token
+	return r.db().Model(&models.User{}).Where("id = ?", user.ID).Update("calendar_token", token).Error
+}
+
 // All returns all users
 // The users are ordered by id ascending
 // The role is eager loaded
 func (r *UserRepository) All() (*[]models.User, error) {
 	var users []models.User
-	err := Find(&models.User{}, &users, []string{"Hobbies", "Languages", "Lunch", "Buddies", "Blacklist", "Likes", "Areas"}, "id asc")
+	err := r.find(&models.User{}, &users, []string{"Hobbies", "Languages", "Lunch", "Buddies", "Blacklist", "Likes", "Areas", "Diets", "Cuisines", "Team.Department"}, "id asc")
+	return &users, err
+}
+
+// GetMany returns the users with the ids
+// The hobbies and the areas are eager loaded
+func (r *UserRepository) GetMany(ids []uuid.UUID) (*[]models.User, error) {
+	var users []models.User
+	if len(ids) == 0 {
+		return &users, nil
+	}
+	err := r.db().Preload("Hobbies").Preload("Areas").Where("id IN ?", ids).Find(&users).Error
 	return &users, err
 }
 
@@ -76,17 +138,92 @@ func (r *UserRepository) All() (*[]models.User, error) {
 // and pass it to the query function
 func (r *UserRepository) Query(q *models.User) (*[]models.User, error) {
 	var users []models.User
-	err := Find(&q, &users, []string{"Hobbies", "Languages", "Lunch", "Buddies", "Blacklist", "Likes", "Areas"}, "id asc")
+	err := r.find(&q, &users, []string{"Hobbies", "Languages", "Lunch", "Buddies", "Blacklist", "Likes", "Areas", "Diets", "Cuisines", "Team.Department"}, "id asc")
 	return &users, err
 }
 
 // Add adds a user to the database
-// The role is added to the database
 // The user is added to the database
+// A UserRegistered event is recorded in the same transaction
 func (r *UserRepository) Add(user *models.User) error {
-	err := Create(&user)
-	err = Save(&user)
-	return err
+	return r.Register(user, nil)
+}
+
+// ErrInviteCodeUsed is returned by Register when the invite code has no uses left or expired
+var ErrInviteCodeUsed = errors.New("the invite code is used up or expired")
+
+// ErrUsernameTaken is returned by Register when a user of any organization already has the username
+var ErrUsernameTaken = errors.New("the username is already taken")
+
+// UsernameTaken returns true if a user of any organization other than the given one has the username
+// The usernames are unique in the whole deployment, the users log in before their organization is known
+func (r *UserRepository) UsernameTaken(username string, except uuid.UUID) (bool, error) {
+	return usernameTaken(r.db(), username, except)
+}
+
+// usernameTaken returns true if a user other than the given one has the username, whatever the scope of database
+func usernameTaken(tx *gorm.DB, username string, except uuid.UUID) (bool, error) {
+	var existing int64
+	err := tx.WithContext(database.WithoutOrganization(tx.Statement.Context)).Model(&models.User{}).
+		Where("username = ? AND id <> ?", username, except).Count(&existing).Error
+	return existing > 0, err
+}
+
+// Register adds a registered user to the database and uses the invite code if there is one
+// The use of the code and the UserRegistered event are recorded in the same transaction
+// It returns ErrUsernameTaken if the username is already used and ErrInviteCodeUsed if the code cannot be used anymore
+func (r *UserRepository) Register(user *models.User, code *models.InviteCode) error {
+	return r.db().Transaction(func(tx *gorm.DB) error {
+		// The unique index refuses the concurrent registrations this check misses
+		if taken, err := usernameTaken(tx, user.Username, user.ID); err != nil {
+			return err
+		} else if taken {
+			return ErrUsernameTaken
+		}
+		if code != nil {
+			// The uses are checked by the update so that concurrent registrations cannot exceed them
+			result := tx.Model(&models.InviteCode{}).
+				Where("id = ? AND uses < max_uses AND (expires_at IS NULL OR expires_at > ?)", code.ID, time.Now()).
+				UpdateColumns(map[string]interface{}{"uses": gorm.Expr("uses + 1"), "updated_at": time.Now()})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrInviteCodeUsed
+			}
+			code.Uses++
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return events.Record(tx, events.UserRegistered, user.ID, events.UserRegisteredPayload{UserID: user.ID, Username: user.Username})
+	})
+}
+
+// VerifyEmail marks the username of the user as verified
+// Verifying it again keeps the first time
+func (r *UserRepository) VerifyEmail(user *models.User) error {
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	return r.db().Model(&models.User{}).Where("id = ? AND email_verified_at IS NULL", user.ID).
+		UpdateColumns(map[string]interface{}{"email_verified_at": now, "updated_at": now}).Error
+}
+
+// CompleteProfile marks the profile of the user as set up
+// A ProfileCompleted event is recorded in the same transaction the first time only
+func (r *UserRepository) CompleteProfile(user *models.User) error {
+	return r.db().Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).Where("id = ? AND first_login = ?", user.ID, false).
+			Updates(map[string]interface{}{"first_login": true, "updated_at": time.Now()})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		user.IsSetup = true
+		return events.Record(tx, events.ProfileCompleted, user.ID, events.ProfileCompletedPayload{UserID: user.ID})
+	})
 }
 
 // Update updates a user in the database
@@ -94,10 +231,10 @@ func (r *UserRepository) Add(user *models.User) error {
 // The user is updated in the database
 func (r *UserRepository) Update(user *models.User) error {
 	/*	var userRole models.UserRole
-		_, err := First(models.UserRole{UserID: user.ID}, &userRole, []string{})
+		_, err := r.first(models.UserRole{UserID: user.ID}, &userRole, []string{})
 		//userRole.RoleName = user.Role.RoleName
 		err = Save(&userRole)*/
-	err := db.GetDB().Omit("Hobbies", "Languages", "Lunch", "Buddies", "Blacklist", "Likes", "Areas").Save(&user).Error
+	err := r.db().Omit("Hobbies", "Languages", "Lunch", "Buddies", "Blacklist", "Likes", "Areas", "Diets", "Cuisines", "Team").Save(&user).Error
 	//user.Role = userRole
 	return err
 }
@@ -106,80 +243,130 @@ func (r *UserRepository) Update(user *models.User) error {
 // The role is deleted from the database
 // The user is deleted from the database
 func (r *UserRepository) Delete(user *models.User) error {
-	//err := db.GetDB().Unscoped().Delete(models.UserRole{UserID: user.ID}).Error
-	err := db.GetDB().Unscoped().Delete(&user).Error
+	//err := r.db().Unscoped().Delete(models.UserRole{UserID: user.ID}).Error
+	err := r.db().Unscoped().Delete(&user).Error
 	return err
 }
 
 func (r *UserRepository) ChangeUserArea(user *models.User, area *models.Area) error {
-	err := db.GetDB().Model(&user).Association("Areas").Replace(area)
+	err := r.db().Model(&user).Association("Areas").Replace(area)
 	return err
 }
 
 func (r *UserRepository) ChangeUserHobbies(user *models.User, hobbies []models.Hobby) error {
-	err := db.GetDB().Model(&user).Association("Hobbies").Replace(hobbies)
+	err := r.db().Model(&user).Association("Hobbies").Replace(hobbies)
 	return err
 }
 
 func (r *UserRepository) ChangeUserLanguages(user *models.User, languages []models.Language) error {
-	err := db.GetDB().Model(&user).Association("Languages").Replace(languages)
+	err := r.db().Model(&user).Association("Languages").Replace(languages)
 	return err
 }
 
+// ChangeUserDiets replaces the dietary restrictions and allergies of the user
+func (r *UserRepository) ChangeUserDiets(user *models.User, diets []models.Diet) error {
+	return r.db().Model(&user).Association("Diets").Replace(diets)
+}
+
+// ChangeUserCuisines replaces the favorite cuisines of the user
+func (r *UserRepository) ChangeUserCuisines(user *models.User, cuisines []models.Cuisine) error {
+	return r.db().Model(&user).Association("Cuisines").Replace(cuisines)
+}
+
 func (r *UserRepository) ChangeUserLunch(user *models.User, lunch *models.Lunch) error {
-	err := db.GetDB().Model(&user).Association("Lunch").Replace(lunch)
+	err := r.db().Model(&user).Association("Lunch").Replace(lunch)
 	return err
 }
 
 func (r *UserRepository) AddUserBuddies(user *models.User, buddies []models.User) error {
-	err := db.GetDB().Model(&user).Association("Buddies").Append(buddies)
+	err := r.db().Model(&user).Association("Buddies").Append(buddies)
 	return err
 }
 
+// MakeBuddies links both users as buddies of each other
+// A BuddyMatched event is recorded in the same transaction
+func (r *UserRepository) MakeBuddies(user *models.User, buddy *models.User) error {
+	return r.db().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Association("Buddies").Append([]models.User{*buddy}); err != nil {
+			return err
+		}
+		if err := tx.Model(buddy).Association("Buddies").Append([]models.User{*user}); err != nil {
+			return err
+		}
+		return events.Record(tx, events.BuddyMatched, user.ID, events.BuddyMatchedPayload{UserID: user.ID, BuddyID: buddy.ID})
+	})
+}
+
 func (r *UserRepository) RemoveUserBuddies(user *models.User, buddies []models.User) error {
-	err := db.GetDB().Model(&user).Association("Buddies").Delete(buddies)
+	err := r.db().Model(&user).Association("Buddies").Delete(buddies)
 	return err
 }
 
 func (r *UserRepository) AddUserBlacklist(user *models.User, blacklist []models.User) error {
-	err := db.GetDB().Model(&user).Association("Blacklist").Append(blacklist)
+	err := r.db().Model(&user).Association("Blacklist").Append(blacklist)
 	return err
 }
 
 func (r *UserRepository) RemoveUserBlacklist(user *models.User, blacklist []models.User) error {
-	err := db.GetDB().Model(&user).Association("Blacklist").Delete(blacklist)
+	err := r.db().Model(&user).Association("Blacklist").Delete(blacklist)
 	return err
 }
 
+// AddUserLikes adds the users to the likes of the user
+// A UserLiked event is recorded for every liked user in the same transaction
 func (r *UserRepository) AddUserLikes(user *models.User, likes []models.User) error {
-	err := db.GetDB().Model(&user).Association("Likes").Append(likes)
-	return err
+	return r.db().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Association("Likes").Append(likes); err != nil {
+			return err
+		}
+		for _, like := range likes {
+			if err := events.Record(tx, events.UserLiked, user.ID, events.UserLikedPayload{UserID: user.ID, LikedID: like.ID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (r *UserRepository) RemoveUserLikes(user *models.User, likes []models.User) error {
-	err := db.GetDB().Model(&user).Association("Likes").Delete(likes)
+	err := r.db().Model(&user).Association("Likes").Delete(likes)
 	return err
 }
 
 func (r *UserRepository) GetRandomFiveUsers() ([]models.User, error) {
 	var users []models.User
-	err := db.GetDB().Order(gorm.Expr("random()")).Limit(5).Find(&users).Error
+	err := r.db().Order(gorm.Expr("random()")).Limit(5).Find(&users).Error
 	return users, err
 }
 
 func (r *UserRepository) GetRandomFiveUsersThatShareAtLeastOneAreaAndAtLeastOneHobbyAndAtLeastOneLanguageAndHaveSameLunchTime(user *models.User) ([]models.User, error) {
 	var users []models.User
-	err := db.GetDB().Where("id != ?", user.ID).Where("id NOT IN (?)", user.Blacklist).Where("id NOT IN (?)", user.Buddies).Where("id NOT IN (?)", user.Likes).Where("id IN (?)", user.Areas).Where("id IN (?)", user.Hobbies).Where("id IN (?)", user.Languages).Order(gorm.Expr("random()")).Limit(5).Find(&users).Error
+	err := r.db().Where("id != ?", user.ID).Where("id NOT IN (?)", user.Blacklist).Where("id NOT IN (?)", user.Buddies).Where("id NOT IN (?)", user.Likes).Where("id IN (?)", user.Areas).Where("id IN (?)", user.Hobbies).Where("id IN (?)", user.Languages).Order(gorm.Expr("random()")).Limit(5).Find(&users).Error
 	return users, err
 }
 func (r *UserRepository) GetRandomFiveUsersWithAssociation() ([]models.User, error) {
 	var users []models.User
-	err := db.GetDB().Preload("Hobbies").Preload("Languages").Preload("Lunch").Preload("Buddies").Preload("Blacklist").Preload("Likes").Preload("Areas").Order(gorm.Expr("random()")).Limit(5).Find(&users).Error
+	err := r.db().Preload("Hobbies").Preload("Languages").Preload("Lunch").Preload("Buddies").Preload("Blacklist").Preload("Likes").Preload("Areas").Preload("Diets").Preload("Cuisines").Preload("Team.Department").Order(gorm.Expr("random()")).Limit(5).Find(&users).Error
 	return users, err
 }
 
 func (r *UserRepository) GetUserLunch(user *models.User) (*models.Lunch, error) {
 	var lunch models.Lunch
-	err := db.GetDB().Model(&user).Association("Lunch").Error
+	err := r.db().Model(&user).Association("Lunch").Error
 	return &lunch, err
 }
+
+// HasLiked returns true if the user likes the other user
+func (r *UserRepository) HasLiked(user *models.User, other *models.User) bool {
+	return r.db().Model(user).Where("id = ?", other.ID).Association("Likes").Count() > 0
+}
+
+// HasBlacklisted returns true if the user has the other user in its blacklist
+func (r *UserRepository) HasBlacklisted(user *models.User, other *models.User) bool {
+	return r.db().Model(user).Where("id = ?", other.ID).Association("Blacklist").Count() > 0
+}
+
+// AreBuddies returns true if the user has the other user among its buddies
+func (r *UserRepository) AreBuddies(user *models.User, other *models.User) bool {
+	return r.db().Model(user).Where("id = ?", other.ID).Association("Buddies").Count() > 0
+}
@@ -0,0 +1,96 @@
+package persistence
+
+import (
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/webhooks"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookRepository is a repository for webhooks and their deliveries
+// It is used to access the database
+// It is a singleton
+type WebhookRepository struct{}
+
+var webhookRepository *WebhookRepository
+
+// GetWebhookRepository returns the webhook repository
+// It creates a new one if it does not exist
+// It returns the singleton instance of the webhook repository
+func GetWebhookRepository() *WebhookRepository {
+	if webhookRepository == nil {
+		webhookRepository = &WebhookRepository{}
+	}
+	return webhookRepository
+}
+
+// Get returns a webhook by id
+func (r *WebhookRepository) Get(id string) (*models.Webhook, error) {
+	var webhook models.Webhook
+	stringToUuid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	err = db.GetDB().Where("id = ?", stringToUuid).First(&webhook).Error
+	return &webhook, err
+}
+
+// All returns all webhooks ordered by creation
+func (r *WebhookRepository) All() (*[]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := db.GetDB().Order("created_at").Find(&webhooks).Error
+	return &webhooks, err
+}
+
+// Active returns the active webhooks
+func (r *WebhookRepository) Active() (*[]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := db.GetDB().Where("active = ?", true).Find(&webhooks).Error
+	return &webhooks, err
+}
+
+// Add adds a webhook to the database
+func (r *WebhookRepository) Add(webhook *models.Webhook) error {
+	return Create(webhook)
+}
+
+// Update updates a webhook in the database
+func (r *WebhookRepository) Update(webhook *models.Webhook) error {
+	return Save(webhook)
+}
+
+// Delete deletes a webhook and its delivery log from the database
+func (r *WebhookRepository) Delete(webhook *models.Webhook) error {
+	if err := db.GetDB().Where("webhook_id = ?", webhook.ID).Delete(&models.Delivery{}).Error; err != nil {
+		return err
+	}
+	return db.GetDB().Delete(webhook).Error
+}
+
+// Deliveries returns a page of the delivery log of the webhook, the newest first
+// The status is ignored when it is empty
+func (r *WebhookRepository) Deliveries(webhookID uuid.UUID, status string, limit int, offset int) (*[]models.Delivery, error) {
+	var deliveries []models.Delivery
+	query := db.GetDB().Where("webhook_id = ?", webhookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at desc").Limit(limit).Offset(offset).Find(&deliveries).Error
+	return &deliveries, err
+}
+
+// Enqueue adds a pending delivery
+// A delivery of the same event to the same webhook is only added once, so redelivered events are ignored
+func (r *WebhookRepository) Enqueue(delivery *models.Delivery) error {
+	return db.GetDB().Clauses(clause.OnConflict{DoNothing: true}).Create(delivery).Error
+}
+
+// AddDelivery adds a delivery to the log
+func (r *WebhookRepository) AddDelivery(delivery *models.Delivery) error {
+	return Create(delivery)
+}
+
+// UpdateDelivery updates a delivery in the log
+func (r *WebhookRepository) UpdateDelivery(delivery *models.Delivery) error {
+	return Save(delivery)
+}
@@ -0,0 +1,79 @@
+// Package places finds the places to have lunch at near the users
+package places
+
+import (
+	"errors"
+	"log"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/geo"
+)
+
+// ErrNoOrigin is returned when none of the areas of the user has coordinates
+var ErrNoOrigin = errors.New("none of your areas has coordinates")
+
+// Nearby is a place and its distance in meters
+type Nearby struct {
+	users.Place
+	Distance float64 `json:"distance"`
+}
+
+// Origin returns the coordinates of the area the places are searched around
+// It is the area of the user with the id, or the first area of the user with coordinates when the id is empty
+// The user must be loaded with its areas
+// It returns ErrNoOrigin when the area has no coordinates
+func Origin(user *users.User, areaID string) (geo.Point, error) {
+	for i := range user.Areas {
+		area := &user.Areas[i]
+		if areaID != "" && area.ID.String() != areaID {
+			continue
+		}
+		if point, ok := area.Point(); ok {
+			return point, nil
+		}
+	}
+	return geo.Point{}, ErrNoOrigin
+}
+
+// Near returns the places matching the filter within radius meters of the point, the nearest first
+// The places without coordinates are left out
+// The distances are computed by PostGIS when geo.postgis is set, with the haversine formula otherwise or when PostGIS fails
+func Near(center geo.Point, radius float64, filter persistence.PlaceFilter) ([]Nearby, error) {
+	r := persistence.GetPlaceRepository()
+	limit := filter.Limit
+	filter.Limit = 0
+	matches, err := r.Search(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var distances map[uuid.UUID]float64
+	if config.GetConfig().Geo.PostGIS {
+		if distances, err = r.Within(center, radius); err != nil {
+			log.Println("postgis:", err)
+			distances = nil
+		}
+	}
+	results := []Nearby{}
+	for _, place := range matches {
+		distance, ok := distances[place.ID]
+		if distances == nil {
+			point, known := place.Point()
+			distance, ok = geo.Distance(center, point), known
+		}
+		if ok && distance <= radius {
+			results = append(results, Nearby{Place: place, Distance: distance})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Distance < results[j].Distance
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
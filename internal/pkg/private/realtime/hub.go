@@ -0,0 +1,85 @@
+package realtime
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// BufferSize is the number of events kept for a slow connection
+// The events beyond are dropped, the client reloads what it missed from the REST endpoints
+const BufferSize = 16
+
+// Event is a message pushed to the connections of a user
+// The type names the SSE event, e.g. notification or message
+type Event struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// Hub dispatches events to the connections of the users
+// A user can have any number of concurrent connections, e.g. several tabs
+// It is safe for concurrent use
+type Hub struct {
+	mu          sync.RWMutex
+	connections map[uuid.UUID]map[chan Event]struct{}
+}
+
+// hub is created eagerly because it is shared by concurrent requests
+var hub = NewHub()
+
+// GetHub returns the hub of the process
+// It returns the singleton instance of the hub
+func GetHub() *Hub {
+	return hub
+}
+
+// NewHub returns an empty hub
+func NewHub() *Hub {
+	return &Hub{connections: map[uuid.UUID]map[chan Event]struct{}{}}
+}
+
+// Subscribe opens a connection of the user
+// The returned function closes the connection, it must be called once the client is gone
+func (h *Hub) Subscribe(userID uuid.UUID) (<-chan Event, func()) {
+	ch := make(chan Event, BufferSize)
+	h.mu.Lock()
+	if h.connections[userID] == nil {
+		h.connections[userID] = map[chan Event]struct{}{}
+	}
+	h.connections[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.connections[userID], ch)
+			if len(h.connections[userID]) == 0 {
+				delete(h.connections, userID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish sends the event to every connection of the user
+// It never blocks, a connection with a full buffer misses the event
+func (h *Hub) Publish(userID uuid.UUID, event Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.connections[userID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Online returns true if the user has at least one open connection
+func (h *Hub) Online(userID uuid.UUID) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.connections[userID]) > 0
+}
@@ -0,0 +1,150 @@
+package registration
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	mailer "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/mail"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/crypto"
+)
+
+// VerificationPurpose is the purpose of the signed tokens verifying an email address
+const VerificationPurpose = "email_verification"
+
+// The reasons a registration is refused
+var (
+	ErrInvalidEmail      = errors.New("the username must be an email address")
+	ErrInviteRequired    = errors.New("an invite code is required to register")
+	ErrDomainNotAllowed  = errors.New("the domain of the email address is not allowed to register")
+	ErrInvalidInviteCode = errors.New("the invite code is invalid or expired")
+)
+
+// Decision is the outcome of a registration allowed by the policies
+// The user joins the organization, nil for the default organization, and uses the invite code if there is one
+type Decision struct {
+	OrganizationID *uuid.UUID
+	InviteCode     *users.InviteCode
+}
+
+// Decide applies the registration policies to a username and an optional invite code
+// A valid invite code is always accepted and the user joins the organization of the code
+// Otherwise the user joins the organization listing the domain of the username, or the default organization,
+// and its policy is applied, the organizations without policy use the policy of the configuration
+// It returns one of the errors above if the registration is refused
+func Decide(username string, code string) (*Decision, error) {
+	domain, ok := Domain(username)
+	if !ok {
+		return nil, ErrInvalidEmail
+	}
+	if code != "" {
+		// The code decides the organization, it is looked up in every organization
+		inviteCode, err := persistence.GetInviteCodeRepository().Unscoped().GetByCode(code)
+		if err != nil || !inviteCode.Valid(time.Now()) {
+			return nil, ErrInvalidInviteCode
+		}
+		return &Decision{OrganizationID: inviteCode.OrganizationID, InviteCode: inviteCode}, nil
+	}
+
+	configuration := config.GetConfig().Registration
+	policy, domains := configuration.Policy, configuration.Domains
+	decision := &Decision{}
+	organization, err := OrganizationOf(domain)
+	if err != nil {
+		return nil, err
+	}
+	if organization != nil {
+		decision.OrganizationID = &organization.ID
+		domains = organization.Domains()
+		if organization.RegistrationPolicy != "" {
+			policy = organization.RegistrationPolicy
+		}
+	}
+	if err := Allow(policy, domains, domain); err != nil {
+		return nil, err
+	}
+	return decision, nil
+}
+
+// OrganizationOf returns the organization listing the email domain, nil for the default organization
+func OrganizationOf(domain string) (*users.Organization, error) {
+	organizations, err := persistence.GetOrganizationRepository().All()
+	if err != nil {
+		return nil, err
+	}
+	for i := range *organizations {
+		if contains((*organizations)[i].Domains(), domain) {
+			return &(*organizations)[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Allow returns nil if the policy lets the users of the domain register without invite code
+// The domain policy only accepts the domains, the invite policy none
+func Allow(policy string, domains []string, domain string) error {
+	switch policy {
+	case config.RegistrationOpen:
+		return nil
+	case config.RegistrationDomain:
+		if contains(domains, domain) {
+			return nil
+		}
+		return ErrDomainNotAllowed
+	default:
+		return ErrInviteRequired
+	}
+}
+
+// Domain returns the domain of an email address in lowercase
+// It returns false if the username is not a plain email address
+func Domain(username string) (string, bool) {
+	address, err := mail.ParseAddress(username)
+	if err != nil || address.Address != username {
+		return "", false
+	}
+	at := strings.LastIndex(address.Address, "@")
+	return strings.ToLower(address.Address[at+1:]), true
+}
+
+// SendVerification queues the email with the link verifying the username of the user
+func SendVerification(user *users.User) error {
+	ttl := config.GetConfig().Registration.VerificationTTL
+	token, err := crypto.CreateSignedToken(VerificationPurpose, user.Username, ttl)
+	if err != nil {
+		return err
+	}
+	return mailer.QueueEmailVerification(user, token, ttl)
+}
+
+// Verify marks the username of a verification token as verified
+// The token stops working when the username changes
+// It returns the verified user or an error if the token is invalid or expired
+func Verify(token string) (*users.User, error) {
+	username, err := crypto.ParseSignedToken(VerificationPurpose, token)
+	if err != nil {
+		return nil, err
+	}
+	// The usernames are unique in the whole deployment, the user is loaded before its organization is known
+	u := persistence.GetUserRepository().Unscoped()
+	user, err := u.GetByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	return user, u.VerifyEmail(user)
+}
+
+// contains returns true if the domains contain the domain, regardless of its case
+func contains(domains []string, domain string) bool {
+	for _, d := range domains {
+		if strings.EqualFold(strings.TrimSpace(d), domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/registration"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/crypto"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/oidc"
+	"gorm.io/gorm"
+)
+
+// The reasons a single sign-on login is refused
+var (
+	ErrDisabled       = errors.New("single sign-on is not enabled")
+	ErrInvalidState   = errors.New("the login is invalid or expired, start again")
+	ErrNoUsername     = errors.New("the identity provider did not send the username")
+	ErrNotProvisioned = errors.New("there is no account for this identity")
+	ErrLinkRefused    = errors.New("an account with this username already exists and cannot be linked")
+)
+
+var (
+	provider      *oidc.Provider
+	providerMutex sync.Mutex
+)
+
+// getProvider returns the provider of the configuration
+// Its configuration is discovered at the first login, and again at the next one when it failed
+func getProvider(ctx context.Context) (*oidc.Provider, error) {
+	configuration := config.GetConfig().OIDC
+	if !configuration.Enabled {
+		return nil, ErrDisabled
+	}
+	providerMutex.Lock()
+	defer providerMutex.Unlock()
+	if provider == nil {
+		discovered, err := oidc.Discover(ctx, &http.Client{Timeout: configuration.Timeout}, configuration.Issuer)
+		if err != nil {
+			return nil, err
+		}
+		provider = discovered
+	}
+	return provider, nil
+}
+
+// clientConfig returns the client of the configuration
+func clientConfig() oidc.Config {
+	configuration := config.GetConfig().OIDC
+	return oidc.Config{
+		ClientID:     configuration.ClientID,
+		ClientSecret: configuration.ClientSecret,
+		RedirectURL:  configuration.RedirectURL,
+		Scopes:       configuration.Scopes,
+	}
+}
+
+// Begin starts a login with the authorization code flow and PKCE
+// The state, the verifier and the nonce are stored until the callback
+// It returns the url of the provider the user is redirected to
+func Begin(ctx context.Context) (string, error) {
+	p, err := getProvider(ctx)
+	if err != nil {
+		return "", err
+	}
+	var secrets [3]string
+	for i := range secrets {
+		if secrets[i], err = oidc.NewVerifier(); err != nil {
+			return "", err
+		}
+	}
+	state, verifier, nonce := secrets[0], secrets[1], secrets[2]
+	login := users.SSOLogin{
+		StateHash: crypto.HashToken(state),
+		Verifier:  verifier,
+		Nonce:     nonce,
+		ExpiresAt: time.Now().Add(config.GetConfig().OIDC.LoginTTL),
+	}
+	if err := persistence.GetSSOLoginRepository().Add(&login); err != nil {
+		return "", err
+	}
+	return p.AuthCodeURL(clientConfig(), state, nonce, oidc.Challenge(verifier)), nil
+}
+
+// Complete finishes the login of the callback of the provider
+// The code is exchanged with the verifier of the state and the id token is verified with its nonce
+// It returns the user of the identity, see Resolve
+func Complete(ctx context.Context, code string, state string) (*users.User, error) {
+	p, err := getProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	login, err := persistence.GetSSOLoginRepository().Take(crypto.HashToken(state))
+	if err != nil || !login.Valid() {
+		return nil, ErrInvalidState
+	}
+	client := clientConfig()
+	token, err := p.Exchange(ctx, client, code, login.Verifier)
+	if err != nil {
+		return nil, err
+	}
+	idToken, err := p.Verify(ctx, client.ClientID, token.IDToken, login.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	return Resolve(ctx, idToken)
+}
+
+// Resolve returns the user of a verified id token
+// The user linked to the subject is returned first. Otherwise the user with the username of the token is linked,
+// when the linking is enabled and the username is the email address the provider verified, or a new user
+// is provisioned when the provisioning is enabled and the registration policies accept its username, see registration.Decide
+func Resolve(ctx context.Context, idToken *oidc.IDToken) (*users.User, error) {
+	configuration := config.GetConfig().OIDC
+	// The subjects and the usernames are unique in the whole deployment, the user is loaded before its organization is known
+	u := persistence.GetUserRepository().Unscoped()
+	user, err := u.GetByOIDCSubject(idToken.Subject)
+	if err == nil {
+		return user, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	username := idToken.Claim(configuration.UsernameClaim)
+	if username == "" {
+		return nil, ErrNoUsername
+	}
+	if user, err := u.GetByUsername(username); err == nil {
+		if !configuration.LinkAccounts || !verifiedUsername(idToken, username) || user.OIDCSubject != nil {
+			return nil, ErrLinkRefused
+		}
+		linked := persistence.GetUserRepository().Scoped(db.WithOrganization(ctx, user.OrganizationID))
+		if err := linked.LinkOIDCSubject(user, idToken.Subject); err != nil {
+			return nil, err
+		}
+		return user, linked.VerifyEmail(user)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if !configuration.Provision {
+		return nil, ErrNotProvisioned
+	}
+	decision, err := registration.Decide(username, "")
+	if err != nil {
+		return nil, err
+	}
+	user = NewUser(idToken, username)
+	if err := persistence.GetUserRepository().Scoped(db.WithOrganization(ctx, decision.OrganizationID)).Register(user, nil); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// NewUser maps the claims of an id token onto a new user with the username
+// The user has no password and its username is verified when it is the email address the provider verified
+func NewUser(idToken *oidc.IDToken, username string) *users.User {
+	subject := idToken.Subject
+	user := &users.User{
+		Username:    username,
+		Firstname:   idToken.GivenName,
+		Lastname:    idToken.FamilyName,
+		OIDCSubject: &subject,
+	}
+	if names := strings.Fields(idToken.Name); user.Firstname == "" && user.Lastname == "" && len(names) > 0 {
+		user.Firstname = names[0]
+		user.Lastname = strings.Join(names[1:], " ")
+	}
+	if verifiedUsername(idToken, username) {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	return user
+}
+
+// verifiedUsername returns true if the username is the email address the provider verified
+// The identity only proves the ownership of the username then, the username claim may be another claim
+func verifiedUsername(idToken *oidc.IDToken, username string) bool {
+	return idToken.EmailVerified && strings.EqualFold(idToken.Email, username)
+}
@@ -0,0 +1,175 @@
+package stats
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+)
+
+// AreaConnections is how much the users of an area have lunch with the users of other areas and other teams
+// A lunch is cross-area when the two users have no area in common, cross-team when both have a team and the teams differ
+// The connections are the other areas met at the cross-area lunches, the most met first
+type AreaConnections struct {
+	Area           string  `json:"area"`
+	Users          int     `json:"users"`
+	Lunches        int     `json:"lunches"`
+	CrossArea      int     `json:"cross_area"`
+	CrossRatio     float64 `json:"cross_ratio"`
+	CrossTeam      int     `json:"cross_team"`
+	CrossTeamRatio float64 `json:"cross_team_ratio"`
+	Connections    []Count `json:"connections"`
+}
+
+// Dashboard is the anonymised overview of the lunches of the organisation within a window
+// It only counts lunches per area, no user or team is named
+type Dashboard struct {
+	From             time.Time         `json:"from"`
+	To               time.Time         `json:"to"`
+	Lunches          int               `json:"lunches"`
+	CrossAreaLunches int               `json:"cross_area_lunches"`
+	CrossTeamLunches int               `json:"cross_team_lunches"`
+	Areas            []AreaConnections `json:"areas"`
+}
+
+// LoadDashboard returns the dashboard of the accepted lunches taking place within the window
+// Only the users of the organization of ctx and their lunches are counted, see db.WithOrganization
+func LoadDashboard(ctx context.Context, from time.Time, to time.Time) (*Dashboard, error) {
+	invitations, err := persistence.GetInvitationRepository().Scoped(ctx).AcceptedBetween(from, to)
+	if err != nil {
+		return nil, err
+	}
+	all, err := persistence.GetUserRepository().Scoped(ctx).All()
+	if err != nil {
+		return nil, err
+	}
+	dashboard := ComputeDashboard(*invitations, *all)
+	dashboard.From, dashboard.To = from, to
+	return &dashboard, nil
+}
+
+// ComputeDashboard returns the connections between the areas and the teams of the users at the lunches of the invitations
+// The users must be loaded with their areas, the lunches of the other users are left out
+// The areas with fewer than MinAreaUsers users are grouped under OtherAreas, which is left out when it is that small too
+// The teams with fewer than MinAreaUsers members are grouped the same way, a lunch between two of their members is not cross-team
+func ComputeDashboard(invitations []users.Invitation, all []users.User) Dashboard {
+	members := map[string]int{}
+	teamMembers := map[uuid.UUID]int{}
+	for i := range all {
+		for _, area := range all[i].Areas {
+			members[area.Name]++
+		}
+		if all[i].TeamID != nil {
+			teamMembers[*all[i].TeamID]++
+		}
+	}
+	// teams are the teams of the users, uuid.Nil for the members of the small teams
+	teams := map[uuid.UUID]uuid.UUID{}
+	for i := range all {
+		if all[i].TeamID == nil {
+			continue
+		}
+		teams[all[i].ID] = uuid.Nil
+		if teamMembers[*all[i].TeamID] >= MinAreaUsers {
+			teams[all[i].ID] = *all[i].TeamID
+		}
+	}
+	groups := make(map[uuid.UUID][]string, len(all))
+	for i := range all {
+		for _, area := range all[i].Areas {
+			if members[area.Name] >= MinAreaUsers {
+				groups[all[i].ID] = append(groups[all[i].ID], area.Name)
+			}
+		}
+		if len(groups[all[i].ID]) == 0 {
+			groups[all[i].ID] = []string{OtherAreas}
+			members[OtherAreas]++
+		}
+	}
+
+	dashboard := Dashboard{Areas: []AreaConnections{}}
+	areas := map[string]*AreaConnections{}
+	connections := map[string]map[string]int{}
+	area := func(name string) *AreaConnections {
+		if areas[name] == nil {
+			areas[name] = &AreaConnections{Area: name, Users: members[name]}
+			connections[name] = map[string]int{}
+		}
+		return areas[name]
+	}
+	for i := range invitations {
+		inviter, invitee := groups[invitations[i].InviterID], groups[invitations[i].InviteeID]
+		if inviter == nil || invitee == nil {
+			continue
+		}
+		dashboard.Lunches++
+		cross := !intersects(inviter, invitee)
+		if cross {
+			dashboard.CrossAreaLunches++
+		}
+		inviterTeam, inviterHasTeam := teams[invitations[i].InviterID]
+		inviteeTeam, inviteeHasTeam := teams[invitations[i].InviteeID]
+		crossTeam := inviterHasTeam && inviteeHasTeam && inviterTeam != inviteeTeam
+		if crossTeam {
+			dashboard.CrossTeamLunches++
+		}
+		for _, name := range union(inviter, invitee) {
+			entry := area(name)
+			entry.Lunches++
+			if cross {
+				entry.CrossArea++
+			}
+			if crossTeam {
+				entry.CrossTeam++
+			}
+		}
+		if cross {
+			for _, from := range inviter {
+				for _, to := range invitee {
+					connections[from][to]++
+					connections[to][from]++
+				}
+			}
+		}
+	}
+
+	for name, entry := range areas {
+		if entry.Users < MinAreaUsers {
+			continue
+		}
+		entry.CrossRatio = float64(entry.CrossArea) / float64(entry.Lunches)
+		entry.CrossTeamRatio = float64(entry.CrossTeam) / float64(entry.Lunches)
+		entry.Connections = top(connections[name], 0)
+		dashboard.Areas = append(dashboard.Areas, *entry)
+	}
+	sort.Slice(dashboard.Areas, func(i, j int) bool { return dashboard.Areas[i].Area < dashboard.Areas[j].Area })
+	return dashboard
+}
+
+// intersects returns true if the two lists have a name in common
+func intersects(a []string, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// union returns the names of both lists without duplicates
+func union(a []string, b []string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, name := range append(append([]string{}, a...), b...) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,194 @@
+// Package stats computes the lunch history of the users and the connections between the areas
+package stats
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/calendar"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+)
+
+// TopEntries is the number of entries of the rankings, e.g. of the favorite places
+const TopEntries = 5
+
+// MinAreaUsers is the number of users an area needs to be shown on its own in the dashboard
+// The smaller areas are grouped under OtherAreas so no user can be singled out
+const MinAreaUsers = 3
+
+// OtherAreas is the group of the users of the small areas and of the users without area
+const OtherAreas = "Other areas"
+
+// Period is the number of lunches in the week or the month starting on Start
+// Start is a date for a week, e.g. 2026-10-12, and a month for a month, e.g. 2026-10
+type Period struct {
+	Start   string `json:"start"`
+	Lunches int    `json:"lunches"`
+}
+
+// Count is how often a name comes up, e.g. a place in the lunches of a user
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Stats is the lunch history of a user
+// The lunches are the accepted invitations which took place
+// The streaks are numbers of consecutive weeks with at least one lunch, the current one may end last week
+type Stats struct {
+	Lunches        int                     `json:"lunches"`
+	PerWeek        []Period                `json:"per_week"`
+	PerMonth       []Period                `json:"per_month"`
+	Buddies        int                     `json:"buddies"`
+	FavoritePlaces []Count                 `json:"favorite_places"`
+	SharedHobbies  []Count                 `json:"shared_hobbies"`
+	LongestStreak  int                     `json:"longest_streak"`
+	CurrentStreak  int                     `json:"current_streak"`
+	Engagement     *persistence.Engagement `json:"engagement"`
+}
+
+// ForUser returns the lunch history of the user until now
+// The user must be loaded with its hobbies, only the data of its organization is read
+func ForUser(user *users.User, now time.Time) (*Stats, error) {
+	organization := db.WithOrganization(context.Background(), user.OrganizationID)
+	invitations, err := persistence.GetInvitationRepository().Scoped(organization).QueryForUser(user.ID, "", users.InvitationAccepted)
+	if err != nil {
+		return nil, err
+	}
+	var ids []uuid.UUID
+	seen := map[uuid.UUID]bool{}
+	for i := range *invitations {
+		other := (*invitations)[i].Other(user.ID)
+		if (*invitations)[i].Met(user.ID, now) && !seen[other] {
+			seen[other] = true
+			ids = append(ids, other)
+		}
+	}
+	loaded, err := persistence.GetUserRepository().Scoped(organization).GetMany(ids)
+	if err != nil {
+		return nil, err
+	}
+	companions := make(map[uuid.UUID]*users.User, len(*loaded))
+	for i := range *loaded {
+		companions[(*loaded)[i].ID] = &(*loaded)[i]
+	}
+	stats := Compute(user, *invitations, companions, now, calendar.Location(user))
+	if stats.Engagement, err = persistence.GetTaskRepository().Scoped(organization).Engagement(user.ID); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Compute returns the lunch history of the user from its invitations
+// The companions are the users met, keyed by id and loaded with their hobbies
+// The weeks start on Monday in the location
+func Compute(user *users.User, invitations []users.Invitation, companions map[uuid.UUID]*users.User, now time.Time, location *time.Location) Stats {
+	stats := Stats{PerWeek: []Period{}, PerMonth: []Period{}}
+	weeks := map[string]int{}
+	months := map[string]int{}
+	buddies := map[uuid.UUID]bool{}
+	places := map[string]int{}
+	hobbies := map[string]int{}
+	var starts []time.Time
+	for i := range invitations {
+		invitation := &invitations[i]
+		if !invitation.Met(user.ID, now) {
+			continue
+		}
+		stats.Lunches++
+		week := weekStart(invitation.Time, location)
+		if weeks[week.Format("2006-01-02")] == 0 {
+			starts = append(starts, week)
+		}
+		weeks[week.Format("2006-01-02")]++
+		months[invitation.Time.In(location).Format("2006-01")]++
+
+		other := invitation.Other(user.ID)
+		buddies[other] = true
+		if invitation.Place != nil {
+			places[invitation.Place.Name]++
+		} else if invitation.Location != "" {
+			places[invitation.Location]++
+		}
+		if companion, ok := companions[other]; ok {
+			for _, hobby := range user.Hobbies {
+				for _, shared := range companion.Hobbies {
+					if hobby.ID == shared.ID {
+						hobbies[hobby.Name]++
+					}
+				}
+			}
+		}
+	}
+	stats.PerWeek = periods(weeks)
+	stats.PerMonth = periods(months)
+	stats.Buddies = len(buddies)
+	stats.FavoritePlaces = top(places, TopEntries)
+	stats.SharedHobbies = top(hobbies, TopEntries)
+	stats.LongestStreak, stats.CurrentStreak = streaks(starts, weekStart(now, location))
+	return stats
+}
+
+// weekStart returns the midnight of the Monday of the week of the time in the location
+func weekStart(t time.Time, location *time.Location) time.Time {
+	t = t.In(location)
+	monday := t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+	return time.Date(monday.Year(), monday.Month(), monday.Day(), 0, 0, 0, 0, location)
+}
+
+// streaks returns the longest run of consecutive weeks and the run ending this week or the week before
+func streaks(starts []time.Time, thisWeek time.Time) (int, int) {
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	longest, run := 0, 0
+	for i, start := range starts {
+		// The weeks are compared by date, a week around a daylight saving change is not 7 times 24 hours long
+		if i > 0 && starts[i-1].AddDate(0, 0, 7).Equal(start) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+	if len(starts) == 0 {
+		return 0, 0
+	}
+	last := starts[len(starts)-1]
+	if last.Equal(thisWeek) || last.AddDate(0, 0, 7).Equal(thisWeek) {
+		return longest, run
+	}
+	return longest, 0
+}
+
+// periods returns the counted periods ordered by start
+func periods(counts map[string]int) []Period {
+	result := []Period{}
+	for start, lunches := range counts {
+		result = append(result, Period{Start: start, Lunches: lunches})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Start < result[j].Start })
+	return result
+}
+
+// top returns up to limit names, the most counted first, then by name
+func top(counts map[string]int, limit int) []Count {
+	result := []Count{}
+	for name, count := range counts {
+		result = append(result, Count{Name: name, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
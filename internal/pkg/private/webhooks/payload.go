@@ -0,0 +1,101 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/events"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/notifications"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+)
+
+// PingEvent is the event type of the test pings
+const PingEvent = "ping"
+
+// Payload is the JSON body posted to the webhooks
+// The text is a human readable summary, chat tools accepting {"text": ...} can post it as is
+type Payload struct {
+	ID         uuid.UUID       `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Text       string          `json:"text"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// NewPayload returns the body posted for the event
+func NewPayload(event events.Event) ([]byte, error) {
+	return json.Marshal(Payload{
+		ID:         event.ID,
+		Type:       event.Type,
+		OccurredAt: event.OccurredAt,
+		Text:       summary(event),
+		Data:       event.Payload,
+	})
+}
+
+// pingPayload returns the body posted by a test ping
+func pingPayload(id uuid.UUID) ([]byte, error) {
+	return json.Marshal(Payload{
+		ID:         id,
+		Type:       PingEvent,
+		OccurredAt: time.Now(),
+		Text:       "Ping from Lunch Buddy",
+		Data:       json.RawMessage("{}"),
+	})
+}
+
+// summary returns the text of the event
+func summary(event events.Event) string {
+	switch event.Type {
+	case events.BuddyMatched:
+		var payload events.BuddyMatchedPayload
+		if err := event.Decode(&payload); err == nil {
+			return displayName(payload.UserID) + " and " + displayName(payload.BuddyID) + " are now lunch buddies"
+		}
+	case events.LunchInvitationAccepted:
+		var payload events.LunchInvitationPayload
+		if err := event.Decode(&payload); err == nil {
+			text := displayName(payload.InviterID) + " and " + displayName(payload.InviteeID) + " are having lunch " + when(payload.Time)
+			if payload.Location != "" {
+				text += " at " + payload.Location
+			}
+			return text
+		}
+	case events.LunchInvitationSent:
+		var payload events.LunchInvitationPayload
+		if err := event.Decode(&payload); err == nil {
+			return displayName(payload.InviterID) + " invited " + displayName(payload.InviteeID) + " to lunch " + when(payload.Time)
+		}
+	case events.UserRegistered:
+		var payload events.UserRegisteredPayload
+		if err := event.Decode(&payload); err == nil {
+			return payload.Username + " joined Lunch Buddy"
+		}
+	case events.ProfileCompleted:
+		var payload events.ProfileCompletedPayload
+		if err := event.Decode(&payload); err == nil {
+			return displayName(payload.UserID) + " is ready for lunch"
+		}
+	}
+	return event.Type
+}
+
+// displayName returns the name of the user shown in the texts
+// The events are delivered outside of any request, the user is looked up in every organization
+func displayName(id uuid.UUID) string {
+	user, err := persistence.GetUserRepository().Unscoped().Get(id.String())
+	if err != nil {
+		return "Someone"
+	}
+	return notifications.DisplayName(user)
+}
+
+// when returns the day and the time of a lunch relative to today
+func when(t time.Time) string {
+	now := time.Now().In(t.Location())
+	if t.YearDay() == now.YearDay() && t.Year() == now.Year() {
+		return "today at " + t.Format("15:04")
+	}
+	return "on " + t.Format("Mon 2 Jan at 15:04")
+}
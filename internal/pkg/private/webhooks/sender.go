@@ -0,0 +1,189 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/events"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/webhooks"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxResponseLength is the number of bytes of a response kept in the delivery log
+const MaxResponseLength = 1024
+
+// Sender posts the queued deliveries to the webhooks
+// A failed delivery is retried with an exponential backoff until the maximal number of attempts
+type Sender struct {
+	client       *http.Client
+	pollInterval time.Duration
+	batchSize    int
+	maxAttempts  int
+}
+
+// NewSender returns a sender with the settings of the configuration
+func NewSender(configuration config.WebhooksConfiguration) *Sender {
+	return &Sender{
+		client:       &http.Client{Timeout: configuration.Timeout},
+		pollInterval: configuration.PollInterval,
+		batchSize:    configuration.BatchSize,
+		maxAttempts:  configuration.MaxAttempts,
+	}
+}
+
+// Run sends the due deliveries until the context is cancelled
+// The errors are logged and the sender keeps running
+func (s *Sender) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			count, err := s.SendPending()
+			if err != nil {
+				log.Println(err)
+			}
+			if err != nil || count < s.batchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendPending sends one batch of the due deliveries, the oldest first
+// The deliveries are leased before they are sent so that another instance does not send them at the same time
+// It returns the number of deliveries attempted
+func (s *Sender) SendPending() (int, error) {
+	deliveries, err := s.claim()
+	if err != nil {
+		return 0, err
+	}
+	r := persistence.GetWebhookRepository()
+	webhooks := map[uuid.UUID]*models.Webhook{}
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			if webhook, err = r.Get(delivery.WebhookID.String()); err != nil {
+				webhook = nil
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+		if webhook == nil || !webhook.Active {
+			delivery.Status = models.DeliveryFailed
+			delivery.Error = "the webhook is disabled"
+		} else {
+			s.Attempt(webhook, delivery)
+			if delivery.Status == models.DeliveryPending {
+				if delivery.Attempts >= s.maxAttempts {
+					delivery.Status = models.DeliveryFailed
+				} else {
+					delivery.NextAttemptAt = time.Now().Add(events.Backoff(delivery.Attempts))
+				}
+			}
+		}
+		if err := r.UpdateDelivery(delivery); err != nil {
+			return i, err
+		}
+	}
+	return len(deliveries), nil
+}
+
+// claim locks the due deliveries and postpones them by the lease
+// A delivery whose sender stopped is sent again once the lease expired
+func (s *Sender) claim() ([]models.Delivery, error) {
+	var deliveries []models.Delivery
+	lease := time.Now().Add(2*s.client.Timeout + time.Minute)
+	err := db.GetDB().Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, time.Now()).
+			Order("next_attempt_at").Limit(s.batchSize).Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+		ids := make([]uuid.UUID, len(deliveries))
+		for i, delivery := range deliveries {
+			ids[i] = delivery.ID
+		}
+		return tx.Model(&models.Delivery{}).Where("id IN ?", ids).Update("next_attempt_at", lease).Error
+	})
+	return deliveries, err
+}
+
+// Ping posts a test event to the webhook right away, even when it is not active
+// The attempt is kept in the delivery log and is not retried
+func (s *Sender) Ping(webhook *models.Webhook) (*models.Delivery, error) {
+	delivery := models.Delivery{WebhookID: webhook.ID, EventType: PingEvent}
+	if err := persistence.GetWebhookRepository().AddDelivery(&delivery); err != nil {
+		return nil, err
+	}
+	body, err := pingPayload(delivery.ID)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = string(body)
+	s.Attempt(webhook, &delivery)
+	if delivery.Status == models.DeliveryPending {
+		delivery.Status = models.DeliveryFailed
+	}
+	return &delivery, persistence.GetWebhookRepository().UpdateDelivery(&delivery)
+}
+
+// Attempt posts the delivery to the webhook once and records the outcome in the delivery
+// The delivery succeeds on a 2xx response, otherwise it stays pending
+// It does not store the delivery
+func (s *Sender) Attempt(webhook *models.Webhook, delivery *models.Delivery) {
+	delivery.Attempts++
+	delivery.StatusCode = 0
+	delivery.Response = ""
+	delivery.Error = ""
+	start := time.Now()
+	defer func() {
+		delivery.Duration = time.Since(start).Milliseconds()
+	}()
+
+	body := []byte(delivery.Payload)
+	request, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return
+	}
+	timestamp := start.Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "LunchBuddy-Webhooks/1.0")
+	request.Header.Set(HeaderEvent, delivery.EventType)
+	request.Header.Set(HeaderDelivery, delivery.ID.String())
+	request.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, body))
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		delivery.Error = err.Error()
+		return
+	}
+	defer response.Body.Close()
+	content, _ := io.ReadAll(io.LimitReader(response.Body, MaxResponseLength))
+	delivery.StatusCode = response.StatusCode
+	delivery.Response = string(content)
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		delivery.Error = "unexpected status " + response.Status
+		return
+	}
+	now := time.Now()
+	delivery.Status = models.DeliverySucceeded
+	delivery.DeliveredAt = &now
+}
@@ -0,0 +1,45 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The headers of a webhook request
+const (
+	HeaderEvent     = "X-LunchBuddy-Event"
+	HeaderDelivery  = "X-LunchBuddy-Delivery"
+	HeaderTimestamp = "X-LunchBuddy-Timestamp"
+	HeaderSignature = "X-LunchBuddy-Signature"
+)
+
+// signaturePrefix names the algorithm in the signature header
+const signaturePrefix = "sha256="
+
+// Sign returns the signature header of a request body sent at the timestamp
+// It is the HMAC-SHA256 of "timestamp.body" with the secret of the webhook
+// The receivers compute it the same way and compare it in constant time
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns true if the signature matches the body and the timestamp is not older than the tolerance
+// A zero tolerance disables the check of the timestamp
+func Verify(secret string, timestamp string, signature string, body []byte, tolerance time.Duration) bool {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+	if tolerance > 0 && time.Since(time.Unix(seconds, 0)) > tolerance {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, seconds, body)))
+}
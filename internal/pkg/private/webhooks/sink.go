@@ -0,0 +1,43 @@
+package webhooks
+
+import (
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/events"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/webhooks"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+)
+
+// Sink queues a delivery for every active webhook subscribed to an event
+// The deliveries are sent by the Sender, so a slow webhook never holds the other receivers of the bus
+type Sink struct{}
+
+// Name identifies the sink in the errors
+func (s Sink) Name() string {
+	return "webhooks"
+}
+
+// Deliver queues the deliveries of the event
+// A redelivered event does not queue the deliveries twice
+func (s Sink) Deliver(event events.Event) error {
+	r := persistence.GetWebhookRepository()
+	webhooks, err := r.Active()
+	if err != nil {
+		return err
+	}
+	var body []byte
+	for _, webhook := range *webhooks {
+		if !webhook.Subscribes(event.Type) {
+			continue
+		}
+		if body == nil {
+			if body, err = NewPayload(event); err != nil {
+				return err
+			}
+		}
+		eventID := event.ID
+		delivery := models.Delivery{WebhookID: webhook.ID, EventID: &eventID, EventType: event.Type, Payload: string(body)}
+		if err := r.Enqueue(&delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
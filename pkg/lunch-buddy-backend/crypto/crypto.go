@@ -1,11 +1,16 @@
 package crypto
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v4"
 	config2 "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
 	"golang.org/x/crypto/bcrypt"
 	"log"
+	"strings"
 	"time"
 )
 
@@ -20,6 +25,24 @@ func HashAndSalt(pwd []byte) string {
 	return string(hash)
 }
 
+// RandomToken returns a random hex string of the given number of bytes
+// It is used for secrets and the tokens of links
+// returns an error if the random source fails
+func RandomToken(size int) (string, error) {
+	buffer := make([]byte, size)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buffer), nil
+}
+
+// HashToken returns the SHA-256 of a token as a hex string
+// Only the hashes of the tokens sent to the users are stored
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // ComparePasswords compares a hashed password with a plain password
 // returns true if they match
 // returns false if they don't match
@@ -33,10 +56,18 @@ func ComparePasswords(hashedPwd string, plainPwd []byte) bool {
 	return true
 }
 
+// Claims are the claims of a token
+// The organization is the id of the organization of the user, empty for the default organization
+type Claims struct {
+	Username     string
+	Organization string
+}
+
 // CreateToken creates a token
+// The organization is the id of the organization of the user, empty for the default organization
 // returns a token string
 // returns an error if something goes wrong with the hashing
-func CreateToken(username string) (string, error) {
+func CreateToken(username string, organization string) (string, error) {
 	config := config2.GetConfig()
 
 	var err error
@@ -44,6 +75,9 @@ func CreateToken(username string) (string, error) {
 	atClaims := jwt.MapClaims{}
 	atClaims["authorized"] = true
 	atClaims["username"] = username
+	if organization != "" {
+		atClaims["organization"] = organization
+	}
 	atClaims["exp"] = time.Now().Add(time.Hour * 24 * 365).Unix()
 	at := jwt.NewWithClaims(jwt.SigningMethodHS512, atClaims)
 	token, err := at.SignedString([]byte(config.Server.Secret)) // SECRET
@@ -53,12 +87,68 @@ func CreateToken(username string) (string, error) {
 	return token, nil
 }
 
+// CreateSignedToken creates a token for the purpose, such as the verification of an email address, valid for the duration
+// The subject is what the token is about, it is returned by ParseSignedToken
+// The token cannot be used for another purpose nor to authenticate
+// returns an error if the token could not be signed
+func CreateSignedToken(purpose string, subject string, ttl time.Duration) (string, error) {
+	claims := jwt.MapClaims{
+		"purpose": purpose,
+		"sub":     subject,
+		"exp":     time.Now().Add(ttl).Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString([]byte(config2.GetConfig().Server.Secret))
+}
+
+// ParseSignedToken validates a token created for the purpose and returns its subject
+// returns an error if the token is invalid, expired or was created for another purpose
+func ParseSignedToken(purpose string, tokenString string) (string, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return []byte(config2.GetConfig().Server.Secret), nil
+	})
+	if err != nil {
+		return "", err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claims["purpose"] != purpose {
+		return "", errors.New("invalid token")
+	}
+	subject, ok := claims["sub"].(string)
+	if !ok || subject == "" {
+		return "", errors.New("token has no subject")
+	}
+	return subject, nil
+}
+
 // ValidateToken validates a token
 // returns true if the token is valid
 // returns false if the token is invalid
 // returns an error if something goes wrong with the hashing
 func ValidateToken(tokenString string) bool {
+	_, err := ParseToken(tokenString)
+	return err == nil
+}
+
+// ParseToken validates a token and returns the username it was issued for
+// The token may be prefixed with "Bearer "
+// returns an error if the token is invalid
+func ParseToken(tokenString string) (string, error) {
+	claims, err := ParseClaims(tokenString)
+	if err != nil {
+		return "", err
+	}
+	return claims.Username, nil
+}
+
+// ParseClaims validates a token and returns its claims
+// The token may be prefixed with "Bearer "
+// returns an error if the token is invalid
+func ParseClaims(tokenString string) (Claims, error) {
 	config := config2.GetConfig()
+	tokenString = strings.TrimPrefix(tokenString, "Bearer ")
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("there was an error")
@@ -66,7 +156,16 @@ func ValidateToken(tokenString string) bool {
 		return []byte(config.Server.Secret), nil
 	})
 	if err != nil {
-		return false
+		return Claims{}, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return Claims{}, errors.New("invalid token")
+	}
+	username, ok := claims["username"].(string)
+	if !ok || username == "" {
+		return Claims{}, errors.New("token has no username")
 	}
-	return token.Valid
+	organization, _ := claims["organization"].(string)
+	return Claims{Username: username, Organization: organization}, nil
 }
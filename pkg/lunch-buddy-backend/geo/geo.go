@@ -0,0 +1,66 @@
+// Package geo computes distances between coordinates on the surface of the earth
+package geo
+
+import (
+	"errors"
+	"math"
+)
+
+// EarthRadius is the mean radius of the earth in meters
+const EarthRadius = 6371008.8
+
+// Point is a position given by its latitude and longitude in degrees
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// NewPoint returns the point of the coordinates
+// It returns false when one of them is missing
+func NewPoint(latitude *float64, longitude *float64) (Point, bool) {
+	if latitude == nil || longitude == nil {
+		return Point{}, false
+	}
+	return Point{Latitude: *latitude, Longitude: *longitude}, true
+}
+
+// Validate returns an error if only one of the coordinates is given or if they are out of range
+// No coordinates at all are valid
+func Validate(latitude *float64, longitude *float64) error {
+	if (latitude == nil) != (longitude == nil) {
+		return errors.New("latitude and longitude must be given together")
+	}
+	if latitude != nil && (*latitude < -90 || *latitude > 90 || *longitude < -180 || *longitude > 180) {
+		return errors.New("latitude must be between -90 and 90 and longitude between -180 and 180")
+	}
+	return nil
+}
+
+// Distance returns the great circle distance between two points in meters
+// It uses the haversine formula, which is precise to a few meters at the scale of a city
+func Distance(a Point, b Point) float64 {
+	lat1, lat2 := radians(a.Latitude), radians(b.Latitude)
+	dLat := lat2 - lat1
+	dLon := radians(b.Longitude - a.Longitude)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Nearest returns the shortest distance between a point of from and a point of to
+// It returns false when one of the lists is empty
+func Nearest(from []Point, to []Point) (float64, bool) {
+	nearest, found := 0.0, false
+	for _, a := range from {
+		for _, b := range to {
+			if distance := Distance(a, b); !found || distance < nearest {
+				nearest, found = distance, true
+			}
+		}
+	}
+	return nearest, found
+}
+
+// radians converts degrees to radians
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
@@ -24,7 +24,13 @@ func Offset(offset string) int {
 func Limit(limit string) int {
 	limitInt, err := strconv.Atoi(limit)
 	if err != nil {
-		limitInt = 25
+		return 25
+	}
+	if limitInt > 100 {
+		return 100
+	}
+	if limitInt < 1 {
+		return 1
 	}
 	return limitInt
 }
@@ -0,0 +1,290 @@
+package ics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxOccurrences bounds the expansion of a recurrence rule
+const maxOccurrences = 100000
+
+// Period is a span of time, the end is excluded
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Overlaps returns true if the periods share some time
+func (p Period) Overlaps(start time.Time, end time.Time) bool {
+	return p.Start.Before(end) && start.Before(p.End)
+}
+
+// BusyPeriods returns the busy periods of the calendar overlapping the window, sorted by start
+// The opaque events which are not cancelled are busy, their recurrences are expanded
+// The busy periods of the VFREEBUSY components are busy too
+// The floating times and the dates are read in the location
+func BusyPeriods(calendar *Component, from time.Time, to time.Time, location *time.Location) ([]Period, error) {
+	overridden := map[string]bool{}
+	for _, component := range calendar.Components {
+		if component.Name != "VEVENT" {
+			continue
+		}
+		if property := component.Property("RECURRENCE-ID"); property != nil {
+			if t, _, err := property.Time(location); err == nil {
+				overridden[component.Value("UID")+"/"+t.UTC().Format(time.RFC3339)] = true
+			}
+		}
+	}
+
+	var periods []Period
+	for _, component := range calendar.Components {
+		var found []Period
+		var err error
+		switch component.Name {
+		case "VEVENT":
+			found, err = eventPeriods(component, from, to, location, overridden)
+		case "VFREEBUSY":
+			found, err = freeBusyPeriods(component, location)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, period := range found {
+			if period.Overlaps(from, to) {
+				periods = append(periods, period)
+			}
+		}
+	}
+	sort.SliceStable(periods, func(i, j int) bool {
+		return periods[i].Start.Before(periods[j].Start)
+	})
+	return periods, nil
+}
+
+// eventPeriods returns the occurrences of a busy event until the end of the window
+// The occurrences replaced by another VEVENT with a RECURRENCE-ID are left out
+func eventPeriods(event *Component, from time.Time, to time.Time, location *time.Location, overridden map[string]bool) ([]Period, error) {
+	if strings.EqualFold(event.Value("STATUS"), StatusCancelled) || strings.EqualFold(event.Value("TRANSP"), "TRANSPARENT") {
+		return nil, nil
+	}
+	startProperty := event.Property("DTSTART")
+	if startProperty == nil {
+		return nil, nil
+	}
+	start, allDay, err := startProperty.Time(location)
+	if err != nil {
+		return nil, fmt.Errorf("event %s: %w", event.Value("UID"), err)
+	}
+	length, err := eventLength(event, start, allDay, location)
+	if err != nil {
+		return nil, fmt.Errorf("event %s: %w", event.Value("UID"), err)
+	}
+	if length <= 0 {
+		return nil, nil
+	}
+
+	starts := []time.Time{start}
+	if rule := event.Value("RRULE"); rule != "" && event.Property("RECURRENCE-ID") == nil {
+		recurrence, err := parseRule(rule, location)
+		if err != nil {
+			return nil, fmt.Errorf("event %s: %w", event.Value("UID"), err)
+		}
+		starts = recurrence.occurrences(start, to)
+	}
+	for _, date := range event.All("RDATE") {
+		for _, value := range strings.Split(date.Value, ",") {
+			if t, _, err := parseTime(strings.Split(value, "/")[0], date.Params["VALUE"], date.Params["TZID"], start.Location()); err == nil {
+				starts = append(starts, t)
+			}
+		}
+	}
+	excluded := map[string]bool{}
+	for _, date := range event.All("EXDATE") {
+		for _, value := range strings.Split(date.Value, ",") {
+			if t, _, err := parseTime(value, date.Params["VALUE"], date.Params["TZID"], start.Location()); err == nil {
+				excluded[t.UTC().Format(time.RFC3339)] = true
+			}
+		}
+	}
+
+	uid := event.Value("UID")
+	var periods []Period
+	for _, occurrence := range starts {
+		key := occurrence.UTC().Format(time.RFC3339)
+		if excluded[key] || (event.Property("RECURRENCE-ID") == nil && overridden[uid+"/"+key]) {
+			continue
+		}
+		end := occurrence.Add(length)
+		if allDay {
+			end = occurrence.AddDate(0, 0, int((length+12*time.Hour)/(24*time.Hour)))
+		}
+		periods = append(periods, Period{Start: occurrence, End: end})
+	}
+	return periods, nil
+}
+
+// eventLength returns the duration of an event from its DTEND or its DURATION
+// An all-day event without an end lasts one day, other events without an end take no time
+func eventLength(event *Component, start time.Time, allDay bool, location *time.Location) (time.Duration, error) {
+	if property := event.Property("DTEND"); property != nil {
+		end, _, err := property.Time(location)
+		if err != nil {
+			return 0, err
+		}
+		return end.Sub(start), nil
+	}
+	if value := event.Value("DURATION"); value != "" {
+		return ParseDuration(value)
+	}
+	if allDay {
+		return 24 * time.Hour, nil
+	}
+	return 0, nil
+}
+
+// freeBusyPeriods returns the busy periods of a VFREEBUSY component
+// The periods are written start/end or start/duration, the free ones are left out
+func freeBusyPeriods(component *Component, location *time.Location) ([]Period, error) {
+	var periods []Period
+	for _, property := range component.All("FREEBUSY") {
+		if kind := property.Params["FBTYPE"]; kind != "" && strings.EqualFold(kind, "FREE") {
+			continue
+		}
+		for _, value := range strings.Split(property.Value, ",") {
+			startValue, endValue, ok := strings.Cut(value, "/")
+			if !ok {
+				return nil, fmt.Errorf("invalid period %s", value)
+			}
+			start, _, err := parseTime(startValue, "", "", location)
+			if err != nil {
+				return nil, err
+			}
+			var end time.Time
+			if strings.HasPrefix(endValue, "P") || strings.HasPrefix(endValue, "+P") {
+				duration, err := ParseDuration(endValue)
+				if err != nil {
+					return nil, err
+				}
+				end = start.Add(duration)
+			} else if end, _, err = parseTime(endValue, "", "", location); err != nil {
+				return nil, err
+			}
+			periods = append(periods, Period{Start: start, End: end})
+		}
+	}
+	return periods, nil
+}
+
+// rule is a parsed recurrence rule
+// Only FREQ, INTERVAL, COUNT, UNTIL and the plain weekdays of BYDAY in weekly rules are supported,
+// the occurrences of the other rules fall on the day of the first one
+type rule struct {
+	frequency string
+	interval  int
+	count     int
+	until     time.Time
+	weekdays  []time.Weekday
+}
+
+// weekdays are the BYDAY codes
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseRule parses a recurrence rule such as FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE
+func parseRule(value string, location *time.Location) (*rule, error) {
+	r := &rule{interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.frequency = strings.ToUpper(val)
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 {
+				return nil, fmt.Errorf("invalid interval %s", val)
+			}
+			r.interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil || count < 1 {
+				return nil, fmt.Errorf("invalid count %s", val)
+			}
+			r.count = count
+		case "UNTIL":
+			until, _, err := parseTime(val, "", "", location)
+			if err != nil {
+				return nil, err
+			}
+			r.until = until
+		case "BYDAY":
+			for _, code := range strings.Split(val, ",") {
+				if day, ok := weekdays[strings.ToUpper(code)]; ok {
+					r.weekdays = append(r.weekdays, day)
+				}
+			}
+		}
+	}
+	switch r.frequency {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+		return r, nil
+	}
+	return nil, fmt.Errorf("unsupported frequency %s", r.frequency)
+}
+
+// occurrences returns the starts of the occurrences of the rule beginning at start, until the time
+// The dates are computed on the wall clock of the start, so they keep their time of day across DST changes
+func (r *rule) occurrences(start time.Time, to time.Time) []time.Time {
+	var starts []time.Time
+	emitted := 0
+	accept := func(t time.Time) bool {
+		if t.Before(start) {
+			return true
+		}
+		if (!r.until.IsZero() && t.After(r.until)) || (r.count > 0 && emitted >= r.count) || !t.Before(to) {
+			return false
+		}
+		starts = append(starts, t)
+		emitted++
+		return true
+	}
+	for step := 0; step < maxOccurrences; step++ {
+		switch {
+		case r.frequency == "WEEKLY" && len(r.weekdays) > 0:
+			// The weeks start on monday
+			monday := start.AddDate(0, 0, -((int(start.Weekday())+6)%7)+7*r.interval*step)
+			days := make([]int, len(r.weekdays))
+			for i, day := range r.weekdays {
+				days[i] = (int(day) + 6) % 7
+			}
+			sort.Ints(days)
+			for _, offset := range days {
+				if !accept(monday.AddDate(0, 0, offset)) {
+					return starts
+				}
+			}
+		case r.frequency == "DAILY":
+			if !accept(start.AddDate(0, 0, r.interval*step)) {
+				return starts
+			}
+		case r.frequency == "WEEKLY":
+			if !accept(start.AddDate(0, 0, 7*r.interval*step)) {
+				return starts
+			}
+		case r.frequency == "MONTHLY":
+			// The months without the day of the start are skipped
+			if t := start.AddDate(0, r.interval*step, 0); t.Day() == start.Day() && !accept(t) {
+				return starts
+			}
+		case r.frequency == "YEARLY":
+			if t := start.AddDate(r.interval*step, 0, 0); t.Day() == start.Day() && !accept(t) {
+				return starts
+			}
+		}
+	}
+	return starts
+}
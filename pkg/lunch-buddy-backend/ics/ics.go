@@ -0,0 +1,207 @@
+// Package ics reads and writes iCalendar (RFC 5545) calendars
+package ics
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxLineLength is the maximal length of a content line in octets, the longer lines are folded
+const maxLineLength = 75
+
+// The statuses of an event
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusTentative = "TENTATIVE"
+	StatusCancelled = "CANCELLED"
+)
+
+// Calendar is a VCALENDAR
+// The name is shown by the clients subscribing to the calendar
+type Calendar struct {
+	ProdID string
+	Name   string
+	Events []Event
+}
+
+// Event is a VEVENT
+// The start and the end are written in their location with a TZID, or in UTC when the location is UTC
+// The recurrence rule is optional, e.g. FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR
+type Event struct {
+	UID          string
+	Summary      string
+	Description  string
+	Location     string
+	URL          string
+	Start        time.Time
+	End          time.Time
+	RRule        string
+	Status       string
+	Created      time.Time
+	LastModified time.Time
+}
+
+// Encode writes the calendar to w
+// A VTIMEZONE is written for every location used by the events
+func (c *Calendar) Encode(w io.Writer) error {
+	out := &writer{w: bufio.NewWriter(w)}
+	out.line("BEGIN", "VCALENDAR")
+	out.line("VERSION", "2.0")
+	out.line("PRODID", c.ProdID)
+	out.line("CALSCALE", "GREGORIAN")
+	out.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		out.line("X-WR-CALNAME", Escape(c.Name))
+	}
+
+	for _, location := range c.locations() {
+		writeTimezone(out, location, c.firstYear(location))
+	}
+	stamp := time.Now().UTC()
+	for _, event := range c.Events {
+		out.line("BEGIN", "VEVENT")
+		out.line("UID", event.UID)
+		out.line("DTSTAMP", formatUTC(stamp))
+		out.dateTime("DTSTART", event.Start)
+		out.dateTime("DTEND", event.End)
+		if event.RRule != "" {
+			out.line("RRULE", event.RRule)
+		}
+		out.line("SUMMARY", Escape(event.Summary))
+		if event.Description != "" {
+			out.line("DESCRIPTION", Escape(event.Description))
+		}
+		if event.Location != "" {
+			out.line("LOCATION", Escape(event.Location))
+		}
+		if event.URL != "" {
+			out.line("URL", event.URL)
+		}
+		if event.Status != "" {
+			out.line("STATUS", event.Status)
+		}
+		if !event.Created.IsZero() {
+			out.line("CREATED", formatUTC(event.Created))
+		}
+		if !event.LastModified.IsZero() {
+			out.line("LAST-MODIFIED", formatUTC(event.LastModified))
+		}
+		out.line("END", "VEVENT")
+	}
+	out.line("END", "VCALENDAR")
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+// locations returns the locations of the events other than UTC, sorted by name
+func (c *Calendar) locations() []*time.Location {
+	byName := map[string]*time.Location{}
+	for _, event := range c.Events {
+		for _, t := range []time.Time{event.Start, event.End} {
+			if !isUTC(t.Location()) {
+				byName[t.Location().String()] = t.Location()
+			}
+		}
+	}
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	locations := make([]*time.Location, len(names))
+	for i, name := range names {
+		locations[i] = byName[name]
+	}
+	return locations
+}
+
+// firstYear returns the year of the earliest event in the location
+func (c *Calendar) firstYear(location *time.Location) int {
+	year := 0
+	for _, event := range c.Events {
+		if event.Start.Location().String() == location.String() && (year == 0 || event.Start.Year() < year) {
+			year = event.Start.Year()
+		}
+	}
+	return year
+}
+
+// Escape escapes a text value
+func Escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// writer writes folded content lines and keeps the first error
+type writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line writes a property, the value must already be escaped
+func (w *writer) line(name string, value string) {
+	w.fold(name + ":" + value)
+}
+
+// dateTime writes a date-time property in the location of t
+func (w *writer) dateTime(name string, t time.Time) {
+	if isUTC(t.Location()) {
+		w.line(name, formatUTC(t))
+		return
+	}
+	w.line(name+";TZID="+t.Location().String(), t.Format("20060102T150405"))
+}
+
+// fold writes a content line split in lines of at most 75 octets, without splitting a UTF-8 character
+func (w *writer) fold(line string) {
+	if w.err != nil {
+		return
+	}
+	first := true
+	for len(line) > 0 {
+		limit := maxLineLength
+		if !first {
+			// The continuation lines start with a space
+			limit--
+		}
+		cut := len(line)
+		if cut > limit {
+			cut = limit
+			for cut > 0 && !isCharStart(line[cut]) {
+				cut--
+			}
+		}
+		if !first {
+			w.write(" ")
+		}
+		w.write(line[:cut] + "\r\n")
+		line = line[cut:]
+		first = false
+	}
+}
+
+// write writes s unless an error occurred
+func (w *writer) write(s string) {
+	if w.err == nil {
+		_, w.err = w.w.WriteString(s)
+	}
+}
+
+// isCharStart returns true if the byte starts a UTF-8 character
+func isCharStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// isUTC returns true if the location is UTC
+func isUTC(location *time.Location) bool {
+	return location == time.UTC || location.String() == "UTC"
+}
+
+// formatUTC formats t as a UTC date-time
+func formatUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
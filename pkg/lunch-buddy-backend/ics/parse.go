@@ -0,0 +1,236 @@
+package ics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxLineSize is the maximal size of an unfolded content line
+const maxLineSize = 1 << 20
+
+// Component is a parsed calendar component such as VCALENDAR, VEVENT or VFREEBUSY
+type Component struct {
+	Name       string
+	Properties []Property
+	Components []*Component
+}
+
+// Property is a parsed content line, the value is not unescaped
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Property returns the first property with the name, or nil
+func (c *Component) Property(name string) *Property {
+	for i := range c.Properties {
+		if c.Properties[i].Name == name {
+			return &c.Properties[i]
+		}
+	}
+	return nil
+}
+
+// All returns every property with the name
+func (c *Component) All(name string) []Property {
+	var properties []Property
+	for _, property := range c.Properties {
+		if property.Name == name {
+			properties = append(properties, property)
+		}
+	}
+	return properties
+}
+
+// Value returns the value of the first property with the name, or an empty string
+func (c *Component) Value(name string) string {
+	if property := c.Property(name); property != nil {
+		return property.Value
+	}
+	return ""
+}
+
+// Parse reads a calendar
+// It returns the VCALENDAR component, or an error if the content is not a calendar
+func Parse(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	var stack []*Component
+	var root *Component
+	for number, line := range lines {
+		if line == "" {
+			continue
+		}
+		property, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number+1, err)
+		}
+		switch property.Name {
+		case "BEGIN":
+			component := &Component{Name: strings.ToUpper(property.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, component)
+			} else if root == nil {
+				root = component
+			}
+			stack = append(stack, component)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(property.Value) {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", number+1, property.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: property outside of a component", number+1)
+			}
+			current := stack[len(stack)-1]
+			current.Properties = append(current.Properties, property)
+		}
+	}
+	if root == nil || root.Name != "VCALENDAR" {
+		return nil, errors.New("not an iCalendar file")
+	}
+	if len(stack) > 0 {
+		return nil, errors.New("unterminated " + stack[len(stack)-1].Name)
+	}
+	return root, nil
+}
+
+// unfold returns the content lines, the folded lines joined
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseLine parses a content line such as DTSTART;TZID=Europe/Bratislava:20240105T120000
+func parseLine(line string) (Property, error) {
+	property := Property{Params: map[string]string{}}
+	quoted := false
+	start := 0
+	name := ""
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '"':
+			quoted = !quoted
+		case quoted:
+		case line[i] == ';' || line[i] == ':':
+			part := line[start:i]
+			if name == "" {
+				name = part
+			} else if key, value, ok := strings.Cut(part, "="); ok {
+				property.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+			}
+			start = i + 1
+			if line[i] == ':' {
+				if name == "" {
+					return property, errors.New("missing property name")
+				}
+				property.Name = strings.ToUpper(name)
+				property.Value = line[i+1:]
+				return property, nil
+			}
+		}
+	}
+	return property, errors.New("missing property value")
+}
+
+// Time returns the date or date-time value of the property
+// The floating times and the unknown time zones are read in the location
+// It returns true when the value is a date, i.e. an all-day value
+func (p *Property) Time(location *time.Location) (time.Time, bool, error) {
+	return parseTime(p.Value, p.Params["VALUE"], p.Params["TZID"], location)
+}
+
+// parseTime parses a date or a date-time, see Property.Time
+func parseTime(value string, kind string, tzid string, location *time.Location) (time.Time, bool, error) {
+	if tzid != "" {
+		if zone, err := time.LoadLocation(tzid); err == nil {
+			location = zone
+		}
+	}
+	if strings.EqualFold(kind, "DATE") || len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, location)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, location)
+	return t, false, err
+}
+
+// ParseDuration parses a duration such as PT1H30M, P1D or -P1W
+func ParseDuration(value string) (time.Duration, error) {
+	sign := time.Duration(1)
+	rest := value
+	switch {
+	case strings.HasPrefix(rest, "-"):
+		sign = -1
+		rest = rest[1:]
+	case strings.HasPrefix(rest, "+"):
+		rest = rest[1:]
+	}
+	if !strings.HasPrefix(rest, "P") || len(rest) < 3 {
+		return 0, errors.New("invalid duration " + value)
+	}
+	rest = rest[1:]
+	var duration time.Duration
+	inTime := false
+	number := 0
+	digits := false
+	for _, char := range rest {
+		if char >= '0' && char <= '9' {
+			number = number*10 + int(char-'0')
+			digits = true
+			continue
+		}
+		if char == 'T' && !inTime && !digits {
+			inTime = true
+			continue
+		}
+		if !digits {
+			return 0, errors.New("invalid duration " + value)
+		}
+		unit := time.Duration(0)
+		switch {
+		case char == 'W' && !inTime:
+			unit = 7 * 24 * time.Hour
+		case char == 'D' && !inTime:
+			unit = 24 * time.Hour
+		case char == 'H' && inTime:
+			unit = time.Hour
+		case char == 'M' && inTime:
+			unit = time.Minute
+		case char == 'S' && inTime:
+			unit = time.Second
+		default:
+			return 0, errors.New("invalid duration " + value)
+		}
+		duration += time.Duration(number) * unit
+		number = 0
+		digits = false
+	}
+	if digits {
+		return 0, errors.New("invalid duration " + value)
+	}
+	return sign * duration, nil
+}
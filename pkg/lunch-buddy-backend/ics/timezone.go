@@ -0,0 +1,106 @@
+package ics
+
+import (
+	"fmt"
+	"time"
+)
+
+// transition is a change of the offset of a location
+type transition struct {
+	// at is the instant of the change
+	at time.Time
+	// from and to are the offsets in seconds before and after the change
+	from, to int
+	// name is the abbreviation after the change, e.g. CEST
+	name string
+	// daylight is true if the change starts the daylight saving time
+	daylight bool
+}
+
+// writeTimezone writes the VTIMEZONE of a location
+// The daylight saving rules are derived from the transitions of the year and written as yearly RRULEs
+// A location without daylight saving time gets a single STANDARD observance
+func writeTimezone(w *writer, location *time.Location, year int) {
+	w.line("BEGIN", "VTIMEZONE")
+	w.line("TZID", location.String())
+	transitions := yearTransitions(location, year)
+	if len(transitions) == 0 {
+		name, offset := time.Date(year, time.January, 1, 0, 0, 0, 0, location).Zone()
+		w.line("BEGIN", "STANDARD")
+		w.line("DTSTART", "19700101T000000")
+		w.line("TZOFFSETFROM", formatOffset(offset))
+		w.line("TZOFFSETTO", formatOffset(offset))
+		w.line("TZNAME", name)
+		w.line("END", "STANDARD")
+	}
+	for _, change := range transitions {
+		component := "STANDARD"
+		if change.daylight {
+			component = "DAYLIGHT"
+		}
+		// The local wall time when the change happens, in the offset before it
+		local := change.at.In(time.FixedZone("", change.from))
+		w.line("BEGIN", component)
+		w.line("DTSTART", local.Format("20060102T150405"))
+		w.line("TZOFFSETFROM", formatOffset(change.from))
+		w.line("TZOFFSETTO", formatOffset(change.to))
+		w.line("TZNAME", change.name)
+		w.line("RRULE", yearlyRule(local))
+		w.line("END", component)
+	}
+	w.line("END", "VTIMEZONE")
+}
+
+// yearTransitions returns the changes of offset of the location during the year
+// The changes are found day by day and refined to the second
+func yearTransitions(location *time.Location, year int) []transition {
+	var transitions []transition
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(1, 0, 0)
+	_, previous := start.In(location).Zone()
+	for day := start; day.Before(end); day = day.Add(24 * time.Hour) {
+		next := day.Add(24 * time.Hour)
+		name, offset := next.In(location).Zone()
+		if offset == previous {
+			continue
+		}
+		// Binary search of the first second with the new offset
+		low, high := day.Unix(), next.Unix()
+		for high-low > 1 {
+			middle := (low + high) / 2
+			if _, o := time.Unix(middle, 0).In(location).Zone(); o == previous {
+				low = middle
+			} else {
+				high = middle
+			}
+		}
+		transitions = append(transitions, transition{
+			at:       time.Unix(high, 0),
+			from:     previous,
+			to:       offset,
+			name:     name,
+			daylight: offset > previous,
+		})
+		previous = offset
+	}
+	return transitions
+}
+
+// yearlyRule returns the RRULE repeating a change every year, e.g. on the last Sunday of March
+func yearlyRule(local time.Time) string {
+	weekday := [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}[local.Weekday()]
+	if local.AddDate(0, 0, 7).Month() != local.Month() {
+		return fmt.Sprintf("FREQ=YEARLY;BYMONTH=%d;BYDAY=-1%s", local.Month(), weekday)
+	}
+	return fmt.Sprintf("FREQ=YEARLY;BYMONTH=%d;BYDAY=%d%s", local.Month(), (local.Day()-1)/7+1, weekday)
+}
+
+// formatOffset formats an offset in seconds, e.g. +0100
+func formatOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, offset/3600, offset%3600/60)
+}
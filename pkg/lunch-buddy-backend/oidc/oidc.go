@@ -0,0 +1,162 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// maxResponseSize bounds the responses read from the provider
+const maxResponseSize = 1 << 20
+
+// Config is the client registered at the provider
+// The scopes must contain openid
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Provider is an OpenID Connect provider found by Discover
+type Provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+
+	client *http.Client
+	mutex  sync.Mutex
+	keys   map[string]interface{}
+}
+
+// Token is the response of the token endpoint
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Error is an error returned by the token endpoint
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// Error returns the code and the description of the error
+func (e *Error) Error() string {
+	if e.Description == "" {
+		return "oidc: " + e.Code
+	}
+	return "oidc: " + e.Code + ": " + e.Description
+}
+
+// Discover reads the configuration of the provider from its well-known document
+// The issuer of the document must be the issuer, without its trailing slash
+// It returns an error if the document could not be read or is invalid
+func Discover(ctx context.Context, client *http.Client, issuer string) (*Provider, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+	provider := &Provider{client: client}
+	if err := provider.get(ctx, issuer+"/.well-known/openid-configuration", provider); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(provider.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("oidc: the issuer %q does not match %q", provider.Issuer, issuer)
+	}
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JWKSURI == "" {
+		return nil, errors.New("oidc: the provider configuration is incomplete")
+	}
+	return provider, nil
+}
+
+// AuthCodeURL returns the url of the authorization endpoint starting an authorization code flow
+// The state and the nonce are returned by the provider, the challenge is the S256 challenge of the PKCE verifier
+func (p *Provider) AuthCodeURL(config Config, state string, nonce string, challenge string) string {
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {config.ClientID},
+		"redirect_uri":          {config.RedirectURL},
+		"scope":                 {strings.Join(config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(p.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return p.AuthorizationEndpoint + separator + query.Encode()
+}
+
+// Exchange exchanges an authorization code for the tokens
+// The verifier is the PKCE verifier of the challenge given to AuthCodeURL
+// A client with a secret authenticates with HTTP basic authentication
+// It returns an *Error if the provider refused the code
+func (p *Provider) Exchange(ctx context.Context, config Config, code string, verifier string) (*Token, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {config.RedirectURL},
+		"client_id":     {config.ClientID},
+		"code_verifier": {verifier},
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if config.ClientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(config.ClientID), url.QueryEscape(config.ClientSecret))
+	}
+	response, err := p.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		var tokenError Error
+		if json.Unmarshal(body, &tokenError) == nil && tokenError.Code != "" {
+			return nil, &tokenError
+		}
+		return nil, fmt.Errorf("oidc: the token endpoint answered %s", response.Status)
+	}
+	var token Token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, err
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("oidc: the token response has no id token")
+	}
+	return &token, nil
+}
+
+// get reads a JSON document of the provider into out
+func (p *Provider) get(ctx context.Context, url string, out interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	response, err := p.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: %s answered %s", url, response.Status)
+	}
+	return json.NewDecoder(io.LimitReader(response.Body, maxResponseSize)).Decode(out)
+}
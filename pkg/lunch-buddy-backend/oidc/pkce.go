@@ -0,0 +1,27 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// verifierSize is the number of random bytes of a PKCE verifier, 43 characters once encoded
+const verifierSize = 32
+
+// NewVerifier returns a random PKCE code verifier
+// It is also used for the states and the nonces
+// returns an error if the random source fails
+func NewVerifier() (string, error) {
+	buffer := make([]byte, verifierSize)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
+
+// Challenge returns the S256 PKCE challenge of a verifier
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
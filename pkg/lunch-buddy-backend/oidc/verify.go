@@ -0,0 +1,192 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// signingMethods are the accepted algorithms of the id tokens
+var signingMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+// IDToken holds the claims of a verified id token
+type IDToken struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	GivenName     string
+	FamilyName    string
+	// Claims are every claim of the token
+	Claims jwt.MapClaims
+}
+
+// Claim returns a string claim of the token, empty if it is missing or not a string
+func (t *IDToken) Claim(name string) string {
+	value, _ := t.Claims[name].(string)
+	return value
+}
+
+// Verify checks the signature, the issuer, the audience, the expiry and the nonce of an id token
+// The signing keys are read from the jwks_uri of the provider and read again when a key is unknown
+// It returns the claims of the token or an error if it is invalid
+func (p *Provider) Verify(ctx context.Context, clientID string, rawIDToken string, nonce string) (*IDToken, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods(signingMethods))
+	token, err := parser.Parse(rawIDToken, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("oidc: invalid id token: %w", err)
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("oidc: invalid id token")
+	}
+	if !claims.VerifyIssuer(p.Issuer, true) {
+		return nil, errors.New("oidc: the id token was issued by another issuer")
+	}
+	if !claims.VerifyAudience(clientID, true) {
+		return nil, errors.New("oidc: the id token was issued for another client")
+	}
+	if !claims.VerifyExpiresAt(jwt.TimeFunc().Unix(), true) {
+		return nil, errors.New("oidc: the id token has no expiry")
+	}
+	if claimed, _ := claims["nonce"].(string); claimed != nonce {
+		return nil, errors.New("oidc: the nonce of the id token does not match")
+	}
+	idToken := &IDToken{Claims: claims}
+	idToken.Subject = idToken.Claim("sub")
+	if idToken.Subject == "" {
+		return nil, errors.New("oidc: the id token has no subject")
+	}
+	idToken.Email = idToken.Claim("email")
+	idToken.Name = idToken.Claim("name")
+	idToken.GivenName = idToken.Claim("given_name")
+	idToken.FamilyName = idToken.Claim("family_name")
+	// Some providers send the boolean as a string
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		idToken.EmailVerified = verified
+	case string:
+		idToken.EmailVerified = verified == "true"
+	}
+	return idToken, nil
+}
+
+// key returns the signing key with the id, the keys are read again once when the id is unknown
+// A token without key id is accepted when the provider has a single key
+func (p *Provider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for attempt := 0; attempt < 2; attempt++ {
+		if key, ok := p.keys[kid]; ok {
+			return key, nil
+		}
+		if kid == "" && len(p.keys) == 1 {
+			for _, key := range p.keys {
+				return key, nil
+			}
+		}
+		if attempt == 0 {
+			if err := p.loadKeys(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, fmt.Errorf("oidc: unknown signing key %q", kid)
+}
+
+// jwk is a JSON web key, only the fields of the RSA and EC public keys are read
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// loadKeys reads the signing keys of the provider, the keys which are not RSA or EC keys are skipped
+func (p *Provider) loadKeys(ctx context.Context) error {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.get(ctx, p.JWKSURI, &set); err != nil {
+		return err
+	}
+	keys := map[string]interface{}{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if key, err := k.publicKey(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+	p.keys = keys
+	return nil
+}
+
+// publicKey returns the RSA or EC public key of the JSON web key
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("oidc: invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("oidc: unsupported curve %q", k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("oidc: the EC key is not on its curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("oidc: unsupported key type %q", k.Kty)
+	}
+}
+
+// decodeInt decodes a base64url encoded big endian integer
+func decodeInt(value string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(bytes) == 0 {
+		return nil, errors.New("oidc: invalid key parameter")
+	}
+	return new(big.Int).SetBytes(bytes), nil
+}
@@ -0,0 +1,125 @@
+package textsearch
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Scores of the different kinds of matches
+// The better the match the higher the score
+const (
+	ScoreExact      = 100
+	ScorePrefix     = 80
+	ScoreWordPrefix = 60
+	ScoreContains   = 40
+	ScoreFuzzy      = 20
+)
+
+// Fold returns the form of s used for searching
+// It lower cases s, removes the diacritics and collapses the whitespace
+//
+// Example: " Čítanie  kníh" becomes "citanie knih"
+func Fold(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		folded = s
+	}
+	return strings.ToLower(strings.Join(strings.Fields(folded), " "))
+}
+
+// Score returns how well candidate matches query
+// Both are expected to be folded with Fold
+// When fuzzy is true, a prefix within a few typos still matches
+// It returns false if candidate does not match query at all
+func Score(query string, candidate string, fuzzy bool) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+	switch {
+	case candidate == query:
+		return ScoreExact, true
+	case strings.HasPrefix(candidate, query):
+		return ScorePrefix, true
+	case strings.Contains(candidate, " "+query):
+		return ScoreWordPrefix, true
+	case strings.Contains(candidate, query):
+		return ScoreContains, true
+	}
+	if !fuzzy {
+		return 0, false
+	}
+
+	maxEdits := allowedEdits(query)
+	if maxEdits == 0 {
+		return 0, false
+	}
+	best := maxEdits + 1
+	for _, word := range append([]string{candidate}, strings.Fields(candidate)...) {
+		if distance := prefixDistance(query, word); distance < best {
+			best = distance
+		}
+	}
+	if best > maxEdits {
+		return 0, false
+	}
+	return ScoreFuzzy - best, true
+}
+
+// allowedEdits returns the number of typos tolerated in query
+// Short queries must match exactly
+func allowedEdits(query string) int {
+	length := len([]rune(query))
+	switch {
+	case length < 3:
+		return 0
+	case length < 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// prefixDistance returns the smallest Levenshtein distance between query and a prefix of word
+func prefixDistance(query string, word string) int {
+	q, w := []rune(query), []rune(word)
+	previous := make([]int, len(w)+1)
+	current := make([]int, len(w)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(q); i++ {
+		current[0] = i
+		for j := 1; j <= len(w); j++ {
+			cost := 1
+			if q[i-1] == w[j-1] {
+				cost = 0
+			}
+			current[j] = minimum(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	// The last row holds the distances between query and every prefix of word
+	best := previous[0]
+	for _, distance := range previous {
+		if distance < best {
+			best = distance
+		}
+	}
+	return best
+}
+
+// minimum returns the smallest of the given numbers
+func minimum(numbers ...int) int {
+	smallest := numbers[0]
+	for _, n := range numbers[1:] {
+		if n < smallest {
+			smallest = n
+		}
+	}
+	return smallest
+}
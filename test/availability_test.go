@@ -0,0 +1,164 @@
+package test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/availability"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/availability"
+	users "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/ics"
+)
+
+// fixtureWindow is the span of time covered by the calendar fixtures
+var fixtureWindow = [2]time.Time{
+	time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2030, 2, 1, 0, 0, 0, 0, time.UTC),
+}
+
+// readBusyFixture returns the busy periods of a calendar of testdata, dates and floating times read in Bratislava
+func readBusyFixture(t *testing.T, name string) []ics.Period {
+	file, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer file.Close()
+	calendar, err := ics.Parse(file)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	location, _ := time.LoadLocation("Europe/Bratislava")
+	periods, err := ics.BusyPeriods(calendar, fixtureWindow[0], fixtureWindow[1], location)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return periods
+}
+
+// expectPeriods checks the periods against the expected UTC start and end times
+func expectPeriods(t *testing.T, periods []ics.Period, expected [][2]string) {
+	if len(periods) != len(expected) {
+		t.Fatalf("Expected %d busy periods, got %d: %v", len(expected), len(periods), periods)
+	}
+	for i, period := range periods {
+		start := period.Start.UTC().Format(time.RFC3339)
+		end := period.End.UTC().Format(time.RFC3339)
+		if start != expected[i][0] || end != expected[i][1] {
+			t.Errorf("Expected busy period %s - %s, got %s - %s", expected[i][0], expected[i][1], start, end)
+		}
+	}
+}
+
+func TestParseBusyEvents(t *testing.T) {
+	expectPeriods(t, readBusyFixture(t, "busy.ics"), [][2]string{
+		{"2030-01-07T08:30:00Z", "2030-01-07T08:45:00Z"},
+		{"2030-01-07T11:00:00Z", "2030-01-07T12:00:00Z"},
+		{"2030-01-08T11:00:00Z", "2030-01-08T12:00:00Z"},
+		{"2030-01-09T23:00:00Z", "2030-01-10T23:00:00Z"},
+		{"2030-01-14T09:00:00Z", "2030-01-14T09:15:00Z"},
+		{"2030-01-16T08:30:00Z", "2030-01-16T08:45:00Z"},
+	})
+}
+
+func TestParseFreeBusy(t *testing.T) {
+	expectPeriods(t, readBusyFixture(t, "freebusy.ics"), [][2]string{
+		{"2030-01-07T11:00:00Z", "2030-01-07T12:00:00Z"},
+		{"2030-01-08T09:00:00Z", "2030-01-08T10:00:00Z"},
+		{"2030-01-10T11:00:00Z", "2030-01-10T11:30:00Z"},
+	})
+}
+
+func TestParseInvalidCalendar(t *testing.T) {
+	file, err := os.Open("testdata/invalid.ics")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer file.Close()
+	if _, err := ics.Parse(file); err == nil {
+		t.Fatalf("Expected an error for an unterminated event")
+	}
+}
+
+func TestFreeLunches(t *testing.T) {
+	user, buddy := uuid.New(), uuid.New()
+	monday := time.Date(2030, 1, 7, 11, 0, 0, 0, time.UTC)
+	busy := availability.Busy{
+		buddy: {{Start: monday.Add(15 * time.Minute), End: monday.Add(time.Hour)}},
+	}
+	slots := []time.Time{monday, monday.AddDate(0, 0, 1)}
+	free := busy.FreeLunches(slots, user, buddy)
+	if len(free) != 1 || !free[0].Equal(slots[1]) {
+		t.Fatalf("Expected only the tuesday lunch to be free, got %v", free)
+	}
+	if len(busy.FreeLunches(slots, user)) != 2 {
+		t.Fatalf("Expected both lunches to be free for the user alone")
+	}
+}
+
+func TestImportAvailability(t *testing.T) {
+	setupDatabase(t)
+	u := persistence.GetUserRepository().Unscoped()
+	user := users.User{Firstname: "Busy", Lastname: "Bee", Username: "busy-" + uuid.NewString() + "@example.com", Hash: "hash"}
+	if err := u.Add(&user); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer func() { _ = u.Delete(&user) }()
+	source := models.Source{UserID: user.ID, Name: "Fixture"}
+	r := persistence.GetAvailabilityRepository().Unscoped()
+	if err := r.AddSource(&source); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	file, err := os.Open("testdata/freebusy.ics")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer file.Close()
+	if err := availability.Import(&user, &source, file); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if source.SyncedAt == nil || source.Error != "" {
+		t.Fatalf("Expected the source to be synchronized, got %+v", source)
+	}
+	if err := r.DeleteSource(&source); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+func TestCheckCalendarURL(t *testing.T) {
+	if err := config.Setup(config.Options{Path: "./config.yml"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, url := range []string{"http://127.0.0.1/calendar.ics", "webcal://localhost/calendar.ics", "http://10.0.0.8/calendar.ics",
+		"http://169.254.169.254/latest/meta-data", "http://[::1]:8080/calendar.ics"} {
+		if err := availability.CheckURL(url); !errors.Is(err, availability.ErrForbiddenAddress) {
+			t.Errorf("Expected %s to be forbidden, got %v", url, err)
+		}
+	}
+	if err := availability.CheckURL("ftp://93.184.216.34/calendar.ics"); err == nil {
+		t.Errorf("Expected a ftp url to be rejected")
+	}
+	if err := availability.CheckURL("https://93.184.216.34/calendar.ics"); err != nil {
+		t.Errorf("Expected a public address to be accepted, got %v", err)
+	}
+}
+
+func TestFetchPrivateCalendar(t *testing.T) {
+	if err := config.Setup(config.Options{Path: "./config.yml"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"))
+	}))
+	defer server.Close()
+	// The address is checked when connecting, whatever the url was when it was registered
+	if _, err := availability.Fetch(server.URL); !errors.Is(err, availability.ErrForbiddenAddress) {
+		t.Fatalf("Expected the loopback server not to be fetched, got %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+)
+
+// setupDatabase connects to the test database of config.yml and migrates it
+// The test is skipped when the database cannot be reached
+func setupDatabase(t *testing.T) {
+	if err := config.Setup(config.Options{Path: "./config.yml"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := db.SetupDB(); err != nil {
+		t.Skipf("Skipping, the test database cannot be reached: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/availability"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/matching"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	users "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+)
+
+func feedbackUser(username string, hobbies ...users.Hobby) users.User {
+	return users.User{Model: models.Model{ID: uuid.New()}, Username: username, Hobbies: hobbies}
+}
+
+func TestFeedbackMatching(t *testing.T) {
+	if err := config.Setup(config.Options{Path: "./config.yml"}); err != nil {
+		t.Fatal(err)
+	}
+	chess := users.Hobby{Model: models.Model{ID: uuid.New()}, Name: "chess"}
+	hiking := users.Hobby{Model: models.Model{ID: uuid.New()}, Name: "hiking"}
+	cooking := users.Hobby{Model: models.Model{ID: uuid.New()}, Name: "cooking"}
+	user := feedbackUser("user", chess, hiking, cooking)
+	candidates := []users.User{
+		user,
+		feedbackUser("companion", chess, hiking),
+		feedbackUser("similar", chess, hiking),
+		feedbackUser("other", cooking),
+		feedbackUser("disliked", chess, hiking, cooking),
+	}
+	feedback := matching.Feedback{user.ID: {
+		candidates[1].ID: true,
+		candidates[4].ID: false,
+	}}
+
+	suggestions := matching.Rank(&user, candidates, matching.Data{Busy: availability.Busy{}, Feedback: feedback}, 10)
+	scores := map[string]int{}
+	for _, suggestion := range suggestions {
+		scores[suggestion.Username] = suggestion.Score
+	}
+	if _, ok := scores["disliked"]; ok {
+		t.Errorf("Expected the disliked companion not to be suggested")
+	}
+	if scores["similar"] != 2*matching.HobbyWeight+matching.FeedbackWeight {
+		t.Errorf("Expected the user similar to the enjoyed companion to be boosted, got %d", scores["similar"])
+	}
+	if scores["other"] != matching.HobbyWeight {
+		t.Errorf("Expected no boost for a user unlike the enjoyed companion, got %d", scores["other"])
+	}
+
+	// The thumbs down suppresses the suggestion in both directions
+	disliked := candidates[4]
+	for _, suggestion := range matching.Rank(&disliked, candidates, matching.Data{Busy: availability.Busy{}, Feedback: feedback}, 10) {
+		if suggestion.Username == "user" {
+			t.Errorf("Expected the author of the thumbs down not to be suggested to the companion")
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/fixtures"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+)
+
+// fixturePaths are the fixtures shipped with the application
+var fixturePaths = []string{"../data/fixtures.yml", "../data/fixtures-demo.json"}
+
+// SetupFixtures loads the curated taxonomies and the demo users into the test database
+// It fails the test if the fixtures could not be loaded
+func SetupFixtures(t *testing.T) {
+	for _, path := range fixturePaths {
+		if _, err := fixtures.Load(path); err != nil {
+			t.Fatalf("Expected no error loading %s, got %v", path, err)
+		}
+	}
+}
+
+func TestLoadFixturesTwice(t *testing.T) {
+	setupDatabase(t)
+	SetupFixtures(t)
+
+	users := persistence.GetUserRepository().Unscoped()
+	user, err := users.GetByUsername("jana.novakova@example.com")
+	if err != nil {
+		t.Fatalf("Expected the demo user, got %v", err)
+	}
+	// An admin promoted the demo user, the fixtures do not say whether the user is an admin
+	user.IsAdmin = true
+	if err := users.Update(user); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer func() {
+		user.IsAdmin = false
+		_ = users.Update(user)
+	}()
+
+	for _, path := range fixturePaths {
+		report, err := fixtures.Load(path)
+		if err != nil {
+			t.Fatalf("Expected no error loading %s again, got %v", path, err)
+		}
+		if report.Created != 0 {
+			t.Errorf("Expected loading %s again to create nothing, got %s", path, report)
+		}
+	}
+	if user, err := users.GetByUsername("jana.novakova@example.com"); err != nil || !user.IsAdmin || !user.IsSetup {
+		t.Errorf("Expected the demo user to stay a set up admin, got %+v %v", user, err)
+	}
+}
@@ -0,0 +1,66 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/matching"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	users "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+)
+
+func TestPlaceOffers(t *testing.T) {
+	vegan := users.Diet{Name: "Vegan"}
+	glutenFree := users.Diet{Name: "Gluten-free"}
+	place := users.Place{Name: "Bistro Verde", Dietary: users.NewTags("vegetarian", "vegan", "Gluten-free")}
+	if !place.Offers([]users.Diet{vegan, glutenFree}) {
+		t.Errorf("Expected the place to cater for vegan and gluten-free diets")
+	}
+	if place.Offers([]users.Diet{{Name: "Halal"}}) {
+		t.Errorf("Expected the place not to cater for a halal diet")
+	}
+	if !place.Offers(nil) {
+		t.Errorf("Expected every place to cater for no diet")
+	}
+}
+
+func TestFoodMatching(t *testing.T) {
+	if err := config.Setup(config.Options{Path: "./config.yml"}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { config.GetConfig().Food.Matching = config.FoodMatchingPrefer }()
+	vegan := users.Diet{Model: models.Model{ID: uuid.New()}, Name: "Vegan"}
+	halal := users.Diet{Model: models.Model{ID: uuid.New()}, Name: "Halal"}
+	italian := users.Cuisine{Model: models.Model{ID: uuid.New()}, Name: "Italian"}
+	user := users.User{Model: models.Model{ID: uuid.New()}, Username: "user", Diets: []users.Diet{vegan}, Cuisines: []users.Cuisine{italian}}
+	candidates := []users.User{
+		{Model: models.Model{ID: uuid.New()}, Username: "vegan", Diets: []users.Diet{vegan}, Cuisines: []users.Cuisine{italian}},
+		{Model: models.Model{ID: uuid.New()}, Username: "halal", Diets: []users.Diet{halal}, Cuisines: []users.Cuisine{italian}},
+	}
+	places := []users.Place{{Name: "Bistro Verde", Dietary: users.NewTags("vegan")}}
+	data := matching.Data{Places: places}
+
+	scores := func() map[string]int {
+		scores := map[string]int{}
+		for _, suggestion := range matching.Rank(&user, candidates, data, 10) {
+			scores[suggestion.Username] = suggestion.Score
+		}
+		return scores
+	}
+	config.GetConfig().Food.Matching = config.FoodMatchingPrefer
+	if got := scores(); got["vegan"] != matching.DietWeight+matching.CuisineWeight || got["halal"] != matching.CuisineWeight {
+		t.Errorf("Expected the shared diet and cuisine to add up, got %v", got)
+	}
+	config.GetConfig().Food.Matching = config.FoodMatchingRequire
+	if _, ok := scores()["halal"]; ok {
+		t.Errorf("Expected no suggestion without a place catering for vegan and halal diets")
+	}
+	if !matching.FoodCompatible(&user, &candidates[0], places) {
+		t.Errorf("Expected two vegans to be compatible with a vegan place")
+	}
+	config.GetConfig().Food.Matching = config.FoodMatchingOff
+	if got := scores(); len(got) != 0 {
+		t.Errorf("Expected the food to be ignored, got %v", got)
+	}
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/places"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/geo"
+)
+
+func TestDistance(t *testing.T) {
+	bratislava := geo.Point{Latitude: 48.1486, Longitude: 17.1077}
+	vienna := geo.Point{Latitude: 48.2082, Longitude: 16.3738}
+	if distance := geo.Distance(bratislava, vienna); math.Abs(distance-54800) > 500 {
+		t.Errorf("Expected about 54.8 km between Bratislava and Vienna, got %.0f m", distance)
+	}
+	if distance := geo.Distance(bratislava, bratislava); distance != 0 {
+		t.Errorf("Expected no distance to the same point, got %f", distance)
+	}
+	nearest, ok := geo.Nearest([]geo.Point{vienna, bratislava}, []geo.Point{{Latitude: 48.1456, Longitude: 17.1167}})
+	if !ok || nearest > 1000 {
+		t.Errorf("Expected the canteen within a kilometer of Bratislava, got %.0f m", nearest)
+	}
+	if _, ok := geo.Nearest(nil, []geo.Point{vienna}); ok {
+		t.Errorf("Expected no distance without points")
+	}
+}
+
+func TestValidateCoordinates(t *testing.T) {
+	latitude, longitude, invalid := 48.1, 17.1, 200.0
+	if err := geo.Validate(nil, nil); err != nil {
+		t.Errorf("Expected no coordinates to be valid, got %v", err)
+	}
+	if err := geo.Validate(&latitude, nil); err == nil {
+		t.Errorf("Expected an error for a latitude without longitude")
+	}
+	if err := geo.Validate(&latitude, &invalid); err == nil {
+		t.Errorf("Expected an error for a longitude of 200")
+	}
+	if err := geo.Validate(&latitude, &longitude); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestPlacesOrigin(t *testing.T) {
+	latitude, longitude := 48.1456, 17.1167
+	unlocated := models.Area{Name: "Remote"}
+	located := models.Area{Name: "Engineering", Latitude: &latitude, Longitude: &longitude}
+	unlocated.ID, located.ID = uuid.New(), uuid.New()
+	user := models.User{Areas: []models.Area{unlocated, located}}
+	origin, err := places.Origin(&user, "")
+	if err != nil || origin.Latitude != latitude {
+		t.Fatalf("Expected the coordinates of the located area, got %v %v", origin, err)
+	}
+	if _, err := places.Origin(&user, unlocated.ID.String()); err != places.ErrNoOrigin {
+		t.Errorf("Expected ErrNoOrigin for an area without coordinates, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/jobs"
+)
+
+// expectNext checks the next run of a cron expression after a time, both in UTC and formatted like 2006-01-02 15:04
+func expectNext(t *testing.T, expression string, after string, expected string) {
+	schedule, err := jobs.ParseSchedule(expression)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	from, _ := time.Parse("2006-01-02 15:04", after)
+	if next := schedule.Next(from).Format("2006-01-02 15:04"); next != expected {
+		t.Errorf("Expected %q after %s to run at %s, got %s", expression, after, expected, next)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	expectNext(t, "*/5 * * * *", "2030-01-07 11:02", "2030-01-07 11:05")
+	expectNext(t, "*/5 * * * *", "2030-01-07 11:05", "2030-01-07 11:10")
+	expectNext(t, "0 6 * * *", "2030-01-07 06:00", "2030-01-08 06:00")
+	expectNext(t, "0 7 * * 1", "2030-01-07 08:00", "2030-01-14 07:00")
+	expectNext(t, "30 12 * * 1-5", "2030-01-11 13:00", "2030-01-14 12:30")
+	expectNext(t, "0 0 1,15 * *", "2030-01-02 00:00", "2030-01-15 00:00")
+	expectNext(t, "0 0 29 2 *", "2030-01-01 00:00", "2032-02-29 00:00")
+	expectNext(t, "0 9 1 * 0", "2030-01-02 00:00", "2030-01-06 09:00")
+	expectNext(t, "@daily", "2030-12-31 23:59", "2031-01-01 00:00")
+	expectNext(t, "0 0 * * 7", "2030-01-07 00:00", "2030-01-13 00:00")
+}
+
+func TestCronInvalid(t *testing.T) {
+	for _, expression := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := jobs.ParseSchedule(expression); err == nil {
+			t.Errorf("Expected %q to be invalid", expression)
+		}
+	}
+}
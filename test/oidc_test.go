@@ -0,0 +1,321 @@
+package test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	users "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/registration"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/sso"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/oidc"
+)
+
+// mockProvider is a local OpenID Connect provider
+// Its authorization endpoint logs the user of claims in without asking and redirects back with a code
+type mockProvider struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	kid      string
+	clientID string
+	secret   string
+	claims   jwt.MapClaims
+
+	mutex sync.Mutex
+	codes map[string]url.Values
+}
+
+// newMockProvider starts a provider with a client, it is closed at the end of the test
+func newMockProvider(t *testing.T, clientID string, secret string) *mockProvider {
+	p := &mockProvider{clientID: clientID, secret: secret, codes: map[string]url.Values{}}
+	p.rotate(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("client_id") != p.clientID || query.Get("code_challenge_method") != "S256" {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		code := base64.RawURLEncoding.EncodeToString(big.NewInt(time.Now().UnixNano()).Bytes())
+		p.mutex.Lock()
+		p.codes[code] = query
+		p.mutex.Unlock()
+		http.Redirect(w, r, query.Get("redirect_uri")+"?"+url.Values{"code": {code}, "state": {query.Get("state")}}.Encode(), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		refuse := func(code string) {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": code})
+		}
+		if id, secret, ok := r.BasicAuth(); !ok || id != p.clientID || secret != p.secret {
+			refuse("invalid_client")
+			return
+		}
+		p.mutex.Lock()
+		authorization, ok := p.codes[r.PostFormValue("code")]
+		delete(p.codes, r.PostFormValue("code"))
+		p.mutex.Unlock()
+		if !ok || r.PostFormValue("grant_type") != "authorization_code" ||
+			r.PostFormValue("redirect_uri") != authorization.Get("redirect_uri") ||
+			oidc.Challenge(r.PostFormValue("code_verifier")) != authorization.Get("code_challenge") {
+			refuse("invalid_grant")
+			return
+		}
+		claims := jwt.MapClaims{"iss": p.URL, "aud": p.clientID, "nonce": authorization.Get("nonce"),
+			"iat": time.Now().Unix(), "exp": time.Now().Add(time.Hour).Unix()}
+		for name, value := range p.claims {
+			claims[name] = value
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access", "token_type": "Bearer", "expires_in": 3600, "id_token": p.sign(claims),
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA", "use": "sig", "alg": "RS256", "kid": p.kid,
+			"n": base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}}})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// rotate replaces the signing key of the provider
+func (p *mockProvider) rotate(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.key, p.kid = key, time.Now().Format(time.RFC3339Nano)
+}
+
+// sign returns an id token with the claims signed by the current key
+func (p *mockProvider) sign(claims jwt.MapClaims) string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = p.kid
+	signed, _ := token.SignedString(p.key)
+	return signed
+}
+
+// login follows the authorization url of the provider and returns the code and the state of the callback
+func (p *mockProvider) login(t *testing.T, authorization string) (string, string) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	response, err := client.Get(authorization)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	location, err := url.Parse(response.Header.Get("Location"))
+	if response.StatusCode != http.StatusFound || err != nil {
+		t.Fatalf("Expected a redirect to the callback, got %s %v", response.Status, err)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestOIDCLogin(t *testing.T) {
+	p := newMockProvider(t, "lunch-buddy", "secret")
+	p.claims = jwt.MapClaims{"sub": "248289761001", "email": "jana@acme.com", "email_verified": true,
+		"given_name": "Jana", "family_name": "Nováková"}
+	ctx := context.Background()
+	provider, err := oidc.Discover(ctx, http.DefaultClient, p.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := oidc.Config{ClientID: "lunch-buddy", ClientSecret: "secret", RedirectURL: "http://localhost:3000/api/sso/callback",
+		Scopes: []string{"openid", "email", "profile"}}
+	verifier, _ := oidc.NewVerifier()
+	code, state := p.login(t, provider.AuthCodeURL(client, "state", "nonce", oidc.Challenge(verifier)))
+	if state != "state" {
+		t.Errorf("Expected the state to be returned, got %q", state)
+	}
+	token, err := provider.Exchange(ctx, client, code, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idToken, err := provider.Verify(ctx, client.ClientID, token.IDToken, "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idToken.Subject != "248289761001" || idToken.Email != "jana@acme.com" || !idToken.EmailVerified || idToken.FamilyName != "Nováková" {
+		t.Errorf("Expected the claims of the user, got %+v", idToken)
+	}
+	if _, err := provider.Exchange(ctx, client, code, verifier); err == nil {
+		t.Error("Expected a code to be used once")
+	}
+}
+
+func TestOIDCPKCE(t *testing.T) {
+	p := newMockProvider(t, "lunch-buddy", "secret")
+	p.claims = jwt.MapClaims{"sub": "1"}
+	ctx := context.Background()
+	provider, err := oidc.Discover(ctx, http.DefaultClient, p.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := oidc.Config{ClientID: "lunch-buddy", ClientSecret: "secret", RedirectURL: "http://localhost/callback", Scopes: []string{"openid"}}
+	verifier, _ := oidc.NewVerifier()
+	other, _ := oidc.NewVerifier()
+	code, _ := p.login(t, provider.AuthCodeURL(client, "state", "nonce", oidc.Challenge(verifier)))
+	var tokenError *oidc.Error
+	if _, err := provider.Exchange(ctx, client, code, other); !errors.As(err, &tokenError) || tokenError.Code != "invalid_grant" {
+		t.Errorf("Expected another verifier to be refused, got %v", err)
+	}
+	client.ClientSecret = "wrong"
+	code, _ = p.login(t, provider.AuthCodeURL(client, "state", "nonce", oidc.Challenge(verifier)))
+	if _, err := provider.Exchange(ctx, client, code, verifier); !errors.As(err, &tokenError) || tokenError.Code != "invalid_client" {
+		t.Errorf("Expected a wrong secret to be refused, got %v", err)
+	}
+}
+
+func TestOIDCVerify(t *testing.T) {
+	p := newMockProvider(t, "lunch-buddy", "secret")
+	ctx := context.Background()
+	provider, err := oidc.Discover(ctx, http.DefaultClient, p.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := func(changes jwt.MapClaims) jwt.MapClaims {
+		claims := jwt.MapClaims{"iss": p.URL, "aud": []string{"lunch-buddy", "other"}, "sub": "1", "nonce": "nonce",
+			"exp": time.Now().Add(time.Minute).Unix()}
+		for name, value := range changes {
+			if value == nil {
+				delete(claims, name)
+			} else {
+				claims[name] = value
+			}
+		}
+		return claims
+	}
+	if _, err := provider.Verify(ctx, "lunch-buddy", p.sign(claims(nil)), "nonce"); err != nil {
+		t.Errorf("Expected a valid token, got %v", err)
+	}
+	invalid := map[string]jwt.MapClaims{
+		"another nonce":    {"nonce": "other"},
+		"another audience": {"aud": "other"},
+		"another issuer":   {"iss": "https://evil.example.com"},
+		"an expired token": {"exp": time.Now().Add(-time.Minute).Unix()},
+		"no expiry":        {"exp": nil},
+		"no subject":       {"sub": nil},
+	}
+	for name, changes := range invalid {
+		if _, err := provider.Verify(ctx, "lunch-buddy", p.sign(claims(changes)), "nonce"); err == nil {
+			t.Errorf("Expected a token with %s to be refused", name)
+		}
+	}
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims(nil)).SignedString([]byte("secret"))
+	if _, err := provider.Verify(ctx, "lunch-buddy", unsigned, "nonce"); err == nil {
+		t.Error("Expected a token signed with a shared secret to be refused")
+	}
+	p.rotate(t)
+	if _, err := provider.Verify(ctx, "lunch-buddy", p.sign(claims(nil)), "nonce"); err != nil {
+		t.Errorf("Expected the keys to be read again after a rotation, got %v", err)
+	}
+}
+
+func TestSSONewUser(t *testing.T) {
+	user := sso.NewUser(&oidc.IDToken{Subject: "1", Name: "Jana Nováková Kováčová", Email: "Jana@acme.com", EmailVerified: true}, "jana@acme.com")
+	if user.Username != "jana@acme.com" || user.Firstname != "Jana" || user.Lastname != "Nováková Kováčová" ||
+		user.OIDCSubject == nil || *user.OIDCSubject != "1" || user.EmailVerifiedAt == nil || user.Hash != "" {
+		t.Errorf("Expected the user of the claims, got %+v", user)
+	}
+	user = sso.NewUser(&oidc.IDToken{Subject: "2", GivenName: "Peter", FamilyName: "Horváth", Name: "Peter H."}, "peter@acme.com")
+	if user.Firstname != "Peter" || user.Lastname != "Horváth" || user.EmailVerifiedAt != nil {
+		t.Errorf("Expected the given and family names and an unverified email, got %+v", user)
+	}
+	// The username claim is not the email address the provider verified
+	user = sso.NewUser(&oidc.IDToken{Subject: "3", Email: "peter@acme.com", EmailVerified: true}, "peter.horvath@acme.com")
+	if user.EmailVerifiedAt != nil {
+		t.Errorf("Expected the username not to be verified, got %+v", user)
+	}
+}
+
+func TestSSOResolve(t *testing.T) {
+	setupDatabase(t)
+	configuration := config.GetConfig()
+	oidcConfiguration, registrationConfiguration := configuration.OIDC, configuration.Registration
+	defer func() { configuration.OIDC, configuration.Registration = oidcConfiguration, registrationConfiguration }()
+	configuration.OIDC.UsernameClaim, configuration.OIDC.LinkAccounts, configuration.OIDC.Provision = "preferred_username", true, true
+	domain := "sso-" + uuid.NewString() + ".example.com"
+	configuration.Registration.Policy, configuration.Registration.Domains = config.RegistrationDomain, []string{domain}
+
+	u := persistence.GetUserRepository().Unscoped()
+	user := users.User{Firstname: "Jana", Lastname: "Nováková", Username: "jana@" + domain, Hash: "hash"}
+	if err := u.Add(&user); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer func() { _ = u.Delete(&user) }()
+
+	// The provider verified another email address than the username
+	idToken := &oidc.IDToken{Subject: uuid.NewString(), Email: "mallory@" + domain, EmailVerified: true,
+		Claims: jwt.MapClaims{"preferred_username": user.Username}}
+	if _, err := sso.Resolve(context.Background(), idToken); !errors.Is(err, sso.ErrLinkRefused) {
+		t.Errorf("Expected the link to be refused, got %v", err)
+	}
+	idToken.Email = "JANA@" + domain
+	linked, err := sso.Resolve(context.Background(), idToken)
+	if err != nil || linked.ID != user.ID || linked.OIDCSubject == nil || *linked.OIDCSubject != idToken.Subject {
+		t.Fatalf("Expected the user to be linked, got %+v %v", linked, err)
+	}
+
+	// The registration policy only accepts the domain
+	idToken = &oidc.IDToken{Subject: uuid.NewString(), Email: "peter@acme.com", EmailVerified: true,
+		Claims: jwt.MapClaims{"preferred_username": "peter@acme.com"}}
+	if _, err := sso.Resolve(context.Background(), idToken); !errors.Is(err, registration.ErrDomainNotAllowed) {
+		t.Errorf("Expected the provisioning to be refused, got %v", err)
+	}
+	idToken.Claims["preferred_username"] = "peter@" + domain
+	provisioned, err := sso.Resolve(context.Background(), idToken)
+	if err != nil || provisioned.Username != "peter@"+domain {
+		t.Fatalf("Expected the user to be provisioned, got %+v %v", provisioned, err)
+	}
+	_ = u.Delete(provisioned)
+}
+
+func TestValidateOIDCConfig(t *testing.T) {
+	configuration, err := config.Load(config.Options{Path: "./config.yml", Overrides: map[string]interface{}{"oidc.enabled": true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := configuration.Validate(); err == nil {
+		t.Error("Expected the issuer and the client to be required")
+	}
+	configuration.OIDC.Issuer, configuration.OIDC.ClientID = "https://login.acme.com", "lunch-buddy"
+	if err := configuration.Validate(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	configuration.OIDC.Scopes = []string{"email"}
+	if err := configuration.Validate(); err == nil {
+		t.Error("Expected the openid scope to be required")
+	}
+}
@@ -0,0 +1,55 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/fixtures"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+)
+
+func TestReadPlacesCSV(t *testing.T) {
+	places, err := fixtures.ReadPlaces("testdata/places.csv")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(places) != 2 {
+		t.Fatalf("Expected 2 places, got %d", len(places))
+	}
+	canteen := places[0]
+	if canteen.Name != "kantína" || canteen.Address != "Karadžičova 2, Bratislava" || canteen.PriceLevel != 1 {
+		t.Errorf("Expected the canteen, got %+v", canteen)
+	}
+	if canteen.Latitude == nil || *canteen.Latitude != 48.1456 || len(canteen.Aliases) != 1 {
+		t.Errorf("Expected the coordinates and the alias of the canteen, got %+v", canteen)
+	}
+	if places[1].Latitude != nil || places[1].Longitude != nil || len(places[1].Dietary) != 2 {
+		t.Errorf("Expected a bistro without coordinates, got %+v", places[1])
+	}
+}
+
+func TestPlaceTags(t *testing.T) {
+	tags := models.NewTags("Slovak", " canteen ", "slovak", "", "Gluten  Free")
+	if len(tags) != 3 || tags[0] != "slovak" || tags[2] != "gluten free" {
+		t.Fatalf("Expected normalized tags, got %v", tags)
+	}
+	if !tags.Has("SLOVAK") || tags.Has("italian") {
+		t.Errorf("Expected the tags to contain slovak only, got %v", tags)
+	}
+	var scanned models.Tags
+	if err := scanned.Scan("vegan,vegetarian"); err != nil || !scanned.Has("vegan") || len(scanned) != 2 {
+		t.Errorf("Expected the stored tags to be read, got %v %v", scanned, err)
+	}
+}
+
+func TestValidatePlace(t *testing.T) {
+	latitude := 48.1
+	if err := (&models.Place{Name: "Kantína", PriceLevel: 5}).Validate(); err == nil {
+		t.Errorf("Expected an error for a price level of 5")
+	}
+	if err := (&models.Place{Name: "Kantína", Latitude: &latitude}).Validate(); err == nil {
+		t.Errorf("Expected an error for a latitude without longitude")
+	}
+	if err := (&models.Place{Name: "Kantína", Latitude: &latitude, Longitude: &latitude}).Validate(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	users "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/registration"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/crypto"
+)
+
+func TestRegistrationPolicy(t *testing.T) {
+	domains := []string{"acme.com", "Acme.sk"}
+	cases := []struct {
+		policy string
+		domain string
+		want   error
+	}{
+		{config.RegistrationOpen, "gmail.com", nil},
+		{config.RegistrationDomain, "acme.com", nil},
+		{config.RegistrationDomain, "acme.sk", nil},
+		{config.RegistrationDomain, "gmail.com", registration.ErrDomainNotAllowed},
+		{config.RegistrationInvite, "acme.com", registration.ErrInviteRequired},
+	}
+	for _, c := range cases {
+		if err := registration.Allow(c.policy, domains, c.domain); err != c.want {
+			t.Errorf("Expected %v for %s with the %s policy, got %v", c.want, c.domain, c.policy, err)
+		}
+	}
+}
+
+func TestRegistrationDomain(t *testing.T) {
+	if domain, ok := registration.Domain("jana.novakova@Acme.com"); !ok || domain != "acme.com" {
+		t.Errorf("Expected the domain acme.com, got %q %v", domain, ok)
+	}
+	for _, username := range []string{"admin", "Jana <jana@acme.com>", "jana@"} {
+		if _, ok := registration.Domain(username); ok {
+			t.Errorf("Expected %q not to be an email address", username)
+		}
+	}
+}
+
+func TestVerificationToken(t *testing.T) {
+	if err := config.Setup(config.Options{Path: "./config.yml"}); err != nil {
+		t.Fatal(err)
+	}
+	token, err := crypto.CreateSignedToken(registration.VerificationPurpose, "jana@acme.com", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject, err := crypto.ParseSignedToken(registration.VerificationPurpose, token); err != nil || subject != "jana@acme.com" {
+		t.Errorf("Expected the username of the token, got %q %v", subject, err)
+	}
+	if _, err := crypto.ParseSignedToken("password_reset", token); err == nil {
+		t.Error("Expected a token of another purpose to be rejected")
+	}
+	if _, err := crypto.ParseClaims(token); err == nil {
+		t.Error("Expected a verification token not to authenticate")
+	}
+	login, _ := crypto.CreateToken("jana@acme.com", "")
+	if _, err := crypto.ParseSignedToken(registration.VerificationPurpose, login); err == nil {
+		t.Error("Expected a login token not to verify an email address")
+	}
+	expired, _ := crypto.CreateSignedToken(registration.VerificationPurpose, "jana@acme.com", -time.Minute)
+	if _, err := crypto.ParseSignedToken(registration.VerificationPurpose, expired); err == nil {
+		t.Error("Expected an expired token to be rejected")
+	}
+}
+
+func TestInviteCodeValid(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	cases := []struct {
+		code users.InviteCode
+		want bool
+	}{
+		{users.InviteCode{MaxUses: 1}, true},
+		{users.InviteCode{MaxUses: 1, Uses: 1}, false},
+		{users.InviteCode{MaxUses: 10, Uses: 3, ExpiresAt: &future}, true},
+		{users.InviteCode{MaxUses: 10, ExpiresAt: &past}, false},
+	}
+	for _, c := range cases {
+		if got := c.code.Valid(now); got != c.want {
+			t.Errorf("Expected %v for %+v, got %v", c.want, c.code, got)
+		}
+	}
+}
+
+func TestValidateRegistrationPolicy(t *testing.T) {
+	organization := users.Organization{Name: "Acme", Slug: "acme", RegistrationPolicy: config.RegistrationDomain, EmailDomains: " acme.com, ACME.sk "}
+	if err := organization.Validate(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if domains := organization.Domains(); len(domains) != 2 || domains[1] != "acme.sk" {
+		t.Errorf("Expected the normalized domains, got %v", domains)
+	}
+	if err := (&users.Organization{Name: "Acme", Slug: "acme", RegistrationPolicy: config.RegistrationDomain}).Validate(); err == nil {
+		t.Error("Expected the domain policy to require domains")
+	}
+	if err := (&users.Organization{Name: "Acme", Slug: "acme", RegistrationPolicy: "closed"}).Validate(); err == nil {
+		t.Error("Expected an unknown policy to be rejected")
+	}
+
+	configuration, err := config.Load(config.Options{Path: "./config.yml", Overrides: map[string]interface{}{"registration.policy": config.RegistrationDomain}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := configuration.Validate(); err == nil {
+		t.Error("Expected the domain policy of the configuration to require domains")
+	}
+}
+
+func TestRegisterTakenUsername(t *testing.T) {
+	setupDatabase(t)
+	u := persistence.GetUserRepository().Unscoped()
+	user := users.User{Firstname: "Jana", Lastname: "Nováková", Username: "jana@" + uuid.NewString() + ".example.com", Hash: "hash"}
+	if err := u.Add(&user); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer func() { _ = u.Delete(&user) }()
+
+	// The usernames are unique in the whole deployment, not only in the organization
+	organization := users.Organization{Name: "Taken " + uuid.NewString(), Slug: uuid.NewString()}
+	if err := persistence.GetOrganizationRepository().Add(&organization); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer func() { _ = persistence.GetOrganizationRepository().Delete(&organization) }()
+	duplicate := users.User{Firstname: "Mallory", Username: user.Username, Hash: "hash"}
+	scoped := persistence.GetUserRepository().Scoped(db.WithOrganization(context.Background(), &organization.ID))
+	if err := scoped.Register(&duplicate, nil); !errors.Is(err, persistence.ErrUsernameTaken) {
+		t.Errorf("Expected the username to be taken, got %v", err)
+		_ = u.Delete(&duplicate)
+	}
+	if taken, err := u.UsernameTaken(user.Username, user.ID); err != nil || taken {
+		t.Errorf("Expected the username not to be taken by another user, got %v %v", taken, err)
+	}
+}
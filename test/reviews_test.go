@@ -0,0 +1,103 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+)
+
+func TestValidateReview(t *testing.T) {
+	review := models.Review{Rating: 4, Text: "Great goulash", Dishes: models.NewTags("Goulash", "goulash")}
+	if err := review.Validate(); err != nil {
+		t.Errorf("Expected a valid review, got %v", err)
+	}
+	if len(review.Dishes) != 1 {
+		t.Errorf("Expected the duplicate dishes to be dropped, got %v", review.Dishes)
+	}
+	for _, rating := range []int{0, 6} {
+		if err := (&models.Review{Rating: rating}).Validate(); err == nil {
+			t.Errorf("Expected an error for a rating of %d", rating)
+		}
+	}
+	if err := (&models.Review{Rating: 3, Text: strings.Repeat("a", models.MaxReviewLength+1)}).Validate(); err == nil {
+		t.Errorf("Expected an error for a too long text")
+	}
+}
+
+func TestInvitationAttended(t *testing.T) {
+	now := time.Now()
+	inviter, invitee, placeID := uuid.New(), uuid.New(), uuid.New()
+	invitation := models.Invitation{InviterID: inviter, InviteeID: invitee, PlaceID: &placeID,
+		Status: models.InvitationAccepted, Time: now.Add(-time.Hour)}
+	if !invitation.Attended(invitee, now) || !invitation.Attended(inviter, now) {
+		t.Errorf("Expected both users to have attended the lunch")
+	}
+	if invitation.Attended(uuid.New(), now) {
+		t.Errorf("Expected another user not to have attended the lunch")
+	}
+	if invitation.Attended(invitee, now.Add(-2*time.Hour)) {
+		t.Errorf("Expected a future lunch not to be attended")
+	}
+	invitation.Status = models.InvitationPending
+	if invitation.Attended(invitee, now) {
+		t.Errorf("Expected a pending invitation not to be attended")
+	}
+	invitation.Status, invitation.PlaceID = models.InvitationAccepted, nil
+	if invitation.Attended(invitee, now) {
+		t.Errorf("Expected a lunch without a place not to be reviewable")
+	}
+}
+
+func TestPlaceRatingPerOrganization(t *testing.T) {
+	setupDatabase(t)
+	organization := models.Organization{Name: "Rating " + uuid.NewString(), Slug: uuid.NewString()}
+	if err := persistence.GetOrganizationRepository().Add(&organization); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer func() { _ = persistence.GetOrganizationRepository().Delete(&organization) }()
+	place := models.Place{Name: "Bistro " + uuid.NewString()}
+	if err := persistence.GetPlaceRepository().Add(&place); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	u := persistence.GetUserRepository().Unscoped()
+	var reviewers []models.User
+	// The place is deleted with its reviews before their authors
+	defer func() {
+		_ = persistence.GetPlaceRepository().Delete(&place)
+		for i := range reviewers {
+			_ = u.Delete(&reviewers[i])
+		}
+	}()
+	acme := db.WithOrganization(context.Background(), &organization.ID)
+	others := db.WithOrganization(context.Background(), nil)
+	for _, review := range []struct {
+		ctx    context.Context
+		rating int
+	}{{acme, 5}, {acme, 4}, {others, 1}} {
+		user := models.User{Firstname: "Jana", Username: "jana@" + uuid.NewString() + ".example.com", Hash: "hash"}
+		if review.ctx == acme {
+			user.OrganizationID = &organization.ID
+		}
+		if err := u.Add(&user); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		reviewers = append(reviewers, user)
+		err := persistence.GetReviewRepository().Scoped(review.ctx).Add(&models.Review{UserID: user.ID, PlaceID: place.ID, InvitationID: uuid.New(), Rating: review.rating})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if err := persistence.GetReviewRepository().Scoped(acme).Rate([]*models.Place{&place}); err != nil || place.Rating != 4.5 || place.RatingCount != 2 {
+		t.Errorf("Expected the 2 reviews of the organization, got %v of %d %v", place.Rating, place.RatingCount, err)
+	}
+	if err := persistence.GetReviewRepository().Scoped(others).Rate([]*models.Place{&place}); err != nil || place.Rating != 1 || place.RatingCount != 1 {
+		t.Errorf("Expected the review of the default organization, got %v of %d %v", place.Rating, place.RatingCount, err)
+	}
+}
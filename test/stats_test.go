@@ -0,0 +1,110 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	users "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/stats"
+)
+
+func statsUser(areas ...users.Area) users.User {
+	return users.User{Model: models.Model{ID: uuid.New()}, Areas: areas}
+}
+
+func TestComputeStats(t *testing.T) {
+	location := time.UTC
+	// A Wednesday
+	now := time.Date(2026, 10, 21, 15, 0, 0, 0, location)
+	hiking := users.Hobby{Model: models.Model{ID: uuid.New()}, Name: "Hiking"}
+	user := statsUser()
+	user.Hobbies = []users.Hobby{hiking}
+	jana, peter := statsUser(), statsUser()
+	jana.Hobbies = []users.Hobby{hiking}
+	place := users.Place{Name: "Kantína"}
+	lunch := func(other uuid.UUID, at time.Time, status string) users.Invitation {
+		return users.Invitation{InviterID: user.ID, InviteeID: other, Time: at, Status: status, Place: &place}
+	}
+	invitations := []users.Invitation{
+		lunch(jana.ID, now.AddDate(0, 0, -21), users.InvitationAccepted),
+		lunch(jana.ID, now.AddDate(0, 0, -14), users.InvitationAccepted),
+		lunch(peter.ID, now.AddDate(0, 0, -1), users.InvitationAccepted),
+		lunch(peter.ID, now.AddDate(0, 0, -2), users.InvitationDeclined),
+		lunch(peter.ID, now.AddDate(0, 0, 1), users.InvitationAccepted),
+	}
+	companions := map[uuid.UUID]*users.User{jana.ID: &jana, peter.ID: &peter}
+
+	result := stats.Compute(&user, invitations, companions, now, location)
+	if result.Lunches != 3 || result.Buddies != 2 {
+		t.Errorf("Expected 3 lunches with 2 buddies, got %d with %d", result.Lunches, result.Buddies)
+	}
+	if len(result.PerWeek) != 3 || len(result.PerMonth) != 2 {
+		t.Errorf("Expected 3 weeks and 2 months, got %v and %v", result.PerWeek, result.PerMonth)
+	}
+	if len(result.FavoritePlaces) != 1 || result.FavoritePlaces[0].Count != 3 {
+		t.Errorf("Expected the canteen 3 times, got %v", result.FavoritePlaces)
+	}
+	if len(result.SharedHobbies) != 1 || result.SharedHobbies[0].Count != 2 {
+		t.Errorf("Expected hiking shared twice, got %v", result.SharedHobbies)
+	}
+	if result.LongestStreak != 2 || result.CurrentStreak != 1 {
+		t.Errorf("Expected a longest streak of 2 weeks and a current one of 1, got %d and %d", result.LongestStreak, result.CurrentStreak)
+	}
+}
+
+func TestComputeDashboard(t *testing.T) {
+	engineering := users.Area{Model: models.Model{ID: uuid.New()}, Name: "Engineering"}
+	sales := users.Area{Model: models.Model{ID: uuid.New()}, Name: "Sales"}
+	legal := users.Area{Model: models.Model{ID: uuid.New()}, Name: "Legal"}
+	all := []users.User{
+		statsUser(engineering), statsUser(engineering), statsUser(engineering),
+		statsUser(sales), statsUser(sales), statsUser(sales),
+		statsUser(legal),
+	}
+	lunch := func(a int, b int) users.Invitation {
+		return users.Invitation{InviterID: all[a].ID, InviteeID: all[b].ID, Status: users.InvitationAccepted}
+	}
+	dashboard := stats.ComputeDashboard([]users.Invitation{lunch(0, 1), lunch(0, 3), lunch(4, 6)}, all)
+	if dashboard.Lunches != 3 || dashboard.CrossAreaLunches != 2 {
+		t.Errorf("Expected 2 of 3 lunches across areas, got %d of %d", dashboard.CrossAreaLunches, dashboard.Lunches)
+	}
+	for _, area := range dashboard.Areas {
+		switch area.Area {
+		case "Engineering":
+			if area.Lunches != 2 || area.CrossArea != 1 || area.CrossRatio != 0.5 {
+				t.Errorf("Expected half of the 2 engineering lunches across areas, got %+v", area)
+			}
+		case "Sales":
+			if area.Lunches != 2 || area.CrossArea != 2 || len(area.Connections) != 2 {
+				t.Errorf("Expected 2 sales lunches with engineering and the other areas, got %+v", area)
+			}
+		default:
+			t.Errorf("Expected the small areas not to be shown, got %s", area.Area)
+		}
+	}
+}
+
+func TestComputeDashboardTeams(t *testing.T) {
+	engineering := users.Area{Model: models.Model{ID: uuid.New()}, Name: "Engineering"}
+	platform, mobile, legal := uuid.New(), uuid.New(), uuid.New()
+	all := []users.User{
+		statsUser(engineering), statsUser(engineering), statsUser(engineering), statsUser(engineering),
+		statsUser(engineering), statsUser(engineering), statsUser(engineering), statsUser(engineering),
+	}
+	for i, team := range []*uuid.UUID{&platform, &platform, &platform, &mobile, &mobile, &mobile, &legal, nil} {
+		all[i].TeamID = team
+	}
+	lunch := func(a int, b int) users.Invitation {
+		return users.Invitation{InviterID: all[a].ID, InviteeID: all[b].ID, Status: users.InvitationAccepted}
+	}
+	// Across teams, within the team, with the small team and with a user without team
+	dashboard := stats.ComputeDashboard([]users.Invitation{lunch(0, 3), lunch(0, 1), lunch(4, 6), lunch(2, 7)}, all)
+	if dashboard.Lunches != 4 || dashboard.CrossTeamLunches != 2 {
+		t.Errorf("Expected 2 of 4 lunches across teams, got %d of %d", dashboard.CrossTeamLunches, dashboard.Lunches)
+	}
+	if len(dashboard.Areas) != 1 || dashboard.Areas[0].CrossTeam != 2 || dashboard.Areas[0].CrossTeamRatio != 0.5 {
+		t.Errorf("Expected half of the engineering lunches across teams, got %+v", dashboard.Areas)
+	}
+}
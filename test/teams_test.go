@@ -0,0 +1,87 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/fixtures"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/matching"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	users "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+)
+
+func TestReadMemberships(t *testing.T) {
+	memberships, err := fixtures.ReadMemberships("testdata/teams.csv")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(memberships) != 2 {
+		t.Fatalf("Expected 2 memberships, got %d", len(memberships))
+	}
+	if memberships[0] != (fixtures.Membership{Username: "jana@example.com", Team: "Platform", Department: "Engineering"}) {
+		t.Errorf("Expected jana in the platform team, got %+v", memberships[0])
+	}
+	if memberships[1].Team != "" {
+		t.Errorf("Expected peter without team, got %+v", memberships[1])
+	}
+	if _, err := fixtures.ReadMembershipsCSV(strings.NewReader("username,squad\njana,Platform\n")); err == nil {
+		t.Errorf("Expected an error for an unknown column")
+	}
+	if _, err := fixtures.ReadMembershipsCSV(strings.NewReader("team\nPlatform\n")); err == nil {
+		t.Errorf("Expected an error without the username column")
+	}
+}
+
+func TestTeamMatching(t *testing.T) {
+	if err := config.Setup(config.Options{Path: "./config.yml"}); err != nil {
+		t.Fatal(err)
+	}
+	platform, recruiting := uuid.New(), uuid.New()
+	chess := users.Hobby{Name: "Chess"}
+	member := func(name string, team *uuid.UUID, preference string) users.User {
+		return users.User{Model: models.Model{ID: uuid.New()}, Username: name, TeamID: team, TeamMatching: preference, Hobbies: []users.Hobby{chess}}
+	}
+	suggested := func(user users.User, candidates []users.User) map[string]bool {
+		found := map[string]bool{}
+		for _, suggestion := range matching.Rank(&user, candidates, matching.Data{}, 10) {
+			found[suggestion.Username] = true
+		}
+		return found
+	}
+	candidates := []users.User{
+		member("teammate", &platform, users.TeamMatchingAny),
+		member("recruiter", &recruiting, users.TeamMatchingAny),
+		member("freelancer", nil, users.TeamMatchingAny),
+	}
+
+	if got := suggested(member("any", &platform, users.TeamMatchingAny), candidates); len(got) != 3 {
+		t.Errorf("Expected every user to be suggested, got %v", got)
+	}
+	if got := suggested(member("same", &platform, users.TeamMatchingSame), candidates); len(got) != 1 || !got["teammate"] {
+		t.Errorf("Expected only the teammate to be suggested, got %v", got)
+	}
+	if got := suggested(member("other", &platform, users.TeamMatchingOther), candidates); len(got) != 2 || got["teammate"] {
+		t.Errorf("Expected the teammate not to be suggested, got %v", got)
+	}
+	if got := suggested(member("teamless", nil, users.TeamMatchingSame), candidates); len(got) != 3 {
+		t.Errorf("Expected the preference of a user without team to be ignored, got %v", got)
+	}
+	// The preference of the candidate is honoured too
+	candidates[1].TeamMatching = users.TeamMatchingSame
+	if got := suggested(member("any", &platform, users.TeamMatchingAny), candidates); got["recruiter"] {
+		t.Errorf("Expected the recruiter preferring its own team not to be suggested, got %v", got)
+	}
+}
+
+func TestValidTeamMatching(t *testing.T) {
+	for _, preference := range []string{users.TeamMatchingAny, users.TeamMatchingSame, users.TeamMatchingOther} {
+		if !users.ValidTeamMatching(preference) {
+			t.Errorf("Expected %s to be valid", preference)
+		}
+	}
+	if users.ValidTeamMatching("department") || users.ValidTeamMatching("") {
+		t.Errorf("Expected unknown preferences to be invalid")
+	}
+}
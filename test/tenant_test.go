@@ -0,0 +1,143 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/db"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/matching"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models"
+	users "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/users"
+	"github.com/sHyben/lunch-buddy-backend/pkg/lunch-buddy-backend/crypto"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRun returns a database which builds the statements without running them
+func dryRun(t *testing.T) *gorm.DB {
+	database, err := gorm.Open(postgres.Open("host=localhost dbname=lunch"), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RegisterTenantScope(database); err != nil {
+		t.Fatal(err)
+	}
+	return database
+}
+
+func TestTenantScope(t *testing.T) {
+	database := dryRun(t)
+	organizationID := uuid.New()
+	scoped := db.WithOrganization(context.Background(), &organizationID)
+
+	statement := database.WithContext(scoped).Where("username = ?", "jana").Find(&[]users.User{}).Statement
+	if sql := statement.SQL.String(); !strings.Contains(sql, `"users"."organization_id" = $2`) {
+		t.Errorf("Expected the users to be scoped to the organization, got %s", sql)
+	}
+	if len(statement.Vars) != 2 || statement.Vars[1] != organizationID {
+		t.Errorf("Expected the organization to be bound, got %v", statement.Vars)
+	}
+	defaultOrganization := db.WithOrganization(context.Background(), nil)
+	if sql := database.WithContext(defaultOrganization).Find(&[]users.Hobby{}).Statement.SQL.String(); !strings.Contains(sql, `"hobbies"."organization_id" IS NULL`) {
+		t.Errorf("Expected the hobbies to be scoped to the default organization, got %s", sql)
+	}
+	if err := database.Find(&[]users.User{}).Error; !errors.Is(err, db.ErrNoOrganization) {
+		t.Errorf("Expected a context without organization to fail, got %v", err)
+	}
+	if err := database.Create(&users.Hobby{Name: "Chess"}).Error; !errors.Is(err, db.ErrNoOrganization) {
+		t.Errorf("Expected a creation without organization to fail, got %v", err)
+	}
+	if err := database.Find(&[]users.Place{}).Error; err != nil {
+		t.Errorf("Expected the shared places not to need an organization, got %v", err)
+	}
+	unscoped := db.WithoutOrganization(context.Background())
+	if statement := database.WithContext(unscoped).Find(&[]users.User{}).Statement; statement.Error != nil || strings.Contains(statement.SQL.String(), "organization_id") {
+		t.Errorf("Expected an unscoped context to see every organization, got %s %v", statement.SQL.String(), statement.Error)
+	}
+	if sql := database.WithContext(scoped).Find(&[]users.Invitation{}).Statement.SQL.String(); !strings.Contains(sql, `"invitations"."organization_id" = $1`) {
+		t.Errorf("Expected the invitations to be scoped to the organization, got %s", sql)
+	}
+	if sql := database.WithContext(scoped).Find(&[]users.Alias{}).Statement.SQL.String(); !strings.Contains(sql, `"aliases"."organization_id" = $1`) {
+		t.Errorf("Expected the aliases to be scoped to the organization, got %s", sql)
+	}
+	if sql := database.WithContext(scoped).Find(&[]users.Place{}).Statement.SQL.String(); strings.Contains(sql, "organization_id") {
+		t.Errorf("Expected the shared places not to be scoped, got %s", sql)
+	}
+	if sql := database.WithContext(scoped).Model(&users.User{}).Where("id = ?", uuid.New()).Update("bio", "").Statement.SQL.String(); !strings.Contains(sql, "organization_id") {
+		t.Errorf("Expected the updates to be scoped, got %s", sql)
+	}
+
+	hobby := users.Hobby{Name: "Chess"}
+	database.WithContext(scoped).Create(&hobby)
+	if hobby.OrganizationID == nil || *hobby.OrganizationID != organizationID {
+		t.Errorf("Expected the created hobby to belong to the organization, got %v", hobby.OrganizationID)
+	}
+	other := uuid.New()
+	team := users.Team{Name: "Platform", OrganizationID: &other}
+	if err := database.WithContext(scoped).Create(&team).Error; !errors.Is(err, db.ErrOtherOrganization) {
+		t.Errorf("Expected a team of another organization to be refused, got %v", err)
+	}
+	team = users.Team{Name: "Platform", OrganizationID: &other}
+	if err := database.WithContext(unscoped).Create(&team).Error; err != nil || *team.OrganizationID != other {
+		t.Errorf("Expected an unscoped context to keep the explicit organization, got %v %v", team.OrganizationID, err)
+	}
+}
+
+func TestOrganizationClaims(t *testing.T) {
+	if err := config.Setup(config.Options{Path: "./config.yml"}); err != nil {
+		t.Fatal(err)
+	}
+	organizationID := uuid.New().String()
+	token, err := crypto.CreateToken("jana@example.com", organizationID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := crypto.ParseClaims("Bearer " + token)
+	if err != nil || claims.Username != "jana@example.com" || claims.Organization != organizationID {
+		t.Errorf("Expected the username and the organization of the token, got %+v %v", claims, err)
+	}
+	token, _ = crypto.CreateToken("peter@example.com", "")
+	if claims, err := crypto.ParseClaims(token); err != nil || claims.Organization != "" {
+		t.Errorf("Expected a token of the default organization, got %+v %v", claims, err)
+	}
+	if username, err := crypto.ParseToken(token); err != nil || username != "peter@example.com" {
+		t.Errorf("Expected the username of the token, got %s %v", username, err)
+	}
+}
+
+func TestOrganizationMatching(t *testing.T) {
+	if err := config.Setup(config.Options{Path: "./config.yml"}); err != nil {
+		t.Fatal(err)
+	}
+	acme, globex := uuid.New(), uuid.New()
+	chess := users.Hobby{Name: "Chess"}
+	member := func(name string, organizationID *uuid.UUID) users.User {
+		return users.User{Model: models.Model{ID: uuid.New()}, Username: name, OrganizationID: organizationID, Hobbies: []users.Hobby{chess}}
+	}
+	candidates := []users.User{member("colleague", &acme), member("competitor", &globex), member("default", nil)}
+	user := member("user", &acme)
+	suggestions := matching.Rank(&user, candidates, matching.Data{}, 10)
+	if len(suggestions) != 1 || suggestions[0].Username != "colleague" {
+		t.Errorf("Expected only the colleague of the same organization, got %v", suggestions)
+	}
+	user = member("user", nil)
+	suggestions = matching.Rank(&user, candidates, matching.Data{}, 10)
+	if len(suggestions) != 1 || suggestions[0].Username != "default" {
+		t.Errorf("Expected only the user of the default organization, got %v", suggestions)
+	}
+}
+
+func TestValidateOrganization(t *testing.T) {
+	if err := (&users.Organization{Name: "Acme Slovakia", Slug: "acme-slovakia"}).Validate(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	for _, slug := range []string{"", "Acme", "acme slovakia", "-acme", "acme--sk"} {
+		if err := (&users.Organization{Name: "Acme", Slug: slug}).Validate(); err == nil {
+			t.Errorf("Expected an error for the slug %q", slug)
+		}
+	}
+}
@@ -12,8 +12,12 @@ import (
 var userTest models.User
 
 func Setup() {
-	config.Setup("./config.yml")
-	db.SetupDB()
+	if err := config.Setup(config.Options{Path: "./config.yml"}); err != nil {
+		panic(err)
+	}
+	if err := db.SetupDB(); err != nil {
+		panic(err)
+	}
 	db.GetDB().Exec("DELETE FROM users")
 }
 
@@ -24,9 +28,8 @@ func TestAddUser(t *testing.T) {
 		Lastname:  "Paya",
 		Username:  "antonio",
 		Hash:      "hash",
-		Role:      models.UserRole{RoleName: "user"},
 	}
-	s := persistence.GetUserRepository()
+	s := persistence.GetUserRepository().Unscoped()
 	if err := s.Add(&user); err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -34,7 +37,7 @@ func TestAddUser(t *testing.T) {
 }
 
 func TestGetAllUsers(t *testing.T) {
-	s := persistence.GetUserRepository()
+	s := persistence.GetUserRepository().Unscoped()
 	if _, err := s.All(); err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -43,7 +46,7 @@ func TestGetAllUsers(t *testing.T) {
 func TestGetUserById(t *testing.T) {
 	db.SetupDB()
 	db.SetupDB()
-	s := persistence.GetUserRepository()
+	s := persistence.GetUserRepository().Unscoped()
 	if _, err := s.Get(fmt.Sprint(userTest.ID)); err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -0,0 +1,160 @@
+package test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/config"
+	models "github.com/sHyben/lunch-buddy-backend/internal/pkg/private/models/webhooks"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/persistence"
+	"github.com/sHyben/lunch-buddy-backend/internal/pkg/private/webhooks"
+)
+
+const webhookTestSecret = "webhook-test-secret"
+
+// WebhookRequest is a request received by a WebhookReceiver
+type WebhookRequest struct {
+	Event     string
+	Delivery  string
+	Body      []byte
+	Signature bool
+}
+
+// WebhookReceiver is a local webhook endpoint recording the requests it receives
+// The first failures requests are answered with a 500 to exercise the retries
+type WebhookReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	failures int
+	requests []WebhookRequest
+}
+
+// NewWebhookReceiver starts a receiver checking the signatures with the secret
+// It is closed when the test ends
+func NewWebhookReceiver(t *testing.T, secret string, failures int) *WebhookReceiver {
+	receiver := &WebhookReceiver{failures: failures}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receiver.mu.Lock()
+		defer receiver.mu.Unlock()
+		receiver.requests = append(receiver.requests, WebhookRequest{
+			Event:     r.Header.Get(webhooks.HeaderEvent),
+			Delivery:  r.Header.Get(webhooks.HeaderDelivery),
+			Body:      body,
+			Signature: webhooks.Verify(secret, r.Header.Get(webhooks.HeaderTimestamp), r.Header.Get(webhooks.HeaderSignature), body, time.Minute),
+		})
+		if receiver.failures > 0 {
+			receiver.failures--
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver
+}
+
+// Requests returns the requests received so far
+func (r *WebhookReceiver) Requests() []WebhookRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]WebhookRequest{}, r.requests...)
+}
+
+// newTestWebhook registers a webhook pointing to the receiver
+func newTestWebhook(t *testing.T, receiver *WebhookReceiver) *models.Webhook {
+	webhook := models.Webhook{URL: receiver.URL, Secret: webhookTestSecret, Active: true}
+	if err := persistence.GetWebhookRepository().Add(&webhook); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return &webhook
+}
+
+func TestWebhookSignature(t *testing.T) {
+	body := []byte(`{"type":"ping"}`)
+	timestamp := time.Now().Unix()
+	signature := webhooks.Sign(webhookTestSecret, timestamp, body)
+	if !webhooks.Verify(webhookTestSecret, fmtUnix(timestamp), signature, body, time.Minute) {
+		t.Fatalf("Expected the signature to be valid")
+	}
+	if webhooks.Verify("another-secret", fmtUnix(timestamp), signature, body, time.Minute) {
+		t.Fatalf("Expected the signature of another secret to be invalid")
+	}
+	if webhooks.Verify(webhookTestSecret, fmtUnix(timestamp), signature, []byte(`{"type":"tampered"}`), time.Minute) {
+		t.Fatalf("Expected the signature of another body to be invalid")
+	}
+}
+
+func TestWebhookAttempt(t *testing.T) {
+	receiver := NewWebhookReceiver(t, webhookTestSecret, 0)
+	sender := webhooks.NewSender(config.WebhooksConfiguration{Timeout: time.Second, PollInterval: time.Second, BatchSize: 1, MaxAttempts: 3})
+	webhook := models.Webhook{URL: receiver.URL, Secret: webhookTestSecret, Active: true}
+	delivery := models.Delivery{EventType: webhooks.PingEvent, Payload: `{"type":"ping"}`, Status: models.DeliveryPending}
+	sender.Attempt(&webhook, &delivery)
+	if delivery.Status != models.DeliverySucceeded || delivery.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected a successful delivery, got %s %d %s", delivery.Status, delivery.StatusCode, delivery.Error)
+	}
+	requests := receiver.Requests()
+	if len(requests) != 1 || !requests[0].Signature || requests[0].Event != webhooks.PingEvent {
+		t.Fatalf("Expected one signed ping, got %+v", requests)
+	}
+}
+
+func TestWebhookPing(t *testing.T) {
+	setupDatabase(t)
+	receiver := NewWebhookReceiver(t, webhookTestSecret, 0)
+	webhook := newTestWebhook(t, receiver)
+	delivery, err := webhooks.NewSender(config.GetConfig().Webhooks).Ping(webhook)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if delivery.Status != models.DeliverySucceeded || len(receiver.Requests()) != 1 {
+		t.Fatalf("Expected the ping to be delivered, got %s %s", delivery.Status, delivery.Error)
+	}
+	deliveries, err := persistence.GetWebhookRepository().Deliveries(webhook.ID, "", 10, 0)
+	if err != nil || len(*deliveries) != 1 {
+		t.Fatalf("Expected the ping in the delivery log, got %v %v", deliveries, err)
+	}
+}
+
+func TestWebhookRetry(t *testing.T) {
+	setupDatabase(t)
+	receiver := NewWebhookReceiver(t, webhookTestSecret, 1)
+	webhook := newTestWebhook(t, receiver)
+	r := persistence.GetWebhookRepository()
+	delivery := models.Delivery{WebhookID: webhook.ID, EventType: "buddy.matched", Payload: `{"type":"buddy.matched"}`}
+	if err := r.Enqueue(&delivery); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	sender := webhooks.NewSender(config.WebhooksConfiguration{Timeout: time.Second, PollInterval: time.Second, BatchSize: 10, MaxAttempts: 3})
+	if _, err := sender.SendPending(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	deliveries, _ := r.Deliveries(webhook.ID, models.DeliveryPending, 10, 0)
+	if len(*deliveries) != 1 || (*deliveries)[0].Attempts != 1 || !(*deliveries)[0].NextAttemptAt.After(time.Now()) {
+		t.Fatalf("Expected the failed delivery to be retried later, got %+v", deliveries)
+	}
+
+	retry := (*deliveries)[0]
+	retry.NextAttemptAt = time.Now()
+	if err := r.UpdateDelivery(&retry); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := sender.SendPending(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	deliveries, _ = r.Deliveries(webhook.ID, models.DeliverySucceeded, 10, 0)
+	if len(*deliveries) != 1 || (*deliveries)[0].Attempts != 2 || len(receiver.Requests()) != 2 {
+		t.Fatalf("Expected the delivery to succeed on the second attempt, got %+v", deliveries)
+	}
+}
+
+// fmtUnix formats a unix timestamp like the timestamp header
+func fmtUnix(timestamp int64) string {
+	return strconv.FormatInt(timestamp, 10)
+}